@@ -0,0 +1,86 @@
+package toolkit
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AccessLogFormat selects the line format written by AccessLogMiddleware.
+type AccessLogFormat int
+
+const (
+	// AccessLogCommon is the Apache common log format: %h %l %u %t "%r" %>s %b.
+	AccessLogCommon AccessLogFormat = iota
+	// AccessLogCombined is the common format followed by the quoted Referer and User-Agent.
+	AccessLogCombined
+)
+
+const accessLogTimeFormat = "02/Jan/2006:15:04:05 -0700"
+
+// AccessLogMiddleware writes one line per request to out in the Apache common or combined
+// format, with the client address taken from ClientIP. Lines are written with a single Write
+// call each, so out can be a file, a RotatingWriter or any other io.Writer.
+func (t *Tools) AccessLogMiddleware(out io.Writer, format AccessLogFormat) func(http.Handler) http.Handler {
+	var mu sync.Mutex
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			sw := &statusWriter{ResponseWriter: w}
+			next.ServeHTTP(sw, r)
+
+			user := "-"
+			if u, _, ok := r.BasicAuth(); ok && u != "" {
+				user = escapeLogField(u)
+			}
+			size := "-"
+			if sw.bytes > 0 {
+				size = strconv.FormatInt(sw.bytes, 10)
+			}
+
+			var line strings.Builder
+			fmt.Fprintf(&line, "%s - %s [%s] \"%s %s %s\" %d %s",
+				t.ClientIP(r), user, start.Format(accessLogTimeFormat),
+				escapeLogField(r.Method), escapeLogField(r.RequestURI), escapeLogField(r.Proto),
+				sw.code(), size)
+			if format == AccessLogCombined {
+				fmt.Fprintf(&line, " \"%s\" \"%s\"", logFieldOrDash(r.Referer()), logFieldOrDash(r.UserAgent()))
+			}
+			line.WriteByte('\n')
+
+			mu.Lock()
+			_, _ = io.WriteString(out, line.String())
+			mu.Unlock()
+		})
+	}
+}
+
+func logFieldOrDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return escapeLogField(s)
+}
+
+// escapeLogField escapes quotes, backslashes and non-printable bytes the way Apache does, so
+// client supplied values cannot break the line structure.
+func escapeLogField(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '"' || c == '\\':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case c < 0x20 || c >= 0x7f:
+			fmt.Fprintf(&b, "\\x%02x", c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
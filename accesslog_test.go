@@ -0,0 +1,42 @@
+package toolkit
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+)
+
+func TestTools_AccessLogMiddleware(t *testing.T) {
+	var testTools Tools
+	var buf bytes.Buffer
+	h := testTools.AccessLogMiddleware(&buf, AccessLogCombined)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte("hello"))
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/index.html?q=1", nil)
+	req.RemoteAddr = "198.51.100.4:5555"
+	req.SetBasicAuth("frank", "pw")
+	req.Header.Set("Referer", "http://example.com/start")
+	req.Header.Set("User-Agent", "Mozilla/5.0 \"quoted\"\n")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	expected := regexp.MustCompile(`^198\.51\.100\.4 - frank \[\d{2}/\w{3}/\d{4}:\d{2}:\d{2}:\d{2} [+-]\d{4}\] "GET /index\.html\?q=1 HTTP/1\.1" 200 5 "http://example\.com/start" "Mozilla/5\.0 \\"quoted\\"\\x0a"\n$`)
+	if !expected.MatchString(buf.String()) {
+		t.Errorf("unexpected combined line %q", buf.String())
+	}
+
+	buf.Reset()
+	h = testTools.AccessLogMiddleware(&buf, AccessLogCommon)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodDelete, "/items/1", nil))
+	if !regexp.MustCompile(`^192\.0\.2\.1 - - \[.+\] "DELETE /items/1 HTTP/1\.1" 204 -\n$`).MatchString(buf.String()) {
+		t.Errorf("unexpected common line %q", buf.String())
+	}
+}
@@ -0,0 +1,139 @@
+package toolkit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+const (
+	apiKeyAlphabet     = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+	apiKeySecretLength = 32
+)
+
+// NewAPIKey generates an API key of the form prefix_secret, where the secret is 32 random
+// base62 characters, and returns it along with the hash to store instead of the key itself.
+// The prefix makes keys recognisable, e.g. by secret scanners; it must not contain "_".
+func (t *Tools) NewAPIKey(prefix string) (key, hash string) {
+	key = prefix + "_" + t.RandomStringFrom(apiKeyAlphabet, apiKeySecretLength)
+	return key, HashAPIKey(key)
+}
+
+// HashAPIKey returns the hex SHA-256 of key, the form keys are stored and looked up in.
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// ValidAPIKeyFormat reports whether key looks like a key made by NewAPIKey with prefix, so
+// malformed keys can be rejected without a lookup.
+func ValidAPIKeyFormat(key, prefix string) bool {
+	secret, ok := strings.CutPrefix(key, prefix+"_")
+	if !ok || len(secret) != apiKeySecretLength {
+		return false
+	}
+	for i := 0; i < len(secret); i++ {
+		if !strings.Contains(apiKeyAlphabet, secret[i:i+1]) {
+			return false
+		}
+	}
+	return true
+}
+
+// APIKeyIdentity is the caller an API key belongs to.
+type APIKeyIdentity struct {
+	ID   string
+	Name string
+	// Limit overrides APIKeyOptions.Limit for this key when Requests is set.
+	Limit RateLimit
+}
+
+// APIKeyOptions configures APIKeyMiddleware.
+type APIKeyOptions struct {
+	// Header carries the key, "X-API-Key" when empty. A bearer token in the Authorization header
+	// is accepted as well.
+	Header string
+	// Prefix, when set, rejects keys not in the NewAPIKey format with this prefix.
+	Prefix string
+	// Lookup resolves the hash of a key, as returned by HashAPIKey, to its identity. It reports
+	// false for unknown or revoked keys.
+	Lookup func(ctx context.Context, hash string) (APIKeyIdentity, bool, error)
+	// Limit is the quota and burst of each key. Keys are not limited when Requests is 0 and the
+	// identity has no limit of its own.
+	Limit RateLimit
+	// Store counts requests per key identity, a new MemoryRateLimitStore when nil.
+	Store RateLimitStore
+}
+
+type apiKeyIdentityKey struct{}
+
+// APIKeyMiddleware authenticates callers by API key and enforces a per-key quota. Missing and
+// unknown keys get a 401 JSON error, callers over their quota a 429 with the RateLimit headers of
+// RateLimitMiddleware. The identity of accepted keys is available through APIKeyFromContext, and
+// is logged with the request ID when the lookup or the store fails.
+func (t *Tools) APIKeyMiddleware(opts APIKeyOptions) (func(http.Handler) http.Handler, error) {
+	if opts.Lookup == nil {
+		return nil, errors.New("api key middleware needs a lookup function")
+	}
+	if opts.Limit.Requests < 0 || opts.Limit.Requests > 0 && opts.Limit.Per <= 0 {
+		return nil, errors.New("api key rate limit requests and period should be greater than 0")
+	}
+	if opts.Header == "" {
+		opts.Header = "X-API-Key"
+	}
+	if opts.Store == nil {
+		opts.Store = NewMemoryRateLimitStore()
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(opts.Header)
+			if key == "" {
+				if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+					key = strings.TrimSpace(token)
+				}
+			}
+			if key == "" || opts.Prefix != "" && !ValidAPIKeyFormat(key, opts.Prefix) {
+				_ = t.ErrorJSON(w, errors.New("a valid api key is required"), http.StatusUnauthorized)
+				return
+			}
+
+			id, ok, err := opts.Lookup(r.Context(), HashAPIKey(key))
+			if err != nil {
+				t.logger().ErrorContext(r.Context(), "api key lookup failed", slog.String("error", err.Error()))
+				_ = t.ErrorJSON(w, errors.New("could not verify api key"), http.StatusInternalServerError)
+				return
+			}
+			if !ok {
+				_ = t.ErrorJSON(w, errors.New("a valid api key is required"), http.StatusUnauthorized)
+				return
+			}
+			ctx := context.WithValue(r.Context(), apiKeyIdentityKey{}, id)
+
+			limit := opts.Limit
+			if id.Limit.Requests > 0 && id.Limit.Per > 0 {
+				limit = id.Limit
+			}
+			if limit.Requests > 0 {
+				res, err := opts.Store.Take(ctx, "apikey:"+id.ID, limit)
+				if err != nil {
+					t.logger().ErrorContext(ctx, "api key quota store failed",
+						slog.String("api_key", id.ID), slog.String("error", err.Error()))
+				} else if !t.applyRateLimit(w, rateLimitPolicy(limit), res) {
+					return
+				}
+			}
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}, nil
+}
+
+// APIKeyFromContext returns the identity of the key accepted by APIKeyMiddleware.
+func APIKeyFromContext(ctx context.Context) (APIKeyIdentity, bool) {
+	id, ok := ctx.Value(apiKeyIdentityKey{}).(APIKeyIdentity)
+	return id, ok
+}
@@ -0,0 +1,98 @@
+package toolkit
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTools_NewAPIKey(t *testing.T) {
+	var testTools Tools
+	key, hash := testTools.NewAPIKey("tk")
+	if !ValidAPIKeyFormat(key, "tk") {
+		t.Errorf("generated key %q has an invalid format", key)
+	}
+	if hash != HashAPIKey(key) || len(hash) != 64 {
+		t.Errorf("unexpected hash %q", hash)
+	}
+	if other, _ := testTools.NewAPIKey("tk"); other == key {
+		t.Error("generated keys are not random")
+	}
+
+	for _, bad := range []string{"", "tk_", "xx" + key[2:], key + "a", key[:len(key)-1] + "!"} {
+		if ValidAPIKeyFormat(bad, "tk") {
+			t.Errorf("%q should be an invalid key", bad)
+		}
+	}
+}
+
+func TestTools_APIKeyMiddleware(t *testing.T) {
+	testTools := Tools{Logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+	gold, _ := testTools.NewAPIKey("tk")
+	basic, _ := testTools.NewAPIKey("tk")
+	broken, _ := testTools.NewAPIKey("tk")
+	keys := map[string]APIKeyIdentity{
+		HashAPIKey(gold):  {ID: "gold", Limit: RateLimit{Requests: 3, Per: time.Minute}},
+		HashAPIKey(basic): {ID: "basic"},
+	}
+
+	mw, err := testTools.APIKeyMiddleware(APIKeyOptions{
+		Prefix: "tk",
+		Limit:  RateLimit{Requests: 1, Per: time.Minute},
+		Lookup: func(_ context.Context, hash string) (APIKeyIdentity, bool, error) {
+			if hash == HashAPIKey(broken) {
+				return APIKeyIdentity{}, false, errors.New("database down")
+			}
+			id, ok := keys[hash]
+			return id, ok, nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, _ := APIKeyFromContext(r.Context())
+		_, _ = w.Write([]byte(id.ID))
+	}))
+
+	send := func(header, value string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if value != "" {
+			req.Header.Set(header, value)
+		}
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		return rr
+	}
+
+	if rr := send("X-API-Key", basic); rr.Code != http.StatusOK || rr.Body.String() != "basic" || rr.Header().Get("RateLimit-Remaining") != "0" {
+		t.Errorf("expected basic key to be accepted, got %d %q", rr.Code, rr.Body.String())
+	}
+	if rr := send("X-API-Key", basic); rr.Code != http.StatusTooManyRequests || rr.Header().Get("Retry-After") == "" {
+		t.Errorf("expected basic key to be over quota, got %d", rr.Code)
+	}
+	for i := 0; i < 3; i++ {
+		if rr := send("Authorization", "Bearer "+gold); rr.Code != http.StatusOK || rr.Body.String() != "gold" {
+			t.Errorf("request %d: expected gold key to use its own limit, got %d", i, rr.Code)
+		}
+	}
+
+	unknown, _ := testTools.NewAPIKey("tk")
+	for _, value := range []string{"", "not-a-key", unknown} {
+		if rr := send("X-API-Key", value); rr.Code != http.StatusUnauthorized {
+			t.Errorf("key %q: expected 401, got %d", value, rr.Code)
+		}
+	}
+	if rr := send("X-API-Key", broken); rr.Code != http.StatusInternalServerError {
+		t.Errorf("expected lookup failure to be a 500, got %d", rr.Code)
+	}
+
+	if _, err := testTools.APIKeyMiddleware(APIKeyOptions{}); err == nil {
+		t.Error("expected an error without a lookup function")
+	}
+}
@@ -0,0 +1,548 @@
+package toolkit
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ArchiveLimits bounds what UnzipTo, UntarGz and ExtractArchive will extract, protecting against
+// zip bombs.
+type ArchiveLimits struct {
+	MaxEntrySize int64
+	MaxTotalSize int64
+	MaxEntries   int
+}
+
+var defaultArchiveLimits = ArchiveLimits{
+	MaxEntrySize: 1 << 30,
+	MaxTotalSize: 4 << 30,
+	MaxEntries:   10000,
+}
+
+func archiveLimits(limits []ArchiveLimits) ArchiveLimits {
+	l := defaultArchiveLimits
+	if len(limits) > 0 {
+		if limits[0].MaxEntrySize > 0 {
+			l.MaxEntrySize = limits[0].MaxEntrySize
+		}
+		if limits[0].MaxTotalSize > 0 {
+			l.MaxTotalSize = limits[0].MaxTotalSize
+		}
+		if limits[0].MaxEntries > 0 {
+			l.MaxEntries = limits[0].MaxEntries
+		}
+	}
+	return l
+}
+
+// ZipDir writes a zip archive of the directory tree rooted at dir to w. Entry names are
+// relative to dir; symbolic links are skipped.
+func (t *Tools) ZipDir(w io.Writer, dir string) error {
+	zw := zip.NewWriter(w)
+	if err := t.addZipTree(zw, dir, ""); err != nil {
+		zw.Close()
+		return err
+	}
+	return zw.Close()
+}
+
+// addZipTree adds the directory tree rooted at dir to zw, naming entries prefix followed by
+// their path relative to dir.
+func (t *Tools) addZipTree(zw *zip.Writer, dir, prefix string) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == dir && prefix == "" || d.Type()&fs.ModeSymlink != 0 {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		name := filepath.ToSlash(rel)
+		if prefix != "" {
+			name = strings.TrimSuffix(prefix+"/"+name, "/.")
+		}
+		return t.addZipEntry(zw, path, name, info)
+	})
+}
+
+// CreateZip writes a zip archive of paths to w. Files are stored under their base names and
+// directories as trees under their base names, so two paths sharing a base name are rejected.
+// Symbolic links inside directories are skipped, and paths that are links fail with ErrSymlink
+// when Tools.NoFollowSymlinks is set.
+func (t *Tools) CreateZip(w io.Writer, paths []string) error {
+	zw := zip.NewWriter(w)
+	seen := make(map[string]bool, len(paths))
+
+	for _, path := range paths {
+		name := filepath.Base(path)
+		if seen[name] {
+			zw.Close()
+			return fmt.Errorf("duplicate file name %s in archive", name)
+		}
+		seen[name] = true
+
+		info, err := t.stat(path)
+		if err != nil {
+			zw.Close()
+			return err
+		}
+		switch {
+		case info.IsDir():
+			err = t.addZipTree(zw, path, name)
+		case info.Mode().IsRegular():
+			err = t.addZipEntry(zw, path, name, info)
+		default:
+			err = fmt.Errorf("%s is not a regular file", path)
+		}
+		if err != nil {
+			zw.Close()
+			return err
+		}
+	}
+
+	return zw.Close()
+}
+
+// ZipFiles writes a zip archive containing the given files, stored under their base names, to w.
+// It is CreateZip without directories.
+func (t *Tools) ZipFiles(w io.Writer, files ...string) error {
+	for _, file := range files {
+		info, err := t.stat(file)
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return fmt.Errorf("%s is not a regular file", file)
+		}
+	}
+	return t.CreateZip(w, files)
+}
+
+// DownloadZip streams the files, typically uploads, to the client as a zip archive named
+// download.zip, each stored under its base name. Encrypted uploads are decrypted on the way.
+// All files are opened before anything is sent, so a missing one is answered with 404; the
+// archive is then built while it is being sent and abandoned when the client goes away.
+func (t *Tools) DownloadZip(w http.ResponseWriter, r *http.Request, files ...string) {
+	if t.Metrics != nil {
+		sw := &statusWriter{ResponseWriter: w}
+		defer func() { t.Metrics.observeDownload(sw.code()) }()
+		w = sw
+	}
+
+	type zipSource struct {
+		name string
+		info fs.FileInfo
+		f    *os.File
+	}
+	sources := make([]zipSource, 0, len(files))
+	seen := make(map[string]bool, len(files))
+	defer func() {
+		for _, src := range sources {
+			src.f.Close()
+		}
+	}()
+
+	for _, file := range files {
+		name := filepath.Base(file)
+		if seen[name] {
+			http.Error(w, fmt.Sprintf("duplicate file name %s in archive", name), http.StatusBadRequest)
+			return
+		}
+		seen[name] = true
+
+		f, err := t.open(file)
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		info, err := f.Stat()
+		if err != nil || !info.Mode().IsRegular() {
+			f.Close()
+			http.NotFound(w, r)
+			return
+		}
+		sources = append(sources, zipSource{name, info, f})
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", "attachement; filename=\"download.zip\"")
+	w.WriteHeader(http.StatusOK)
+
+	zw := zip.NewWriter(w)
+	for _, src := range sources {
+		var content io.Reader = src.f
+		if t.encryptionEnabled() {
+			// Files stored before encryption was turned on are sent as they are.
+			decrypted, err := t.DecryptReader(src.f)
+			if errors.Is(err, ErrNotEncrypted) {
+				_, err = src.f.Seek(0, io.SeekStart)
+			} else if err == nil {
+				content = decrypted
+			}
+			if err != nil {
+				t.logger().ErrorContext(r.Context(), "zip download failed",
+					slog.String("file", src.name),
+					slog.String("error", err.Error()),
+				)
+				return
+			}
+		}
+
+		header, err := zip.FileInfoHeader(src.info)
+		if err != nil {
+			return
+		}
+		header.Name = src.name
+		header.Method = zip.Deflate
+		entry, err := zw.CreateHeader(header)
+		if err != nil {
+			return
+		}
+		if _, err = io.Copy(entry, contextReader{r.Context(), content}); err != nil {
+			// The client went away or the file could not be read; an unfinished archive is
+			// all that can be sent now.
+			return
+		}
+	}
+	_ = zw.Close()
+}
+
+func (t *Tools) addZipEntry(zw *zip.Writer, path, name string, info fs.FileInfo) error {
+	header, err := zip.FileInfoHeader(info)
+	if err != nil {
+		return err
+	}
+	header.Name = name
+	if info.IsDir() {
+		header.Name += "/"
+		_, err = zw.CreateHeader(header)
+		return err
+	}
+	header.Method = zip.Deflate
+
+	f, err := t.open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w, err := zw.CreateHeader(header)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, f)
+	return err
+}
+
+// UnzipTo extracts the zip archive src into dst and returns the extracted file paths relative
+// to dst. Entries escaping dst, symbolic links, and archives exceeding the limits are rejected.
+func (t *Tools) UnzipTo(src, dst string, limits ...ArchiveLimits) ([]string, error) {
+	l := archiveLimits(limits)
+
+	zr, err := zip.OpenReader(src)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+
+	if len(zr.File) > l.MaxEntries {
+		return nil, fmt.Errorf("archive contains more than %d entries", l.MaxEntries)
+	}
+
+	var extracted []string
+	var total int64
+	for _, f := range zr.File {
+		target, err := safeArchivePath(dst, f.Name)
+		if err != nil {
+			return extracted, err
+		}
+
+		mode := f.Mode()
+		switch {
+		case mode&fs.ModeSymlink != 0:
+			return extracted, fmt.Errorf("archive entry %s is a symbolic link", f.Name)
+		case mode.IsDir():
+			if err = os.MkdirAll(target, t.dirMode()); err != nil {
+				return extracted, err
+			}
+			continue
+		case !mode.IsRegular():
+			return extracted, fmt.Errorf("archive entry %s is not a regular file", f.Name)
+		}
+
+		if f.UncompressedSize64 > uint64(l.MaxEntrySize) {
+			return extracted, fmt.Errorf("archive entry %s exceeds %s", f.Name, t.HumanBytes(l.MaxEntrySize))
+		}
+
+		rc, err := f.Open()
+		if err != nil {
+			return extracted, err
+		}
+		n, err := t.extractArchiveEntry(rc, target, mode, l.MaxEntrySize, l.MaxTotalSize-total)
+		rc.Close()
+		total += n
+		if err != nil {
+			return extracted, fmt.Errorf("archive entry %s: %w", f.Name, err)
+		}
+
+		extracted = append(extracted, filepath.FromSlash(strings.TrimPrefix(f.Name, "./")))
+	}
+
+	return extracted, nil
+}
+
+var errArchiveTooLarge = errors.New("archive exceeds the allowed size")
+
+// extractArchiveEntry copies r to target, never trusting the size declared in the archive header.
+func (t *Tools) extractArchiveEntry(r io.Reader, target string, mode fs.FileMode, maxEntry, remaining int64) (int64, error) {
+	limit := maxEntry
+	if remaining < limit {
+		limit = remaining
+	}
+
+	if err := os.MkdirAll(filepath.Dir(target), t.dirMode()); err != nil {
+		return 0, err
+	}
+
+	perm := mode.Perm()
+	if perm == 0 {
+		perm = t.fileMode()
+	}
+	out, err := t.openFile(target, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := io.Copy(out, io.LimitReader(r, limit+1))
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil && n > limit {
+		err = errArchiveTooLarge
+	}
+	if err != nil {
+		_ = os.Remove(target)
+	}
+	return n, err
+}
+
+// safeArchivePath resolves an archive entry name inside dst, rejecting names that would
+// escape it (zip slip).
+func safeArchivePath(dst, name string) (string, error) {
+	name = filepath.FromSlash(name)
+	if filepath.IsAbs(name) || filepath.VolumeName(name) != "" || strings.HasPrefix(name, string(filepath.Separator)) {
+		return "", fmt.Errorf("archive entry %s has an absolute path", name)
+	}
+
+	root, err := filepath.Abs(dst)
+	if err != nil {
+		return "", err
+	}
+	target := filepath.Join(root, name)
+	if target != root && !strings.HasPrefix(target, root+string(filepath.Separator)) {
+		return "", fmt.Errorf("archive entry %s escapes the destination directory", name)
+	}
+	return target, nil
+}
+
+// TarGz writes a gzip compressed tar archive of the directory tree rooted at dir to w,
+// recording file modes and modification times. Symbolic links are skipped.
+func (t *Tools) TarGz(w io.Writer, dir string) error {
+	gw := gzip.NewWriter(w)
+	tw := tar.NewWriter(gw)
+
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == dir || d.Type()&fs.ModeSymlink != 0 {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(rel)
+		if info.IsDir() {
+			header.Name += "/"
+		}
+		if err = tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		f, err := t.open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		_, err = io.Copy(tw, f)
+		return err
+	})
+	if err != nil {
+		tw.Close()
+		gw.Close()
+		return err
+	}
+
+	if err = tw.Close(); err != nil {
+		gw.Close()
+		return err
+	}
+	return gw.Close()
+}
+
+// UntarGz extracts the gzip compressed tar archive src into dst, restoring file modes and
+// modification times, and returns the extracted file paths relative to dst. It applies the
+// same protections as UnzipTo: links, devices, escaping paths and oversized content are rejected.
+func (t *Tools) UntarGz(src, dst string, limits ...ArchiveLimits) ([]string, error) {
+	l := archiveLimits(limits)
+
+	f, err := t.open(src)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	gr, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, err
+	}
+	defer gr.Close()
+
+	type dirTime struct {
+		path    string
+		modTime time.Time
+	}
+	var dirTimes []dirTime
+	var extracted []string
+	var total int64
+	entries := 0
+
+	tr := tar.NewReader(gr)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return extracted, err
+		}
+		if header.Typeflag == tar.TypeXGlobalHeader {
+			continue
+		}
+
+		entries++
+		if entries > l.MaxEntries {
+			return extracted, fmt.Errorf("archive contains more than %d entries", l.MaxEntries)
+		}
+
+		target, err := safeArchivePath(dst, header.Name)
+		if err != nil {
+			return extracted, err
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err = os.MkdirAll(target, t.dirMode()); err != nil {
+				return extracted, err
+			}
+			if perm := header.FileInfo().Mode().Perm(); perm != 0 {
+				if err = os.Chmod(target, perm|0700); err != nil {
+					return extracted, err
+				}
+			}
+			dirTimes = append(dirTimes, dirTime{target, header.ModTime})
+			continue
+		case tar.TypeReg:
+		case tar.TypeSymlink, tar.TypeLink:
+			return extracted, fmt.Errorf("archive entry %s is a link", header.Name)
+		default:
+			return extracted, fmt.Errorf("archive entry %s is not a regular file", header.Name)
+		}
+
+		if header.Size > l.MaxEntrySize {
+			return extracted, fmt.Errorf("archive entry %s exceeds %s", header.Name, t.HumanBytes(l.MaxEntrySize))
+		}
+
+		n, err := t.extractArchiveEntry(tr, target, header.FileInfo().Mode(), l.MaxEntrySize, l.MaxTotalSize-total)
+		total += n
+		if err != nil {
+			return extracted, fmt.Errorf("archive entry %s: %w", header.Name, err)
+		}
+		if err = os.Chmod(target, header.FileInfo().Mode().Perm()); err != nil {
+			return extracted, err
+		}
+		if err = os.Chtimes(target, header.ModTime, header.ModTime); err != nil {
+			return extracted, err
+		}
+
+		extracted = append(extracted, filepath.FromSlash(strings.TrimPrefix(header.Name, "./")))
+	}
+
+	for i := len(dirTimes) - 1; i >= 0; i-- {
+		if err := os.Chtimes(dirTimes[i].path, dirTimes[i].modTime, dirTimes[i].modTime); err != nil {
+			return extracted, err
+		}
+	}
+
+	return extracted, nil
+}
+
+// ExtractArchive extracts the zip or gzip compressed tar archive src into destDir, telling the
+// formats apart by their content rather than the file name, and returns the extracted file paths
+// relative to destDir. It applies the protections and limits of UnzipTo and UntarGz.
+func (t *Tools) ExtractArchive(src, destDir string, limits ...ArchiveLimits) ([]string, error) {
+	f, err := t.open(src)
+	if err != nil {
+		return nil, err
+	}
+	magic := make([]byte, 4)
+	n, err := io.ReadFull(f, magic)
+	f.Close()
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return nil, err
+	}
+	magic = magic[:n]
+
+	switch {
+	case bytes.HasPrefix(magic, []byte("PK\x03\x04")), bytes.HasPrefix(magic, []byte("PK\x05\x06")):
+		return t.UnzipTo(src, destDir, limits...)
+	case bytes.HasPrefix(magic, []byte{0x1f, 0x8b}):
+		return t.UntarGz(src, destDir, limits...)
+	}
+	return nil, fmt.Errorf("%s is not a zip or tar.gz archive", filepath.Base(src))
+}
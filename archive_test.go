@@ -0,0 +1,366 @@
+package toolkit
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTools_ZipDirAndUnzip(t *testing.T) {
+	var testTools Tools
+	src := t.TempDir()
+	_ = os.MkdirAll(filepath.Join(src, "docs", "empty"), 0755)
+	_ = os.WriteFile(filepath.Join(src, "docs", "a.txt"), []byte("alpha"), 0644)
+	_ = os.WriteFile(filepath.Join(src, "b.txt"), []byte("beta"), 0600)
+
+	var buf bytes.Buffer
+	if err := testTools.ZipDir(&buf, src); err != nil {
+		t.Fatal(err)
+	}
+
+	archive := filepath.Join(t.TempDir(), "out.zip")
+	_ = os.WriteFile(archive, buf.Bytes(), 0644)
+
+	dst := t.TempDir()
+	files, err := testTools.UnzipTo(archive, dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 {
+		t.Errorf("expected 2 extracted files, got %v", files)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dst, "docs", "a.txt"))
+	if err != nil || string(data) != "alpha" {
+		t.Error("nested file not extracted:", err)
+	}
+	if info, err := os.Stat(filepath.Join(dst, "b.txt")); err != nil || info.Mode().Perm() != 0600 {
+		t.Error("file mode not restored:", err)
+	}
+	if info, err := os.Stat(filepath.Join(dst, "docs", "empty")); err != nil || !info.IsDir() {
+		t.Error("empty directory not extracted:", err)
+	}
+}
+
+func TestTools_ZipFiles(t *testing.T) {
+	var testTools Tools
+	dir := t.TempDir()
+	_ = os.MkdirAll(filepath.Join(dir, "sub"), 0755)
+	_ = os.WriteFile(filepath.Join(dir, "one.txt"), []byte("1"), 0644)
+	_ = os.WriteFile(filepath.Join(dir, "sub", "one.txt"), []byte("1"), 0644)
+	_ = os.WriteFile(filepath.Join(dir, "two.txt"), []byte("2"), 0644)
+
+	var buf bytes.Buffer
+	if err := testTools.ZipFiles(&buf, filepath.Join(dir, "one.txt"), filepath.Join(dir, "two.txt")); err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(zr.File) != 2 || zr.File[0].Name != "one.txt" {
+		t.Error("unexpected archive content")
+	}
+
+	buf.Reset()
+	if err = testTools.ZipFiles(&buf, filepath.Join(dir, "one.txt"), filepath.Join(dir, "sub", "one.txt")); err == nil {
+		t.Error("expected error for duplicate names")
+	}
+}
+
+type zipEntry struct {
+	name string
+	mode fs.FileMode
+	body string
+}
+
+func writeTestZip(t *testing.T, entries ...zipEntry) string {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, e := range entries {
+		h := &zip.FileHeader{Name: e.name, Method: zip.Deflate}
+		h.SetMode(e.mode)
+		w, err := zw.CreateHeader(h)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _ = w.Write([]byte(e.body))
+	}
+	_ = zw.Close()
+
+	path := filepath.Join(t.TempDir(), "test.zip")
+	_ = os.WriteFile(path, buf.Bytes(), 0644)
+	return path
+}
+
+var unzipTests = []struct {
+	name   string
+	entry  zipEntry
+	limits ArchiveLimits
+}{
+	{name: "zip slip", entry: zipEntry{name: "../evil.txt", mode: 0644, body: "x"}},
+	{name: "nested zip slip", entry: zipEntry{name: "a/../../evil.txt", mode: 0644, body: "x"}},
+	{name: "absolute path", entry: zipEntry{name: "/etc/evil.txt", mode: 0644, body: "x"}},
+	{name: "symlink", entry: zipEntry{name: "link", mode: fs.ModeSymlink | 0777, body: "/etc/passwd"}},
+	{name: "entry too large", entry: zipEntry{name: "big.txt", mode: 0644, body: strings.Repeat("a", 100)}, limits: ArchiveLimits{MaxEntrySize: 10}},
+	{name: "total too large", entry: zipEntry{name: "big.txt", mode: 0644, body: strings.Repeat("a", 100)}, limits: ArchiveLimits{MaxTotalSize: 10}},
+}
+
+func TestTools_UnzipToRejects(t *testing.T) {
+	var testTools Tools
+	for _, test := range unzipTests {
+		archive := writeTestZip(t, test.entry)
+		dst := filepath.Join(t.TempDir(), "out")
+
+		if _, err := testTools.UnzipTo(archive, dst, test.limits); err == nil {
+			t.Errorf("%s: error expected but none received", test.name)
+		}
+		if _, err := os.Stat(filepath.Join(filepath.Dir(dst), "evil.txt")); err == nil {
+			t.Errorf("%s: file written outside destination", test.name)
+		}
+	}
+}
+
+func TestTools_TarGzAndUntarGz(t *testing.T) {
+	var testTools Tools
+	src := t.TempDir()
+	_ = os.MkdirAll(filepath.Join(src, "docs"), 0755)
+	_ = os.WriteFile(filepath.Join(src, "docs", "a.txt"), []byte("alpha"), 0640)
+	modTime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	_ = os.Chtimes(filepath.Join(src, "docs", "a.txt"), modTime, modTime)
+	_ = os.Chtimes(filepath.Join(src, "docs"), modTime, modTime)
+
+	var buf bytes.Buffer
+	if err := testTools.TarGz(&buf, src); err != nil {
+		t.Fatal(err)
+	}
+	archive := filepath.Join(t.TempDir(), "out.tar.gz")
+	_ = os.WriteFile(archive, buf.Bytes(), 0644)
+
+	dst := t.TempDir()
+	files, err := testTools.UntarGz(archive, dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 || files[0] != filepath.Join("docs", "a.txt") {
+		t.Errorf("unexpected extracted files %v", files)
+	}
+
+	info, err := os.Stat(filepath.Join(dst, "docs", "a.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0640 {
+		t.Errorf("mode not restored: %v", info.Mode().Perm())
+	}
+	if !info.ModTime().Equal(modTime) {
+		t.Errorf("mtime not restored: %v", info.ModTime())
+	}
+	if info, err = os.Stat(filepath.Join(dst, "docs")); err != nil || !info.ModTime().Equal(modTime) {
+		t.Error("directory mtime not restored:", err)
+	}
+}
+
+type tarEntry struct {
+	header tar.Header
+	body   string
+}
+
+func writeTestTarGz(t *testing.T, entries ...tarEntry) string {
+	t.Helper()
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+	for _, e := range entries {
+		h := e.header
+		h.Size = int64(len(e.body))
+		if err := tw.WriteHeader(&h); err != nil {
+			t.Fatal(err)
+		}
+		_, _ = tw.Write([]byte(e.body))
+	}
+	_ = tw.Close()
+	_ = gw.Close()
+
+	path := filepath.Join(t.TempDir(), "test.tar.gz")
+	_ = os.WriteFile(path, buf.Bytes(), 0644)
+	return path
+}
+
+var untarTests = []struct {
+	name   string
+	entry  tarEntry
+	limits ArchiveLimits
+}{
+	{name: "path traversal", entry: tarEntry{header: tar.Header{Name: "../evil.txt", Typeflag: tar.TypeReg, Mode: 0644}, body: "x"}},
+	{name: "symlink", entry: tarEntry{header: tar.Header{Name: "link", Typeflag: tar.TypeSymlink, Linkname: "/etc/passwd"}}},
+	{name: "hard link", entry: tarEntry{header: tar.Header{Name: "link", Typeflag: tar.TypeLink, Linkname: "/etc/passwd"}}},
+	{name: "device", entry: tarEntry{header: tar.Header{Name: "dev", Typeflag: tar.TypeChar}}},
+	{name: "entry too large", entry: tarEntry{header: tar.Header{Name: "big.txt", Typeflag: tar.TypeReg, Mode: 0644}, body: strings.Repeat("a", 100)}, limits: ArchiveLimits{MaxEntrySize: 10}},
+}
+
+func TestTools_UntarGzRejects(t *testing.T) {
+	var testTools Tools
+	for _, test := range untarTests {
+		archive := writeTestTarGz(t, test.entry)
+		dst := filepath.Join(t.TempDir(), "out")
+
+		if _, err := testTools.UntarGz(archive, dst, test.limits); err == nil {
+			t.Errorf("%s: error expected but none received", test.name)
+		}
+		if _, err := os.Stat(filepath.Join(filepath.Dir(dst), "evil.txt")); err == nil {
+			t.Errorf("%s: file written outside destination", test.name)
+		}
+	}
+}
+
+func TestTools_CreateZip(t *testing.T) {
+	var testTools Tools
+	dir := t.TempDir()
+	_ = os.MkdirAll(filepath.Join(dir, "docs", "sub"), 0755)
+	_ = os.WriteFile(filepath.Join(dir, "docs", "sub", "a.txt"), []byte("alpha"), 0644)
+	_ = os.WriteFile(filepath.Join(dir, "b.txt"), []byte("beta"), 0644)
+
+	var buf bytes.Buffer
+	if err := testTools.CreateZip(&buf, []string{filepath.Join(dir, "docs"), filepath.Join(dir, "b.txt")}); err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, f := range zr.File {
+		names = append(names, f.Name)
+	}
+	if got := strings.Join(names, ","); got != "docs/,docs/sub/,docs/sub/a.txt,b.txt" {
+		t.Errorf("unexpected entries %s", got)
+	}
+
+	buf.Reset()
+	if err = testTools.CreateZip(&buf, []string{filepath.Join(dir, "b.txt"), filepath.Join(dir, "b.txt")}); err == nil {
+		t.Error("expected error for duplicate names")
+	}
+	if err = testTools.CreateZip(&buf, []string{filepath.Join(dir, "missing.txt")}); err == nil {
+		t.Error("expected error for a missing file")
+	}
+	// Links inside a tree are skipped and links given as paths are followed, unless
+	// NoFollowSymlinks is set.
+	if err = os.Symlink(filepath.Join(dir, "b.txt"), filepath.Join(dir, "docs", "link.txt")); err != nil {
+		t.Skip("symlinks not supported:", err)
+	}
+	_ = os.Symlink(filepath.Join(dir, "b.txt"), filepath.Join(dir, "top.txt"))
+	buf.Reset()
+	if err = testTools.CreateZip(&buf, []string{filepath.Join(dir, "docs"), filepath.Join(dir, "top.txt")}); err != nil {
+		t.Fatal(err)
+	}
+	zr, _ = zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	names = names[:0]
+	for _, f := range zr.File {
+		names = append(names, f.Name)
+	}
+	if got := strings.Join(names, ","); got != "docs/,docs/sub/,docs/sub/a.txt,top.txt" {
+		t.Errorf("unexpected entries with links %s", got)
+	}
+
+	testTools.NoFollowSymlinks = true
+	if err = testTools.CreateZip(io.Discard, []string{filepath.Join(dir, "docs"), filepath.Join(dir, "top.txt")}); !errors.Is(err, ErrSymlink) {
+		t.Errorf("expected ErrSymlink for a linked path, got %v", err)
+	}
+}
+
+func TestTools_DownloadZip(t *testing.T) {
+	dir := t.TempDir()
+	_ = os.WriteFile(filepath.Join(dir, "plain.txt"), []byte("plain"), 0644)
+	testTools := Tools{EncryptionKeys: map[uint32][]byte{1: bytes.Repeat([]byte("k"), 32)}, EncryptionKeyVersion: 1}
+
+	var enc bytes.Buffer
+	ew, err := testTools.EncryptWriter(&enc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = ew.Write([]byte("secret"))
+	_ = ew.Close()
+	_ = os.WriteFile(filepath.Join(dir, "secret.txt"), enc.Bytes(), 0644)
+
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	testTools.DownloadZip(rr, req, filepath.Join(dir, "plain.txt"), filepath.Join(dir, "secret.txt"))
+
+	if rr.Code != http.StatusOK || rr.Header().Get("Content-Type") != "application/zip" {
+		t.Fatalf("unexpected response %d %s", rr.Code, rr.Header().Get("Content-Type"))
+	}
+	zr, err := zip.NewReader(bytes.NewReader(rr.Body.Bytes()), int64(rr.Body.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"plain.txt": "plain", "secret.txt": "secret"}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := io.ReadAll(rc)
+		rc.Close()
+		if want[f.Name] != string(data) {
+			t.Errorf("%s: expected %q, got %q", f.Name, want[f.Name], data)
+		}
+		delete(want, f.Name)
+	}
+	if len(want) > 0 {
+		t.Errorf("entries missing from archive: %v", want)
+	}
+
+	rr = httptest.NewRecorder()
+	testTools.DownloadZip(rr, req, filepath.Join(dir, "plain.txt"), filepath.Join(dir, "missing.txt"))
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for a missing file, got %d", rr.Code)
+	}
+}
+
+func TestTools_ExtractArchive(t *testing.T) {
+	var testTools Tools
+	src := t.TempDir()
+	_ = os.WriteFile(filepath.Join(src, "a.txt"), []byte("alpha"), 0644)
+
+	dir := t.TempDir()
+	var zipBuf, tarBuf bytes.Buffer
+	_ = testTools.ZipDir(&zipBuf, src)
+	_ = testTools.TarGz(&tarBuf, src)
+	// Extensions do not matter, the content does.
+	_ = os.WriteFile(filepath.Join(dir, "archive.bin"), zipBuf.Bytes(), 0644)
+	_ = os.WriteFile(filepath.Join(dir, "archive.zip"), tarBuf.Bytes(), 0644)
+	_ = os.WriteFile(filepath.Join(dir, "notes.zip"), []byte("not an archive"), 0644)
+
+	for _, name := range []string{"archive.bin", "archive.zip"} {
+		dst := t.TempDir()
+		files, err := testTools.ExtractArchive(filepath.Join(dir, name), dst)
+		if err != nil {
+			t.Errorf("%s: %s", name, err)
+			continue
+		}
+		if data, _ := os.ReadFile(filepath.Join(dst, "a.txt")); len(files) != 1 || string(data) != "alpha" {
+			t.Errorf("%s: unexpected extraction %v", name, files)
+		}
+	}
+
+	if _, err := testTools.ExtractArchive(filepath.Join(dir, "notes.zip"), t.TempDir()); err == nil {
+		t.Error("expected error for a file that is no archive")
+	}
+	evil := writeTestZip(t, zipEntry{name: "../evil.txt", mode: 0644, body: "x"})
+	if _, err := testTools.ExtractArchive(evil, filepath.Join(t.TempDir(), "out")); err == nil {
+		t.Error("expected error for zip slip")
+	}
+}
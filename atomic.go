@@ -0,0 +1,62 @@
+package toolkit
+
+import (
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// WriteFileAtomic writes the content of r to path so that readers see either the old file or the
+// complete new one: data goes to a temporary file in the same directory, is synced, and then renamed.
+func (t *Tools) WriteFileAtomic(path string, r io.Reader, mode fs.FileMode) (int64, error) {
+	dir, base := filepath.Split(path)
+	if dir == "" {
+		dir = "."
+	}
+
+	tmp, err := os.CreateTemp(dir, "."+base+".tmp-*")
+	if err != nil {
+		return 0, err
+	}
+	tmpName := tmp.Name()
+
+	n, err := func() (int64, error) {
+		defer tmp.Close()
+
+		n, err := io.Copy(tmp, r)
+		if err != nil {
+			return n, err
+		}
+		if err = tmp.Sync(); err != nil {
+			return n, err
+		}
+		if err = tmp.Chmod(mode); err != nil {
+			return n, err
+		}
+		return n, tmp.Close()
+	}()
+	if err != nil {
+		_ = os.Remove(tmpName)
+		return n, err
+	}
+
+	if err = os.Rename(tmpName, path); err != nil {
+		_ = os.Remove(tmpName)
+		return n, err
+	}
+
+	syncDir(dir)
+	return n, nil
+}
+
+// syncDir flushes the directory entry of a freshly renamed file. Not every platform
+// supports syncing directories, so failures are ignored.
+func syncDir(dir string) {
+	d, err := os.Open(dir)
+	if err != nil {
+		return
+	}
+	_ = d.Sync()
+	_ = d.Close()
+}
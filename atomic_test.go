@@ -0,0 +1,52 @@
+package toolkit
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type failingReader struct{}
+
+func (failingReader) Read([]byte) (int, error) {
+	return 0, errors.New("read failed")
+}
+
+func TestTools_WriteFileAtomic(t *testing.T) {
+	var testTools Tools
+	dir := t.TempDir()
+	path := filepath.Join(dir, "state.json")
+
+	n, err := testTools.WriteFileAtomic(path, strings.NewReader(`{"a":1}`), 0640)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 7 {
+		t.Errorf("expected 7 bytes written, got %d", n)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0640 {
+		t.Errorf("wrong mode: %v", info.Mode().Perm())
+	}
+
+	_, err = testTools.WriteFileAtomic(path, failingReader{}, 0640)
+	if err == nil {
+		t.Error("error expected but none received")
+	}
+
+	data, _ := os.ReadFile(path)
+	if string(data) != `{"a":1}` {
+		t.Error("original file modified by failed write:", string(data))
+	}
+
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("temporary files left behind: %d entries", len(entries))
+	}
+}
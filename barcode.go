@@ -0,0 +1,164 @@
+package toolkit
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/boombuler/barcode"
+	"github.com/boombuler/barcode/code128"
+	"github.com/boombuler/barcode/qr"
+)
+
+// Quiet zones, in modules, that scanners need around codes.
+const (
+	qrQuietZone      = 4
+	code128QuietZone = 10
+)
+
+// GenerateQRCode encodes data as a size×size QR code with medium error correction, including
+// its quiet zone.
+func (t *Tools) GenerateQRCode(data string, size int) (image.Image, error) {
+	bc, err := qr.Encode(data, qr.M, qr.Auto)
+	if err != nil {
+		return nil, err
+	}
+	return renderBarcode(bc, size, size, qrQuietZone)
+}
+
+// WriteQRCode writes the QR code of GenerateQRCode to w as a PNG image.
+func (t *Tools) WriteQRCode(w io.Writer, data string, size int) error {
+	img, err := t.GenerateQRCode(data, size)
+	if err != nil {
+		return err
+	}
+	return png.Encode(w, img)
+}
+
+// GenerateCode128 encodes data as a width×height Code 128 barcode, including its quiet zone.
+func (t *Tools) GenerateCode128(data string, width, height int) (image.Image, error) {
+	bc, err := code128.Encode(data)
+	if err != nil {
+		return nil, err
+	}
+	return renderBarcode(bc, width, height, code128QuietZone)
+}
+
+// WriteCode128 writes the barcode of GenerateCode128 to w as a PNG image.
+func (t *Tools) WriteCode128(w io.Writer, data string, width, height int) error {
+	img, err := t.GenerateCode128(data, width, height)
+	if err != nil {
+		return err
+	}
+	return png.Encode(w, img)
+}
+
+// renderBarcode scales bc by the largest whole number of pixels per module that fits within
+// width and height with the quiet zone, and centres it on a white image. Whole pixels keep
+// modules sharp enough to scan.
+func renderBarcode(bc barcode.Barcode, width, height, quiet int) (image.Image, error) {
+	if width <= 0 || height <= 0 {
+		return nil, errors.New("barcode size should be greater than 0")
+	}
+	b := bc.Bounds()
+	factor := width / (b.Dx() + 2*quiet)
+	if bc.Metadata().Dimensions == 2 {
+		factor = min(factor, height/(b.Dy()+2*quiet))
+	}
+	if factor < 1 {
+		return nil, fmt.Errorf("barcode of %d modules does not fit in %dx%d pixels", b.Dx()+2*quiet, width, height)
+	}
+
+	codeHeight := height
+	if bc.Metadata().Dimensions == 2 {
+		codeHeight = b.Dy() * factor
+	}
+	scaled, err := barcode.Scale(bc, b.Dx()*factor, codeHeight)
+	if err != nil {
+		return nil, err
+	}
+
+	img := image.NewGray(image.Rect(0, 0, width, height))
+	draw.Draw(img, img.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	sb := scaled.Bounds()
+	at := image.Pt((width-sb.Dx())/2, (height-sb.Dy())/2)
+	draw.Draw(img, sb.Add(at), scaled, sb.Min, draw.Src)
+	return img, nil
+}
+
+// BarcodeHandlerOptions configures BarcodeHandler.
+type BarcodeHandlerOptions struct {
+	// MaxSize bounds the requested dimensions in pixels, 1024 when 0.
+	MaxSize int
+	// MaxData bounds the length of the encoded data, 1024 bytes when 0.
+	MaxData int
+}
+
+// BarcodeHandler renders codes on the fly as PNG images. The query names the code with "type",
+// either "qr" (the default) or "code128", and its "data". QR codes are "size" pixels square,
+// 256 when unset; barcodes are "width" by "height" pixels, 400 by 100 when unset.
+//
+// The data ends up in access logs and browser history, so secrets such as 2FA provisioning
+// URIs should be rendered with WriteQRCode instead.
+func (t *Tools) BarcodeHandler(opts BarcodeHandlerOptions) http.Handler {
+	if opts.MaxSize <= 0 {
+		opts.MaxSize = 1024
+	}
+	if opts.MaxData <= 0 {
+		opts.MaxData = 1024
+	}
+
+	dimension := func(r *http.Request, name string, def int) (int, error) {
+		s := r.URL.Query().Get(name)
+		if s == "" {
+			return def, nil
+		}
+		n, err := strconv.Atoi(s)
+		if err != nil || n <= 0 || n > opts.MaxSize {
+			return 0, fmt.Errorf("%s should be between 1 and %d", name, opts.MaxSize)
+		}
+		return n, nil
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		data := q.Get("data")
+		if data == "" || len(data) > opts.MaxData {
+			_ = t.ErrorJSON(w, fmt.Errorf("data should be between 1 and %d bytes", opts.MaxData), http.StatusBadRequest)
+			return
+		}
+
+		var img image.Image
+		var err error
+		switch q.Get("type") {
+		case "", "qr":
+			var size int
+			if size, err = dimension(r, "size", 256); err == nil {
+				img, err = t.GenerateQRCode(data, size)
+			}
+		case "code128":
+			var width, height int
+			if width, err = dimension(r, "width", 400); err == nil {
+				if height, err = dimension(r, "height", 100); err == nil {
+					img, err = t.GenerateCode128(data, width, height)
+				}
+			}
+		default:
+			err = fmt.Errorf("unsupported code type %q", q.Get("type"))
+		}
+		if err != nil {
+			_ = t.ErrorJSON(w, err, http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "image/png")
+		w.Header().Set("Cache-Control", "public, max-age=86400")
+		_ = png.Encode(w, img)
+	})
+}
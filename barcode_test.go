@@ -0,0 +1,114 @@
+package toolkit
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTools_GenerateQRCode(t *testing.T) {
+	var testTools Tools
+	img, err := testTools.GenerateQRCode("otpauth://totp/Toolkit:jane?secret=JBSWY3DPEHPK3PXP&issuer=Toolkit", 200)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := img.Bounds(); b.Dx() != 200 || b.Dy() != 200 {
+		t.Errorf("expected a 200x200 image, got %v", b)
+	}
+	if !isWhite(img.At(0, 0)) || !isWhite(img.At(199, 199)) {
+		t.Error("expected a white quiet zone")
+	}
+	if isWhite(img.At(30, 30)) && isWhite(img.At(35, 35)) {
+		t.Error("expected the finder pattern near the top left corner")
+	}
+
+	if _, err = testTools.GenerateQRCode("data", 10); err == nil {
+		t.Error("expected a size too small for the code to fail")
+	}
+
+	var buf bytes.Buffer
+	if err = testTools.WriteQRCode(&buf, "hello", 100); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = png.Decode(&buf); err != nil {
+		t.Errorf("expected a PNG image: %v", err)
+	}
+}
+
+func TestTools_GenerateCode128(t *testing.T) {
+	var testTools Tools
+	img, err := testTools.GenerateCode128("PKG-000123", 400, 80)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := img.Bounds(); b.Dx() != 400 || b.Dy() != 80 {
+		t.Errorf("expected a 400x80 image, got %v", b)
+	}
+	dark := 0
+	for x := range 400 {
+		if !isWhite(img.At(x, 40)) {
+			dark++
+			if img.At(x, 0) != img.At(x, 79) {
+				t.Fatalf("expected bars to span the full height at x=%d", x)
+			}
+		}
+	}
+	if dark == 0 || !isWhite(img.At(0, 40)) {
+		t.Errorf("expected bars inside a quiet zone, got %d dark columns", dark)
+	}
+
+	if _, err = testTools.GenerateCode128("PKG-000123", 50, 80); err == nil {
+		t.Error("expected a width too small for the code to fail")
+	}
+}
+
+var barcodeHandlerTests = []struct {
+	name   string
+	query  string
+	status int
+	width  int
+	height int
+}{
+	{name: "qr default size", query: "data=hello", status: http.StatusOK, width: 256, height: 256},
+	{name: "qr size", query: "type=qr&data=hello&size=128", status: http.StatusOK, width: 128, height: 128},
+	{name: "code128", query: "type=code128&data=PKG-1&width=300&height=60", status: http.StatusOK, width: 300, height: 60},
+	{name: "missing data", query: "type=qr", status: http.StatusBadRequest},
+	{name: "too large", query: "data=hello&size=5000", status: http.StatusBadRequest},
+	{name: "bad size", query: "data=hello&size=big", status: http.StatusBadRequest},
+	{name: "unknown type", query: "type=ean13&data=hello", status: http.StatusBadRequest},
+	{name: "does not fit", query: "type=code128&data=PKG-1&width=20", status: http.StatusBadRequest},
+}
+
+func TestTools_BarcodeHandler(t *testing.T) {
+	var testTools Tools
+	h := testTools.BarcodeHandler(BarcodeHandlerOptions{})
+
+	for _, test := range barcodeHandlerTests {
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/codes?"+test.query, nil))
+		if rr.Code != test.status {
+			t.Errorf("%s: expected status %d, got %d", test.name, test.status, rr.Code)
+			continue
+		}
+		if test.status != http.StatusOK {
+			continue
+		}
+		img, err := png.Decode(rr.Body)
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if img.Bounds() != image.Rect(0, 0, test.width, test.height) {
+			t.Errorf("%s: unexpected image bounds %v", test.name, img.Bounds())
+		}
+	}
+}
+
+func isWhite(c color.Color) bool {
+	r, g, b, _ := c.RGBA()
+	return r == 0xffff && g == 0xffff && b == 0xffff
+}
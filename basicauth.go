@@ -0,0 +1,73 @@
+package toolkit
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"strconv"
+)
+
+// BasicAuthOptions configures BasicAuthMiddleware. Either Credentials or Validate must be set.
+type BasicAuthOptions struct {
+	// Realm is sent in the WWW-Authenticate challenge, "Restricted" when empty.
+	Realm string
+	// Credentials maps user names to passwords.
+	Credentials map[string]string
+	// Validate checks credentials against another source. It is responsible for comparing
+	// secrets in constant time, e.g. with crypto/subtle or a password hash.
+	Validate func(r *http.Request, user, password string) bool
+}
+
+type basicAuthUserKey struct{}
+
+// BasicAuthMiddleware protects next with HTTP basic authentication. Static credentials are compared
+// in constant time, checking every entry so the response time does not reveal which user names
+// exist. Failed attempts get a 401 JSON error with a WWW-Authenticate challenge; the user name of
+// successful ones is available through BasicAuthUser.
+func (t *Tools) BasicAuthMiddleware(opts BasicAuthOptions) (func(http.Handler) http.Handler, error) {
+	if len(opts.Credentials) == 0 && opts.Validate == nil {
+		return nil, errors.New("basic auth needs credentials or a validate function")
+	}
+	if opts.Realm == "" {
+		opts.Realm = "Restricted"
+	}
+	challenge := "Basic realm=" + strconv.Quote(opts.Realm) + `, charset="UTF-8"`
+
+	type credential struct{ user, password [sha256.Size]byte }
+	credentials := make([]credential, 0, len(opts.Credentials))
+	for user, password := range opts.Credentials {
+		credentials = append(credentials, credential{sha256.Sum256([]byte(user)), sha256.Sum256([]byte(password))})
+	}
+
+	valid := func(r *http.Request, user, password string) bool {
+		if opts.Validate != nil {
+			return opts.Validate(r, user, password)
+		}
+		u, p := sha256.Sum256([]byte(user)), sha256.Sum256([]byte(password))
+		match := 0
+		for _, c := range credentials {
+			match |= subtle.ConstantTimeCompare(u[:], c.user[:]) & subtle.ConstantTimeCompare(p[:], c.password[:])
+		}
+		return match == 1
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user, password, ok := r.BasicAuth()
+			if !ok || !valid(r, user, password) {
+				w.Header().Set("WWW-Authenticate", challenge)
+				_ = t.ErrorJSON(w, errors.New("authentication required"), http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), basicAuthUserKey{}, user)))
+		})
+	}, nil
+}
+
+// BasicAuthUser returns the user authenticated by BasicAuthMiddleware, or "".
+func BasicAuthUser(ctx context.Context) string {
+	user, _ := ctx.Value(basicAuthUserKey{}).(string)
+	return user
+}
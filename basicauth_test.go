@@ -0,0 +1,71 @@
+package toolkit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+var basicAuthTests = []struct {
+	name     string
+	user     string
+	password string
+	noAuth   bool
+	expected int
+}{
+	{name: "valid", user: "admin", password: "s3cret", expected: http.StatusOK},
+	{name: "wrong password", user: "admin", password: "guess", expected: http.StatusUnauthorized},
+	{name: "unknown user", user: "root", password: "s3cret", expected: http.StatusUnauthorized},
+	{name: "no credentials", noAuth: true, expected: http.StatusUnauthorized},
+}
+
+func TestTools_BasicAuthMiddleware(t *testing.T) {
+	var testTools Tools
+	mw, err := testTools.BasicAuthMiddleware(BasicAuthOptions{
+		Realm:       `Admin "area"`,
+		Credentials: map[string]string{"admin": "s3cret", "ops": "other"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var user string
+	h := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user = BasicAuthUser(r.Context())
+	}))
+
+	for _, test := range basicAuthTests {
+		user = ""
+		req := httptest.NewRequest(http.MethodGet, "/admin", nil)
+		if !test.noAuth {
+			req.SetBasicAuth(test.user, test.password)
+		}
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+
+		if rr.Code != test.expected {
+			t.Errorf("%s: expected %d, got %d", test.name, test.expected, rr.Code)
+		}
+		if test.expected == http.StatusOK && user != test.user {
+			t.Errorf("%s: expected user %q in context, got %q", test.name, test.user, user)
+		}
+		if test.expected == http.StatusUnauthorized && rr.Header().Get("WWW-Authenticate") != `Basic realm="Admin \"area\"", charset="UTF-8"` {
+			t.Errorf("%s: unexpected challenge %q", test.name, rr.Header().Get("WWW-Authenticate"))
+		}
+	}
+
+	if _, err = testTools.BasicAuthMiddleware(BasicAuthOptions{}); err == nil {
+		t.Error("expected an error without credentials")
+	}
+
+	mw, _ = testTools.BasicAuthMiddleware(BasicAuthOptions{Validate: func(r *http.Request, user, password string) bool {
+		return user == "fn" && password == "ok"
+	}})
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.SetBasicAuth("fn", "ok")
+	rr := httptest.NewRecorder()
+	mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})).ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Error("validate function was not used, got", rr.Code)
+	}
+}
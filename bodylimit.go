@@ -0,0 +1,73 @@
+package toolkit
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// ErrBodyTooLarge matches errors caused by a request body over its size limit. ErrorJSON
+// responds to them with 413 Request Entity Too Large.
+var ErrBodyTooLarge = errors.New("request body too large")
+
+type bodyTooLargeError struct {
+	limit int64
+}
+
+func (e *bodyTooLargeError) Error() string {
+	return fmt.Sprintf("body must not be larger than %d bytes", e.limit)
+}
+
+func (e *bodyTooLargeError) Is(target error) bool {
+	return target == ErrBodyTooLarge
+}
+
+func isBodyTooLarge(err error) bool {
+	var maxBytesError *http.MaxBytesError
+	return errors.Is(err, ErrBodyTooLarge) || errors.As(err, &maxBytesError)
+}
+
+// BodyLimitOptions configures BodyLimitMiddleware.
+type BodyLimitOptions struct {
+	// Limit is the maximum body size in bytes for every request.
+	Limit int64
+	// Routes overrides Limit for path prefixes, the longest matching prefix winning. A negative
+	// value lifts the limit, e.g. for an upload endpoint that enforces its own.
+	Routes map[string]int64
+}
+
+// BodyLimitMiddleware caps the size of request bodies. Requests announcing a larger
+// Content-Length are rejected up front with a 413 JSON error; other bodies are wrapped with
+// http.MaxBytesReader, so reading past the limit fails with an error ErrorJSON maps to 413.
+func (t *Tools) BodyLimitMiddleware(opts BodyLimitOptions) (func(http.Handler) http.Handler, error) {
+	if opts.Limit <= 0 {
+		return nil, errors.New("body limit should be greater than 0")
+	}
+
+	limitFor := func(path string) int64 {
+		limit, matched := opts.Limit, -1
+		for prefix, l := range opts.Routes {
+			if strings.HasPrefix(path, prefix) && len(prefix) > matched {
+				limit, matched = l, len(prefix)
+			}
+		}
+		return limit
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			limit := limitFor(r.URL.Path)
+			if limit < 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if r.ContentLength > limit {
+				_ = t.ErrorJSON(w, &bodyTooLargeError{limit: limit})
+				return
+			}
+			r.Body = http.MaxBytesReader(w, r.Body, limit)
+			next.ServeHTTP(w, r)
+		})
+	}, nil
+}
@@ -0,0 +1,66 @@
+package toolkit
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestTools_BodyLimitMiddleware(t *testing.T) {
+	var testTools Tools
+	mw, err := testTools.BodyLimitMiddleware(BodyLimitOptions{
+		Limit:  10,
+		Routes: map[string]int64{"/api/": 20, "/api/upload": -1},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := io.ReadAll(r.Body); err != nil {
+			_ = testTools.ErrorJSON(w, err)
+		}
+	}))
+
+	var tests = []struct {
+		path     string
+		size     int
+		chunked  bool
+		expected int
+	}{
+		{path: "/", size: 10, expected: http.StatusOK},
+		{path: "/", size: 11, expected: http.StatusRequestEntityTooLarge},
+		{path: "/", size: 11, chunked: true, expected: http.StatusRequestEntityTooLarge},
+		{path: "/api/orders", size: 20, expected: http.StatusOK},
+		{path: "/api/orders", size: 21, chunked: true, expected: http.StatusRequestEntityTooLarge},
+		{path: "/api/upload", size: 1000, expected: http.StatusOK},
+	}
+	for _, test := range tests {
+		req := httptest.NewRequest(http.MethodPost, test.path, strings.NewReader(strings.Repeat("a", test.size)))
+		if test.chunked {
+			req.ContentLength = -1
+		}
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		if rr.Code != test.expected {
+			t.Errorf("%s %d bytes: expected %d, got %d", test.path, test.size, test.expected, rr.Code)
+		}
+	}
+}
+
+func TestTools_ReadJSONBodyTooLarge(t *testing.T) {
+	testTools := Tools{MaxJSONSize: 5}
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"foo": "bar"}`))
+
+	var data map[string]string
+	err := testTools.ReadJSON(rr, req, &data)
+	if err == nil || err.Error() != "body must not be larger than 5 bytes" {
+		t.Fatal("unexpected error:", err)
+	}
+	_ = testTools.ErrorJSON(rr, err)
+	if rr.Code != http.StatusRequestEntityTooLarge {
+		t.Error("expected 413, got", rr.Code)
+	}
+}
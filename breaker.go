@@ -0,0 +1,179 @@
+package toolkit
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned for remote calls to a host whose circuit is open. ErrorJSON responds
+// to it with 503 Service Unavailable.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// CircuitState is the state of the circuit of a host.
+type CircuitState int
+
+const (
+	// CircuitClosed lets calls through.
+	CircuitClosed CircuitState = iota
+	// CircuitOpen rejects calls until the cooldown has passed.
+	CircuitOpen
+	// CircuitHalfOpen lets a single call through to probe whether the host recovered.
+	CircuitHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	}
+	return "closed"
+}
+
+// CircuitBreakerOptions configures a CircuitBreaker.
+type CircuitBreakerOptions struct {
+	// FailureThreshold is the number of consecutive failed calls that opens the circuit of a
+	// host, 5 when 0.
+	FailureThreshold int
+	// Cooldown is how long an open circuit rejects calls before a probe is let through, 30s
+	// when 0.
+	Cooldown time.Duration
+	// IsFailure reports whether a response status counts as a failed call, as do calls without a
+	// response. Statuses of 500 and above when nil.
+	IsFailure func(status int) bool
+	// OnStateChange, when set, is called when the circuit of a host changes state. It runs after
+	// the breaker is unlocked, so it may call State, but transitions of concurrent calls may be
+	// reported out of order.
+	OnStateChange func(host string, from, to CircuitState)
+}
+
+// CircuitBreaker stops remote calls to hosts that keep failing, so requests fail fast instead of
+// waiting for the timeout of a dead service. Every host has its own circuit: FailureThreshold
+// consecutive failures open it, and after Cooldown one call is let through, which closes it
+// again when it succeeds.
+type CircuitBreaker struct {
+	opts CircuitBreakerOptions
+	now  func() time.Time
+
+	mu    sync.Mutex
+	hosts map[string]*circuit
+}
+
+type circuit struct {
+	state    CircuitState
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+// NewCircuitBreaker returns a CircuitBreaker with all circuits closed. Set it as
+// Tools.RemoteBreaker to guard PushJSONToRemote and CallRemote.
+func (t *Tools) NewCircuitBreaker(opts CircuitBreakerOptions) *CircuitBreaker {
+	if opts.FailureThreshold <= 0 {
+		opts.FailureThreshold = 5
+	}
+	if opts.Cooldown <= 0 {
+		opts.Cooldown = 30 * time.Second
+	}
+	if opts.IsFailure == nil {
+		opts.IsFailure = func(status int) bool { return status >= 500 }
+	}
+	return &CircuitBreaker{opts: opts, now: time.Now, hosts: make(map[string]*circuit)}
+}
+
+// State returns the state of the circuit of host.
+func (b *CircuitBreaker) State(host string) CircuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	c, ok := b.hosts[host]
+	if !ok {
+		return CircuitClosed
+	}
+	if c.state == CircuitOpen && b.now().Sub(c.openedAt) >= b.opts.Cooldown {
+		return CircuitHalfOpen
+	}
+	return c.state
+}
+
+// allow reports with ErrCircuitOpen that a call to host may not be made. A nil breaker allows
+// every call.
+func (b *CircuitBreaker) allow(host string) error {
+	if b == nil {
+		return nil
+	}
+	var notify func()
+	defer func() {
+		if notify != nil {
+			notify()
+		}
+	}()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	c, ok := b.hosts[host]
+	if !ok {
+		return nil
+	}
+	if c.state == CircuitOpen && b.now().Sub(c.openedAt) >= b.opts.Cooldown {
+		notify = b.setState(host, c, CircuitHalfOpen)
+	}
+	switch {
+	case c.state == CircuitOpen, c.state == CircuitHalfOpen && c.probing:
+		return fmt.Errorf("%w for %s", ErrCircuitOpen, host)
+	case c.state == CircuitHalfOpen:
+		c.probing = true
+	}
+	return nil
+}
+
+// record counts the outcome of a call to host allowed before: status is that of the response, 0
+// for calls without one. Calls given up by the caller, with aborted set, are not counted.
+func (b *CircuitBreaker) record(host string, status int, aborted bool) {
+	if b == nil {
+		return
+	}
+	var notify func()
+	defer func() {
+		if notify != nil {
+			notify()
+		}
+	}()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	c, ok := b.hosts[host]
+	if !ok {
+		c = &circuit{}
+		b.hosts[host] = c
+	}
+	wasProbe := c.probing
+	c.probing = false
+	if aborted {
+		return
+	}
+
+	if status > 0 && !b.opts.IsFailure(status) {
+		c.failures = 0
+		if c.state != CircuitClosed {
+			notify = b.setState(host, c, CircuitClosed)
+		}
+		return
+	}
+	c.failures++
+	if wasProbe || c.state == CircuitClosed && c.failures >= b.opts.FailureThreshold {
+		c.openedAt = b.now()
+		notify = b.setState(host, c, CircuitOpen)
+	}
+}
+
+// setState moves the circuit of host to state with b.mu held. It returns the call of
+// OnStateChange to make once b.mu is released, or nil when there is nothing to report.
+func (b *CircuitBreaker) setState(host string, c *circuit, state CircuitState) func() {
+	from := c.state
+	c.state = state
+	if b.opts.OnStateChange == nil || from == state {
+		return nil
+	}
+	return func() { b.opts.OnStateChange(host, from, state) }
+}
@@ -0,0 +1,122 @@
+package toolkit
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTools_CircuitBreaker(t *testing.T) {
+	var testTools Tools
+	var changes []string
+	b := testTools.NewCircuitBreaker(CircuitBreakerOptions{
+		FailureThreshold: 2,
+		Cooldown:         time.Minute,
+		OnStateChange: func(host string, from, to CircuitState) {
+			changes = append(changes, fmt.Sprintf("%s:%s>%s", host, from, to))
+		},
+	})
+	now := time.Now()
+	b.now = func() time.Time { return now }
+
+	b.record("a", 500, false)
+	b.record("a", 200, false)
+	b.record("a", 0, false)
+	if b.State("a") != CircuitClosed || b.allow("a") != nil {
+		t.Fatal("expected a success to reset the failures")
+	}
+	b.record("a", 503, false)
+	if b.State("a") != CircuitOpen || !errors.Is(b.allow("a"), ErrCircuitOpen) {
+		t.Fatal("expected the circuit to open after 2 consecutive failures")
+	}
+	if b.allow("b") != nil {
+		t.Error("expected other hosts to be unaffected")
+	}
+
+	now = now.Add(time.Minute)
+	if b.State("a") != CircuitHalfOpen || b.allow("a") != nil {
+		t.Fatal("expected a probe after the cooldown")
+	}
+	if !errors.Is(b.allow("a"), ErrCircuitOpen) {
+		t.Error("expected a single probe at a time")
+	}
+	b.record("a", 500, false)
+	if b.State("a") != CircuitOpen {
+		t.Fatal("expected a failed probe to open the circuit again")
+	}
+
+	now = now.Add(time.Minute)
+	_ = b.allow("a")
+	b.record("a", 0, true)
+	if b.allow("a") != nil {
+		t.Fatal("expected an aborted probe to let another one through")
+	}
+	b.record("a", 204, false)
+	if b.State("a") != CircuitClosed {
+		t.Fatal("expected a successful probe to close the circuit")
+	}
+
+	expected := "[a:closed>open a:open>half-open a:half-open>open a:open>half-open a:half-open>closed]"
+	if fmt.Sprint(changes) != expected {
+		t.Errorf("expected changes %s, got %v", expected, changes)
+	}
+}
+
+func TestTools_CircuitBreakerStateFromHook(t *testing.T) {
+	var testTools Tools
+	var b *CircuitBreaker
+	var states []CircuitState
+	b = testTools.NewCircuitBreaker(CircuitBreakerOptions{
+		FailureThreshold: 1,
+		OnStateChange: func(host string, from, to CircuitState) {
+			states = append(states, b.State(host))
+		},
+	})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		b.record("a", 500, false)
+		b.record("a", 200, false)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected OnStateChange to be able to call State")
+	}
+	if fmt.Sprint(states) != "[open closed]" {
+		t.Errorf("unexpected states %v", states)
+	}
+}
+
+func TestTools_CircuitBreakerRemote(t *testing.T) {
+	calls := 0
+	client := NewTestClient(func(req *http.Request) *http.Response {
+		calls++
+		return &http.Response{StatusCode: http.StatusBadGateway, Body: io.NopCloser(bytes.NewBufferString("")), Header: make(http.Header)}
+	})
+
+	var testTools Tools
+	testTools.RemoteBreaker = testTools.NewCircuitBreaker(CircuitBreakerOptions{FailureThreshold: 2})
+	for range 2 {
+		if _, status, _ := testTools.PushJSONToRemote("http://remote.example", "x", client); status != http.StatusBadGateway {
+			t.Errorf("expected the remote's response, got %d", status)
+		}
+	}
+	_, _, err := testTools.CallRemote(context.Background(), http.MethodGet, "http://remote.example", nil, nil, WithHTTPClient(client))
+	if !errors.Is(err, ErrCircuitOpen) || calls != 2 {
+		t.Errorf("expected the open circuit to stop the call, got %v after %d calls", err, calls)
+	}
+
+	rr := httptest.NewRecorder()
+	_ = testTools.ErrorJSON(rr, err)
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503, got %d", rr.Code)
+	}
+}
@@ -0,0 +1,198 @@
+package toolkit
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// CacheOptions configures a Cache.
+type CacheOptions struct {
+	// TTL is how long entries live unless set with their own TTL. Entries do not expire when 0.
+	TTL time.Duration
+	// MaxEntries bounds the cache, evicting the least recently used entries. Unbounded when 0.
+	MaxEntries int
+	// Hooks, when set, are called on every lookup and eviction, e.g. to feed counters. They are
+	// called with the cache locked and must not use it.
+	OnHit   func()
+	OnMiss  func()
+	OnEvict func()
+}
+
+// Cache is an in-memory cache with per-entry TTL and LRU eviction. It is safe for concurrent use.
+type Cache[K comparable, V any] struct {
+	opts    CacheOptions
+	mu      sync.Mutex
+	entries map[K]*list.Element
+	lru     *list.List
+	calls   map[K]*cacheCall[V]
+	now     func() time.Time
+}
+
+type cacheEntry[K comparable, V any] struct {
+	key     K
+	value   V
+	expires time.Time
+}
+
+type cacheCall[V any] struct {
+	done  chan struct{}
+	value V
+	err   error
+}
+
+// NewCache returns an empty cache.
+func NewCache[K comparable, V any](opts CacheOptions) *Cache[K, V] {
+	return &Cache[K, V]{
+		opts:    opts,
+		entries: make(map[K]*list.Element),
+		lru:     list.New(),
+		calls:   make(map[K]*cacheCall[V]),
+		now:     time.Now,
+	}
+}
+
+// Get returns the value cached for key.
+func (c *Cache[K, V]) Get(key K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.get(key)
+}
+
+func (c *Cache[K, V]) get(key K) (V, bool) {
+	if el, ok := c.entries[key]; ok {
+		e := el.Value.(*cacheEntry[K, V])
+		if e.expires.IsZero() || c.now().Before(e.expires) {
+			c.lru.MoveToFront(el)
+			if c.opts.OnHit != nil {
+				c.opts.OnHit()
+			}
+			return e.value, true
+		}
+		c.remove(el)
+	}
+	if c.opts.OnMiss != nil {
+		c.opts.OnMiss()
+	}
+	var zero V
+	return zero, false
+}
+
+// Set caches value for key with the cache's TTL.
+func (c *Cache[K, V]) Set(key K, value V) {
+	c.SetWithTTL(key, value, c.opts.TTL)
+}
+
+// SetWithTTL caches value for key for ttl, or without expiry when ttl is 0.
+func (c *Cache[K, V]) SetWithTTL(key K, value V, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var expires time.Time
+	if ttl > 0 {
+		expires = c.now().Add(ttl)
+	}
+	if el, ok := c.entries[key]; ok {
+		e := el.Value.(*cacheEntry[K, V])
+		e.value, e.expires = value, expires
+		c.lru.MoveToFront(el)
+		return
+	}
+	c.entries[key] = c.lru.PushFront(&cacheEntry[K, V]{key: key, value: value, expires: expires})
+	for c.opts.MaxEntries > 0 && c.lru.Len() > c.opts.MaxEntries {
+		c.remove(c.lru.Back())
+		if c.opts.OnEvict != nil {
+			c.opts.OnEvict()
+		}
+	}
+}
+
+// Delete removes key from the cache.
+func (c *Cache[K, V]) Delete(key K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.entries[key]; ok {
+		c.remove(el)
+	}
+}
+
+// Len returns the number of entries, including expired ones not yet removed.
+func (c *Cache[K, V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len()
+}
+
+// Sweep removes expired entries and returns how many it removed. Expired entries are otherwise
+// only removed when looked up, so long-lived caches should sweep periodically, e.g. from a
+// Scheduler.
+func (c *Cache[K, V]) Sweep() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	n := 0
+	for el := c.lru.Back(); el != nil; {
+		prev := el.Prev()
+		if e := el.Value.(*cacheEntry[K, V]); !e.expires.IsZero() && !now.Before(e.expires) {
+			c.remove(el)
+			n++
+		}
+		el = prev
+	}
+	return n
+}
+
+func (c *Cache[K, V]) remove(el *list.Element) {
+	c.lru.Remove(el)
+	delete(c.entries, el.Value.(*cacheEntry[K, V]).key)
+}
+
+// GetOrLoad returns the value cached for key, calling load to fill the cache on a miss.
+// Concurrent misses for the same key share a single load call. Errors are returned to every
+// waiting caller and not cached; a caller whose ctx ends stops waiting, while the load goes on
+// for the others.
+func (c *Cache[K, V]) GetOrLoad(ctx context.Context, key K, load func(ctx context.Context) (V, error)) (V, error) {
+	c.mu.Lock()
+	if v, ok := c.get(key); ok {
+		c.mu.Unlock()
+		return v, nil
+	}
+	call, loading := c.calls[key]
+	if !loading {
+		call = &cacheCall[V]{done: make(chan struct{})}
+		c.calls[key] = call
+	}
+	c.mu.Unlock()
+
+	if !loading {
+		// The load is detached from the first caller's cancellation, since others may wait on it.
+		go c.load(context.WithoutCancel(ctx), key, call, load)
+	}
+
+	select {
+	case <-call.done:
+		return call.value, call.err
+	case <-ctx.Done():
+		var zero V
+		return zero, ctx.Err()
+	}
+}
+
+func (c *Cache[K, V]) load(ctx context.Context, key K, call *cacheCall[V], load func(ctx context.Context) (V, error)) {
+	defer func() {
+		if p := recover(); p != nil {
+			call.err = fmt.Errorf("cache load panicked: %v", p)
+		}
+		c.mu.Lock()
+		delete(c.calls, key)
+		c.mu.Unlock()
+		close(call.done)
+	}()
+
+	call.value, call.err = load(ctx)
+	if call.err == nil {
+		c.Set(key, call.value)
+	}
+}
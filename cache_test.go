@@ -0,0 +1,110 @@
+package toolkit
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestTools_Cache(t *testing.T) {
+	var hits, misses, evictions int
+	c := NewCache[string, int](CacheOptions{
+		TTL:        time.Minute,
+		MaxEntries: 2,
+		OnHit:      func() { hits++ },
+		OnMiss:     func() { misses++ },
+		OnEvict:    func() { evictions++ },
+	})
+	clock := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	c.now = func() time.Time { return clock }
+
+	c.Set("a", 1)
+	c.Set("b", 2)
+	if v, ok := c.Get("a"); !ok || v != 1 {
+		t.Errorf("expected a=1, got %d %v", v, ok)
+	}
+	c.Set("c", 3)
+	if _, ok := c.Get("b"); ok {
+		t.Error("expected the least recently used entry to be evicted")
+	}
+	if _, ok := c.Get("a"); !ok {
+		t.Error("recently used entry was evicted")
+	}
+
+	c.SetWithTTL("a", 10, 0)
+	clock = clock.Add(2 * time.Minute)
+	if _, ok := c.Get("c"); ok {
+		t.Error("expected c to expire")
+	}
+	if v, ok := c.Get("a"); !ok || v != 10 {
+		t.Error("entry without TTL expired")
+	}
+	c.Set("d", 4)
+	clock = clock.Add(2 * time.Minute)
+	if n := c.Sweep(); n != 1 || c.Len() != 1 {
+		t.Errorf("expected the sweep to remove the expired entry, removed %d leaving %d", n, c.Len())
+	}
+	c.Delete("a")
+	if c.Len() != 0 {
+		t.Errorf("expected an empty cache, have %d entries", c.Len())
+	}
+	if hits != 3 || misses != 2 || evictions != 1 {
+		t.Errorf("unexpected hook counts: hits %d, misses %d, evictions %d", hits, misses, evictions)
+	}
+}
+
+func TestTools_CacheGetOrLoad(t *testing.T) {
+	c := NewCache[string, string](CacheOptions{})
+	var calls atomic.Int32
+	release := make(chan struct{})
+	load := func(ctx context.Context) (string, error) {
+		calls.Add(1)
+		<-release
+		return "value", nil
+	}
+
+	var wg sync.WaitGroup
+	results := make(chan string, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, _ := c.GetOrLoad(context.Background(), "k", load)
+			results <- v
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	close(results)
+
+	for v := range results {
+		if v != "value" {
+			t.Errorf("expected the loaded value, got %q", v)
+		}
+	}
+	if calls.Load() != 1 {
+		t.Errorf("expected a single load, got %d", calls.Load())
+	}
+	if v, ok := c.Get("k"); !ok || v != "value" {
+		t.Error("loaded value was not cached")
+	}
+
+	_, err := c.GetOrLoad(context.Background(), "err", func(context.Context) (string, error) { return "", errors.New("boom") })
+	if err == nil || c.Len() != 1 {
+		t.Errorf("expected error not to be cached, got %v with %d entries", err, c.Len())
+	}
+	_, err = c.GetOrLoad(context.Background(), "panic", func(context.Context) (string, error) { panic("boom") })
+	if err == nil {
+		t.Error("expected a panicking load to fail")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err = c.GetOrLoad(ctx, "slow", func(context.Context) (string, error) { return "x", nil }); !errors.Is(err, context.Canceled) && err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+}
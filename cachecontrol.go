@@ -0,0 +1,50 @@
+package toolkit
+
+import (
+	"net/http"
+	"path"
+	"strings"
+)
+
+// Common Cache-Control values for CacheRule.
+const (
+	// CacheImmutable suits fingerprinted static assets that never change under the same URL.
+	CacheImmutable = "public, max-age=31536000, immutable"
+	// CacheNoStore keeps responses, such as API data, out of every cache.
+	CacheNoStore = "no-store"
+	// CacheRevalidate lets caches keep a response but makes them check it before each use.
+	CacheRevalidate = "no-cache"
+)
+
+// CacheRule applies a Cache-Control value to request paths matching Pattern. Patterns are slash
+// separated globs where "**" matches any number of segments, e.g. "/static/**" or "/api/**".
+type CacheRule struct {
+	Pattern string
+	Value   string
+}
+
+// CacheControlMiddleware sets the Cache-Control header from the first rule matching the request
+// path, or def when none matches and def is not empty. Handlers can still override the header.
+func (t *Tools) CacheControlMiddleware(rules []CacheRule, def string) func(http.Handler) http.Handler {
+	compiled := make([][]string, len(rules))
+	for i, rule := range rules {
+		compiled[i] = strings.Split(rule.Pattern, "/")
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			value := def
+			parts := strings.Split(path.Clean("/"+r.URL.Path), "/")
+			for i, rule := range rules {
+				if matchSegments(compiled[i], parts) {
+					value = rule.Value
+					break
+				}
+			}
+			if value != "" {
+				w.Header().Set("Cache-Control", value)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
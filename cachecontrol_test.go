@@ -0,0 +1,44 @@
+package toolkit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+var cacheControlTests = []struct {
+	path     string
+	expected string
+}{
+	{path: "/static/app.3f2a.js", expected: CacheImmutable},
+	{path: "/static/css/site.css", expected: CacheImmutable},
+	{path: "/api", expected: CacheNoStore},
+	{path: "/api/orders/1", expected: CacheNoStore},
+	{path: "/docs/intro.pdf", expected: "public, max-age=3600"},
+	{path: "/", expected: CacheRevalidate},
+	{path: "/static/../api/secret", expected: CacheNoStore},
+	{path: "/override", expected: "private"},
+}
+
+func TestTools_CacheControlMiddleware(t *testing.T) {
+	var testTools Tools
+	h := testTools.CacheControlMiddleware([]CacheRule{
+		{Pattern: "/static/**", Value: CacheImmutable},
+		{Pattern: "/api/**", Value: CacheNoStore},
+		{Pattern: "/docs/*.pdf", Value: "public, max-age=3600"},
+	}, CacheRevalidate)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/override" {
+			w.Header().Set("Cache-Control", "private")
+		}
+	}))
+
+	for _, test := range cacheControlTests {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.URL.Path = test.path
+		h.ServeHTTP(rr, req)
+		if got := rr.Header().Get("Cache-Control"); got != test.expected {
+			t.Errorf("%s: expected %q, got %q", test.path, test.expected, got)
+		}
+	}
+}
@@ -0,0 +1,227 @@
+package toolkit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidHash is returned by ContentStore methods given something that is not a SHA-256 hex digest.
+var ErrInvalidHash = errors.New("invalid content hash")
+
+// ContentStore is a content-addressed file store: every object is kept once under its SHA-256
+// digest as root/aa/bb/aabbcc..., with a reference count next to it in a .refs file. Storing
+// content that is already present only increments its count. Reference counts are updated under
+// a file lock, so a store can be shared between processes.
+type ContentStore struct {
+	Root string
+
+	tools *Tools
+}
+
+// NewContentStore returns a store rooted at root, creating the directory if needed. Objects are
+// written with the Tools' FileMode and DirMode and encrypted when EncryptionKeys is set.
+func (t *Tools) NewContentStore(root string) (*ContentStore, error) {
+	if _, err := t.CreateDirIfNotExists(root); err != nil {
+		return nil, err
+	}
+	return &ContentStore{Root: root, tools: t}, nil
+}
+
+// Put stores the content of r and returns its hex encoded SHA-256 digest and size. When the
+// content is already stored, the existing object is kept and its reference count incremented.
+func (s *ContentStore) Put(r io.Reader) (string, int64, error) {
+	tmp, err := os.CreateTemp(s.Root, ".cas.tmp-*")
+	if err != nil {
+		return "", 0, err
+	}
+	tmpName := tmp.Name()
+	defer os.Remove(tmpName)
+
+	h := sha256.New()
+	src := io.TeeReader(r, h)
+	n, err := func() (int64, error) {
+		defer tmp.Close()
+
+		var n int64
+		if s.tools.encryptionEnabled() {
+			enc, err := s.tools.encryptingReader(src)
+			if err != nil {
+				return 0, err
+			}
+			_, err = io.Copy(tmp, enc)
+			enc.Close()
+			if err != nil {
+				return 0, err
+			}
+			n = enc.n
+		} else if n, err = io.Copy(tmp, src); err != nil {
+			return n, err
+		}
+
+		if err := tmp.Sync(); err != nil {
+			return n, err
+		}
+		if err := tmp.Chmod(s.tools.fileMode()); err != nil {
+			return n, err
+		}
+		return n, tmp.Close()
+	}()
+	if err != nil {
+		return "", n, err
+	}
+
+	hash := hex.EncodeToString(h.Sum(nil))
+	path := s.Path(hash)
+	if _, err = s.tools.CreateDirIfNotExists(filepath.Dir(path)); err != nil {
+		return "", n, err
+	}
+
+	err = s.withRefs(hash, func(refs int) (int, error) {
+		if refs == 0 {
+			if err := os.Rename(tmpName, path); err != nil {
+				return 0, err
+			}
+			syncDir(filepath.Dir(path))
+		}
+		return refs + 1, nil
+	})
+	if err != nil {
+		return "", n, err
+	}
+	return hash, n, nil
+}
+
+// Path returns where the object with the given digest is stored. The object may not exist.
+func (s *ContentStore) Path(hash string) string {
+	hash = strings.ToLower(hash)
+	if len(hash) < 4 {
+		return filepath.Join(s.Root, hash)
+	}
+	return filepath.Join(s.Root, hash[:2], hash[2:4], hash)
+}
+
+// RelPath returns Path relative to Root, in the form used as UploadedFile.NewFileName.
+func (s *ContentStore) RelPath(hash string) string {
+	rel, _ := filepath.Rel(s.Root, s.Path(hash))
+	return filepath.ToSlash(rel)
+}
+
+// Exists reports whether an object with the given digest is stored.
+func (s *ContentStore) Exists(hash string) bool {
+	if !validHash(hash) {
+		return false
+	}
+	_, err := os.Stat(s.Path(hash))
+	return err == nil
+}
+
+// Open opens a stored object for reading, decrypting it when it was stored encrypted.
+func (s *ContentStore) Open(hash string) (io.ReadSeekCloser, error) {
+	if !validHash(hash) {
+		return nil, ErrInvalidHash
+	}
+	if s.tools.encryptionEnabled() {
+		return s.tools.OpenDecrypted(s.Path(hash))
+	}
+	return s.tools.open(s.Path(hash))
+}
+
+// RefCount returns the number of references held on an object, 0 when it is not stored.
+func (s *ContentStore) RefCount(hash string) (int, error) {
+	if !validHash(hash) {
+		return 0, ErrInvalidHash
+	}
+	return s.readRefs(hash)
+}
+
+// AddRef records one more reference to a stored object and returns the new count.
+func (s *ContentStore) AddRef(hash string) (int, error) {
+	var count int
+	err := s.withRefs(hash, func(refs int) (int, error) {
+		if refs == 0 {
+			return 0, &fs.PathError{Op: "addref", Path: s.Path(hash), Err: fs.ErrNotExist}
+		}
+		count = refs + 1
+		return count, nil
+	})
+	return count, err
+}
+
+// Release drops one reference to a stored object and returns the remaining count. The object is
+// deleted when the last reference is released.
+func (s *ContentStore) Release(hash string) (int, error) {
+	var count int
+	err := s.withRefs(hash, func(refs int) (int, error) {
+		if refs == 0 {
+			return 0, &fs.PathError{Op: "release", Path: s.Path(hash), Err: fs.ErrNotExist}
+		}
+		count = refs - 1
+		if count == 0 {
+			if err := os.Remove(s.Path(hash)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return refs, err
+			}
+		}
+		return count, nil
+	})
+	return count, err
+}
+
+// withRefs runs fn with the current reference count of hash while holding the store lock and
+// saves the count it returns. A count of zero removes the .refs file.
+func (s *ContentStore) withRefs(hash string, fn func(refs int) (int, error)) error {
+	if !validHash(hash) {
+		return ErrInvalidHash
+	}
+
+	return s.tools.WithLock(filepath.Join(s.Root, ".cas.lock"), func() error {
+		refs, err := s.readRefs(hash)
+		if err != nil {
+			return err
+		}
+		count, err := fn(refs)
+		if err != nil {
+			return err
+		}
+
+		refsPath := s.Path(hash) + ".refs"
+		if count == 0 {
+			if err = os.Remove(refsPath); errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		_, err = s.tools.WriteFileAtomic(refsPath, strings.NewReader(strconv.Itoa(count)), s.tools.fileMode())
+		return err
+	})
+}
+
+func (s *ContentStore) readRefs(hash string) (int, error) {
+	data, err := os.ReadFile(s.Path(hash) + ".refs")
+	if errors.Is(err, fs.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	refs, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		return 0, fmt.Errorf("corrupt reference count for %s: %w", hash, err)
+	}
+	return refs, nil
+}
+
+func validHash(hash string) bool {
+	if len(hash) != sha256.Size*2 {
+		return false
+	}
+	_, err := hex.DecodeString(hash)
+	return err == nil
+}
@@ -0,0 +1,112 @@
+package toolkit
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTools_ContentStore(t *testing.T) {
+	var testTools Tools
+	store, err := testTools.NewContentStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hash, n, err := store.Put(strings.NewReader("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hash != "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824" || n != 5 {
+		t.Errorf("unexpected hash %s or size %d", hash, n)
+	}
+	if store.Path(hash) != filepath.Join(store.Root, "2c", "f2", hash) {
+		t.Error("unexpected object path:", store.Path(hash))
+	}
+
+	if again, _, err := store.Put(strings.NewReader("hello")); err != nil || again != hash {
+		t.Fatal("second put returned", again, err)
+	}
+	if refs, _ := store.RefCount(hash); refs != 2 {
+		t.Errorf("expected 2 references, got %d", refs)
+	}
+	if refs, _ := store.AddRef(hash); refs != 3 {
+		t.Errorf("expected 3 references, got %d", refs)
+	}
+
+	f, err := store.Open(hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := io.ReadAll(f)
+	f.Close()
+	if string(data) != "hello" {
+		t.Errorf("unexpected content %q", data)
+	}
+
+	for want := 2; want >= 0; want-- {
+		if refs, err := store.Release(hash); err != nil || refs != want {
+			t.Fatalf("expected %d references after release, got %d (%v)", want, refs, err)
+		}
+	}
+	if store.Exists(hash) {
+		t.Error("object not removed after last release")
+	}
+	if _, err = store.Release(hash); !errors.Is(err, fs.ErrNotExist) {
+		t.Error("expected releasing a missing object to fail, got", err)
+	}
+	if _, err = store.AddRef(hash); !errors.Is(err, fs.ErrNotExist) {
+		t.Error("expected adding a reference to a missing object to fail, got", err)
+	}
+	if _, err = store.RefCount("../../etc/passwd"); !errors.Is(err, ErrInvalidHash) {
+		t.Error("expected ErrInvalidHash, got", err)
+	}
+
+	entries, _ := os.ReadDir(store.Root)
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name(), ".cas.tmp-") {
+			t.Error("temporary file left behind:", e.Name())
+		}
+	}
+}
+
+func TestTools_UploadFilesContentAddressed(t *testing.T) {
+	testTools := encryptionTools()
+	testTools.ContentAddressed = true
+	dir := t.TempDir()
+	img := testPNG(t)
+
+	first, err := testTools.UploadFiles(newUploadRequest(t, map[string][]byte{"a.png": img}), dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := testTools.UploadFiles(newUploadRequest(t, map[string][]byte{"b.png": img}), dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first[0].NewFileName != second[0].NewFileName || first[0].FileSize != int64(len(img)) {
+		t.Fatalf("expected identical uploads to share an object: %+v %+v", first[0], second[0])
+	}
+
+	store, _ := testTools.NewContentStore(dir)
+	hash := filepath.Base(first[0].NewFileName)
+	if refs, _ := store.RefCount(hash); refs != 2 {
+		t.Errorf("expected 2 references, got %d", refs)
+	}
+	if stored, _ := os.ReadFile(store.Path(hash)); bytes.Equal(stored, img) {
+		t.Error("object was not encrypted at rest")
+	}
+
+	rr := httptest.NewRecorder()
+	testTools.DownloadStaticFile(rr, httptest.NewRequest(http.MethodGet, "/", nil), dir, first[0].NewFileName, "a.png")
+	if !bytes.Equal(rr.Body.Bytes(), img) || rr.Header().Get("Content-Type") != "image/png" {
+		t.Errorf("unexpected download %d %s", rr.Code, rr.Header().Get("Content-Type"))
+	}
+}
@@ -0,0 +1,86 @@
+package toolkit
+
+import (
+	"strings"
+	"unicode"
+)
+
+var commonAcronyms = map[string]bool{
+	"API": true, "CSS": true, "CSV": true, "DNS": true, "HTML": true, "HTTP": true, "HTTPS": true,
+	"ID": true, "IP": true, "JSON": true, "JWT": true, "SQL": true, "TLS": true, "UI": true,
+	"URI": true, "URL": true, "UUID": true, "XML": true,
+}
+
+// ToSnake converts s to snake_case, e.g. "UserID" -> "user_id".
+func (t *Tools) ToSnake(s string) string {
+	return strings.ToLower(strings.Join(splitWords(s), "_"))
+}
+
+// ToKebab converts s to kebab-case, e.g. "HTTPServer" -> "http-server".
+func (t *Tools) ToKebab(s string) string {
+	return strings.ToLower(strings.Join(splitWords(s), "-"))
+}
+
+// ToPascal converts s to PascalCase keeping common acronyms upper case, e.g. "user_id" -> "UserID".
+func (t *Tools) ToPascal(s string) string {
+	var b strings.Builder
+	for _, w := range splitWords(s) {
+		b.WriteString(capitalizeWord(w))
+	}
+	return b.String()
+}
+
+// ToCamel converts s to camelCase keeping common acronyms upper case, e.g. "url_path" -> "urlPath".
+func (t *Tools) ToCamel(s string) string {
+	var b strings.Builder
+	for i, w := range splitWords(s) {
+		if i == 0 {
+			b.WriteString(strings.ToLower(w))
+			continue
+		}
+		b.WriteString(capitalizeWord(w))
+	}
+	return b.String()
+}
+
+func capitalizeWord(w string) string {
+	if upper := strings.ToUpper(w); commonAcronyms[upper] {
+		return upper
+	}
+	r := []rune(strings.ToLower(w))
+	r[0] = unicode.ToUpper(r[0])
+	return string(r)
+}
+
+// splitWords breaks s into words on separators and case boundaries, keeping acronym runs
+// like "HTTP" in "HTTPServer" together.
+func splitWords(s string) []string {
+	var words []string
+	var current []rune
+	runes := []rune(s)
+
+	flush := func() {
+		if len(current) > 0 {
+			words = append(words, string(current))
+			current = current[:0]
+		}
+	}
+
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			flush()
+			continue
+		}
+		if unicode.IsUpper(r) && len(current) > 0 {
+			prev := current[len(current)-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				flush()
+			}
+		}
+		current = append(current, r)
+	}
+	flush()
+
+	return words
+}
@@ -0,0 +1,37 @@
+package toolkit
+
+import "testing"
+
+var caseTests = []struct {
+	input  string
+	snake  string
+	kebab  string
+	camel  string
+	pascal string
+}{
+	{input: "user_id", snake: "user_id", kebab: "user-id", camel: "userID", pascal: "UserID"},
+	{input: "UserID", snake: "user_id", kebab: "user-id", camel: "userID", pascal: "UserID"},
+	{input: "HTTPServer", snake: "http_server", kebab: "http-server", camel: "httpServer", pascal: "HTTPServer"},
+	{input: "url-path", snake: "url_path", kebab: "url-path", camel: "urlPath", pascal: "URLPath"},
+	{input: "max file size", snake: "max_file_size", kebab: "max-file-size", camel: "maxFileSize", pascal: "MaxFileSize"},
+	{input: "parseJSONBody", snake: "parse_json_body", kebab: "parse-json-body", camel: "parseJSONBody", pascal: "ParseJSONBody"},
+	{input: "v2Api", snake: "v2_api", kebab: "v2-api", camel: "v2API", pascal: "V2API"},
+}
+
+func TestTools_CaseConversion(t *testing.T) {
+	var testTools Tools
+	for _, test := range caseTests {
+		if s := testTools.ToSnake(test.input); s != test.snake {
+			t.Errorf("%s: expected snake %s, got %s", test.input, test.snake, s)
+		}
+		if s := testTools.ToKebab(test.input); s != test.kebab {
+			t.Errorf("%s: expected kebab %s, got %s", test.input, test.kebab, s)
+		}
+		if s := testTools.ToCamel(test.input); s != test.camel {
+			t.Errorf("%s: expected camel %s, got %s", test.input, test.camel, s)
+		}
+		if s := testTools.ToPascal(test.input); s != test.pascal {
+			t.Errorf("%s: expected pascal %s, got %s", test.input, test.pascal, s)
+		}
+	}
+}
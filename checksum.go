@@ -0,0 +1,111 @@
+package toolkit
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+)
+
+// HashAlgorithm names a supported checksum algorithm.
+type HashAlgorithm string
+
+const (
+	HashMD5    HashAlgorithm = "md5"
+	HashSHA1   HashAlgorithm = "sha1"
+	HashSHA256 HashAlgorithm = "sha256"
+	HashSHA512 HashAlgorithm = "sha512"
+)
+
+// ErrChecksumMismatch is returned when a written file does not hash to the expected digest.
+var ErrChecksumMismatch = errors.New("checksum mismatch")
+
+func newHash(algo HashAlgorithm) (hash.Hash, error) {
+	switch algo {
+	case HashMD5:
+		return md5.New(), nil
+	case HashSHA1:
+		return sha1.New(), nil
+	case HashSHA256, "":
+		return sha256.New(), nil
+	case HashSHA512:
+		return sha512.New(), nil
+	default:
+		return nil, fmt.Errorf("unsupported hash algorithm %s", algo)
+	}
+}
+
+// FileChecksum returns the hex encoded digest of the file at path.
+func (t *Tools) FileChecksum(path string, algo HashAlgorithm) (string, error) {
+	h, err := newHash(algo)
+	if err != nil {
+		return "", err
+	}
+
+	f, err := t.open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	if _, err = io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// CopyFileVerified copies src to dst while hashing the data, then hashes dst again to make sure
+// what landed on disk is identical. It returns the hex encoded digest. On a mismatch dst is
+// removed and ErrChecksumMismatch is returned.
+func (t *Tools) CopyFileVerified(src, dst string, algo HashAlgorithm) (string, error) {
+	h, err := newHash(algo)
+	if err != nil {
+		return "", err
+	}
+
+	in, err := t.open(src)
+	if err != nil {
+		return "", err
+	}
+	defer in.Close()
+
+	info, err := in.Stat()
+	if err != nil {
+		return "", err
+	}
+	if !info.Mode().IsRegular() {
+		return "", fmt.Errorf("%s is not a regular file", src)
+	}
+
+	if _, err = t.WriteFileAtomic(dst, io.TeeReader(in, h), info.Mode().Perm()); err != nil {
+		return "", err
+	}
+	digest := hex.EncodeToString(h.Sum(nil))
+
+	written, err := t.FileChecksum(dst, algo)
+	if err != nil {
+		return "", err
+	}
+	if written != digest {
+		_ = os.Remove(dst)
+		return "", fmt.Errorf("%w: copied %s to %s", ErrChecksumMismatch, src, dst)
+	}
+
+	return digest, nil
+}
+
+// MoveFileVerified moves src to dst through CopyFileVerified, removing src only once the copy
+// has been verified.
+func (t *Tools) MoveFileVerified(src, dst string, algo HashAlgorithm) (string, error) {
+	digest, err := t.CopyFileVerified(src, dst, algo)
+	if err != nil {
+		return "", err
+	}
+	return digest, os.Remove(src)
+}
@@ -0,0 +1,65 @@
+package toolkit
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var checksumTests = []struct {
+	algo     HashAlgorithm
+	expected string
+}{
+	{algo: HashMD5, expected: "5d41402abc4b2a76b9719d911017c592"},
+	{algo: HashSHA1, expected: "aaf4c61ddcc5e8a2dabede0f3b482cd9aea9434d"},
+	{algo: HashSHA256, expected: "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"},
+}
+
+func TestTools_CopyFileVerified(t *testing.T) {
+	var testTools Tools
+
+	for _, test := range checksumTests {
+		dir := t.TempDir()
+		src := filepath.Join(dir, "customer.pdf")
+		_ = os.WriteFile(src, []byte("hello"), 0600)
+
+		digest, err := testTools.CopyFileVerified(src, filepath.Join(dir, "copy.pdf"), test.algo)
+		if err != nil {
+			t.Fatalf("%s: %s", test.algo, err)
+		}
+		if digest != test.expected {
+			t.Errorf("%s: expected digest %s, got %s", test.algo, test.expected, digest)
+		}
+
+		sum, _ := testTools.FileChecksum(filepath.Join(dir, "copy.pdf"), test.algo)
+		if sum != test.expected {
+			t.Errorf("%s: wrong checksum of copy %s", test.algo, sum)
+		}
+	}
+
+	if _, err := testTools.CopyFileVerified("a", "b", "crc"); err == nil {
+		t.Error("expected error for unsupported algorithm")
+	}
+}
+
+func TestTools_MoveFileVerified(t *testing.T) {
+	var testTools Tools
+	dir := t.TempDir()
+	src := filepath.Join(dir, "customer.pdf")
+	dst := filepath.Join(dir, "archive.pdf")
+	_ = os.WriteFile(src, []byte("hello"), 0600)
+
+	digest, err := testTools.MoveFileVerified(src, dst, HashSHA256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if digest != checksumTests[2].expected {
+		t.Error("wrong digest", digest)
+	}
+	if _, err = os.Stat(src); !os.IsNotExist(err) {
+		t.Error("source not removed")
+	}
+	if info, err := os.Stat(dst); err != nil || info.Mode().Perm() != 0600 {
+		t.Error("destination missing or wrong mode:", err)
+	}
+}
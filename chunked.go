@@ -0,0 +1,352 @@
+package toolkit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrInvalidChunk is returned by ChunkedUpload.Receive for chunks with missing or
+	// inconsistent parameters.
+	ErrInvalidChunk = errors.New("invalid upload chunk")
+	// ErrChunkTooBig is returned by ChunkedUpload.Receive for chunks larger than MaxChunkSize,
+	// and for uploads growing beyond Tools.MaxFileSize.
+	ErrChunkTooBig = errors.New("upload chunk is too big")
+	// ErrUploadNotFound is returned for upload IDs without received chunks.
+	ErrUploadNotFound = errors.New("upload not found")
+)
+
+var uploadIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+const chunkMetaFile = "upload.json"
+
+// ChunkedUploadOptions configures NewChunkedUpload.
+type ChunkedUploadOptions struct {
+	// UploadDir is where assembled files are stored, as the directory given to UploadFiles.
+	UploadDir string
+	// KeepFileName stores assembled files under the name the client sent rather than a random
+	// one.
+	KeepFileName bool
+	// Dir is where the chunks of unfinished uploads are kept, toolkit-chunks in Tools.TempRoot
+	// when empty. It must be shared by all instances receiving chunks of the same uploads.
+	Dir string
+	// MaxChunkSize is the largest chunk accepted, 10 MiB when 0.
+	MaxChunkSize int64
+	// MaxChunks is the most chunks an upload may have, 10000 when 0.
+	MaxChunks int
+	// Expiry is how long an upload may go without receiving a chunk before Cleanup removes it,
+	// 24 hours when 0.
+	Expiry time.Duration
+	// OnComplete, when set, is called with the assembled file, e.g. to record it. An error is
+	// reported to the client as the result of the last chunk.
+	OnComplete func(r *http.Request, f *UploadedFile) error
+}
+
+// ChunkedUploadStatus is the progress of a chunked upload.
+type ChunkedUploadStatus struct {
+	ID       string        `json:"upload_id"`
+	Chunks   int           `json:"chunks"`
+	Received []int         `json:"received"`
+	Complete bool          `json:"complete"`
+	File     *UploadedFile `json:"file,omitempty"`
+}
+
+// ChunkedUpload receives files sent in chunks, so large files can be uploaded over unreliable
+// connections by retrying only the chunks that failed. Chunks may arrive in any order, also in
+// parallel. Once all of them are there, the file is assembled and stored the way UploadFiles
+// stores files, with the same type checks, checksums and thumbnails.
+//
+// Every chunk is a POST request with the parameters upload_id, a client generated ID of up to 64
+// letters, digits, - and _, chunk_index, counted from 0, total_chunks and filename, in the query
+// string or a multipart form. The chunk is the request body, or the "file" field of a multipart
+// form. A GET request with upload_id reports the chunks received so far, to resume an upload.
+type ChunkedUpload struct {
+	opts  ChunkedUploadOptions
+	tools *Tools
+}
+
+type chunkMeta struct {
+	FileName string `json:"filename"`
+	Chunks   int    `json:"chunks"`
+}
+
+// NewChunkedUpload returns a receiver of chunked uploads configured by opts.
+func (t *Tools) NewChunkedUpload(opts ChunkedUploadOptions) (*ChunkedUpload, error) {
+	if opts.Dir == "" {
+		opts.Dir = filepath.Join(t.tempRoot(), "toolkit-chunks")
+	}
+	if opts.MaxChunkSize <= 0 {
+		opts.MaxChunkSize = 10 << 20
+	}
+	if opts.MaxChunks <= 0 {
+		opts.MaxChunks = 10000
+	}
+	if opts.Expiry <= 0 {
+		opts.Expiry = 24 * time.Hour
+	}
+	if _, err := t.CreateDirIfNotExists(opts.Dir); err != nil {
+		return nil, err
+	}
+	return &ChunkedUpload{opts: opts, tools: t}, nil
+}
+
+// ServeHTTP receives a chunk on POST and reports the status of an upload on GET.
+func (c *ChunkedUpload) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var st *ChunkedUploadStatus
+	var err error
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		st, err = c.Status(r.FormValue("upload_id"))
+	case http.MethodPost, http.MethodPut:
+		st, err = c.Receive(r)
+	default:
+		w.Header().Set("Allow", "GET, HEAD, POST, PUT")
+		_ = c.tools.ErrorJSON(w, errors.New("method not allowed"), http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, ErrInvalidChunk):
+			status = http.StatusBadRequest
+		case errors.Is(err, ErrUploadNotFound):
+			status = http.StatusNotFound
+		case errors.Is(err, ErrChunkTooBig):
+			status = http.StatusRequestEntityTooLarge
+		}
+		_ = c.tools.ErrorJSON(w, err, status)
+		return
+	}
+	_ = c.tools.WriteJSON(w, http.StatusOK, st)
+}
+
+// Receive stores the chunk sent with r. When it was the last one missing, the file is assembled
+// and returned in the status.
+func (c *ChunkedUpload) Receive(r *http.Request) (*ChunkedUploadStatus, error) {
+	id := r.FormValue("upload_id")
+	if !uploadIDPattern.MatchString(id) {
+		return nil, fmt.Errorf("%w: upload_id should be 1 to 64 letters, digits, - or _", ErrInvalidChunk)
+	}
+	index, err := strconv.Atoi(r.FormValue("chunk_index"))
+	if err != nil {
+		return nil, fmt.Errorf("%w: chunk_index is not a number", ErrInvalidChunk)
+	}
+	total, err := strconv.Atoi(r.FormValue("total_chunks"))
+	if err != nil || total < 1 || total > c.opts.MaxChunks {
+		return nil, fmt.Errorf("%w: total_chunks should be between 1 and %d", ErrInvalidChunk, c.opts.MaxChunks)
+	}
+	if index < 0 || index >= total {
+		return nil, fmt.Errorf("%w: chunk_index should be between 0 and %d", ErrInvalidChunk, total-1)
+	}
+	meta := chunkMeta{FileName: filepath.Base(r.FormValue("filename")), Chunks: total}
+	if meta.FileName == "." || meta.FileName == string(filepath.Separator) {
+		return nil, fmt.Errorf("%w: filename is required", ErrInvalidChunk)
+	}
+
+	dir := filepath.Join(c.opts.Dir, id)
+	if _, err = c.tools.CreateDirIfNotExists(dir); err != nil {
+		return nil, err
+	}
+	if err = c.checkMeta(dir, meta); err != nil {
+		return nil, err
+	}
+
+	body := io.Reader(r.Body)
+	if r.MultipartForm != nil {
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidChunk, err)
+		}
+		defer file.Close()
+		body = file
+	}
+	limited := &uploadLimitReader{r: contextReader{r.Context(), body}, n: c.opts.MaxChunkSize}
+	if _, err = c.tools.WriteFileAtomic(filepath.Join(dir, chunkName(index)), limited, c.tools.fileMode()); err != nil {
+		if limited.n < 0 {
+			return nil, fmt.Errorf("%w: max size is %s", ErrChunkTooBig, c.tools.HumanBytes(c.opts.MaxChunkSize))
+		}
+		return nil, err
+	}
+	now := time.Now()
+	_ = os.Chtimes(dir, now, now)
+
+	st, size, err := c.status(id, dir, total)
+	if err != nil {
+		return nil, err
+	}
+	if c.tools.MaxFileSize > 0 && size > int64(c.tools.MaxFileSize) {
+		_ = os.RemoveAll(dir)
+		return nil, fmt.Errorf("%w: the uploaded file is too big. Max size is %s", ErrChunkTooBig, c.tools.HumanBytes(int64(c.tools.MaxFileSize)))
+	}
+	if len(st.Received) < total {
+		return st, nil
+	}
+
+	// Only the request that creates the marker assembles the file; chunks completing at the same
+	// time report the upload as still in progress.
+	marker := filepath.Join(dir, ".assembling")
+	if err = os.Mkdir(marker, c.tools.dirMode()); err != nil {
+		if errors.Is(err, fs.ErrExist) {
+			return st, nil
+		}
+		return nil, err
+	}
+
+	st.File, err = c.assemble(r.Context(), dir, meta, size)
+	if err != nil {
+		// Keep the chunks, so resending the last one retries the assembly.
+		_ = os.Remove(marker)
+		return nil, err
+	}
+	_ = os.RemoveAll(dir)
+	if c.opts.OnComplete != nil {
+		if err = c.opts.OnComplete(r, st.File); err != nil {
+			return nil, err
+		}
+	}
+	st.Complete = true
+	return st, nil
+}
+
+// Status reports the chunks of the upload id received so far.
+func (c *ChunkedUpload) Status(id string) (*ChunkedUploadStatus, error) {
+	if !uploadIDPattern.MatchString(id) {
+		return nil, fmt.Errorf("%w: upload_id should be 1 to 64 letters, digits, - or _", ErrInvalidChunk)
+	}
+	dir := filepath.Join(c.opts.Dir, id)
+	meta, err := readChunkMeta(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrUploadNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	st, _, err := c.status(id, dir, meta.Chunks)
+	return st, err
+}
+
+// Cleanup removes the chunks of uploads that have not received any for Expiry. It has the
+// signature of a Job, so it can be run by a Scheduler.
+func (c *ChunkedUpload) Cleanup(ctx context.Context) error {
+	entries, err := os.ReadDir(c.opts.Dir)
+	if err != nil {
+		return err
+	}
+	cutoff := time.Now().Add(-c.opts.Expiry)
+	for _, entry := range entries {
+		if err = ctx.Err(); err != nil {
+			return err
+		}
+		info, err := entry.Info()
+		if err != nil || !entry.IsDir() || info.ModTime().After(cutoff) {
+			continue
+		}
+		if err = os.RemoveAll(filepath.Join(c.opts.Dir, entry.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkMeta records the file name and chunk count of the upload in dir with its first chunk, and
+// makes sure later chunks agree.
+func (c *ChunkedUpload) checkMeta(dir string, meta chunkMeta) error {
+	stored, err := readChunkMeta(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		data, _ := json.Marshal(meta)
+		_, err = c.tools.WriteFileAtomic(filepath.Join(dir, chunkMetaFile), bytes.NewReader(data), c.tools.fileMode())
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	if stored != meta {
+		return fmt.Errorf("%w: the upload was started as %q in %d chunks", ErrInvalidChunk, stored.FileName, stored.Chunks)
+	}
+	return nil
+}
+
+func readChunkMeta(dir string) (chunkMeta, error) {
+	var meta chunkMeta
+	data, err := os.ReadFile(filepath.Join(dir, chunkMetaFile))
+	if err != nil {
+		return meta, err
+	}
+	return meta, json.Unmarshal(data, &meta)
+}
+
+// status lists the chunks received in dir and returns their total size.
+func (c *ChunkedUpload) status(id, dir string, total int) (*ChunkedUploadStatus, int64, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, 0, err
+	}
+	st := &ChunkedUploadStatus{ID: id, Chunks: total, Received: []int{}}
+	var size int64
+	for _, entry := range entries {
+		name, ok := strings.CutPrefix(entry.Name(), "chunk-")
+		if !ok {
+			continue
+		}
+		index, err := strconv.Atoi(name)
+		if err != nil || index >= total {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		st.Received = append(st.Received, index)
+		size += info.Size()
+	}
+	sort.Ints(st.Received)
+	return st, size, nil
+}
+
+// assemble stores the chunks in dir as one file.
+func (c *ChunkedUpload) assemble(ctx context.Context, dir string, meta chunkMeta, size int64) (*UploadedFile, error) {
+	store, uploadDir, err := c.tools.uploadStore(c.opts.UploadDir)
+	if err != nil {
+		return nil, err
+	}
+
+	readers := make([]io.Reader, 0, meta.Chunks)
+	for i := range meta.Chunks {
+		f, err := os.Open(filepath.Join(dir, chunkName(i)))
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		readers = append(readers, f)
+	}
+	src := contextReader{ctx, io.MultiReader(readers...)}
+
+	header := &multipart.FileHeader{Filename: meta.FileName, Header: textproto.MIMEHeader{}, Size: size}
+	uploadedFile, err := c.tools.storeUpload(ctx, store, uploadDir, header, src, !c.opts.KeepFileName)
+	if err != nil {
+		c.tools.Metrics.observeUpload(0, err)
+		return nil, err
+	}
+	c.tools.Metrics.observeUpload(uploadedFile.FileSize, nil)
+	return uploadedFile, nil
+}
+
+func chunkName(index int) string {
+	return fmt.Sprintf("chunk-%08d", index)
+}
@@ -0,0 +1,136 @@
+package toolkit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func chunkRequest(id string, index, total int, filename string, chunk []byte) *http.Request {
+	q := url.Values{
+		"upload_id":    {id},
+		"chunk_index":  {strconv.Itoa(index)},
+		"total_chunks": {strconv.Itoa(total)},
+		"filename":     {filename},
+	}
+	req := httptest.NewRequest(http.MethodPost, "/upload?"+q.Encode(), bytes.NewReader(chunk))
+	req.Header.Set("Content-Type", "application/octet-stream")
+	return req
+}
+
+func TestTools_ChunkedUpload(t *testing.T) {
+	var testTools Tools
+	uploadDir, chunkDir := t.TempDir(), t.TempDir()
+	var completed *UploadedFile
+	c, err := testTools.NewChunkedUpload(ChunkedUploadOptions{
+		UploadDir:    uploadDir,
+		Dir:          chunkDir,
+		KeepFileName: true,
+		MaxChunkSize: 64,
+		OnComplete: func(r *http.Request, f *UploadedFile) error {
+			completed = f
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	content := bytes.Repeat([]byte("0123456789"), 15)
+	parts := [][]byte{content[:64], content[64:128], content[128:]}
+	for _, i := range []int{2, 0} {
+		rr := httptest.NewRecorder()
+		c.ServeHTTP(rr, chunkRequest("abc-1", i, 3, "notes.txt", parts[i]))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("chunk %d: unexpected response %d %s", i, rr.Code, rr.Body)
+		}
+	}
+
+	rr := httptest.NewRecorder()
+	c.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/upload?upload_id=abc-1", nil))
+	var st ChunkedUploadStatus
+	_ = json.NewDecoder(rr.Body).Decode(&st)
+	if st.Chunks != 3 || len(st.Received) != 2 || st.Received[0] != 0 || st.Received[1] != 2 || st.Complete {
+		t.Errorf("unexpected status %+v", st)
+	}
+
+	// The last chunk is sent as a multipart form.
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	for k, v := range map[string]string{"upload_id": "abc-1", "chunk_index": "1", "total_chunks": "3", "filename": "notes.txt"} {
+		_ = writer.WriteField(k, v)
+	}
+	part, _ := writer.CreateFormFile("file", "blob")
+	_, _ = part.Write(parts[1])
+	_ = writer.Close()
+	req := httptest.NewRequest(http.MethodPost, "/upload", &body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	rr = httptest.NewRecorder()
+	c.ServeHTTP(rr, req)
+	st = ChunkedUploadStatus{}
+	_ = json.NewDecoder(rr.Body).Decode(&st)
+	if rr.Code != http.StatusOK || !st.Complete || st.File == nil || st.File.NewFileName != "notes.txt" {
+		t.Fatalf("expected the upload to complete, got %d %+v", rr.Code, st)
+	}
+	if completed == nil || completed.FileSize != int64(len(content)) {
+		t.Errorf("expected OnComplete with the file, got %+v", completed)
+	}
+	if stored, _ := os.ReadFile(filepath.Join(uploadDir, "notes.txt")); !bytes.Equal(stored, content) {
+		t.Error("assembled file differs from the upload")
+	}
+	if _, err = os.Stat(filepath.Join(chunkDir, "abc-1")); !os.IsNotExist(err) {
+		t.Error("expected the chunks to be removed")
+	}
+}
+
+var chunkErrorTests = []struct {
+	name   string
+	req    *http.Request
+	status int
+}{
+	{name: "bad id", req: chunkRequest("../x", 0, 2, "a.txt", []byte("a")), status: http.StatusBadRequest},
+	{name: "index out of range", req: chunkRequest("id", 2, 2, "a.txt", []byte("a")), status: http.StatusBadRequest},
+	{name: "missing filename", req: chunkRequest("id", 0, 2, "", []byte("a")), status: http.StatusBadRequest},
+	{name: "different total", req: chunkRequest("started", 1, 3, "a.txt", []byte("a")), status: http.StatusBadRequest},
+	{name: "chunk too big", req: chunkRequest("id", 0, 2, "a.txt", make([]byte, 65)), status: http.StatusRequestEntityTooLarge},
+	{name: "unknown upload", req: httptest.NewRequest(http.MethodGet, "/?upload_id=nope", nil), status: http.StatusNotFound},
+}
+
+func TestTools_ChunkedUploadErrors(t *testing.T) {
+	var testTools Tools
+	chunkDir := t.TempDir()
+	c, err := testTools.NewChunkedUpload(ChunkedUploadOptions{UploadDir: t.TempDir(), Dir: chunkDir, MaxChunkSize: 64})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.ServeHTTP(httptest.NewRecorder(), chunkRequest("started", 0, 2, "a.txt", []byte("a")))
+
+	for _, test := range chunkErrorTests {
+		rr := httptest.NewRecorder()
+		c.ServeHTTP(rr, test.req)
+		if rr.Code != test.status {
+			t.Errorf("%s: expected %d, got %d %s", test.name, test.status, rr.Code, rr.Body)
+		}
+	}
+
+	old := time.Now().Add(-48 * time.Hour)
+	_ = os.Chtimes(filepath.Join(chunkDir, "started"), old, old)
+	if err = c.Cleanup(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = c.Status("started"); err != ErrUploadNotFound {
+		t.Errorf("expected the expired upload to be removed, got %v", err)
+	}
+	if _, err = c.Status("id"); err != nil {
+		t.Errorf("expected the recent upload to be kept, got %v", err)
+	}
+}
@@ -0,0 +1,92 @@
+package toolkit
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// CleanOptions selects the files removed by CleanDir. Zero values disable a criterion.
+type CleanOptions struct {
+	// OlderThan only removes files last modified more than this long ago.
+	OlderThan time.Duration
+	// Pattern only removes files whose base name matches this filepath.Match pattern.
+	Pattern string
+	// KeepNewest always keeps the n most recently modified matching files.
+	KeepNewest int
+	// DryRun reports what would be removed without removing anything.
+	DryRun bool
+}
+
+// CleanReport describes the outcome of CleanDir.
+type CleanReport struct {
+	Removed []string `json:"removed"`
+	Files   int      `json:"files"`
+	Bytes   int64    `json:"bytes"`
+	DryRun  bool     `json:"dry_run"`
+}
+
+// CleanDir removes the regular files directly inside dir that match opts.
+func (t *Tools) CleanDir(dir string, opts CleanOptions) (*CleanReport, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var candidates []fs.FileInfo
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() {
+			continue
+		}
+		if opts.Pattern != "" {
+			matched, err := filepath.Match(opts.Pattern, entry.Name())
+			if err != nil {
+				return nil, err
+			}
+			if !matched {
+				continue
+			}
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		candidates = append(candidates, info)
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].ModTime().After(candidates[j].ModTime())
+	})
+	if opts.KeepNewest > 0 {
+		if opts.KeepNewest >= len(candidates) {
+			candidates = nil
+		} else {
+			candidates = candidates[opts.KeepNewest:]
+		}
+	}
+
+	report := &CleanReport{DryRun: opts.DryRun}
+	cutoff := time.Now().Add(-opts.OlderThan)
+	for _, info := range candidates {
+		if opts.OlderThan > 0 && info.ModTime().After(cutoff) {
+			continue
+		}
+
+		p := filepath.Join(dir, info.Name())
+		if !opts.DryRun {
+			if err := os.Remove(p); err != nil {
+				if os.IsNotExist(err) {
+					continue
+				}
+				return report, err
+			}
+		}
+		report.Removed = append(report.Removed, p)
+		report.Files++
+		report.Bytes += info.Size()
+	}
+
+	return report, nil
+}
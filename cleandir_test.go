@@ -0,0 +1,64 @@
+package toolkit
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var cleanDirTests = []struct {
+	name          string
+	opts          CleanOptions
+	expectedFiles int
+	expectedBytes int64
+}{
+	{name: "everything", opts: CleanOptions{}, expectedFiles: 5, expectedBytes: 50},
+	{name: "older than", opts: CleanOptions{OlderThan: 150 * time.Minute}, expectedFiles: 2, expectedBytes: 20},
+	{name: "pattern", opts: CleanOptions{Pattern: "*.tmp"}, expectedFiles: 3, expectedBytes: 30},
+	{name: "keep newest", opts: CleanOptions{KeepNewest: 4}, expectedFiles: 1, expectedBytes: 10},
+	{name: "keep more than exist", opts: CleanOptions{KeepNewest: 10}, expectedFiles: 0},
+	{name: "combined", opts: CleanOptions{Pattern: "*.tmp", KeepNewest: 1, OlderThan: 30 * time.Minute}, expectedFiles: 2, expectedBytes: 20},
+}
+
+func TestTools_CleanDir(t *testing.T) {
+	var testTools Tools
+
+	for _, test := range cleanDirTests {
+		for _, dryRun := range []bool{true, false} {
+			dir := t.TempDir()
+			_ = os.Mkdir(filepath.Join(dir, "subdir"), 0755)
+			for i := 0; i < 5; i++ {
+				ext := "tmp"
+				if i%2 == 1 {
+					ext = "zip"
+				}
+				p := filepath.Join(dir, fmt.Sprintf("file%d.%s", i, ext))
+				_ = os.WriteFile(p, make([]byte, 10), 0644)
+				modTime := time.Now().Add(-time.Duration(i) * time.Hour)
+				_ = os.Chtimes(p, modTime, modTime)
+			}
+
+			opts := test.opts
+			opts.DryRun = dryRun
+			report, err := testTools.CleanDir(dir, opts)
+			if err != nil {
+				t.Fatalf("%s: %s", test.name, err)
+			}
+			if report.Files != test.expectedFiles || report.Bytes != test.expectedBytes {
+				t.Errorf("%s: expected %d files/%d bytes, got %d/%d", test.name, test.expectedFiles, test.expectedBytes, report.Files, report.Bytes)
+			}
+
+			entries, _ := os.ReadDir(dir)
+			remaining := len(entries) - 1
+			expectedRemaining := 5 - test.expectedFiles
+			if dryRun {
+				expectedRemaining = 5
+			}
+			if remaining != expectedRemaining {
+				t.Errorf("%s (dry run %v): expected %d files left, got %d", test.name, dryRun, expectedRemaining, remaining)
+			}
+		}
+	}
+}
@@ -0,0 +1,210 @@
+package toolkit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/netip"
+	"slices"
+	"strings"
+)
+
+type clientIPKey struct{}
+
+// ClientIP returns the address of the client that made r. The peer address is used unless it
+// belongs to Tools.TrustedProxies, in which case the Forwarded (RFC 7239), X-Forwarded-For and
+// X-Real-IP headers are consulted, in that order. Forwarding chains are walked from the nearest
+// hop, skipping trusted proxies, so a client cannot spoof its address by sending the headers itself.
+// Behind RealIPMiddleware, the address it found is returned.
+func (t *Tools) ClientIP(r *http.Request) string {
+	if ip, ok := r.Context().Value(clientIPKey{}).(string); ok {
+		return ip
+	}
+
+	remote := peerAddr(r)
+	trusted, _ := t.trustedProxies()
+	if !remote.IsValid() || !containsAddr(trusted, remote) {
+		if remote.IsValid() {
+			return remote.String()
+		}
+		return r.RemoteAddr
+	}
+
+	var hops []string
+	if fwd := r.Header.Values("Forwarded"); len(fwd) > 0 {
+		hops = forwardedParam(fwd, "for")
+	} else if xff := r.Header.Values("X-Forwarded-For"); len(xff) > 0 {
+		for _, v := range xff {
+			hops = append(hops, strings.Split(v, ",")...)
+		}
+	} else if realIP := r.Header.Get("X-Real-IP"); realIP != "" {
+		hops = []string{realIP}
+	}
+
+	client := remote
+	for i := len(hops) - 1; i >= 0; i-- {
+		addr, ok := parseHop(hops[i])
+		if !ok {
+			break
+		}
+		client = addr
+		if !containsAddr(trusted, addr) {
+			break
+		}
+	}
+	return client.String()
+}
+
+// trustedProxyCache is TrustedProxies parsed, along with the list it was parsed from.
+type trustedProxyCache struct {
+	list     []string
+	prefixes []netip.Prefix
+	err      error
+}
+
+// trustedProxies returns TrustedProxies parsed. The result is cached until TrustedProxies
+// changes. An invalid list is logged once and trusts no proxy; IPFilterMiddleware and
+// RealIPMiddleware report it when they are built.
+func (t *Tools) trustedProxies() ([]netip.Prefix, error) {
+	c := t.trusted.Load()
+	if c != nil && slices.Equal(c.list, t.TrustedProxies) {
+		return c.prefixes, c.err
+	}
+
+	c = &trustedProxyCache{list: slices.Clone(t.TrustedProxies)}
+	c.prefixes, c.err = parsePrefixes(c.list)
+	if c.err != nil {
+		c.err = fmt.Errorf("trusted proxies: %w", c.err)
+		t.logger().Error("ignoring invalid trusted proxies", slog.String("error", c.err.Error()))
+	}
+	t.trusted.Store(c)
+	return c.prefixes, c.err
+}
+
+func peerAddr(r *http.Request) netip.Addr {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return netip.Addr{}
+	}
+	return addr.Unmap()
+}
+
+// forwardedParam extracts a parameter, such as for or proto, of RFC 7239 Forwarded headers in
+// hop order.
+func forwardedParam(values []string, param string) []string {
+	var hops []string
+	for _, v := range values {
+		for _, element := range strings.Split(v, ",") {
+			for _, pair := range strings.Split(element, ";") {
+				name, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+				if ok && strings.EqualFold(name, param) {
+					hops = append(hops, value)
+				}
+			}
+		}
+	}
+	return hops
+}
+
+// parseHop parses an address from a forwarding header, with or without port, quotes or the
+// brackets RFC 7239 puts around IPv6 addresses. Obfuscated identifiers and "unknown" are rejected.
+func parseHop(s string) (netip.Addr, bool) {
+	s = strings.Trim(strings.TrimSpace(s), `"`)
+	if ap, err := netip.ParseAddrPort(s); err == nil {
+		return ap.Addr().Unmap(), true
+	}
+	addr, err := netip.ParseAddr(strings.Trim(s, "[]"))
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return addr.Unmap(), true
+}
+
+// parsePrefixes parses CIDR ranges and single addresses.
+func parsePrefixes(list []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(list))
+	for _, s := range list {
+		if strings.Contains(s, "/") {
+			p, err := netip.ParsePrefix(s)
+			if err != nil {
+				return nil, err
+			}
+			prefixes = append(prefixes, p.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid address or CIDR %q", s)
+		}
+		prefixes = append(prefixes, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+	}
+	return prefixes, nil
+}
+
+func containsAddr(prefixes []netip.Prefix, addr netip.Addr) bool {
+	for _, p := range prefixes {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// IPFilterOptions lists the addresses and CIDR ranges IPFilterMiddleware allows and denies.
+type IPFilterOptions struct {
+	// Allow, when not empty, admits only clients within these ranges.
+	Allow []string
+	// Deny rejects clients within these ranges, even if they are allowed.
+	Deny []string
+}
+
+// IPFilterMiddleware rejects requests whose ClientIP is denied or not allowed with a 403 JSON error.
+func (t *Tools) IPFilterMiddleware(opts IPFilterOptions) (func(http.Handler) http.Handler, error) {
+	allow, err := parsePrefixes(opts.Allow)
+	if err != nil {
+		return nil, err
+	}
+	deny, err := parsePrefixes(opts.Deny)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = t.trustedProxies(); err != nil {
+		return nil, err
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			addr, err := netip.ParseAddr(t.ClientIP(r))
+			if err != nil || containsAddr(deny, addr) || len(allow) > 0 && !containsAddr(allow, addr) {
+				_ = t.ErrorJSON(w, errors.New("access from your address is not allowed"), http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}, nil
+}
+
+// RealIPMiddleware resolves the ClientIP of each request once and makes it the request's
+// RemoteAddr, without a port, so handlers and middleware that do not know about
+// TrustedProxies see the real client. Later ClientIP calls return the same address.
+func (t *Tools) RealIPMiddleware() (func(http.Handler) http.Handler, error) {
+	if _, err := t.trustedProxies(); err != nil {
+		return nil, err
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ip := t.ClientIP(r)
+			r = r.WithContext(context.WithValue(r.Context(), clientIPKey{}, ip))
+			r.RemoteAddr = ip
+			next.ServeHTTP(w, r)
+		})
+	}, nil
+}
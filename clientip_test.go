@@ -0,0 +1,123 @@
+package toolkit
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+var clientIPTests = []struct {
+	name     string
+	remote   string
+	headers  map[string]string
+	expected string
+}{
+	{name: "direct", remote: "203.0.113.5:1234", expected: "203.0.113.5"},
+	{name: "untrusted peer spoofing", remote: "203.0.113.5:1234", headers: map[string]string{"X-Forwarded-For": "1.1.1.1"}, expected: "203.0.113.5"},
+	{name: "xff through proxy", remote: "10.0.0.1:80", headers: map[string]string{"X-Forwarded-For": "1.1.1.1, 198.51.100.7"}, expected: "198.51.100.7"},
+	{name: "xff through two proxies", remote: "10.0.0.1:80", headers: map[string]string{"X-Forwarded-For": "198.51.100.7, 10.0.0.2"}, expected: "198.51.100.7"},
+	{name: "forwarded", remote: "10.0.0.1:80", headers: map[string]string{"Forwarded": `for=192.0.2.60;proto=http, for="[2001:db8:cafe::17]:4711"`}, expected: "2001:db8:cafe::17"},
+	{name: "forwarded wins over xff", remote: "10.0.0.1:80", headers: map[string]string{"Forwarded": "for=192.0.2.60", "X-Forwarded-For": "198.51.100.7"}, expected: "192.0.2.60"},
+	{name: "obfuscated", remote: "10.0.0.1:80", headers: map[string]string{"Forwarded": "for=_hidden"}, expected: "10.0.0.1"},
+	{name: "x-real-ip", remote: "10.0.0.1:80", headers: map[string]string{"X-Real-IP": "198.51.100.9"}, expected: "198.51.100.9"},
+	{name: "ipv4 mapped peer", remote: "[::ffff:10.0.0.1]:80", headers: map[string]string{"X-Real-IP": "198.51.100.9"}, expected: "198.51.100.9"},
+}
+
+func TestTools_ClientIP(t *testing.T) {
+	testTools := Tools{TrustedProxies: []string{"10.0.0.0/8"}}
+	for _, test := range clientIPTests {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = test.remote
+		for k, v := range test.headers {
+			req.Header.Set(k, v)
+		}
+		if ip := testTools.ClientIP(req); ip != test.expected {
+			t.Errorf("%s: expected %s, got %s", test.name, test.expected, ip)
+		}
+	}
+}
+
+func TestTools_ClientIPTrustedProxiesCache(t *testing.T) {
+	testTools := Tools{Logger: slog.New(slog.NewTextHandler(io.Discard, nil)), TrustedProxies: []string{"10.0.0.0/8"}}
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "10.0.0.1:80"
+	req.Header.Set("X-Real-IP", "198.51.100.9")
+
+	_ = testTools.ClientIP(req)
+	cached := testTools.trusted.Load()
+	if ip := testTools.ClientIP(req); ip != "198.51.100.9" || testTools.trusted.Load() != cached {
+		t.Errorf("expected the parsed proxies to be reused, got %s", ip)
+	}
+
+	testTools.TrustedProxies = []string{"10.0.0.0/8", "not-an-ip"}
+	if ip := testTools.ClientIP(req); ip != "10.0.0.1" {
+		t.Errorf("expected invalid trusted proxies to trust no proxy, got %s", ip)
+	}
+	if _, err := testTools.trustedProxies(); err == nil {
+		t.Error("expected an error for invalid trusted proxies")
+	}
+
+	testTools.TrustedProxies = []string{"10.0.0.1"}
+	if ip := testTools.ClientIP(req); ip != "198.51.100.9" {
+		t.Errorf("expected changed trusted proxies to be parsed again, got %s", ip)
+	}
+}
+
+func TestTools_IPFilterMiddleware(t *testing.T) {
+	testTools := Tools{TrustedProxies: []string{"10.0.0.1"}}
+	mw, err := testTools.IPFilterMiddleware(IPFilterOptions{
+		Allow: []string{"198.51.100.0/24"},
+		Deny:  []string{"198.51.100.66"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	for ip, status := range map[string]int{"198.51.100.7": http.StatusOK, "198.51.100.66": http.StatusForbidden, "203.0.113.1": http.StatusForbidden} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = "10.0.0.1:80"
+		req.Header.Set("X-Forwarded-For", ip)
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		if rr.Code != status {
+			t.Errorf("%s: expected %d, got %d", ip, status, rr.Code)
+		}
+	}
+
+	if _, err = testTools.IPFilterMiddleware(IPFilterOptions{Deny: []string{"not-an-ip"}}); err == nil {
+		t.Error("expected an error for an invalid range")
+	}
+}
+
+func TestTools_RealIPMiddleware(t *testing.T) {
+	testTools := Tools{TrustedProxies: []string{"10.0.0.0/8"}}
+	mw, err := testTools.RealIPMiddleware()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var remote, clientIP string
+	h := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		remote, clientIP = r.RemoteAddr, testTools.ClientIP(r)
+	}))
+
+	for _, test := range clientIPTests {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = test.remote
+		for k, v := range test.headers {
+			req.Header.Set(k, v)
+		}
+		h.ServeHTTP(httptest.NewRecorder(), req)
+		if remote != test.expected || clientIP != test.expected {
+			t.Errorf("%s: expected %s, got RemoteAddr %s and ClientIP %s", test.name, test.expected, remote, clientIP)
+		}
+	}
+
+	testTools.TrustedProxies = []string{"not-an-ip"}
+	if _, err = testTools.RealIPMiddleware(); err == nil {
+		t.Error("expected an error for invalid trusted proxies")
+	}
+}
@@ -0,0 +1,268 @@
+package toolkit
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// CompressOptions configures CompressMiddleware.
+type CompressOptions struct {
+	// MinSize is the smallest response body, in bytes, worth compressing, 1 KiB when 0.
+	MinSize int
+	// Level is the compression level from 1 (fastest) to 9 (smallest), 6 when 0.
+	Level int
+	// ContentTypes are the media types compressed; entries ending with / match every subtype.
+	// Types with a +json or +xml suffix are always compressed. Text, JSON, JavaScript, XML, YAML,
+	// NDJSON, SVG and WebAssembly when empty.
+	ContentTypes []string
+}
+
+var defaultCompressTypes = []string{
+	"text/",
+	"application/json",
+	"application/javascript",
+	"application/xml",
+	"application/yaml",
+	"application/x-ndjson",
+	"application/wasm",
+	"image/svg+xml",
+}
+
+// CompressMiddleware compresses responses with gzip or deflate, whichever the client prefers in
+// Accept-Encoding, so WriteJSON responses, rendered pages and static downloads travel smaller.
+// Responses below MinSize, of other content types, already encoded, and answers to range requests
+// are sent as they are. Compressed responses lose their Content-Length and Accept-Ranges, and
+// their ETag becomes weak.
+func (t *Tools) CompressMiddleware(opts CompressOptions) func(http.Handler) http.Handler {
+	if opts.MinSize <= 0 {
+		opts.MinSize = 1024
+	}
+	if opts.Level <= 0 || opts.Level > 9 {
+		opts.Level = 6
+	}
+	if len(opts.ContentTypes) == 0 {
+		opts.ContentTypes = defaultCompressTypes
+	}
+	pools := map[string]*sync.Pool{
+		"gzip": {New: func() any {
+			zw, _ := gzip.NewWriterLevel(io.Discard, opts.Level)
+			return zw
+		}},
+		"deflate": {New: func() any {
+			zw, _ := flate.NewWriter(io.Discard, opts.Level)
+			return zw
+		}},
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			encoding := ""
+			if r.Method != http.MethodHead && r.Header.Get("Range") == "" {
+				encoding = negotiateEncoding(r.Header.Get("Accept-Encoding"))
+			}
+			cw := &compressWriter{ResponseWriter: w, opts: &opts, encoding: encoding, pools: pools}
+			defer cw.close()
+			next.ServeHTTP(cw, r)
+		})
+	}
+}
+
+// negotiateEncoding picks gzip or deflate, the one with the higher q-value and gzip on a tie, or
+// nothing when the client accepts neither.
+func negotiateEncoding(acceptEncoding string) string {
+	best, bestQ := "", 0.0
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			var err error
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+		coding = strings.ToLower(coding)
+		if coding == "*" {
+			coding = "gzip"
+		}
+		if (coding == "gzip" || coding == "deflate") && q > 0 && (q > bestQ || q == bestQ && coding == "gzip") {
+			best, bestQ = coding, q
+		}
+	}
+	return best
+}
+
+// compressor is what gzip.Writer and flate.Writer have in common.
+type compressor interface {
+	io.WriteCloser
+	Reset(w io.Writer)
+	Flush() error
+}
+
+// compressWriter holds back the start of a response until it knows whether it is worth
+// compressing: the handler wrote MinSize bytes, set a Content-Length, flushed or returned.
+type compressWriter struct {
+	http.ResponseWriter
+	opts     *CompressOptions
+	encoding string
+	pools    map[string]*sync.Pool
+
+	status  int
+	buf     []byte
+	decided bool
+	zw      compressor
+}
+
+func (w *compressWriter) WriteHeader(status int) {
+	if w.decided || w.status != 0 {
+		return
+	}
+	if status < http.StatusOK {
+		// Informational responses, such as 103 Early Hints, go out right away.
+		w.ResponseWriter.WriteHeader(status)
+		return
+	}
+	w.status = status
+	if cl, err := strconv.Atoi(w.Header().Get("Content-Length")); err == nil {
+		w.decide(cl >= w.opts.MinSize)
+	}
+}
+
+func (w *compressWriter) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+	if !w.decided {
+		w.buf = append(w.buf, p...)
+		if len(w.buf) >= w.opts.MinSize {
+			if err := w.decide(true); err != nil {
+				return 0, err
+			}
+		}
+		return len(p), nil
+	}
+	if w.zw != nil {
+		return w.zw.Write(p)
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+func (w *compressWriter) Flush() {
+	if w.status == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+	if !w.decided {
+		_ = w.decide(true)
+	}
+	if w.zw != nil {
+		_ = w.zw.Flush()
+	}
+	_ = http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Hijack hands the connection over, uncompressed, for protocols such as WebSocket.
+func (w *compressWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := http.NewResponseController(w.ResponseWriter).Hijack()
+	if err == nil {
+		w.decided = true
+	}
+	return conn, rw, err
+}
+
+func (w *compressWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// decide sends the header, compressing the body when big is set and the response qualifies, and
+// then whatever was held back.
+func (w *compressWriter) decide(big bool) error {
+	w.decided = true
+	h := w.Header()
+	if h.Get("Content-Type") == "" && len(w.buf) > 0 {
+		h.Set("Content-Type", http.DetectContentType(w.buf))
+	}
+
+	compressible := h.Get("Content-Encoding") == "" && w.compressibleType(h.Get("Content-Type"))
+	if compressible {
+		addVary(h, "Accept-Encoding")
+	}
+	switch w.status {
+	case http.StatusNoContent, http.StatusPartialContent, http.StatusNotModified:
+		compressible = false
+	}
+
+	if compressible && big && w.encoding != "" {
+		h.Del("Content-Length")
+		h.Del("Accept-Ranges")
+		h.Set("Content-Encoding", w.encoding)
+		if etag := h.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+			h.Set("ETag", "W/"+etag)
+		}
+		w.zw = w.pools[w.encoding].Get().(compressor)
+		w.zw.Reset(w.ResponseWriter)
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+
+	buf := w.buf
+	w.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	var err error
+	if w.zw != nil {
+		_, err = w.zw.Write(buf)
+	} else {
+		_, err = w.ResponseWriter.Write(buf)
+	}
+	return err
+}
+
+// close ends the response once the handler returned.
+func (w *compressWriter) close() {
+	if w.status == 0 {
+		return
+	}
+	if !w.decided {
+		_ = w.decide(len(w.buf) >= w.opts.MinSize)
+	}
+	if w.zw != nil {
+		_ = w.zw.Close()
+		w.pools[w.encoding].Put(w.zw)
+		w.zw = nil
+	}
+}
+
+func (w *compressWriter) compressibleType(contentType string) bool {
+	typ, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	if strings.HasSuffix(typ, "+json") || strings.HasSuffix(typ, "+xml") {
+		return true
+	}
+	for _, t := range w.opts.ContentTypes {
+		if typ == t || strings.HasSuffix(t, "/") && strings.HasPrefix(typ, t) {
+			return true
+		}
+	}
+	return false
+}
+
+// addVary adds field to the Vary header unless it is listed already.
+func addVary(h http.Header, field string) {
+	for _, v := range h.Values("Vary") {
+		for _, f := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(f), field) || strings.TrimSpace(f) == "*" {
+				return
+			}
+		}
+	}
+	h.Add("Vary", field)
+}
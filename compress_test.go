@@ -0,0 +1,131 @@
+package toolkit
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+var negotiateEncodingTests = []struct {
+	header   string
+	expected string
+}{
+	{header: "gzip, deflate, br", expected: "gzip"},
+	{header: "deflate", expected: "deflate"},
+	{header: "gzip;q=0.5, deflate;q=0.8", expected: "deflate"},
+	{header: "gzip;q=0, identity", expected: ""},
+	{header: "*", expected: "gzip"},
+	{header: "br", expected: ""},
+	{header: "", expected: ""},
+}
+
+func TestTools_NegotiateEncoding(t *testing.T) {
+	for _, test := range negotiateEncodingTests {
+		if got := negotiateEncoding(test.header); got != test.expected {
+			t.Errorf("%q: expected %q, got %q", test.header, test.expected, got)
+		}
+	}
+}
+
+func decompress(t *testing.T, encoding string, body []byte) string {
+	t.Helper()
+	var r io.Reader
+	switch encoding {
+	case "gzip":
+		zr, err := gzip.NewReader(bytes.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		r = zr
+	case "deflate":
+		r = flate.NewReader(bytes.NewReader(body))
+	default:
+		return string(body)
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestTools_CompressMiddleware(t *testing.T) {
+	var testTools Tools
+	dir := t.TempDir()
+	big := strings.Repeat("compressible text ", 200)
+	_ = os.WriteFile(dir+"/notes.txt", []byte(big), 0644)
+	_ = os.WriteFile(dir+"/photo.png", testPNG(t), 0644)
+
+	h := testTools.CompressMiddleware(CompressOptions{MinSize: 100})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/json":
+			_ = testTools.WriteJSON(w, http.StatusCreated, map[string]string{"text": big})
+		case "/small":
+			_ = testTools.WriteJSON(w, http.StatusOK, map[string]string{"text": "hi"})
+		case "/encoded":
+			w.Header().Set("Content-Encoding", "br")
+			_, _ = w.Write([]byte(big))
+		case "/stream":
+			w.Header().Set("Content-Type", "text/event-stream")
+			_, _ = w.Write([]byte("data: 1\n\n"))
+			w.(http.Flusher).Flush()
+			_, _ = w.Write([]byte("data: 2\n\n"))
+		default:
+			testTools.ServeStaticFile(w, r, dir, strings.TrimPrefix(r.URL.Path, "/"), StaticFileOptions{Inline: true})
+		}
+	}))
+
+	tests := []struct {
+		path     string
+		accept   string
+		header   http.Header
+		status   int
+		encoding string
+		body     string
+	}{
+		{path: "/json", accept: "gzip", status: http.StatusCreated, encoding: "gzip"},
+		{path: "/json", accept: "deflate", status: http.StatusCreated, encoding: "deflate"},
+		{path: "/json", accept: "", status: http.StatusCreated},
+		{path: "/small", accept: "gzip", status: http.StatusOK, body: "{\"text\":\"hi\"}"},
+		{path: "/encoded", accept: "gzip", status: http.StatusOK, encoding: "br", body: big},
+		{path: "/stream", accept: "gzip", status: http.StatusOK, encoding: "gzip", body: "data: 1\n\ndata: 2\n\n"},
+		{path: "/notes.txt", accept: "gzip", status: http.StatusOK, encoding: "gzip", body: big},
+		{path: "/notes.txt", accept: "gzip", header: http.Header{"Range": {"bytes=0-10"}}, status: http.StatusPartialContent, body: big[:11]},
+		{path: "/photo.png", accept: "gzip", status: http.StatusOK, body: string(testPNG(t))},
+	}
+	for _, test := range tests {
+		req := httptest.NewRequest(http.MethodGet, test.path, nil)
+		for k, v := range test.header {
+			req.Header[k] = v
+		}
+		if test.accept != "" {
+			req.Header.Set("Accept-Encoding", test.accept)
+		}
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+
+		name := test.path + " " + test.accept
+		if rr.Code != test.status || rr.Header().Get("Content-Encoding") != test.encoding {
+			t.Errorf("%s: expected %d %q, got %d %q", name, test.status, test.encoding, rr.Code, rr.Header().Get("Content-Encoding"))
+			continue
+		}
+		body := decompress(t, test.encoding, rr.Body.Bytes())
+		if test.body != "" && body != test.body {
+			t.Errorf("%s: unexpected body %.40q", name, body)
+		}
+		if test.encoding == "gzip" || test.encoding == "deflate" {
+			if rr.Header().Get("Content-Length") != "" || rr.Header().Get("Vary") != "Accept-Encoding" {
+				t.Errorf("%s: unexpected headers %v", name, rr.Header())
+			}
+			if etag := rr.Header().Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+				t.Errorf("%s: expected a weak ETag, got %s", name, etag)
+			}
+		}
+	}
+}
@@ -0,0 +1,405 @@
+package toolkit
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ByteSize is a size in bytes, parsed by LoadConfig from values such as "10MB".
+type ByteSize int64
+
+// Secret is a string that is redacted when printed or logged, for passwords and API keys in
+// configuration structs.
+type Secret string
+
+const redacted = "[REDACTED]"
+
+func (s Secret) String() string {
+	if s == "" {
+		return ""
+	}
+	return redacted
+}
+
+// GoString redacts the secret from %#v too.
+func (s Secret) GoString() string {
+	return strconv.Quote(s.String())
+}
+
+// MarshalJSON redacts the secret from JSON output.
+func (s Secret) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+// Value returns the secret itself.
+func (s Secret) Value() string {
+	return string(s)
+}
+
+// ConfigOptions configures LoadConfig.
+type ConfigOptions struct {
+	// File is a .env, .json, .yaml or .yml file to read; a missing file is ignored.
+	File string
+	// EnvPrefix is prepended to the names of environment variables, e.g. "APP_".
+	EnvPrefix string
+	// Args are the command line arguments parsed as flags, os.Args[1:] when nil. Use an empty
+	// slice to disable flags.
+	Args []string
+	// LookupEnv reads environment variables, os.LookupEnv when nil.
+	LookupEnv func(key string) (string, bool)
+}
+
+// configField is a settable field of a configuration struct.
+type configField struct {
+	value    reflect.Value
+	key      string
+	flag     string
+	usage    string
+	def      string
+	hasDef   bool
+	required bool
+}
+
+// LoadConfig fills the struct dst points to. Every field with an env tag is looked up, from
+// lowest to highest precedence, in its default tag, the configuration file, the environment and
+// the command line:
+//
+//	type Config struct {
+//		Addr    string        `env:"ADDR" default:":8080" usage:"listen address"`
+//		Timeout time.Duration `env:"TIMEOUT" default:"5s"`
+//		MaxBody ByteSize      `env:"MAX_BODY" default:"10MB"`
+//		DB      struct {
+//			URL Secret `env:"URL" required:"true"`
+//		} `env:"DB"`
+//	}
+//
+// Nested structs prefix the keys of their fields with their own, so DB.URL is DB_URL. Keys of
+// JSON and YAML files are matched the same way, e.g. db: {url: ...}, case-insensitively.
+// Flags are named after keys in lower case with dashes, -db-url, unless set with a flag tag.
+// Supported types are strings, Secret, booleans, numbers, time.Duration, ByteSize and string
+// slices, which are comma separated. All missing and malformed values are reported together.
+func (t *Tools) LoadConfig(dst any, options ...ConfigOptions) error {
+	var opts ConfigOptions
+	if len(options) > 0 {
+		opts = options[0]
+	}
+	if opts.Args == nil {
+		opts.Args = os.Args[1:]
+	}
+	if opts.LookupEnv == nil {
+		opts.LookupEnv = os.LookupEnv
+	}
+
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Pointer || v.Elem().Kind() != reflect.Struct {
+		return errors.New("config destination must be a pointer to a struct")
+	}
+	fields, err := configFields(v.Elem(), "")
+	if err != nil {
+		return err
+	}
+
+	file, err := readConfigFile(opts.File)
+	if err != nil {
+		return err
+	}
+
+	flags := flag.NewFlagSet(filepath.Base(os.Args[0]), flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	flagValues := make(map[string]*string)
+	for _, f := range fields {
+		if f.value.Kind() == reflect.Bool {
+			s := f.def
+			flags.Var(boolFlag{&s}, f.flag, f.usage)
+			flagValues[f.flag] = &s
+			continue
+		}
+		flagValues[f.flag] = flags.String(f.flag, f.def, f.usage)
+	}
+	if err = flags.Parse(opts.Args); err != nil {
+		return err
+	}
+	setFlags := make(map[string]bool)
+	flags.Visit(func(f *flag.Flag) { setFlags[f.Name] = true })
+
+	var errs []error
+	for _, f := range fields {
+		value, found := f.def, f.hasDef
+		if s, ok := file[f.key]; ok {
+			value, found = s, true
+		}
+		if s, ok := opts.LookupEnv(opts.EnvPrefix + f.key); ok {
+			value, found = s, true
+		}
+		if setFlags[f.flag] {
+			value, found = *flagValues[f.flag], true
+		}
+
+		if !found || value == "" {
+			if f.required {
+				errs = append(errs, fmt.Errorf("%s is required", f.key))
+			}
+			continue
+		}
+		if err = t.setConfigValue(f.value, value); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", f.key, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// boolFlag is a string flag that may be given without a value, as -debug for -debug=true, like
+// flag.Bool. Its value is parsed with the others, so malformed ones are reported together.
+type boolFlag struct{ s *string }
+
+func (f boolFlag) String() string {
+	if f.s == nil {
+		return ""
+	}
+	return *f.s
+}
+
+func (f boolFlag) Set(s string) error {
+	*f.s = s
+	return nil
+}
+
+func (f boolFlag) IsBoolFlag() bool {
+	return true
+}
+
+func configFields(v reflect.Value, prefix string) ([]configField, error) {
+	var fields []configField
+	for i := 0; i < v.NumField(); i++ {
+		sf := v.Type().Field(i)
+		key, ok := sf.Tag.Lookup("env")
+		if !ok || !sf.IsExported() {
+			continue
+		}
+		key = prefix + key
+
+		fv := v.Field(i)
+		if fv.Kind() == reflect.Struct && fv.Type() != reflect.TypeOf(time.Time{}) {
+			nested, err := configFields(fv, key+"_")
+			if err != nil {
+				return nil, err
+			}
+			fields = append(fields, nested...)
+			continue
+		}
+
+		f := configField{value: fv, key: key, usage: sf.Tag.Get("usage")}
+		f.def, f.hasDef = sf.Tag.Lookup("default")
+		f.required = sf.Tag.Get("required") == "true"
+		f.flag = sf.Tag.Get("flag")
+		if f.flag == "" {
+			f.flag = strings.ToLower(strings.ReplaceAll(key, "_", "-"))
+		}
+		fields = append(fields, f)
+	}
+	return fields, nil
+}
+
+func (t *Tools) setConfigValue(v reflect.Value, s string) error {
+	switch v.Type() {
+	case reflect.TypeOf(time.Duration(0)):
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	case reflect.TypeOf(ByteSize(0)):
+		n, err := t.ParseBytes(s)
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(n)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported type %s", v.Type())
+		}
+		parts := strings.Split(s, ",")
+		slice := reflect.MakeSlice(v.Type(), len(parts), len(parts))
+		for i, p := range parts {
+			slice.Index(i).SetString(strings.TrimSpace(p))
+		}
+		v.Set(slice)
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+// readConfigFile reads a configuration file into values keyed like environment variables.
+func readConfigFile(path string) (map[string]string, error) {
+	values := make(map[string]string)
+	if path == "" {
+		return values, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return values, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var tree map[string]any
+	switch ext := strings.ToLower(filepath.Ext(path)); {
+	case ext == ".json":
+		// Numbers are kept as written, as float64 cannot hold every int64.
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.UseNumber()
+		err = dec.Decode(&tree)
+	case ext == ".yaml" || ext == ".yml":
+		err = yaml.Unmarshal(data, &tree)
+	case ext == ".env" || strings.HasPrefix(filepath.Base(path), ".env"):
+		return parseDotEnv(string(data))
+	default:
+		return nil, fmt.Errorf("unsupported config file type %q", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	flattenConfig(values, "", tree)
+	return values, nil
+}
+
+func flattenConfig(values map[string]string, prefix string, tree map[string]any) {
+	for k, v := range tree {
+		key := prefix + strings.ToUpper(k)
+		switch v := v.(type) {
+		case map[string]any:
+			flattenConfig(values, key+"_", v)
+		case []any:
+			parts := make([]string, len(v))
+			for i, p := range v {
+				parts[i] = configText(p)
+			}
+			values[key] = strings.Join(parts, ",")
+		case nil:
+		default:
+			values[key] = configText(v)
+		}
+	}
+}
+
+// configText formats a value of a configuration file. Numbers decoded as float64 are written
+// without an exponent, so 10000000 stays an integer rather than becoming 1e+07.
+func configText(v any) string {
+	if f, ok := v.(float64); ok {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	return fmt.Sprint(v)
+}
+
+// parseDotEnv parses KEY=value lines, ignoring blank lines, comments and an export keyword.
+// Values may be single quoted, taken literally, or double quoted, with \n and \" escapes.
+func parseDotEnv(s string) (map[string]string, error) {
+	values := make(map[string]string)
+	scanner := bufio.NewScanner(strings.NewReader(s))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf(".env line %d: missing =", n)
+		}
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+
+		switch {
+		case len(value) >= 2 && value[0] == '\'' && value[len(value)-1] == '\'':
+			value = value[1 : len(value)-1]
+		case len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"':
+			unquoted, err := strconv.Unquote(value)
+			if err != nil {
+				return nil, fmt.Errorf(".env line %d: %w", n, err)
+			}
+			value = unquoted
+		default:
+			if i := strings.Index(value, " #"); i >= 0 {
+				value = strings.TrimSpace(value[:i])
+			}
+		}
+		values[key] = value
+	}
+	return values, scanner.Err()
+}
+
+// ConfigString formats a configuration struct for logging, one key=value pair per field with
+// Secret fields and fields tagged secret:"true" redacted.
+func (t *Tools) ConfigString(cfg any) string {
+	v := reflect.Indirect(reflect.ValueOf(cfg))
+	if v.Kind() != reflect.Struct {
+		return fmt.Sprint(cfg)
+	}
+	var b strings.Builder
+	writeConfig(&b, v, "")
+	return strings.TrimSuffix(b.String(), " ")
+}
+
+func writeConfig(b *strings.Builder, v reflect.Value, prefix string) {
+	for i := 0; i < v.NumField(); i++ {
+		sf := v.Type().Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		fv := v.Field(i)
+		if fv.Kind() == reflect.Struct && fv.Type() != reflect.TypeOf(time.Time{}) {
+			writeConfig(b, fv, prefix+sf.Name+".")
+			continue
+		}
+		value := fmt.Sprint(fv.Interface())
+		if sf.Tag.Get("secret") == "true" && !fv.IsZero() {
+			value = redacted
+		}
+		fmt.Fprintf(b, "%s%s=%s ", prefix, sf.Name, value)
+	}
+}
@@ -0,0 +1,140 @@
+package toolkit
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testConfig struct {
+	Addr    string        `env:"ADDR" default:":8080" usage:"listen address"`
+	Debug   bool          `env:"DEBUG"`
+	Workers int           `env:"WORKERS" default:"4"`
+	Timeout time.Duration `env:"TIMEOUT" default:"5s"`
+	MaxBody ByteSize      `env:"MAX_BODY" default:"1MB"`
+	Origins []string      `env:"ORIGINS"`
+	Token   string        `env:"TOKEN" secret:"true"`
+	DB      struct {
+		URL      Secret `env:"URL" required:"true"`
+		MaxConns int    `env:"MAX_CONNS" flag:"conns"`
+	} `env:"DB"`
+	Ignored string
+}
+
+var configFiles = map[string]string{
+	"config.yaml": "addr: \":9000\"\nworkers: 8\norigins: [a.example, b.example]\ndb:\n  url: postgres://file\n  max_conns: 5\n",
+	"config.json": `{"addr": ":9000", "workers": 8, "origins": ["a.example", "b.example"], "db": {"url": "postgres://file", "max_conns": 5}}`,
+	".env":        "# comment\nADDR=':9000'\nexport WORKERS=8 # inline\nORIGINS=a.example,b.example\nDB_URL=\"postgres://file\"\nDB_MAX_CONNS=5\n",
+}
+
+func TestTools_LoadConfig(t *testing.T) {
+	var testTools Tools
+	dir := t.TempDir()
+
+	for name, content := range configFiles {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		env := map[string]string{"APP_WORKERS": "16", "APP_TIMEOUT": "1m", "APP_TOKEN": "hunter2"}
+
+		var cfg testConfig
+		err := testTools.LoadConfig(&cfg, ConfigOptions{
+			File:      path,
+			EnvPrefix: "APP_",
+			Args:      []string{"-debug", "-conns", "7", "-max-body", "2MB"},
+			LookupEnv: func(key string) (string, bool) { v, ok := env[key]; return v, ok },
+		})
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+
+		if cfg.Addr != ":9000" || cfg.Workers != 16 || cfg.Timeout != time.Minute || !cfg.Debug || cfg.MaxBody != 2<<20 {
+			t.Errorf("%s: unexpected precedence %+v", name, cfg)
+		}
+		if !reflect.DeepEqual(cfg.Origins, []string{"a.example", "b.example"}) || cfg.DB.URL.Value() != "postgres://file" || cfg.DB.MaxConns != 7 {
+			t.Errorf("%s: unexpected values %+v", name, cfg)
+		}
+	}
+}
+
+func TestTools_LoadConfigErrors(t *testing.T) {
+	var testTools Tools
+	env := map[string]string{"WORKERS": "many", "TIMEOUT": "soon"}
+	var cfg testConfig
+	err := testTools.LoadConfig(&cfg, ConfigOptions{
+		Args:      []string{"-debug=maybe"},
+		LookupEnv: func(key string) (string, bool) { v, ok := env[key]; return v, ok },
+	})
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	for _, expected := range []string{"DB_URL is required", "WORKERS:", "TIMEOUT:", "DEBUG:"} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("expected %q in %q", expected, err)
+		}
+	}
+	if cfg.Addr != ":8080" || cfg.MaxBody != 1<<20 {
+		t.Errorf("expected defaults to apply, got %+v", cfg)
+	}
+
+	if err = testTools.LoadConfig(cfg); err == nil {
+		t.Error("expected a non-pointer destination to fail")
+	}
+}
+
+func TestTools_LoadConfigLargeNumbers(t *testing.T) {
+	var testTools Tools
+	dir := t.TempDir()
+	files := map[string]string{
+		"config.json": `{"workers": 10000000, "max_body": 20000000, "db": {"url": "postgres://file"}}`,
+		"config.yaml": "workers: 10000000\nmax_body: 20000000\ndb:\n  url: postgres://file\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		var cfg testConfig
+		err := testTools.LoadConfig(&cfg, ConfigOptions{File: path, Args: []string{}, LookupEnv: func(string) (string, bool) { return "", false }})
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if cfg.Workers != 10000000 || cfg.MaxBody != 20000000 {
+			t.Errorf("%s: unexpected values %+v", name, cfg)
+		}
+	}
+}
+
+func TestTools_ConfigString(t *testing.T) {
+	var testTools Tools
+	var cfg testConfig
+	cfg.Addr, cfg.Token, cfg.DB.URL = ":80", "hunter2", "postgres://user:pw@db"
+
+	s := testTools.ConfigString(&cfg)
+	if strings.Contains(s, "hunter2") || strings.Contains(s, "pw@db") {
+		t.Errorf("secrets leaked: %s", s)
+	}
+	if !strings.Contains(s, "Addr=:80") || !strings.Contains(s, "DB.URL=[REDACTED]") {
+		t.Errorf("unexpected config string %s", s)
+	}
+
+	for _, out := range []string{fmt.Sprintf("%v %+v %#v", cfg.DB.URL, cfg.DB, cfg.DB), mustJSON(t, cfg.DB)} {
+		if strings.Contains(out, "pw@db") {
+			t.Errorf("secret leaked: %s", out)
+		}
+	}
+}
+
+func mustJSON(t *testing.T, v any) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
@@ -0,0 +1,328 @@
+package toolkit
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// SymlinkPolicy controls how CopyFile and CopyDir treat symbolic links in the source.
+type SymlinkPolicy int
+
+const (
+	// SymlinkFollow copies the content the link points to.
+	SymlinkFollow SymlinkPolicy = iota
+	// SymlinkPreserve recreates the link itself at the destination.
+	SymlinkPreserve
+	// SymlinkSkip ignores links entirely.
+	SymlinkSkip
+)
+
+// CopyOptions configures CopyFile and CopyDir.
+type CopyOptions struct {
+	PreserveMode  bool
+	PreserveTimes bool
+	Symlinks      SymlinkPolicy
+	// Progress, when set, is called as data is written with the file being copied,
+	// the bytes written so far and the size of that file.
+	Progress func(path string, written, total int64)
+}
+
+type progressWriter struct {
+	w        io.Writer
+	path     string
+	written  int64
+	total    int64
+	progress func(path string, written, total int64)
+}
+
+func (p *progressWriter) Write(b []byte) (int, error) {
+	n, err := p.w.Write(b)
+	p.written += int64(n)
+	p.progress(p.path, p.written, p.total)
+	return n, err
+}
+
+// CopyFile copies src to dst, creating the destination directory if needed, and returns the number of bytes copied.
+func (t *Tools) CopyFile(src, dst string, opts ...CopyOptions) (int64, error) {
+	var o CopyOptions
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+
+	info, err := os.Lstat(src)
+	if err != nil {
+		return 0, err
+	}
+
+	if info.Mode()&fs.ModeSymlink != 0 {
+		switch o.Symlinks {
+		case SymlinkSkip:
+			return 0, nil
+		case SymlinkPreserve:
+			return 0, t.copySymlink(src, dst)
+		}
+		if t.NoFollowSymlinks {
+			return 0, &fs.PathError{Op: "copy", Path: src, Err: ErrSymlink}
+		}
+		if info, err = os.Stat(src); err != nil {
+			return 0, err
+		}
+	}
+
+	if !info.Mode().IsRegular() {
+		return 0, fmt.Errorf("%s is not a regular file", src)
+	}
+
+	// Opening dst truncates it, which would empty src were they the same file.
+	if dstInfo, err := os.Stat(dst); err == nil && os.SameFile(info, dstInfo) {
+		return 0, fmt.Errorf("cannot copy %s onto itself", src)
+	}
+
+	if _, err = t.CreateDirIfNotExists(filepath.Dir(dst)); err != nil {
+		return 0, err
+	}
+
+	mode := t.fileMode()
+	if o.PreserveMode {
+		mode = info.Mode().Perm()
+	}
+
+	in, err := t.open(src)
+	if err != nil {
+		return 0, err
+	}
+	defer in.Close()
+
+	out, err := t.openFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+	if err != nil {
+		return 0, err
+	}
+
+	var w io.Writer = out
+	if o.Progress != nil {
+		w = &progressWriter{w: out, path: src, total: info.Size(), progress: o.Progress}
+	}
+
+	n, err := io.Copy(w, in)
+	if err != nil {
+		out.Close()
+		return n, err
+	}
+	if err = out.Close(); err != nil {
+		return n, err
+	}
+
+	if o.PreserveMode {
+		// the umask may have narrowed the mode passed to OpenFile
+		if err = os.Chmod(dst, mode); err != nil {
+			return n, err
+		}
+	}
+	if o.PreserveTimes {
+		if err = os.Chtimes(dst, time.Now(), info.ModTime()); err != nil {
+			return n, err
+		}
+	}
+
+	return n, nil
+}
+
+// CopyDir recursively copies the directory tree rooted at src into dst. With SymlinkFollow,
+// links to directories are copied as directories, and a link leading back to a directory being
+// copied is reported as an error instead of being followed forever.
+func (t *Tools) CopyDir(src, dst string, opts ...CopyOptions) error {
+	var o CopyOptions
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+	return t.copyDir(src, dst, o, nil)
+}
+
+// copyDir copies src into dst for CopyDir. copying holds the real paths of the directories whose
+// copy is in progress, through followed links, starting with the one CopyDir was called with.
+func (t *Tools) copyDir(src, dst string, o CopyOptions, copying []string) error {
+	info, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", src)
+	}
+	// A copy inside src would be walked into as it is being made.
+	srcPath, err := realPath(src)
+	if err != nil {
+		return err
+	}
+	dstPath, err := realPath(dst)
+	if err != nil {
+		return err
+	}
+	if pathWithin(dstPath, srcPath) {
+		return fmt.Errorf("cannot copy %s into itself", src)
+	}
+	copying = append(slices.Clip(copying), srcPath)
+
+	type dirTime struct {
+		path    string
+		modTime time.Time
+	}
+	var dirTimes []dirTime
+
+	// The resolved path is walked, as WalkDir does not descend into a root that is a link.
+	err = filepath.WalkDir(srcPath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(srcPath, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case d.IsDir():
+			mode := t.dirMode()
+			if o.PreserveMode {
+				mode = info.Mode().Perm()
+			}
+			if err := os.MkdirAll(target, mode); err != nil {
+				return err
+			}
+			if o.PreserveMode {
+				if err := os.Chmod(target, mode); err != nil {
+					return err
+				}
+			}
+			if o.PreserveTimes {
+				dirTimes = append(dirTimes, dirTime{target, info.ModTime()})
+			}
+			return nil
+
+		case d.Type()&fs.ModeSymlink != 0 && o.Symlinks == SymlinkFollow && !t.NoFollowSymlinks:
+			resolved, err := os.Stat(path)
+			if err != nil {
+				return err
+			}
+			if resolved.IsDir() {
+				linked, err := realPath(path)
+				if err != nil {
+					return err
+				}
+				parent, err := realPath(filepath.Dir(path))
+				if err != nil {
+					return err
+				}
+				if pathWithin(parent, linked) || slices.ContainsFunc(copying, func(dir string) bool { return pathWithin(dir, linked) }) {
+					return fmt.Errorf("symbolic link %s leads back into a directory being copied", path)
+				}
+				return t.copyDir(path, target, o, copying)
+			}
+		}
+
+		_, err = t.CopyFile(path, target, o)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	// directory times are restored last, since copying their content changes them
+	for i := len(dirTimes) - 1; i >= 0; i-- {
+		if err := os.Chtimes(dirTimes[i].path, time.Now(), dirTimes[i].modTime); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (t *Tools) copySymlink(src, dst string) error {
+	target, err := os.Readlink(src)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(dst), t.dirMode()); err != nil {
+		return err
+	}
+	_ = os.Remove(dst)
+	return os.Symlink(target, dst)
+}
+
+// renameFile is swapped in tests to simulate moves across filesystems.
+var renameFile = os.Rename
+
+// MoveFile renames src to dst. When they are on different filesystems, where a rename is not
+// possible, the file is copied to dst (synced and with its mode and modification time kept)
+// and src is removed afterwards.
+func (t *Tools) MoveFile(src, dst string) error {
+	err := renameFile(src, dst)
+	if err == nil || !isCrossDeviceError(err) {
+		return err
+	}
+
+	in, err := t.open(src)
+	if err != nil {
+		return err
+	}
+	info, err := in.Stat()
+	if err != nil {
+		in.Close()
+		return err
+	}
+	if !info.Mode().IsRegular() {
+		in.Close()
+		return fmt.Errorf("%s is not a regular file", src)
+	}
+
+	_, err = t.WriteFileAtomic(dst, in, info.Mode().Perm())
+	in.Close()
+	if err != nil {
+		return err
+	}
+
+	if err = os.Chtimes(dst, time.Now(), info.ModTime()); err != nil {
+		return err
+	}
+	return os.Remove(src)
+}
+
+// pathWithin reports whether path, like dir a real path, is dir or inside it.
+func pathWithin(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// realPath returns the absolute form of path with the symbolic links of its existing part
+// resolved, so paths naming the same location compare equal.
+func realPath(path string) (string, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	var missing []string
+	for {
+		resolved, err := filepath.EvalSymlinks(path)
+		if err == nil {
+			return filepath.Join(append([]string{resolved}, missing...)...), nil
+		}
+		parent := filepath.Dir(path)
+		if !errors.Is(err, fs.ErrNotExist) || parent == path {
+			return "", err
+		}
+		missing = append([]string{filepath.Base(path)}, missing...)
+		path = parent
+	}
+}
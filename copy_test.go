@@ -0,0 +1,221 @@
+package toolkit
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTools_CopyFile(t *testing.T) {
+	var testTools Tools
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src.txt")
+	if err := os.WriteFile(src, []byte("hello world"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	modTime := time.Now().Add(-48 * time.Hour).Truncate(time.Second)
+	if err := os.Chtimes(src, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+
+	var lastWritten int64
+	dst := filepath.Join(dir, "nested", "dst.txt")
+	n, err := testTools.CopyFile(src, dst, CopyOptions{
+		PreserveMode:  true,
+		PreserveTimes: true,
+		Progress:      func(path string, written, total int64) { lastWritten = written },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 11 || lastWritten != 11 {
+		t.Errorf("expected 11 bytes copied, got %d (progress %d)", n, lastWritten)
+	}
+
+	info, err := os.Stat(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("mode not preserved: %v", info.Mode().Perm())
+	}
+	if !info.ModTime().Equal(modTime) {
+		t.Errorf("mtime not preserved: %v", info.ModTime())
+	}
+}
+
+func TestTools_CopyFileOntoItself(t *testing.T) {
+	var testTools Tools
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src.txt")
+	if err := os.WriteFile(src, []byte("hello world"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	dsts := []string{src, filepath.Join(dir, ".", "src.txt")}
+	if err := os.Link(src, filepath.Join(dir, "hard.txt")); err == nil {
+		dsts = append(dsts, filepath.Join(dir, "hard.txt"))
+	}
+	if err := os.Symlink("src.txt", filepath.Join(dir, "link.txt")); err == nil {
+		dsts = append(dsts, filepath.Join(dir, "link.txt"))
+	}
+	for _, dst := range dsts {
+		if _, err := testTools.CopyFile(src, dst); err == nil {
+			t.Errorf("%s: expected an error", dst)
+		}
+	}
+	if data, _ := os.ReadFile(src); string(data) != "hello world" {
+		t.Errorf("source was modified: %q", data)
+	}
+}
+
+func TestTools_CopyDirIntoItself(t *testing.T) {
+	var testTools Tools
+	src := t.TempDir()
+	_ = os.WriteFile(filepath.Join(src, "file.txt"), []byte("content"), 0644)
+
+	for _, dst := range []string{src, filepath.Join(src, "copy"), filepath.Join(src, "a", "b")} {
+		if err := testTools.CopyDir(src, dst); err == nil {
+			t.Errorf("%s: expected an error", dst)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(src, "copy")); err == nil {
+		t.Error("copy was started inside the source")
+	}
+
+	// A sibling whose name starts like src is not inside it.
+	if err := testTools.CopyDir(src, src+"-copy"); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+	t.Cleanup(func() { _ = os.RemoveAll(src + "-copy") })
+}
+
+func TestTools_CopyDirSymlinkCycle(t *testing.T) {
+	var testTools Tools
+	src := t.TempDir()
+	_ = os.MkdirAll(filepath.Join(src, "a", "b"), 0755)
+	_ = os.MkdirAll(filepath.Join(src, "c"), 0755)
+	_ = os.WriteFile(filepath.Join(src, "a", "file.txt"), []byte("content"), 0644)
+	if err := os.Symlink(filepath.Join(src, "c"), filepath.Join(src, "a", "b", "to-c")); err != nil {
+		t.Skip("symlinks not supported:", err)
+	}
+
+	// Links to other directories are followed, also more than once.
+	_ = os.Symlink(filepath.Join(src, "c"), filepath.Join(src, "a", "also-c"))
+	dst := filepath.Join(t.TempDir(), "copy")
+	if err := testTools.CopyDir(src, dst); err != nil {
+		t.Fatal(err)
+	}
+	for _, linked := range []string{filepath.Join("a", "also-c"), filepath.Join("a", "b", "to-c")} {
+		if info, err := os.Lstat(filepath.Join(dst, linked)); err != nil || !info.IsDir() {
+			t.Errorf("expected %s to be copied as a directory, got %v", linked, err)
+		}
+	}
+
+	cycles := map[string]string{
+		"self":     ".",
+		"ancestor": "..",
+		"source":   filepath.Join("..", ".."),
+	}
+	for name, target := range cycles {
+		link := filepath.Join(src, "a", "b", "loop")
+		_ = os.Remove(link)
+		_ = os.Symlink(target, link)
+		dst := filepath.Join(t.TempDir(), "copy")
+		if err := testTools.CopyDir(src, dst); err == nil || !strings.Contains(err.Error(), "leads back") {
+			t.Errorf("%s: expected a cycle error, got %v", name, err)
+		}
+	}
+
+	// Directories linking to each other form a cycle through both links.
+	_ = os.Remove(filepath.Join(src, "a", "b", "loop"))
+	_ = os.Symlink(filepath.Join(src, "a"), filepath.Join(src, "c", "to-a"))
+	if err := testTools.CopyDir(filepath.Join(src, "a"), filepath.Join(t.TempDir(), "copy")); err == nil || !strings.Contains(err.Error(), "leads back") {
+		t.Errorf("mutual links: expected a cycle error, got %v", err)
+	}
+}
+
+var copySymlinkTests = []struct {
+	name      string
+	policy    SymlinkPolicy
+	exists    bool
+	isSymlink bool
+}{
+	{name: "follow", policy: SymlinkFollow, exists: true, isSymlink: false},
+	{name: "preserve", policy: SymlinkPreserve, exists: true, isSymlink: true},
+	{name: "skip", policy: SymlinkSkip, exists: false},
+}
+
+func TestTools_CopyDir(t *testing.T) {
+	var testTools Tools
+
+	for _, test := range copySymlinkTests {
+		src := t.TempDir()
+		_ = os.MkdirAll(filepath.Join(src, "a", "b"), 0755)
+		_ = os.WriteFile(filepath.Join(src, "a", "b", "file.txt"), []byte("content"), 0644)
+		_ = os.WriteFile(filepath.Join(src, "top.txt"), []byte("top"), 0644)
+		if err := os.Symlink("top.txt", filepath.Join(src, "link.txt")); err != nil {
+			t.Skip("symlinks not supported:", err)
+		}
+
+		dst := filepath.Join(t.TempDir(), "copy")
+		if err := testTools.CopyDir(src, dst, CopyOptions{Symlinks: test.policy}); err != nil {
+			t.Errorf("%s: %s", test.name, err)
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(dst, "a", "b", "file.txt"))
+		if err != nil || string(data) != "content" {
+			t.Errorf("%s: nested file not copied: %v", test.name, err)
+		}
+
+		info, err := os.Lstat(filepath.Join(dst, "link.txt"))
+		if test.exists != (err == nil) {
+			t.Errorf("%s: unexpected link existence: %v", test.name, err)
+		}
+		if err == nil && (info.Mode()&os.ModeSymlink != 0) != test.isSymlink {
+			t.Errorf("%s: wrong link type: %v", test.name, info.Mode())
+		}
+	}
+}
+
+func TestTools_MoveFile(t *testing.T) {
+	var testTools Tools
+
+	for _, crossDevice := range []bool{false, true} {
+		if crossDevice {
+			renameFile = func(oldpath, newpath string) error {
+				return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: errCrossDevice}
+			}
+		}
+
+		dir := t.TempDir()
+		src := filepath.Join(dir, "staging.bin")
+		dst := filepath.Join(dir, "final.bin")
+		_ = os.WriteFile(src, []byte("payload"), 0640)
+		modTime := time.Now().Add(-time.Hour).Truncate(time.Second)
+		_ = os.Chtimes(src, modTime, modTime)
+
+		if err := testTools.MoveFile(src, dst); err != nil {
+			t.Fatalf("cross device %v: %s", crossDevice, err)
+		}
+		if _, err := os.Stat(src); !os.IsNotExist(err) {
+			t.Errorf("cross device %v: source still exists", crossDevice)
+		}
+
+		info, err := os.Stat(dst)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Mode().Perm() != 0640 || !info.ModTime().Equal(modTime) {
+			t.Errorf("cross device %v: mode or mtime not preserved: %v %v", crossDevice, info.Mode(), info.ModTime())
+		}
+	}
+	renameFile = os.Rename
+
+	if err := testTools.MoveFile(filepath.Join(t.TempDir(), "missing"), filepath.Join(t.TempDir(), "x")); err == nil {
+		t.Error("expected error for missing source")
+	}
+}
@@ -0,0 +1,14 @@
+//go:build !plan9
+
+package toolkit
+
+import (
+	"errors"
+	"syscall"
+)
+
+var errCrossDevice error = syscall.EXDEV
+
+func isCrossDeviceError(err error) bool {
+	return errors.Is(err, errCrossDevice)
+}
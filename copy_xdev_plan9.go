@@ -0,0 +1,12 @@
+package toolkit
+
+import (
+	"errors"
+	"strings"
+)
+
+var errCrossDevice = errors.New("cross-device link")
+
+func isCrossDeviceError(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "cross-device")
+}
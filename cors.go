@@ -0,0 +1,154 @@
+package toolkit
+
+import (
+	"errors"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CORSOptions configures EnableCORS.
+type CORSOptions struct {
+	// AllowedOrigins lists the origins allowed to make cross-origin requests: exact origins, such
+	// as "https://app.example.com", origins with a wildcard subdomain, such as
+	// "https://*.example.com", which does not match example.com itself, or "*" for any origin.
+	// "*" cannot be combined with AllowCredentials.
+	AllowedOrigins []string
+	// AllowedOriginPatterns are further origins allowed, matched as a whole by each expression.
+	AllowedOriginPatterns []*regexp.Regexp
+	// AllowedMethods are the methods cross-origin requests may use, GET, HEAD, POST, PUT, PATCH
+	// and DELETE when empty.
+	AllowedMethods []string
+	// AllowedHeaders are the request headers cross-origin requests may send, Accept,
+	// Authorization, Content-Type and X-Request-ID when empty.
+	AllowedHeaders []string
+	// ExposedHeaders are the response headers scripts may read besides the CORS safelisted ones.
+	ExposedHeaders []string
+	// AllowCredentials lets requests carry cookies and HTTP authentication. Allowed origins are
+	// then always echoed, as browsers do not accept "*" for credentialed requests. Allowing any
+	// origin would let every site read responses with the user's credentials, so the origins
+	// must be listed or matched by AllowedOriginPatterns.
+	AllowCredentials bool
+	// MaxAge is how long browsers may cache the result of a preflight request, 10 minutes when
+	// 0 and not at all when negative.
+	MaxAge time.Duration
+}
+
+var (
+	defaultCORSMethods = []string{http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete}
+	defaultCORSHeaders = []string{"Accept", "Authorization", "Content-Type", RequestIDHeader}
+)
+
+// EnableCORS wraps next with Cross-Origin Resource Sharing for the origins of opts. Preflight
+// requests are answered with 204 when the origin, method and headers are allowed, and with a 403
+// JSON error otherwise, without reaching next. Other requests from allowed origins get the CORS
+// headers and are passed on, as are requests from other origins, without them, which leaves it to
+// the browser to withhold the response. It panics when AllowedOrigins contains "*" and
+// AllowCredentials is set.
+func (t *Tools) EnableCORS(next http.Handler, opts CORSOptions) http.Handler {
+	anyOrigin := slices.Contains(opts.AllowedOrigins, "*")
+	if anyOrigin && opts.AllowCredentials {
+		panic(`toolkit: CORS cannot allow credentials from any origin; list the origins instead of "*"`)
+	}
+
+	if len(opts.AllowedMethods) == 0 {
+		opts.AllowedMethods = defaultCORSMethods
+	}
+	if len(opts.AllowedHeaders) == 0 {
+		opts.AllowedHeaders = defaultCORSHeaders
+	}
+	if opts.MaxAge == 0 {
+		opts.MaxAge = 10 * time.Minute
+	}
+	allowMethods := strings.Join(opts.AllowedMethods, ", ")
+	allowHeaders := strings.Join(opts.AllowedHeaders, ", ")
+	exposeHeaders := strings.Join(opts.ExposedHeaders, ", ")
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h := w.Header()
+		addVary(h, "Origin")
+		origin := r.Header.Get("Origin")
+		preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+		if preflight {
+			addVary(h, "Access-Control-Request-Method")
+			addVary(h, "Access-Control-Request-Headers")
+		}
+
+		if origin == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if !anyOrigin && !corsOriginAllowed(origin, opts) {
+			if preflight {
+				_ = t.ErrorJSON(w, errors.New("cross-origin requests from "+origin+" are not allowed"), http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if anyOrigin {
+			h.Set("Access-Control-Allow-Origin", "*")
+		} else {
+			h.Set("Access-Control-Allow-Origin", origin)
+		}
+		if opts.AllowCredentials {
+			h.Set("Access-Control-Allow-Credentials", "true")
+		}
+
+		if !preflight {
+			if exposeHeaders != "" {
+				h.Set("Access-Control-Expose-Headers", exposeHeaders)
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		method := r.Header.Get("Access-Control-Request-Method")
+		if !slices.Contains(opts.AllowedMethods, method) {
+			_ = t.ErrorJSON(w, errors.New("cross-origin "+method+" requests are not allowed"), http.StatusForbidden)
+			return
+		}
+		for _, header := range strings.Split(r.Header.Get("Access-Control-Request-Headers"), ",") {
+			header = strings.TrimSpace(header)
+			if header != "" && !slices.ContainsFunc(opts.AllowedHeaders, func(allowed string) bool {
+				return strings.EqualFold(allowed, header)
+			}) {
+				_ = t.ErrorJSON(w, errors.New("cross-origin requests may not send the header "+header), http.StatusForbidden)
+				return
+			}
+		}
+
+		h.Set("Access-Control-Allow-Methods", allowMethods)
+		h.Set("Access-Control-Allow-Headers", allowHeaders)
+		if opts.MaxAge > 0 {
+			h.Set("Access-Control-Max-Age", strconv.Itoa(int(opts.MaxAge.Seconds())))
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// corsOriginAllowed reports whether origin is one of the allowed origins of opts.
+func corsOriginAllowed(origin string, opts CORSOptions) bool {
+	for _, allowed := range opts.AllowedOrigins {
+		if prefix, suffix, ok := strings.Cut(allowed, "*."); ok {
+			sub, found := strings.CutPrefix(origin, prefix)
+			if found && strings.HasSuffix(sub, "."+suffix) && len(sub) > len(suffix)+1 {
+				return true
+			}
+			continue
+		}
+		if strings.EqualFold(origin, allowed) {
+			return true
+		}
+	}
+	for _, re := range opts.AllowedOriginPatterns {
+		if loc := re.FindStringIndex(origin); loc != nil && loc[0] == 0 && loc[1] == len(origin) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,120 @@
+package toolkit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+)
+
+var corsOptions = CORSOptions{
+	AllowedOrigins:        []string{"https://app.example.com", "https://*.example.org"},
+	AllowedOriginPatterns: []*regexp.Regexp{regexp.MustCompile(`http://localhost:\d+`)},
+	ExposedHeaders:        []string{"X-Total-Count"},
+	AllowCredentials:      true,
+}
+
+var corsTests = []struct {
+	name           string
+	method         string
+	origin         string
+	requestMethod  string
+	requestHeaders string
+	status         int
+	allowOrigin    string
+	reachedNext    bool
+}{
+	{name: "same origin", method: http.MethodGet, status: http.StatusOK, reachedNext: true},
+	{name: "exact origin", method: http.MethodGet, origin: "https://app.example.com", status: http.StatusOK, allowOrigin: "https://app.example.com", reachedNext: true},
+	{name: "wildcard subdomain", method: http.MethodGet, origin: "https://api.eu.example.org", status: http.StatusOK, allowOrigin: "https://api.eu.example.org", reachedNext: true},
+	{name: "wildcard does not match apex", method: http.MethodGet, origin: "https://example.org", status: http.StatusOK, reachedNext: true},
+	{name: "wildcard does not match lookalike", method: http.MethodGet, origin: "https://evilexample.org", status: http.StatusOK, reachedNext: true},
+	{name: "pattern", method: http.MethodGet, origin: "http://localhost:3000", status: http.StatusOK, allowOrigin: "http://localhost:3000", reachedNext: true},
+	{name: "pattern is anchored", method: http.MethodGet, origin: "http://localhost:3000.evil.com", status: http.StatusOK, reachedNext: true},
+	{name: "preflight", method: http.MethodOptions, origin: "https://app.example.com", requestMethod: http.MethodPut, requestHeaders: "content-type, x-request-id", status: http.StatusNoContent, allowOrigin: "https://app.example.com"},
+	{name: "preflight disallowed origin", method: http.MethodOptions, origin: "https://evil.com", requestMethod: http.MethodPut, status: http.StatusForbidden},
+	{name: "preflight disallowed method", method: http.MethodOptions, origin: "https://app.example.com", requestMethod: "PURGE", status: http.StatusForbidden, allowOrigin: "https://app.example.com"},
+	{name: "preflight disallowed header", method: http.MethodOptions, origin: "https://app.example.com", requestMethod: http.MethodGet, requestHeaders: "X-Secret", status: http.StatusForbidden, allowOrigin: "https://app.example.com"},
+	{name: "plain options", method: http.MethodOptions, origin: "https://app.example.com", status: http.StatusOK, allowOrigin: "https://app.example.com", reachedNext: true},
+}
+
+func TestTools_EnableCORS(t *testing.T) {
+	var testTools Tools
+	for _, test := range corsTests {
+		reached := false
+		h := testTools.EnableCORS(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			reached = true
+		}), corsOptions)
+
+		req := httptest.NewRequest(test.method, "/", nil)
+		if test.origin != "" {
+			req.Header.Set("Origin", test.origin)
+		}
+		if test.requestMethod != "" {
+			req.Header.Set("Access-Control-Request-Method", test.requestMethod)
+		}
+		if test.requestHeaders != "" {
+			req.Header.Set("Access-Control-Request-Headers", test.requestHeaders)
+		}
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+
+		if rr.Code != test.status {
+			t.Errorf("%s: expected status %d, got %d", test.name, test.status, rr.Code)
+		}
+		if got := rr.Header().Get("Access-Control-Allow-Origin"); got != test.allowOrigin {
+			t.Errorf("%s: expected Access-Control-Allow-Origin %q, got %q", test.name, test.allowOrigin, got)
+		}
+		if reached != test.reachedNext {
+			t.Errorf("%s: expected next reached to be %t", test.name, test.reachedNext)
+		}
+		if rr.Header().Get("Vary") == "" {
+			t.Errorf("%s: Vary header missing", test.name)
+		}
+	}
+}
+
+func TestTools_EnableCORSPreflightHeaders(t *testing.T) {
+	var testTools Tools
+	h := testTools.EnableCORS(http.NotFoundHandler(), CORSOptions{AllowedOrigins: []string{"*"}})
+
+	req := httptest.NewRequest(http.MethodOptions, "/", nil)
+	req.Header.Set("Origin", "https://anywhere.test")
+	req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d", rr.Code)
+	}
+	expected := map[string]string{
+		"Access-Control-Allow-Origin":      "*",
+		"Access-Control-Allow-Methods":     "GET, HEAD, POST, PUT, PATCH, DELETE",
+		"Access-Control-Allow-Headers":     "Accept, Authorization, Content-Type, X-Request-ID",
+		"Access-Control-Max-Age":           "600",
+		"Access-Control-Allow-Credentials": "",
+	}
+	for name, value := range expected {
+		if got := rr.Header().Get(name); got != value {
+			t.Errorf("expected %s %q, got %q", name, value, got)
+		}
+	}
+
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Origin", "https://anywhere.test")
+	testTools.EnableCORS(http.NotFoundHandler(), CORSOptions{AllowedOrigins: []string{"*"}, ExposedHeaders: []string{"X-Total-Count"}}).ServeHTTP(rr, req)
+	if rr.Header().Get("Access-Control-Allow-Origin") != "*" || rr.Header().Get("Access-Control-Expose-Headers") != "X-Total-Count" {
+		t.Errorf("unexpected headers %v", rr.Header())
+	}
+}
+
+func TestTools_EnableCORSAnyOriginWithCredentials(t *testing.T) {
+	var testTools Tools
+	defer func() {
+		if recover() == nil {
+			t.Error("expected credentials from any origin to be rejected")
+		}
+	}()
+	testTools.EnableCORS(http.NotFoundHandler(), CORSOptions{AllowedOrigins: []string{"*"}, AllowCredentials: true})
+}
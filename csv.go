@@ -0,0 +1,298 @@
+package toolkit
+
+import (
+	"encoding"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// csvColumn is a struct field read from or written to a CSV column.
+type csvColumn struct {
+	name     string
+	index    int
+	optional bool
+}
+
+// csvColumns returns the columns of the struct typ: its exported fields, named by their csv tag
+// or else their name. Fields tagged "-" are left out; those tagged with omitempty may be missing
+// from files read.
+func csvColumns(typ reflect.Type) []csvColumn {
+	var columns []csvColumn
+	for i := 0; i < typ.NumField(); i++ {
+		sf := typ.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		name, opts, _ := strings.Cut(sf.Tag.Get("csv"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+		columns = append(columns, csvColumn{name: name, index: i, optional: opts == "omitempty"})
+	}
+	return columns
+}
+
+// ReadCSV decodes a CSV file into dest, a pointer to a slice of structs or of pointers to structs,
+// one per record. The file is the request body, or the first file of a multipart form. Its first
+// record is the header, whose columns are matched to the fields of the struct, named by their csv
+// tag, ignoring case. Columns without a field are rejected unless AllowUnknownFields is set, as are
+// missing columns of fields not tagged omitempty. Fields may be strings, numbers, booleans,
+// time.Time, in RFC 3339 or as a date, types implementing encoding.TextUnmarshaler, or pointers
+// to these, which stay nil for empty cells. The body is limited to MaxBodySize, and every record
+// is checked against validate tags, with fields named as columns, and Validatable.
+func (t *Tools) ReadCSV(w http.ResponseWriter, r *http.Request, dest any) error {
+	slice := reflect.ValueOf(dest)
+	if slice.Kind() != reflect.Pointer || slice.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("csv: destination must be a pointer to a slice, not %T", dest)
+	}
+	slice = slice.Elem()
+	elemType := slice.Type().Elem()
+	structType := elemType
+	if structType.Kind() == reflect.Pointer {
+		structType = structType.Elem()
+	}
+	if structType.Kind() != reflect.Struct {
+		return fmt.Errorf("csv: destination must be a slice of structs, not %s", slice.Type())
+	}
+
+	r.Body = http.MaxBytesReader(w, contextBody(r.Context(), r.Body), t.maxBodySize())
+	body, err := csvBody(r)
+	if err != nil {
+		return csvDecodeError(err)
+	}
+	reader := csv.NewReader(body)
+
+	header, err := reader.Read()
+	if err != nil {
+		return csvDecodeError(err)
+	}
+	fields, err := t.csvHeader(header, csvColumns(structType))
+	if err != nil {
+		return err
+	}
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return csvDecodeError(err)
+		}
+		line, _ := reader.FieldPos(0)
+
+		elem := reflect.New(structType)
+		for i, value := range record {
+			if fields[i] < 0 {
+				continue
+			}
+			if err := t.setTextValue(elem.Elem().Field(fields[i]), value); err != nil {
+				return fmt.Errorf("line %d: column %s: invalid value %q", line, header[i], value)
+			}
+		}
+
+		if err := t.NewValidator().validate(elem.Interface(), "csv"); err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+
+		if elemType.Kind() != reflect.Pointer {
+			elem = elem.Elem()
+		}
+		slice.Set(reflect.Append(slice, elem))
+	}
+}
+
+// csvBody returns the first file of a multipart request, or else its body.
+func csvBody(r *http.Request) (io.Reader, error) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "multipart/form-data" {
+		return r.Body, nil
+	}
+	mr, err := r.MultipartReader()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		part, err := mr.NextPart()
+		if err != nil {
+			return nil, err
+		}
+		if part.FileName() != "" {
+			return part, nil
+		}
+	}
+}
+
+// csvHeader maps the columns of header to the indexes of the fields they are read into, -1 for
+// those that are ignored.
+func (t *Tools) csvHeader(header []string, columns []csvColumn) ([]int, error) {
+	if len(header) > 0 {
+		// Spreadsheets often start UTF-8 files with a byte order mark.
+		header[0] = strings.TrimPrefix(header[0], "\ufeff")
+	}
+	fields := make([]int, len(header))
+	found := make(map[string]bool)
+	for i, name := range header {
+		name = strings.TrimSpace(name)
+		fields[i] = -1
+		for _, c := range columns {
+			if strings.EqualFold(c.name, name) {
+				if found[c.name] {
+					return nil, fmt.Errorf("body contains column %q more than once", name)
+				}
+				fields[i], found[c.name] = c.index, true
+			}
+		}
+		if fields[i] < 0 && !t.AllowUnknownFields {
+			return nil, fmt.Errorf("body contains unknown column %q", name)
+		}
+	}
+	for _, c := range columns {
+		if !found[c.name] && !c.optional {
+			return nil, fmt.Errorf("body is missing column %q", c.name)
+		}
+	}
+	return fields, nil
+}
+
+// csvDecodeError turns an error reading a CSV file into a message fit for the client.
+func csvDecodeError(err error) error {
+	var parseError *csv.ParseError
+	var maxBytesError *http.MaxBytesError
+	switch {
+	case errors.As(err, &maxBytesError):
+		return &bodyTooLargeError{limit: maxBytesError.Limit}
+	case errors.As(err, &parseError):
+		return fmt.Errorf("body contains badly-formed CSV (at line %d)", parseError.Line)
+	case errors.Is(err, io.EOF):
+		return errors.New("body must not be empty")
+	}
+	return err
+}
+
+// setTextValue sets v from the text s, as it comes in CSV cells and form values. Pointers stay nil
+// for empty text.
+func (t *Tools) setTextValue(v reflect.Value, s string) error {
+	if v.Kind() == reflect.Pointer {
+		if s == "" {
+			v.SetZero()
+			return nil
+		}
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		v = v.Elem()
+	}
+	if v.Addr().Type().Implements(textUnmarshalerType) && v.Type() != reflect.TypeFor[time.Time]() {
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+	}
+
+	switch v.Type() {
+	case reflect.TypeFor[time.Time]():
+		if s == "" {
+			v.SetZero()
+			return nil
+		}
+		tm, err := parseTimeText(s)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(tm))
+		return nil
+	}
+	if s == "" && v.Kind() != reflect.String {
+		v.SetZero()
+		return nil
+	}
+	return t.setConfigValue(v, s)
+}
+
+// parseTimeText parses s in RFC 3339, as a datetime-local input sends it or as a date.
+func parseTimeText(s string) (time.Time, error) {
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04", time.DateOnly} {
+		if tm, err := time.Parse(layout, s); err == nil {
+			return tm, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q", s)
+}
+
+// WriteCSV writes rows as a CSV file with the given status, for download as filename when it is
+// not empty. rows is a slice of structs, or pointers to them, whose fields are the columns, named
+// like for ReadCSV and written with a header, or a [][]string written as it is. Rows are written
+// as they are formatted, so a failure midway leaves the response cut short.
+func (t *Tools) WriteCSV(w http.ResponseWriter, status int, rows any, filename string) error {
+	v := reflect.ValueOf(rows)
+	if v.Kind() != reflect.Slice {
+		return fmt.Errorf("csv: rows must be a slice, not %T", rows)
+	}
+	records, isRecords := rows.([][]string)
+	structType := v.Type().Elem()
+	if structType.Kind() == reflect.Pointer {
+		structType = structType.Elem()
+	}
+	if !isRecords && structType.Kind() != reflect.Struct {
+		return fmt.Errorf("csv: rows must be a slice of structs, not %T", rows)
+	}
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	if filename != "" {
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachement; filename=\"%s\"", filename))
+	}
+	w.WriteHeader(status)
+	cw := csv.NewWriter(w)
+
+	if isRecords {
+		if err := cw.WriteAll(records); err != nil {
+			return err
+		}
+		return nil
+	}
+
+	columns := csvColumns(structType)
+	record := make([]string, len(columns))
+	for i, c := range columns {
+		record[i] = c.name
+	}
+	if err := cw.Write(record); err != nil {
+		return err
+	}
+	for i := 0; i < v.Len(); i++ {
+		row := indirectValue(v.Index(i))
+		for j, c := range columns {
+			record[j] = ""
+			if row.IsValid() {
+				record[j] = csvText(row.Field(c.index))
+			}
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// csvText formats a field for a CSV cell.
+func csvText(v reflect.Value) string {
+	v = indirectValue(v)
+	if !v.IsValid() {
+		return ""
+	}
+	if m, ok := v.Interface().(encoding.TextMarshaler); ok && v.Type() != reflect.TypeFor[time.Time]() {
+		if b, err := m.MarshalText(); err == nil {
+			return string(b)
+		}
+	}
+	return exportText(v.Interface())
+}
@@ -0,0 +1,138 @@
+package toolkit
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type csvContact struct {
+	Name     string     `csv:"name" validate:"required"`
+	Age      int        `csv:"age"`
+	Active   bool       `csv:"active"`
+	Joined   time.Time  `csv:"joined"`
+	Score    *float64   `csv:"score,omitempty"`
+	Internal string     `csv:"-"`
+	Note     string     `csv:"note,omitempty"`
+	Seen     *time.Time `csv:"seen,omitempty"`
+}
+
+var readCSVTests = []struct {
+	name          string
+	body          string
+	maxSize       int
+	allowUnknown  bool
+	rows          int
+	errorContains string
+}{
+	{name: "good", body: "\ufeffName,age,active,joined,score\nAnn,30,true,2024-05-01,1.5\nBob,41,false,2024-05-01T10:00:00Z,\n", rows: 2},
+	{name: "quoted", body: "name,age,active,joined\n\"Smith, Ann\",30,1,2024-05-01\n", rows: 1},
+	{name: "header only", body: "name,age,active,joined\n", rows: 0},
+	{name: "empty", body: "", errorContains: "must not be empty"},
+	{name: "missing column", body: "name,age,active\nAnn,30,true\n", errorContains: `missing column "joined"`},
+	{name: "unknown column", body: "name,age,active,joined,email\nAnn,30,true,2024-05-01,a@b.c\n", errorContains: `unknown column "email"`},
+	{name: "allow unknown", body: "name,age,active,joined,email\nAnn,30,true,2024-05-01,a@b.c\n", allowUnknown: true, rows: 1},
+	{name: "duplicate column", body: "name,age,active,joined,Name\nAnn,30,true,2024-05-01,B\n", errorContains: `column "Name" more than once`},
+	{name: "bad value", body: "name,age,active,joined\nAnn,thirty,true,2024-05-01\n", errorContains: "line 2: column age: invalid value \"thirty\""},
+	{name: "wrong field count", body: "name,age,active,joined\nAnn,30\n", errorContains: "badly-formed CSV (at line 2)"},
+	{name: "validation", body: "name,age,active,joined\nAnn,30,true,2024-05-01\n,1,true,2024-05-01\n", errorContains: "line 3: validation failed: name: must be provided"},
+	{name: "too large", body: "name,age,active,joined\n" + strings.Repeat("Ann,30,true,2024-05-01\n", 10), maxSize: 64, errorContains: "must not be larger than 64 bytes"},
+}
+
+func TestTools_ReadCSV(t *testing.T) {
+	for _, test := range readCSVTests {
+		var testTools Tools
+		testTools.MaxBodySize = test.maxSize
+		testTools.AllowUnknownFields = test.allowUnknown
+
+		var contacts []csvContact
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(test.body))
+		err := testTools.ReadCSV(httptest.NewRecorder(), req, &contacts)
+		if test.errorContains == "" && err != nil {
+			t.Errorf("%s: unexpected error %v", test.name, err)
+		}
+		if test.errorContains != "" && (err == nil || !strings.Contains(err.Error(), test.errorContains)) {
+			t.Errorf("%s: expected error containing %q, got %v", test.name, test.errorContains, err)
+		}
+		if test.errorContains == "" && len(contacts) != test.rows {
+			t.Errorf("%s: expected %d rows, got %d", test.name, test.rows, len(contacts))
+		}
+	}
+
+	var testTools Tools
+	var contacts []*csvContact
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(readCSVTests[0].body))
+	if err := testTools.ReadCSV(httptest.NewRecorder(), req, &contacts); err != nil {
+		t.Fatal(err)
+	}
+	ann, bob := contacts[0], contacts[1]
+	if ann.Name != "Ann" || ann.Age != 30 || !ann.Active || !ann.Joined.Equal(time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)) || ann.Score == nil || *ann.Score != 1.5 {
+		t.Errorf("unexpected first row %+v", ann)
+	}
+	if bob.Score != nil || bob.Seen != nil || bob.Joined.Hour() != 10 {
+		t.Errorf("unexpected second row %+v", bob)
+	}
+
+	var notSlice csvContact
+	if err := testTools.ReadCSV(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/", nil), &notSlice); err == nil {
+		t.Error("expected an error for a destination that is not a slice")
+	}
+}
+
+func TestTools_ReadCSVMultipart(t *testing.T) {
+	var testTools Tools
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	_ = mw.WriteField("comment", "import")
+	fw, _ := mw.CreateFormFile("file", "contacts.csv")
+	_, _ = fw.Write([]byte("name,age,active,joined\nAnn,30,true,2024-05-01\n"))
+	_ = mw.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	var contacts []csvContact
+	if err := testTools.ReadCSV(httptest.NewRecorder(), req, &contacts); err != nil || len(contacts) != 1 || contacts[0].Name != "Ann" {
+		t.Errorf("unexpected result %+v %v", contacts, err)
+	}
+}
+
+func TestTools_WriteCSV(t *testing.T) {
+	var testTools Tools
+
+	score := 2.5
+	rows := []*csvContact{
+		{Name: "Smith, Ann", Age: 30, Active: true, Joined: time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC), Score: &score, Internal: "x"},
+		nil,
+	}
+	rr := httptest.NewRecorder()
+	if err := testTools.WriteCSV(rr, http.StatusOK, rows, "contacts.csv"); err != nil {
+		t.Fatal(err)
+	}
+	if rr.Header().Get("Content-Type") != "text/csv; charset=utf-8" || rr.Header().Get("Content-Disposition") != `attachement; filename="contacts.csv"` {
+		t.Errorf("unexpected headers %v", rr.Header())
+	}
+	expected := "name,age,active,joined,score,note,seen\n\"Smith, Ann\",30,true,2024-05-01T00:00:00Z,2.5,,\n,,,,,,\n"
+	if rr.Body.String() != expected {
+		t.Errorf("expected %q, got %q", expected, rr.Body.String())
+	}
+
+	var back []csvContact
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(strings.TrimSuffix(expected, ",,,,,,\n")))
+	if err := testTools.ReadCSV(httptest.NewRecorder(), req, &back); err != nil || len(back) != 1 || back[0].Name != "Smith, Ann" {
+		t.Errorf("expected the file to read back, got %+v %v", back, err)
+	}
+
+	rr = httptest.NewRecorder()
+	if err := testTools.WriteCSV(rr, http.StatusOK, [][]string{{"a", "b"}, {"1", "2"}}, ""); err != nil || rr.Body.String() != "a,b\n1,2\n" || rr.Header().Get("Content-Disposition") != "" {
+		t.Errorf("unexpected records output %q %v", rr.Body.String(), err)
+	}
+
+	if err := testTools.WriteCSV(httptest.NewRecorder(), http.StatusOK, []int{1}, ""); err == nil {
+		t.Error("expected an error for rows that are not structs")
+	}
+}
@@ -0,0 +1,45 @@
+package toolkit
+
+import (
+	"context"
+	"io"
+)
+
+// contextReader fails reads once ctx is done, so copies and decoders reading from it stop
+// between reads when the caller gives up.
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (c contextReader) Read(p []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return c.r.Read(p)
+}
+
+// contextReadSeeker is a contextReader for content served with http.ServeContent.
+type contextReadSeeker struct {
+	contextReader
+	s io.Seeker
+}
+
+func (c contextReadSeeker) Seek(offset int64, whence int) (int64, error) {
+	return c.s.Seek(offset, whence)
+}
+
+func newContextReadSeeker(ctx context.Context, rs io.ReadSeeker) io.ReadSeeker {
+	return contextReadSeeker{contextReader{ctx, rs}, rs}
+}
+
+// contextBody wraps a request body in a contextReader, keeping its Close.
+func contextBody(ctx context.Context, body io.ReadCloser) io.ReadCloser {
+	if body == nil {
+		return nil
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{contextReader{ctx, body}, body}
+}
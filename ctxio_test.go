@@ -0,0 +1,72 @@
+package toolkit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestTools_UploadFilesContext(t *testing.T) {
+	var testTools Tools
+	dir := t.TempDir()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := testTools.UploadFilesContext(ctx, newUploadRequest(t, map[string][]byte{"img.png": testPNG(t)}), dir); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("expected nothing to be stored, got %d files", len(entries))
+	}
+
+	files, err := testTools.UploadFilesContext(context.Background(), newUploadRequest(t, map[string][]byte{"img.png": testPNG(t)}), dir, false)
+	if err != nil || len(files) != 1 {
+		t.Fatalf("expected one file, got %v %v", files, err)
+	}
+}
+
+func TestTools_ReadJSONContext(t *testing.T) {
+	var testTools Tools
+	var payload struct {
+		Foo string `json:"foo"`
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"foo": "bar"}`))
+	if err := testTools.ReadJSONContext(ctx, httptest.NewRecorder(), req, &payload); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"foo": "bar"}`))
+	if err := testTools.ReadJSONContext(context.Background(), httptest.NewRecorder(), req, &payload); err != nil || payload.Foo != "bar" {
+		t.Errorf("unexpected result %+v %v", payload, err)
+	}
+}
+
+func TestTools_PushJSONToRemoteContext(t *testing.T) {
+	var testTools Tools
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+	}))
+	defer srv.Close()
+	client := srv.Client()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, _, err := testTools.PushJSONToRemoteContext(ctx, srv.URL, map[string]string{"foo": "bar"}, client); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+	if calls != 0 {
+		t.Errorf("expected no request to be sent, got %d", calls)
+	}
+
+	if _, status, err := testTools.PushJSONToRemoteContext(context.Background(), srv.URL, map[string]string{"foo": "bar"}, client); err != nil || status != http.StatusOK {
+		t.Errorf("unexpected result %d %v", status, err)
+	}
+}
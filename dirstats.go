@@ -0,0 +1,102 @@
+package toolkit
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// DirStats returns the total size in bytes and the number of regular files below path.
+// Subdirectories are scanned concurrently; the scan stops early when ctx is cancelled.
+func (t *Tools) DirStats(ctx context.Context, path string) (int64, int, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, 0, err
+	}
+	if !info.IsDir() {
+		return info.Size(), 1, nil
+	}
+
+	var (
+		size     atomic.Int64
+		files    atomic.Int64
+		wg       sync.WaitGroup
+		errOnce  sync.Once
+		firstErr error
+	)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	fail := func(err error) {
+		errOnce.Do(func() {
+			firstErr = err
+			cancel()
+		})
+	}
+
+	sem := make(chan struct{}, runtime.NumCPU()*2)
+
+	var scan func(dir string)
+	scan = func(dir string) {
+		if ctx.Err() != nil {
+			return
+		}
+
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			fail(err)
+			return
+		}
+
+		for _, entry := range entries {
+			if ctx.Err() != nil {
+				return
+			}
+			p := filepath.Join(dir, entry.Name())
+
+			if entry.IsDir() {
+				select {
+				case sem <- struct{}{}:
+					wg.Add(1)
+					go func() {
+						defer wg.Done()
+						defer func() { <-sem }()
+						scan(p)
+					}()
+				default:
+					// all workers busy, scan inline rather than block
+					scan(p)
+				}
+				continue
+			}
+
+			if !entry.Type().IsRegular() {
+				continue
+			}
+			info, err := entry.Info()
+			if err != nil {
+				if os.IsNotExist(err) {
+					continue
+				}
+				fail(err)
+				return
+			}
+			size.Add(info.Size())
+			files.Add(1)
+		}
+	}
+
+	scan(path)
+	wg.Wait()
+
+	if firstErr != nil {
+		return size.Load(), int(files.Load()), firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return size.Load(), int(files.Load()), err
+	}
+	return size.Load(), int(files.Load()), nil
+}
@@ -0,0 +1,42 @@
+package toolkit
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestTools_DirStats(t *testing.T) {
+	var testTools Tools
+	dir := t.TempDir()
+
+	for i := 0; i < 5; i++ {
+		sub := filepath.Join(dir, fmt.Sprintf("sub%d", i), "nested")
+		_ = os.MkdirAll(sub, 0755)
+		_ = os.WriteFile(filepath.Join(sub, "file.bin"), make([]byte, 100), 0644)
+	}
+	_ = os.WriteFile(filepath.Join(dir, "root.bin"), make([]byte, 24), 0644)
+
+	size, files, err := testTools.DirStats(context.Background(), dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size != 524 {
+		t.Errorf("expected 524 bytes, got %d", size)
+	}
+	if files != 6 {
+		t.Errorf("expected 6 files, got %d", files)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, _, err = testTools.DirStats(ctx, dir); err == nil {
+		t.Error("expected error for cancelled context")
+	}
+
+	if _, _, err = testTools.DirStats(context.Background(), filepath.Join(dir, "missing")); err == nil {
+		t.Error("expected error for missing directory")
+	}
+}
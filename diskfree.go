@@ -0,0 +1,37 @@
+package toolkit
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrInsufficientStorage is returned when a write would leave less free disk space than
+// Tools.MinFreeSpace. ErrorJSON reports it with status 507 Insufficient Storage.
+var ErrInsufficientStorage = errors.New("insufficient storage")
+
+// DiskFree returns the number of bytes available to unprivileged users on the filesystem
+// containing path. It returns errors.ErrUnsupported on platforms where this is not known.
+func (t *Tools) DiskFree(path string) (int64, error) {
+	return diskFree(path)
+}
+
+// ensureFreeSpace fails with ErrInsufficientStorage when writing size more bytes below dir would
+// drop the free space under Tools.MinFreeSpace. Platforms without DiskFree support are not checked.
+func (t *Tools) ensureFreeSpace(dir string, size int64) error {
+	if t.MinFreeSpace <= 0 {
+		return nil
+	}
+
+	free, err := diskFree(dir)
+	if errors.Is(err, errors.ErrUnsupported) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if free-size < t.MinFreeSpace {
+		return fmt.Errorf("%w: %s free, %s required", ErrInsufficientStorage, t.HumanBytes(free), t.HumanBytes(size+t.MinFreeSpace))
+	}
+	return nil
+}
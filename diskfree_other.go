@@ -0,0 +1,9 @@
+//go:build !(darwin || dragonfly || freebsd || linux || windows)
+
+package toolkit
+
+import "errors"
+
+func diskFree(string) (int64, error) {
+	return 0, errors.ErrUnsupported
+}
@@ -0,0 +1,49 @@
+package toolkit
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTools_DiskFree(t *testing.T) {
+	var testTools Tools
+	free, err := testTools.DiskFree(t.TempDir())
+	if errors.Is(err, errors.ErrUnsupported) {
+		t.Skip("DiskFree not supported on this platform")
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if free <= 0 {
+		t.Errorf("expected positive free space, got %d", free)
+	}
+}
+
+func TestTools_UploadFilesMinFreeSpace(t *testing.T) {
+	testTools := Tools{MinFreeSpace: math.MaxInt64 / 2}
+	if _, err := testTools.DiskFree(t.TempDir()); err != nil {
+		t.Skip("DiskFree not supported on this platform")
+	}
+
+	_, err := testTools.UploadFiles(newUploadRequest(t, map[string][]byte{"img.png": testPNG(t)}), t.TempDir())
+	if !errors.Is(err, ErrInsufficientStorage) {
+		t.Fatalf("expected ErrInsufficientStorage, got %v", err)
+	}
+
+	rr := httptest.NewRecorder()
+	_ = testTools.ErrorJSON(rr, fmt.Errorf("upload failed: %w", err))
+	if rr.Code != http.StatusInsufficientStorage {
+		t.Errorf("expected status 507, got %d", rr.Code)
+	}
+
+	var payload JSONResponse
+	_ = json.NewDecoder(rr.Body).Decode(&payload)
+	if !payload.Error {
+		t.Error("expected error payload")
+	}
+}
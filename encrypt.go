@@ -0,0 +1,346 @@
+package toolkit
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/hkdf"
+)
+
+// Encrypted files start with a header of magic, key version, chunk size and a random salt,
+// followed by the plaintext sealed with AES-GCM in frames of encChunkSize bytes. Each file has its
+// own key, derived from the configured one and the salt with HKDF, so nonces never repeat across
+// files. Each frame's nonce is the frame index and a flag marking the last frame, so frames cannot
+// be reordered or the file truncated without detection. Frames have a fixed size, which keeps the
+// format seekable.
+//
+// Files of the first version, with magic TKE1, used the configured key directly with a random
+// 7-byte nonce prefix in place of the salt. They can still be read.
+const (
+	encMagic      = "TKE2"
+	encMagicV1    = "TKE1"
+	encSaltSize   = 32
+	encHeaderSize = len(encMagic) + 4 + 4 + encSaltSize
+	encPrefixSize = 7
+	encChunkSize  = 64 * 1024
+	encMaxChunk   = 16 * 1024 * 1024
+)
+
+// encKeyInfo binds derived keys to their use.
+var encKeyInfo = []byte("toolkit file encryption")
+
+var (
+	// ErrNotEncrypted is returned when reading a file that does not carry the toolkit's encryption header.
+	ErrNotEncrypted = errors.New("file is not encrypted")
+	// ErrUnknownKeyVersion is returned when a file was encrypted with a key missing from Tools.EncryptionKeys.
+	ErrUnknownKeyVersion = errors.New("unknown encryption key version")
+)
+
+func (t *Tools) encryptionEnabled() bool {
+	return len(t.EncryptionKeys) > 0
+}
+
+// aeadFor returns the cipher of a file encrypted with the key version, its key derived with salt,
+// or the key itself when salt is nil, as in files of the first version.
+func (t *Tools) aeadFor(version uint32, salt []byte) (cipher.AEAD, error) {
+	key, ok := t.EncryptionKeys[version]
+	if !ok {
+		return nil, fmt.Errorf("%w %d", ErrUnknownKeyVersion, version)
+	}
+	if salt != nil {
+		derived := make([]byte, len(key))
+		if _, err := io.ReadFull(hkdf.New(sha256.New, key, salt, encKeyInfo), derived); err != nil {
+			return nil, err
+		}
+		key = derived
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func encNonce(prefix []byte, index uint32, final bool) []byte {
+	nonce := make([]byte, 0, encPrefixSize+5)
+	nonce = append(nonce, prefix...)
+	nonce = binary.BigEndian.AppendUint32(nonce, index)
+	if final {
+		return append(nonce, 1)
+	}
+	return append(nonce, 0)
+}
+
+// EncryptWriter returns a writer that encrypts everything written to it into w with the key
+// selected by Tools.EncryptionKeyVersion. Close must be called to write the final frame; it does
+// not close w.
+func (t *Tools) EncryptWriter(w io.Writer) (io.WriteCloser, error) {
+	salt := make([]byte, encSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	aead, err := t.aeadFor(t.EncryptionKeyVersion, salt)
+	if err != nil {
+		return nil, err
+	}
+
+	header := make([]byte, 0, encHeaderSize)
+	header = append(header, encMagic...)
+	header = binary.BigEndian.AppendUint32(header, t.EncryptionKeyVersion)
+	header = binary.BigEndian.AppendUint32(header, encChunkSize)
+	header = append(header, salt...)
+
+	if _, err = w.Write(header); err != nil {
+		return nil, err
+	}
+	return &encryptWriter{w: w, aead: aead, header: header, prefix: make([]byte, encPrefixSize)}, nil
+}
+
+type encryptWriter struct {
+	w      io.Writer
+	aead   cipher.AEAD
+	header []byte
+	prefix []byte
+	buf    []byte
+	index  uint32
+	err    error
+}
+
+func (e *encryptWriter) Write(p []byte) (int, error) {
+	if e.err != nil {
+		return 0, e.err
+	}
+	e.buf = append(e.buf, p...)
+	// A full chunk is held back until more data arrives, as only then is it known not to be the last.
+	for len(e.buf) > encChunkSize {
+		if e.err = e.seal(e.buf[:encChunkSize], false); e.err != nil {
+			return 0, e.err
+		}
+		e.buf = e.buf[:copy(e.buf, e.buf[encChunkSize:])]
+	}
+	return len(p), nil
+}
+
+func (e *encryptWriter) Close() error {
+	if e.err != nil {
+		return e.err
+	}
+	e.err = e.seal(e.buf, true)
+	if e.err == nil {
+		e.err = errors.New("encrypt writer is closed")
+		return nil
+	}
+	return e.err
+}
+
+func (e *encryptWriter) seal(chunk []byte, final bool) error {
+	out := e.aead.Seal(nil, encNonce(e.prefix, e.index, final), chunk, e.header)
+	e.index++
+	_, err := e.w.Write(out)
+	return err
+}
+
+// DecryptReader returns a reader over the plaintext of an encrypted stream such as a file written
+// by UploadFiles with encryption enabled. The result supports seeking, so it can be passed to
+// http.ServeContent. Every frame is authenticated as it is read.
+func (t *Tools) DecryptReader(r io.ReadSeeker) (io.ReadSeeker, error) {
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	header := make([]byte, len(encMagic)+4+4)
+	if _, err := io.ReadFull(r, header); err != nil {
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, ErrNotEncrypted
+		}
+		return nil, err
+	}
+	v1 := bytes.Equal(header[:len(encMagic)], []byte(encMagicV1))
+	if !v1 && !bytes.Equal(header[:len(encMagic)], []byte(encMagic)) {
+		return nil, ErrNotEncrypted
+	}
+	rest := encSaltSize
+	if v1 {
+		rest = encPrefixSize
+	}
+	header = append(header, make([]byte, rest)...)
+	if _, err := io.ReadFull(r, header[len(header)-rest:]); err != nil {
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, ErrNotEncrypted
+		}
+		return nil, err
+	}
+
+	version := binary.BigEndian.Uint32(header[len(encMagic):])
+	chunk := int64(binary.BigEndian.Uint32(header[len(encMagic)+4:]))
+	if chunk <= 0 || chunk > encMaxChunk {
+		return nil, errors.New("encrypted file has an invalid chunk size")
+	}
+	var aead cipher.AEAD
+	var prefix []byte
+	var err error
+	if v1 {
+		prefix = header[len(header)-encPrefixSize:]
+		aead, err = t.aeadFor(version, nil)
+	} else {
+		prefix = make([]byte, encPrefixSize)
+		aead, err = t.aeadFor(version, header[len(header)-encSaltSize:])
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	end, err := r.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, err
+	}
+	body := end - int64(len(header))
+	frame := chunk + int64(aead.Overhead())
+	frames := (body + frame - 1) / frame
+	if frames == 0 || body-(frames-1)*frame < int64(aead.Overhead()) {
+		return nil, errors.New("encrypted file is truncated")
+	}
+
+	d := &decryptReader{
+		r:      r,
+		aead:   aead,
+		header: header,
+		prefix: prefix,
+		chunk:  chunk,
+		frame:  frame,
+		body:   body,
+		frames: frames,
+		size:   body - frames*int64(aead.Overhead()),
+		loaded: -1,
+	}
+	// Opening the last frame up front rejects wrong keys and truncated files before any data is served.
+	if err = d.load(frames - 1); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+type decryptReader struct {
+	r      io.ReadSeeker
+	aead   cipher.AEAD
+	header []byte
+	prefix []byte
+	chunk  int64
+	frame  int64
+	body   int64
+	frames int64
+	size   int64
+	offset int64
+	buf    []byte
+	loaded int64
+}
+
+func (d *decryptReader) load(index int64) error {
+	if d.loaded == index {
+		return nil
+	}
+	start := index * d.frame
+	n := min(d.frame, d.body-start)
+	if _, err := d.r.Seek(int64(len(d.header))+start, io.SeekStart); err != nil {
+		return err
+	}
+	sealed := make([]byte, n)
+	if _, err := io.ReadFull(d.r, sealed); err != nil {
+		return err
+	}
+	plain, err := d.aead.Open(d.buf[:0], encNonce(d.prefix, uint32(index), index == d.frames-1), sealed, d.header)
+	if err != nil {
+		d.loaded = -1
+		return fmt.Errorf("decrypting frame %d: %w", index, err)
+	}
+	d.buf, d.loaded = plain, index
+	return nil
+}
+
+func (d *decryptReader) Read(p []byte) (int, error) {
+	if d.offset >= d.size {
+		return 0, io.EOF
+	}
+	index := d.offset / d.chunk
+	if err := d.load(index); err != nil {
+		return 0, err
+	}
+	n := copy(p, d.buf[d.offset-index*d.chunk:])
+	d.offset += int64(n)
+	return n, nil
+}
+
+func (d *decryptReader) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += d.offset
+	case io.SeekEnd:
+		offset += d.size
+	default:
+		return 0, errors.New("invalid whence")
+	}
+	if offset < 0 {
+		return 0, errors.New("negative position")
+	}
+	d.offset = offset
+	return offset, nil
+}
+
+// OpenDecrypted opens an encrypted file and returns a seekable reader over its plaintext.
+func (t *Tools) OpenDecrypted(path string) (io.ReadSeekCloser, error) {
+	f, err := t.open(path)
+	if err != nil {
+		return nil, err
+	}
+	r, err := t.DecryptReader(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return struct {
+		io.ReadSeeker
+		io.Closer
+	}{r, f}, nil
+}
+
+// encryptingReader turns a plaintext reader into an encrypted stream through a pipe, so it can be
+// handed to WriteFileAtomic. Close stops the encrypting goroutine and waits for it to finish.
+type encryptingReader struct {
+	*io.PipeReader
+	done chan struct{}
+	n    int64
+}
+
+func (t *Tools) encryptingReader(r io.Reader) (*encryptingReader, error) {
+	if _, err := t.aeadFor(t.EncryptionKeyVersion, nil); err != nil {
+		return nil, err
+	}
+
+	pr, pw := io.Pipe()
+	e := &encryptingReader{PipeReader: pr, done: make(chan struct{})}
+	go func() {
+		defer close(e.done)
+		ew, err := t.EncryptWriter(pw)
+		if err == nil {
+			e.n, err = io.Copy(ew, r)
+			if err == nil {
+				err = ew.Close()
+			}
+		}
+		pw.CloseWithError(err)
+	}()
+	return e, nil
+}
+
+func (e *encryptingReader) Close() error {
+	err := e.PipeReader.Close()
+	<-e.done
+	return err
+}
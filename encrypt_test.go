@@ -0,0 +1,168 @@
+package toolkit
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func encryptionTools() Tools {
+	return Tools{
+		EncryptionKeys:       map[uint32][]byte{1: bytes.Repeat([]byte{1}, 32), 2: bytes.Repeat([]byte{2}, 32)},
+		EncryptionKeyVersion: 2,
+	}
+}
+
+func encryptBytes(t *testing.T, testTools *Tools, plain []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w, err := testTools.EncryptWriter(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = w.Write(plain); err != nil {
+		t.Fatal(err)
+	}
+	if err = w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestTools_EncryptRoundTrip(t *testing.T) {
+	testTools := encryptionTools()
+	for _, size := range []int{0, 10, encChunkSize, 2*encChunkSize + 123} {
+		plain := bytes.Repeat([]byte("0123456789"), size/10+1)[:size]
+		sealed := encryptBytes(t, &testTools, plain)
+		if bytes.Contains(sealed, []byte("0123456789")) {
+			t.Errorf("%d: plaintext visible in encrypted output", size)
+		}
+
+		r, err := testTools.DecryptReader(bytes.NewReader(sealed))
+		if err != nil {
+			t.Fatalf("%d: %v", size, err)
+		}
+		got, err := io.ReadAll(r)
+		if err != nil || !bytes.Equal(got, plain) {
+			t.Errorf("%d: round trip failed: %v", size, err)
+		}
+
+		if size > 100 {
+			_, _ = r.Seek(int64(size-50), io.SeekStart)
+			tail, _ := io.ReadAll(r)
+			if !bytes.Equal(tail, plain[size-50:]) {
+				t.Errorf("%d: seek returned wrong data", size)
+			}
+		}
+	}
+}
+
+func TestTools_EncryptPerFileKeys(t *testing.T) {
+	testTools := encryptionTools()
+	plain := bytes.Repeat([]byte("x"), 100)
+	a, b := encryptBytes(t, &testTools, plain), encryptBytes(t, &testTools, plain)
+	if bytes.Equal(a[encHeaderSize-encSaltSize:encHeaderSize], b[encHeaderSize-encSaltSize:encHeaderSize]) {
+		t.Error("expected a new salt for each file")
+	}
+	if bytes.Equal(a[encHeaderSize:], b[encHeaderSize:]) {
+		t.Error("expected files with the same content to be encrypted differently")
+	}
+
+	// A file of the first version, sealed with the key itself and a nonce prefix, still decrypts.
+	aead, err := testTools.aeadFor(2, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prefix := []byte("1234567")
+	v1 := []byte(encMagicV1)
+	v1 = binary.BigEndian.AppendUint32(v1, 2)
+	v1 = binary.BigEndian.AppendUint32(v1, encChunkSize)
+	v1 = append(v1, prefix...)
+	v1 = append(v1, aead.Seal(nil, encNonce(prefix, 0, true), plain, v1)...)
+	r, err := testTools.DecryptReader(bytes.NewReader(v1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := io.ReadAll(r); err != nil || !bytes.Equal(got, plain) {
+		t.Errorf("first version file did not decrypt: %v", err)
+	}
+}
+
+func TestTools_DecryptRejectsTampering(t *testing.T) {
+	testTools := encryptionTools()
+	sealed := encryptBytes(t, &testTools, bytes.Repeat([]byte("x"), 3*encChunkSize))
+
+	flipped := bytes.Clone(sealed)
+	flipped[encHeaderSize+10] ^= 1
+	if r, err := testTools.DecryptReader(bytes.NewReader(flipped)); err == nil {
+		if _, err = io.ReadAll(r); err == nil {
+			t.Error("expected modified frame to fail authentication")
+		}
+	}
+
+	truncated := sealed[:encHeaderSize+encChunkSize+16]
+	if _, err := testTools.DecryptReader(bytes.NewReader(truncated)); err == nil {
+		t.Error("expected truncated file to be rejected")
+	}
+
+	var other Tools
+	other.EncryptionKeys = map[uint32][]byte{3: bytes.Repeat([]byte{3}, 32)}
+	if _, err := other.DecryptReader(bytes.NewReader(sealed)); !errors.Is(err, ErrUnknownKeyVersion) {
+		t.Error("expected ErrUnknownKeyVersion, got", err)
+	}
+	if _, err := testTools.DecryptReader(bytes.NewReader([]byte("plain text file"))); !errors.Is(err, ErrNotEncrypted) {
+		t.Error("expected ErrNotEncrypted, got", err)
+	}
+}
+
+func TestTools_UploadFilesEncrypted(t *testing.T) {
+	testTools := encryptionTools()
+	dir := t.TempDir()
+	img := testPNG(t)
+
+	uploadedFiles, err := testTools.UploadFiles(newUploadRequest(t, map[string][]byte{"img.png": img}), dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if uploadedFiles[0].FileSize != int64(len(img)) {
+		t.Errorf("expected plaintext size %d, got %d", len(img), uploadedFiles[0].FileSize)
+	}
+
+	stored, _ := os.ReadFile(filepath.Join(dir, "img.png"))
+	if bytes.Equal(stored, img) || string(stored[:len(encMagic)]) != encMagic {
+		t.Fatal("file was not encrypted at rest")
+	}
+
+	// Rotating the key must keep older files readable.
+	testTools.EncryptionKeyVersion = 1
+	f, err := testTools.OpenDecrypted(filepath.Join(dir, "img.png"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, _ := io.ReadAll(f)
+	f.Close()
+	if !bytes.Equal(got, img) {
+		t.Error("decrypted file differs from upload")
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Range", "bytes=0-7")
+	rr := httptest.NewRecorder()
+	testTools.DownloadStaticFile(rr, req, dir, "img.png", "img.png")
+	if rr.Code != http.StatusPartialContent || !bytes.Equal(rr.Body.Bytes(), img[:8]) {
+		t.Errorf("unexpected download response %d %q", rr.Code, rr.Body.Bytes())
+	}
+
+	_ = os.WriteFile(filepath.Join(dir, "legacy.txt"), []byte("stored before encryption"), 0644)
+	rr = httptest.NewRecorder()
+	testTools.DownloadStaticFile(rr, httptest.NewRequest(http.MethodGet, "/", nil), dir, "legacy.txt", "legacy.txt")
+	if rr.Body.String() != "stored before encryption" {
+		t.Error("unencrypted file not served as is:", rr.Body.String())
+	}
+}
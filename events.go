@@ -0,0 +1,109 @@
+package toolkit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrEventDropped is returned by Publish when a subscriber has too many undelivered events.
+var ErrEventDropped = errors.New("event dropped, subscriber is too far behind")
+
+// EventBus fans events out to in-process subscribers. Events are routed by their Go type with
+// the package-level Publish and Subscribe functions, and delivered on a JobRunner, so handlers
+// get its retries and panic isolation.
+type EventBus struct {
+	jobs *JobRunner
+
+	mu     sync.RWMutex
+	subs   map[reflect.Type][]*subscription
+	nextID uint64
+}
+
+type subscription struct {
+	id      uint64
+	buffer  int64
+	pending atomic.Int64
+	deliver func(ctx context.Context, event any) error
+}
+
+// SubscribeOptions configures a subscription.
+type SubscribeOptions struct {
+	// Buffer is the number of events waiting for delivery to the subscriber, 100 when 0. Further
+	// events are dropped until it catches up.
+	Buffer int
+}
+
+// NewEventBus returns a bus delivering events on jobs.
+func (t *Tools) NewEventBus(jobs *JobRunner) *EventBus {
+	return &EventBus{jobs: jobs, subs: make(map[reflect.Type][]*subscription)}
+}
+
+// Subscribe calls handler for every event of type T published on bus until the returned function
+// is called. Events published together are delivered concurrently, so handlers must not rely on
+// their order.
+func Subscribe[T any](bus *EventBus, handler func(ctx context.Context, event T) error, opts ...SubscribeOptions) (unsubscribe func()) {
+	buffer := 100
+	if len(opts) > 0 && opts[0].Buffer > 0 {
+		buffer = opts[0].Buffer
+	}
+	typ := reflect.TypeFor[T]()
+
+	bus.mu.Lock()
+	bus.nextID++
+	sub := &subscription{
+		id:     bus.nextID,
+		buffer: int64(buffer),
+		deliver: func(ctx context.Context, event any) error {
+			return handler(ctx, event.(T))
+		},
+	}
+	bus.subs[typ] = append(bus.subs[typ], sub)
+	bus.mu.Unlock()
+
+	return func() {
+		bus.mu.Lock()
+		defer bus.mu.Unlock()
+		subs := bus.subs[typ]
+		for i, s := range subs {
+			if s.id == sub.id {
+				bus.subs[typ] = append(subs[:i:i], subs[i+1:]...)
+				break
+			}
+		}
+	}
+}
+
+// Publish queues event for delivery to the subscribers of its type and returns without waiting
+// for them. Subscribers that are too far behind miss the event, which is reported as
+// ErrEventDropped; a full job queue as ErrQueueFull.
+func Publish[T any](bus *EventBus, event T) error {
+	bus.mu.RLock()
+	subs := bus.subs[reflect.TypeFor[T]()]
+	bus.mu.RUnlock()
+
+	var errs []error
+	for _, sub := range subs {
+		if sub.pending.Add(1) > sub.buffer {
+			sub.pending.Add(-1)
+			errs = append(errs, fmt.Errorf("%T: %w", event, ErrEventDropped))
+			continue
+		}
+		var started atomic.Bool
+		err := bus.jobs.Enqueue(func(ctx context.Context) error {
+			// The event leaves the buffer on its first attempt; retries do not count again.
+			if !started.Swap(true) {
+				sub.pending.Add(-1)
+			}
+			return sub.deliver(ctx, event)
+		})
+		if err != nil {
+			sub.pending.Add(-1)
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
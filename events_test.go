@@ -0,0 +1,102 @@
+package toolkit
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type uploadCompleted struct {
+	File string
+}
+
+type webhookReceived struct {
+	Source string
+}
+
+func TestTools_EventBus(t *testing.T) {
+	testTools := Tools{Logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+	jobs := testTools.NewJobRunner(JobRunnerOptions{Workers: 2, MaxAttempts: 2, Backoff: time.Millisecond})
+	bus := testTools.NewEventBus(jobs)
+
+	var mu sync.Mutex
+	var files []string
+	var webhooks, failures atomic.Int32
+	Subscribe(bus, func(ctx context.Context, e uploadCompleted) error {
+		mu.Lock()
+		defer mu.Unlock()
+		files = append(files, e.File)
+		return nil
+	})
+	unsubscribe := Subscribe(bus, func(ctx context.Context, e webhookReceived) error {
+		webhooks.Add(1)
+		return nil
+	})
+	Subscribe(bus, func(ctx context.Context, e uploadCompleted) error {
+		if failures.Add(1) == 1 {
+			return errors.New("try again")
+		}
+		return nil
+	})
+	Subscribe(bus, func(ctx context.Context, e uploadCompleted) error {
+		if e.File == "panic" {
+			panic("boom")
+		}
+		return nil
+	})
+
+	for _, f := range []string{"a.png", "b.png", "panic"} {
+		if err := Publish(bus, uploadCompleted{File: f}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_ = Publish(bus, webhookReceived{Source: "github"})
+	unsubscribe()
+	_ = Publish(bus, webhookReceived{Source: "github"})
+
+	if err := jobs.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 3 || webhooks.Load() != 1 {
+		t.Errorf("unexpected deliveries: files %v, webhooks %d", files, webhooks.Load())
+	}
+	if failures.Load() != 4 {
+		t.Errorf("expected the failed delivery to be retried, got %d attempts", failures.Load())
+	}
+}
+
+func TestTools_EventBusBuffer(t *testing.T) {
+	testTools := Tools{Logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+	jobs := testTools.NewJobRunner(JobRunnerOptions{Workers: 1})
+	bus := testTools.NewEventBus(jobs)
+
+	release := make(chan struct{})
+	var delivered atomic.Int32
+	Subscribe(bus, func(ctx context.Context, e int) error {
+		<-release
+		delivered.Add(1)
+		return nil
+	}, SubscribeOptions{Buffer: 2})
+
+	_ = Publish(bus, 1)
+	// Wait for the first event to be picked up, leaving the buffer empty.
+	for deadline := time.Now().Add(time.Second); jobs.QueueDepth() > 0 && time.Now().Before(deadline); {
+		time.Sleep(time.Millisecond)
+	}
+	_ = Publish(bus, 2)
+	_ = Publish(bus, 3)
+	if err := Publish(bus, 4); !errors.Is(err, ErrEventDropped) {
+		t.Errorf("expected the event to be dropped, got %v", err)
+	}
+
+	close(release)
+	_ = jobs.Shutdown(context.Background())
+	if delivered.Load() != 3 {
+		t.Errorf("expected 3 deliveries, got %d", delivered.Load())
+	}
+}
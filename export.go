@@ -0,0 +1,443 @@
+package toolkit
+
+import (
+	"archive/zip"
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ExportFormat is a file format produced by an Exporter.
+type ExportFormat string
+
+const (
+	ExportCSV    ExportFormat = "csv"
+	ExportXLSX   ExportFormat = "xlsx"
+	ExportNDJSON ExportFormat = "ndjson"
+)
+
+// Export states reported in ExportStatus.
+const (
+	ExportPending = "pending"
+	ExportRunning = "running"
+	ExportDone    = "done"
+	ExportFailed  = "failed"
+)
+
+// ExportRequest describes an export.
+type ExportRequest struct {
+	// Name is the download name of the file, without extension.
+	Name   string
+	Format ExportFormat
+	// Columns are the column headers, and the keys of NDJSON objects.
+	Columns []string
+	// Rows yields the rows, one value per column. Iteration stops at the first error, which fails
+	// the export. Rows may be iterated again when the job runner retries a failed export.
+	Rows iter.Seq2[[]any, error]
+	// Total is the expected number of rows, reported for progress bars. Unknown when 0.
+	Total int
+}
+
+// ExportStatus is the progress of an export, as served by the status handler.
+type ExportStatus struct {
+	ID         string       `json:"id"`
+	Name       string       `json:"name"`
+	Format     ExportFormat `json:"format"`
+	State      string       `json:"state"`
+	Rows       int          `json:"rows"`
+	Total      int          `json:"total,omitempty"`
+	Error      string       `json:"error,omitempty"`
+	URL        string       `json:"url,omitempty"`
+	CreatedAt  time.Time    `json:"created_at"`
+	FinishedAt *time.Time   `json:"finished_at,omitempty"`
+}
+
+// ExporterOptions configures NewExporter.
+type ExporterOptions struct {
+	// Dir is where export files are written.
+	Dir string
+	// Jobs runs the exports.
+	Jobs *JobRunner
+	// Downloads, when set, signs a download link to finished exports, reported in their status.
+	// It should serve Dir.
+	Downloads *DownloadSigner
+	// LinkTTL is how long download links stay valid, 24 hours when 0.
+	LinkTTL time.Duration
+}
+
+// Exporter writes data exports in the background and tracks their progress.
+type Exporter struct {
+	opts  ExporterOptions
+	tools *Tools
+
+	mu      sync.Mutex
+	exports map[string]*ExportStatus
+}
+
+// NewExporter returns an exporter writing to opts.Dir, which is created when missing.
+func (t *Tools) NewExporter(opts ExporterOptions) (*Exporter, error) {
+	if opts.Jobs == nil {
+		return nil, errors.New("exporter needs a job runner")
+	}
+	if opts.LinkTTL <= 0 {
+		opts.LinkTTL = 24 * time.Hour
+	}
+	if _, err := t.CreateDirIfNotExists(opts.Dir); err != nil {
+		return nil, err
+	}
+	return &Exporter{opts: opts, tools: t, exports: make(map[string]*ExportStatus)}, nil
+}
+
+// Start queues an export and returns its ID.
+func (e *Exporter) Start(req ExportRequest) (string, error) {
+	switch req.Format {
+	case ExportCSV, ExportXLSX, ExportNDJSON:
+	default:
+		return "", fmt.Errorf("unsupported export format %q", req.Format)
+	}
+	if req.Rows == nil {
+		return "", errors.New("export has no rows")
+	}
+	if req.Name == "" {
+		req.Name = "export"
+	}
+
+	id := e.tools.RandomString(24)
+	e.mu.Lock()
+	e.exports[id] = &ExportStatus{
+		ID:        id,
+		Name:      req.Name + "." + string(req.Format),
+		Format:    req.Format,
+		State:     ExportPending,
+		Total:     req.Total,
+		CreatedAt: time.Now(),
+	}
+	e.mu.Unlock()
+
+	if err := e.opts.Jobs.Enqueue(func(ctx context.Context) error { return e.run(ctx, id, req) }); err != nil {
+		e.mu.Lock()
+		delete(e.exports, id)
+		e.mu.Unlock()
+		return "", err
+	}
+	return id, nil
+}
+
+// Status returns the progress of the export id.
+func (e *Exporter) Status(id string) (ExportStatus, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	st, ok := e.exports[id]
+	if !ok {
+		return ExportStatus{}, false
+	}
+	return *st, true
+}
+
+// Forget drops the status of the export id, e.g. once its file was cleaned up.
+func (e *Exporter) Forget(id string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	delete(e.exports, id)
+}
+
+// StatusHandler serves the status of the export named by the "id" path value, or the "id"
+// query parameter, as JSON.
+func (e *Exporter) StatusHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.PathValue("id")
+		if id == "" {
+			id = r.URL.Query().Get("id")
+		}
+		st, ok := e.Status(id)
+		if !ok {
+			_ = e.tools.ErrorJSON(w, errors.New("export not found"), http.StatusNotFound)
+			return
+		}
+		_ = e.tools.WriteJSON(w, http.StatusOK, st)
+	})
+}
+
+// File returns the path of the export file id, which exists once the export is done.
+func (e *Exporter) File(id string) string {
+	return filepath.Join(e.opts.Dir, e.fileName(id))
+}
+
+func (e *Exporter) fileName(id string) string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if st, ok := e.exports[id]; ok {
+		return id + "." + string(st.Format)
+	}
+	return id
+}
+
+func (e *Exporter) update(id string, fn func(st *ExportStatus)) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if st, ok := e.exports[id]; ok {
+		fn(st)
+	}
+}
+
+func (e *Exporter) run(ctx context.Context, id string, req ExportRequest) (err error) {
+	e.update(id, func(st *ExportStatus) {
+		st.State, st.Rows, st.Error = ExportRunning, 0, ""
+	})
+	defer func() {
+		// A panic leaves err unset while the export is still running.
+		if p := recover(); p != nil {
+			err = fmt.Errorf("export panicked: %v", p)
+		}
+		now := time.Now()
+		e.update(id, func(st *ExportStatus) {
+			st.FinishedAt = &now
+			if err != nil {
+				st.State, st.Error = ExportFailed, err.Error()
+			}
+		})
+	}()
+
+	file := e.fileName(id)
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(e.write(ctx, pw, id, req))
+	}()
+	_, err = e.tools.WriteFileAtomic(filepath.Join(e.opts.Dir, file), pr, e.tools.fileMode())
+	pr.CloseWithError(err)
+	if err != nil {
+		return err
+	}
+
+	var url string
+	if e.opts.Downloads != nil {
+		url = e.opts.Downloads.URL(file, req.Name+"."+string(req.Format), e.opts.LinkTTL)
+	}
+	e.update(id, func(st *ExportStatus) {
+		st.State, st.URL = ExportDone, url
+	})
+	return nil
+}
+
+func (e *Exporter) write(ctx context.Context, w io.Writer, id string, req ExportRequest) error {
+	var rw exportWriter
+	switch req.Format {
+	case ExportCSV:
+		rw = &csvExportWriter{w: csv.NewWriter(w)}
+	case ExportXLSX:
+		rw = &xlsxExportWriter{zw: zip.NewWriter(w)}
+	case ExportNDJSON:
+		rw = &ndjsonExportWriter{w: bufio.NewWriter(w)}
+	}
+
+	if err := rw.header(req.Columns); err != nil {
+		return err
+	}
+	n := 0
+	for row, err := range req.Rows {
+		if err != nil {
+			return err
+		}
+		if err = ctx.Err(); err != nil {
+			return err
+		}
+		if err = rw.row(req.Columns, row); err != nil {
+			return err
+		}
+		// Progress is published every 100 rows to keep the status lock cold.
+		if n++; n%100 == 0 {
+			e.update(id, func(st *ExportStatus) { st.Rows = n })
+		}
+	}
+	e.update(id, func(st *ExportStatus) { st.Rows = n })
+	return rw.close()
+}
+
+type exportWriter interface {
+	header(columns []string) error
+	row(columns []string, values []any) error
+	close() error
+}
+
+// exportText formats a value for the text cells of CSV and XLSX files.
+func exportText(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case time.Time:
+		return v.Format(time.RFC3339)
+	case fmt.Stringer:
+		return v.String()
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+type csvExportWriter struct {
+	w *csv.Writer
+}
+
+func (c *csvExportWriter) header(columns []string) error {
+	if len(columns) == 0 {
+		return nil
+	}
+	return c.w.Write(columns)
+}
+
+func (c *csvExportWriter) row(_ []string, values []any) error {
+	record := make([]string, len(values))
+	for i, v := range values {
+		record[i] = exportText(v)
+	}
+	return c.w.Write(record)
+}
+
+func (c *csvExportWriter) close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+type ndjsonExportWriter struct {
+	w *bufio.Writer
+}
+
+func (n *ndjsonExportWriter) header([]string) error { return nil }
+
+// row writes an object keyed by column, in column order. Rows without columns are written as
+// arrays.
+func (n *ndjsonExportWriter) row(columns []string, values []any) error {
+	if len(columns) == 0 {
+		b, err := json.Marshal(values)
+		if err != nil {
+			return err
+		}
+		_, err = n.w.Write(append(b, '\n'))
+		return err
+	}
+
+	n.w.WriteByte('{')
+	for i, col := range columns {
+		if i > 0 {
+			n.w.WriteByte(',')
+		}
+		var v any
+		if i < len(values) {
+			v = values[i]
+		}
+		k, _ := json.Marshal(col)
+		b, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Errorf("column %s: %w", col, err)
+		}
+		n.w.Write(k)
+		n.w.WriteByte(':')
+		n.w.Write(b)
+	}
+	_, err := n.w.WriteString("}\n")
+	return err
+}
+
+func (n *ndjsonExportWriter) close() error { return n.w.Flush() }
+
+// xlsxExportWriter streams a single-sheet workbook with inline strings, so it needs no shared
+// string table and no rows are held in memory.
+type xlsxExportWriter struct {
+	zw    *zip.Writer
+	sheet *bufio.Writer
+}
+
+const xlsxHeader = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n"
+
+var xlsxParts = []struct{ name, body string }{
+	{"[Content_Types].xml", `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+		`</Types>`},
+	{"_rels/.rels", `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+		`</Relationships>`},
+	{"xl/workbook.xml", `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+		`<sheets><sheet name="Sheet1" sheetId="1" r:id="rId1"/></sheets></workbook>`},
+	{"xl/_rels/workbook.xml.rels", `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+		`</Relationships>`},
+}
+
+func (x *xlsxExportWriter) header(columns []string) error {
+	for _, part := range xlsxParts {
+		w, err := x.zw.Create(part.name)
+		if err != nil {
+			return err
+		}
+		if _, err = io.WriteString(w, xlsxHeader+part.body); err != nil {
+			return err
+		}
+	}
+	w, err := x.zw.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return err
+	}
+	x.sheet = bufio.NewWriter(w)
+	x.sheet.WriteString(xlsxHeader + `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	if len(columns) == 0 {
+		return nil
+	}
+	values := make([]any, len(columns))
+	for i, c := range columns {
+		values[i] = c
+	}
+	return x.row(nil, values)
+}
+
+func (x *xlsxExportWriter) row(_ []string, values []any) error {
+	x.sheet.WriteString("<row>")
+	for _, v := range values {
+		switch v := v.(type) {
+		case nil:
+			x.sheet.WriteString("<c/>")
+		case bool:
+			x.sheet.WriteString(`<c t="b"><v>`)
+			x.sheet.WriteString(map[bool]string{false: "0", true: "1"}[v])
+			x.sheet.WriteString("</v></c>")
+		case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+			fmt.Fprintf(x.sheet, "<c><v>%d</v></c>", v)
+		case float32:
+			x.sheet.WriteString("<c><v>" + strconv.FormatFloat(float64(v), 'g', -1, 32) + "</v></c>")
+		case float64:
+			x.sheet.WriteString("<c><v>" + strconv.FormatFloat(v, 'g', -1, 64) + "</v></c>")
+		default:
+			x.sheet.WriteString(`<c t="inlineStr"><is><t xml:space="preserve">`)
+			if err := xml.EscapeText(x.sheet, []byte(exportText(v))); err != nil {
+				return err
+			}
+			x.sheet.WriteString("</t></is></c>")
+		}
+	}
+	_, err := x.sheet.WriteString("</row>")
+	return err
+}
+
+func (x *xlsxExportWriter) close() error {
+	x.sheet.WriteString("</sheetData></worksheet>")
+	if err := x.sheet.Flush(); err != nil {
+		return err
+	}
+	return x.zw.Close()
+}
@@ -0,0 +1,141 @@
+package toolkit
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func exportRows(rows [][]any, fail error) func(yield func([]any, error) bool) {
+	return func(yield func([]any, error) bool) {
+		for _, row := range rows {
+			if !yield(row, nil) {
+				return
+			}
+		}
+		if fail != nil {
+			yield(nil, fail)
+		}
+	}
+}
+
+var exportTests = []struct {
+	format   ExportFormat
+	expected string
+}{
+	{format: ExportCSV, expected: "id,name,active\n1,\"Smith, Jane\",true\n2,,false\n"},
+	{format: ExportNDJSON, expected: `{"id":1,"name":"Smith, Jane","active":true}` + "\n" + `{"id":2,"name":null,"active":false}` + "\n"},
+	{format: ExportXLSX, expected: `<row><c t="inlineStr"><is><t xml:space="preserve">id</t></is></c><c t="inlineStr"><is><t xml:space="preserve">name</t></is></c><c t="inlineStr"><is><t xml:space="preserve">active</t></is></c></row>` +
+		`<row><c><v>1</v></c><c t="inlineStr"><is><t xml:space="preserve">Smith, Jane</t></is></c><c t="b"><v>1</v></c></row>` +
+		`<row><c><v>2</v></c><c/><c t="b"><v>0</v></c></row>`},
+}
+
+func waitExport(t *testing.T, e *Exporter, id string) ExportStatus {
+	t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+		if st, _ := e.Status(id); st.State == ExportDone || st.State == ExportFailed {
+			return st
+		}
+	}
+	t.Fatal("export did not finish")
+	return ExportStatus{}
+}
+
+func TestTools_Exporter(t *testing.T) {
+	testTools := Tools{Logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+	jobs := testTools.NewJobRunner(JobRunnerOptions{})
+	defer jobs.Shutdown(context.Background())
+	dir := t.TempDir()
+	signer, _ := testTools.NewDownloadSigner(bytes.Repeat([]byte("k"), 32), dir, "/downloads")
+	e, err := testTools.NewExporter(ExporterOptions{Dir: dir, Jobs: jobs, Downloads: signer})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rows := [][]any{{1, "Smith, Jane", true}, {2, nil, false}}
+	for _, test := range exportTests {
+		id, err := e.Start(ExportRequest{
+			Name:    "users",
+			Format:  test.format,
+			Columns: []string{"id", "name", "active"},
+			Rows:    exportRows(rows, nil),
+			Total:   2,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		st := waitExport(t, e, id)
+		if st.State != ExportDone || st.Rows != 2 || st.Name != "users."+string(test.format) || !strings.HasPrefix(st.URL, "/downloads?") {
+			t.Errorf("%s: unexpected status %+v", test.format, st)
+		}
+
+		data, err := os.ReadFile(e.File(id))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if test.format == ExportXLSX {
+			zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			f, err := zr.Open("xl/worksheets/sheet1.xml")
+			if err != nil {
+				t.Fatal(err)
+			}
+			data, _ = io.ReadAll(f)
+			f.Close()
+		}
+		if !strings.Contains(string(data), test.expected) {
+			t.Errorf("%s: expected %q in %q", test.format, test.expected, data)
+		}
+
+		rr := httptest.NewRecorder()
+		signer.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, st.URL, nil))
+		if rr.Code != http.StatusOK || !strings.Contains(rr.Header().Get("Content-Disposition"), "users."+string(test.format)) {
+			t.Errorf("%s: expected the export to be downloadable, got %d", test.format, rr.Code)
+		}
+	}
+
+	id, _ := e.Start(ExportRequest{Format: ExportCSV, Rows: exportRows(rows, errors.New("query failed"))})
+	if st := waitExport(t, e, id); st.State != ExportFailed || st.Error != "query failed" {
+		t.Errorf("expected the export to fail, got %+v", st)
+	}
+	if _, err = e.Start(ExportRequest{Format: "pdf", Rows: exportRows(nil, nil)}); err == nil {
+		t.Error("expected an unsupported format to fail")
+	}
+}
+
+func TestTools_ExporterStatusHandler(t *testing.T) {
+	testTools := Tools{Logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+	jobs := testTools.NewJobRunner(JobRunnerOptions{})
+	defer jobs.Shutdown(context.Background())
+	e, _ := testTools.NewExporter(ExporterOptions{Dir: t.TempDir(), Jobs: jobs})
+	id, _ := e.Start(ExportRequest{Format: ExportNDJSON, Rows: exportRows([][]any{{1}}, nil)})
+	waitExport(t, e, id)
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /exports/{id}", e.StatusHandler())
+
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/exports/"+id, nil))
+	var st ExportStatus
+	if err := json.NewDecoder(rr.Body).Decode(&st); err != nil || st.ID != id || st.State != ExportDone || st.URL != "" {
+		t.Errorf("unexpected status %d %+v %v", rr.Code, st, err)
+	}
+
+	rr = httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/exports/unknown", nil))
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for an unknown export, got %d", rr.Code)
+	}
+}
@@ -0,0 +1,145 @@
+package toolkit
+
+import (
+	"fmt"
+	"mime"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxFileNameLength is the longest name, in bytes, most filesystems accept.
+const maxFileNameLength = 255
+
+// reservedFileNames are the device names Windows does not allow as file names, with any extension.
+var reservedFileNames = map[string]bool{
+	"con": true, "prn": true, "aux": true, "nul": true,
+	"com1": true, "com2": true, "com3": true, "com4": true, "com5": true, "com6": true, "com7": true, "com8": true, "com9": true,
+	"lpt1": true, "lpt2": true, "lpt3": true, "lpt4": true, "lpt5": true, "lpt6": true, "lpt7": true, "lpt8": true, "lpt9": true,
+}
+
+// SanitizeFileName turns a file name supplied by a client into one that is safe to store: any
+// directories, with / or \ separators, are dropped, control characters and those reserved on
+// Windows are replaced with _, leading and trailing dots and spaces are removed, so names cannot
+// be hidden or refer to a parent, Windows device names get a _ prefix and names are shortened to
+// 255 bytes, keeping the extension. A name that ends up empty becomes "file".
+func (t *Tools) SanitizeFileName(name string) string {
+	name = strings.ReplaceAll(name, `\`, "/")
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+
+	name = strings.Map(func(r rune) rune {
+		switch {
+		case r < 0x20, r == 0x7f, r == utf8.RuneError, strings.ContainsRune(`<>:"|?*`, r):
+			return '_'
+		}
+		return r
+	}, name)
+	name = strings.Trim(name, ". ")
+	if name == "" {
+		return "file"
+	}
+
+	base, _, _ := strings.Cut(name, ".")
+	if reservedFileNames[strings.ToLower(strings.TrimRight(base, " "))] {
+		name = "_" + name
+	}
+
+	if len(name) > maxFileNameLength {
+		ext := filepath.Ext(name)
+		if len(ext) > maxFileNameLength/2 {
+			ext = ""
+		}
+		stem := name[:maxFileNameLength-len(ext)]
+		for !utf8.ValidString(stem) {
+			stem = stem[:len(stem)-1]
+		}
+		name = stem + ext
+	}
+	return name
+}
+
+// checkFileExtension checks the extension of the uploaded file filename against
+// AllowedFileExtensions and, with RejectOnMismatch, against the sniffed content type.
+func (t *Tools) checkFileExtension(filename, fileType string) error {
+	ext := normalizeExtension(filepath.Ext(filename))
+	if len(t.AllowedFileExtensions) > 0 {
+		allowed := false
+		for _, x := range t.AllowedFileExtensions {
+			if normalizeExtension(x) == ext {
+				allowed = true
+			}
+		}
+		if !allowed {
+			if ext == "" {
+				return fmt.Errorf("uploaded files without an extension are not permitted")
+			}
+			return fmt.Errorf("the extension %s of uploaded file is not permitted", ext)
+		}
+	}
+
+	if t.RejectOnMismatch && ext != "" && !mimeCompatible(fileType, t.MIMEByExtension(ext)) {
+		return fmt.Errorf("the content of uploaded file %s does not match its extension, it is %s", filename, fileType)
+	}
+	return nil
+}
+
+// sniffedAliases maps the names http.DetectContentType uses to those of the extension table.
+var sniffedAliases = map[string]string{
+	"application/x-gzip":           "application/gzip",
+	"application/x-rar-compressed": "application/vnd.rar",
+	"audio/wave":                   "audio/wav",
+}
+
+// signatureTypes are types with a signature http.DetectContentType recognizes, so files of these
+// types sniffed as application/octet-stream are something else.
+var signatureTypes = map[string]bool{
+	"application/gzip": true, "application/pdf": true, "application/vnd.rar": true,
+	"application/wasm": true, "application/zip": true, "audio/mpeg": true, "audio/ogg": true,
+	"audio/wav": true, "font/woff": true, "font/woff2": true, "image/bmp": true, "image/gif": true,
+	"image/jpeg": true, "image/png": true, "image/webp": true, "image/x-icon": true,
+	"text/html": true, "video/mp4": true, "video/webm": true,
+}
+
+// mimeCompatible reports whether content sniffed as sniffed may be a file of type expected.
+// Sniffing tells formats apart only coarsely: it sees documents of zip based formats as zip
+// archives and all text formats as text.
+func mimeCompatible(sniffed, expected string) bool {
+	sniffed, _, _ = mime.ParseMediaType(sniffed)
+	expected, _, _ = mime.ParseMediaType(expected)
+	if alias, ok := sniffedAliases[sniffed]; ok {
+		sniffed = alias
+	}
+	if sniffed == expected {
+		return true
+	}
+
+	switch sniffed {
+	case "application/octet-stream":
+		return !signatureTypes[expected]
+	case "application/zip":
+		return strings.HasSuffix(expected, "+zip") ||
+			strings.HasPrefix(expected, "application/vnd.openxmlformats-officedocument.") ||
+			strings.HasPrefix(expected, "application/vnd.oasis.opendocument.")
+	case "application/ogg":
+		return expected == "audio/ogg" || expected == "video/ogg"
+	case "text/xml":
+		return expected == "application/xml" || strings.HasSuffix(expected, "+xml")
+	case "text/plain":
+		return isTextType(expected)
+	}
+	return false
+}
+
+// isTextType reports whether files of type typ are plain text to http.DetectContentType.
+func isTextType(typ string) bool {
+	switch typ {
+	case "text/html", "text/xml":
+		// Both are recognized by their markup, so plain text is not one of them.
+		return false
+	case "application/json", "application/xml", "application/yaml", "application/x-ndjson", "image/svg+xml":
+		return true
+	}
+	return strings.HasPrefix(typ, "text/") || strings.HasSuffix(typ, "+json") || strings.HasSuffix(typ, "+xml")
+}
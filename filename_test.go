@@ -0,0 +1,96 @@
+package toolkit
+
+import (
+	"strings"
+	"testing"
+)
+
+var sanitizeFileNameTests = []struct {
+	name     string
+	expected string
+}{
+	{name: "photo.png", expected: "photo.png"},
+	{name: "../../etc/passwd", expected: "passwd"},
+	{name: `..\..\windows\win.ini`, expected: "win.ini"},
+	{name: "shell.php\x00.png", expected: "shell.php_.png"},
+	{name: ".htaccess", expected: "htaccess"},
+	{name: "report. ", expected: "report"},
+	{name: "a<b>c:d|e?.txt", expected: "a_b_c_d_e_.txt"},
+	{name: "CON.txt", expected: "_CON.txt"},
+	{name: "console.txt", expected: "console.txt"},
+	{name: "..", expected: "file"},
+	{name: "", expected: "file"},
+	{name: strings.Repeat("ż", 200) + ".jpeg", expected: strings.Repeat("ż", 125) + ".jpeg"},
+}
+
+func TestTools_SanitizeFileName(t *testing.T) {
+	var testTools Tools
+	for _, test := range sanitizeFileNameTests {
+		if got := testTools.SanitizeFileName(test.name); got != test.expected {
+			t.Errorf("%q: expected %q, got %q", test.name, test.expected, got)
+		}
+	}
+}
+
+var mimeCompatibleTests = []struct {
+	sniffed  string
+	expected string
+	ok       bool
+}{
+	{sniffed: "image/png", expected: "image/png", ok: true},
+	{sniffed: "text/html; charset=utf-8", expected: "image/png", ok: false},
+	{sniffed: "text/plain; charset=utf-8", expected: "text/csv; charset=utf-8", ok: true},
+	{sniffed: "text/plain; charset=utf-8", expected: "application/json", ok: true},
+	{sniffed: "text/plain; charset=utf-8", expected: "text/html; charset=utf-8", ok: false},
+	{sniffed: "application/zip", expected: "application/vnd.openxmlformats-officedocument.wordprocessingml.document", ok: true},
+	{sniffed: "application/zip", expected: "application/pdf", ok: false},
+	{sniffed: "application/x-gzip", expected: "application/gzip", ok: true},
+	{sniffed: "application/octet-stream", expected: "image/heic", ok: true},
+	{sniffed: "application/octet-stream", expected: "image/jpeg", ok: false},
+	{sniffed: "text/xml; charset=utf-8", expected: "image/svg+xml", ok: true},
+}
+
+func TestTools_MIMECompatible(t *testing.T) {
+	for _, test := range mimeCompatibleTests {
+		if got := mimeCompatible(test.sniffed, test.expected); got != test.ok {
+			t.Errorf("%s as %s: expected %v", test.sniffed, test.expected, test.ok)
+		}
+	}
+}
+
+var uploadExtensionTests = []struct {
+	name       string
+	file       string
+	content    []byte
+	extensions []string
+	mismatch   bool
+	errorExp   string
+}{
+	{name: "allowed", file: "img.PNG", extensions: []string{"png", ".jpg"}},
+	{name: "not allowed", file: "img.gif", extensions: []string{".png"}, errorExp: "extension .gif"},
+	{name: "no extension", file: "img", extensions: []string{".png"}, errorExp: "without an extension"},
+	{name: "mismatch", file: "page.png", content: []byte("<html><script>alert(1)</script>"), mismatch: true, errorExp: "does not match"},
+	{name: "mismatch allowed", file: "page.png", content: []byte("<html><script>alert(1)</script>")},
+	{name: "match", file: "img.png", mismatch: true},
+	{name: "text", file: "data.csv", content: []byte("a,b\n1,2\n"), mismatch: true},
+}
+
+func TestTools_UploadFileExtensions(t *testing.T) {
+	for _, test := range uploadExtensionTests {
+		testTools := Tools{AllowedFileExtensions: test.extensions, RejectOnMismatch: test.mismatch}
+		content := test.content
+		if content == nil {
+			content = testPNG(t)
+		}
+		files, err := testTools.UploadFiles(newUploadRequest(t, map[string][]byte{test.file: content}), t.TempDir(), false)
+		if test.errorExp != "" {
+			if err == nil || !strings.Contains(err.Error(), test.errorExp) {
+				t.Errorf("%s: expected an error containing %q, got %v", test.name, test.errorExp, err)
+			}
+			continue
+		}
+		if err != nil || len(files) != 1 {
+			t.Errorf("%s: expected one file, got %v %v", test.name, files, err)
+		}
+	}
+}
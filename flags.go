@@ -0,0 +1,148 @@
+package toolkit
+
+import (
+	"context"
+	"hash/fnv"
+	"net/http"
+	"slices"
+	"sync/atomic"
+)
+
+// Flag is the configuration of a feature flag.
+type Flag struct {
+	// Enabled turns the flag on. A disabled flag is off for every key.
+	Enabled bool `json:"enabled" yaml:"enabled"`
+	// Rollout limits an enabled flag to a percentage of keys, from 1 to 99. Keys fall in the
+	// same bucket on every evaluation, so users keep seeing the same behaviour as the
+	// percentage grows. The flag is on for all keys when 0 or 100.
+	Rollout int `json:"rollout" yaml:"rollout"`
+	// Keys lists keys the enabled flag is always on for, whatever the rollout.
+	Keys []string `json:"keys" yaml:"keys"`
+}
+
+// FlagSource loads flag configuration at runtime, e.g. from a database or a config service.
+type FlagSource interface {
+	LoadFlags(ctx context.Context) (map[string]Flag, error)
+}
+
+// FeatureFlagsOptions configures FeatureFlags.
+type FeatureFlagsOptions struct {
+	// Flags is the initial, static configuration.
+	Flags map[string]Flag
+	// Source, when set, is loaded by Refresh.
+	Source FlagSource
+	// Key returns the stable key, such as a user or tenant ID, flags are evaluated for in
+	// Middleware. The API key identity or else the client IP is used when nil.
+	Key func(r *http.Request) string
+}
+
+// FeatureFlags evaluates feature flags. It is safe for concurrent use, including replacing its
+// configuration while requests are served.
+type FeatureFlags struct {
+	opts  FeatureFlagsOptions
+	tools *Tools
+	flags atomic.Pointer[map[string]Flag]
+}
+
+// NewFeatureFlags returns feature flags with the static configuration in opts.
+func (t *Tools) NewFeatureFlags(opts FeatureFlagsOptions) *FeatureFlags {
+	f := &FeatureFlags{opts: opts, tools: t}
+	f.Set(opts.Flags)
+	return f
+}
+
+// Set replaces the flag configuration.
+func (f *FeatureFlags) Set(flags map[string]Flag) {
+	flags = cloneFlags(flags)
+	f.flags.Store(&flags)
+}
+
+// Refresh replaces the flag configuration with the one loaded from the source, keeping the
+// current one when loading fails. It is a Job, so it can be run periodically by a Scheduler.
+func (f *FeatureFlags) Refresh(ctx context.Context) error {
+	if f.opts.Source == nil {
+		return nil
+	}
+	flags, err := f.opts.Source.LoadFlags(ctx)
+	if err != nil {
+		return err
+	}
+	f.Set(flags)
+	return nil
+}
+
+// Enabled reports whether the flag name is on for key. Unknown flags are off.
+func (f *FeatureFlags) Enabled(name, key string) bool {
+	flag, ok := (*f.flags.Load())[name]
+	return ok && flag.enabledFor(name, key)
+}
+
+func (flag Flag) enabledFor(name, key string) bool {
+	if !flag.Enabled {
+		return false
+	}
+	if flag.Rollout <= 0 || flag.Rollout >= 100 || slices.Contains(flag.Keys, key) {
+		return true
+	}
+	// Hashing the flag name along with the key gives each flag its own split of the keys.
+	h := fnv.New32a()
+	h.Write([]byte(name))
+	h.Write([]byte{0})
+	h.Write([]byte(key))
+	return int(h.Sum32()%100) < flag.Rollout
+}
+
+// Evaluate returns the state of every flag for key.
+func (f *FeatureFlags) Evaluate(key string) FlagSet {
+	flags := *f.flags.Load()
+	set := make(FlagSet, len(flags))
+	for name, flag := range flags {
+		set[name] = flag.enabledFor(name, key)
+	}
+	return set
+}
+
+// Middleware evaluates the flags for the request's key and stores them in the request context,
+// so a request sees a consistent set of flags even when the configuration changes meanwhile.
+func (f *FeatureFlags) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), flagSetKey{}, f.Evaluate(f.key(r)))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func (f *FeatureFlags) key(r *http.Request) string {
+	if f.opts.Key != nil {
+		return f.opts.Key(r)
+	}
+	if id, ok := APIKeyFromContext(r.Context()); ok {
+		return id.ID
+	}
+	return f.tools.ClientIP(r)
+}
+
+// FlagSet holds the state of flags evaluated for a key.
+type FlagSet map[string]bool
+
+type flagSetKey struct{}
+
+// FlagsFromContext returns the flags stored by FeatureFlags.Middleware.
+func FlagsFromContext(ctx context.Context) FlagSet {
+	set, _ := ctx.Value(flagSetKey{}).(FlagSet)
+	return set
+}
+
+// FlagEnabled reports whether the flag name is on for the request of ctx. Flags are off outside
+// FeatureFlags.Middleware.
+func FlagEnabled(ctx context.Context, name string) bool {
+	return FlagsFromContext(ctx)[name]
+}
+
+func cloneFlags(flags map[string]Flag) map[string]Flag {
+	c := make(map[string]Flag, len(flags))
+	for name, flag := range flags {
+		flag.Keys = slices.Clone(flag.Keys)
+		c[name] = flag
+	}
+	return c
+}
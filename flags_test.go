@@ -0,0 +1,117 @@
+package toolkit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type staticFlagSource struct {
+	flags map[string]Flag
+	err   error
+}
+
+func (s staticFlagSource) LoadFlags(ctx context.Context) (map[string]Flag, error) {
+	return s.flags, s.err
+}
+
+var flagTests = []struct {
+	name     string
+	flag     string
+	key      string
+	expected bool
+}{
+	{name: "enabled", flag: "search", key: "u1", expected: true},
+	{name: "disabled", flag: "export", key: "u1", expected: false},
+	{name: "unknown", flag: "missing", key: "u1", expected: false},
+	{name: "listed key", flag: "beta", key: "tenant-7", expected: true},
+	{name: "disabled listed key", flag: "export", key: "tenant-7", expected: false},
+}
+
+var testFlags = map[string]Flag{
+	"search": {Enabled: true},
+	"export": {Keys: []string{"tenant-7"}},
+	"beta":   {Enabled: true, Rollout: 1, Keys: []string{"tenant-7"}},
+	"half":   {Enabled: true, Rollout: 50},
+}
+
+func TestTools_FeatureFlags(t *testing.T) {
+	var testTools Tools
+	f := testTools.NewFeatureFlags(FeatureFlagsOptions{Flags: testFlags})
+
+	for _, test := range flagTests {
+		if got := f.Enabled(test.flag, test.key); got != test.expected {
+			t.Errorf("%s: expected %v, got %v", test.name, test.expected, got)
+		}
+	}
+
+	on := 0
+	for i := range 1000 {
+		key := fmt.Sprintf("user-%d", i)
+		enabled := f.Enabled("half", key)
+		if enabled != f.Enabled("half", key) {
+			t.Fatalf("%s: rollout is not stable", key)
+		}
+		if enabled {
+			on++
+		}
+	}
+	if on < 400 || on > 600 {
+		t.Errorf("expected about half of the keys in a 50%% rollout, got %d of 1000", on)
+	}
+}
+
+func TestTools_FeatureFlagsRefresh(t *testing.T) {
+	var testTools Tools
+	source := &staticFlagSource{flags: map[string]Flag{"search": {Enabled: true}}}
+	f := testTools.NewFeatureFlags(FeatureFlagsOptions{Source: source})
+
+	if f.Enabled("search", "u1") {
+		t.Error("expected the flag to be off before the refresh")
+	}
+	if err := f.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if !f.Enabled("search", "u1") {
+		t.Error("expected the refreshed flag to be on")
+	}
+
+	source.err = errors.New("unavailable")
+	if err := f.Refresh(context.Background()); err == nil {
+		t.Error("expected the refresh to fail")
+	}
+	if !f.Enabled("search", "u1") {
+		t.Error("expected a failed refresh to keep the configuration")
+	}
+}
+
+func TestTools_FeatureFlagsMiddleware(t *testing.T) {
+	var testTools Tools
+	f := testTools.NewFeatureFlags(FeatureFlagsOptions{
+		Flags: testFlags,
+		Key:   func(r *http.Request) string { return r.Header.Get("X-Tenant") },
+	})
+
+	var set FlagSet
+	h := f.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Changes during the request do not affect it.
+		f.Set(nil)
+		set = FlagsFromContext(r.Context())
+		if !FlagEnabled(r.Context(), "beta") {
+			t.Error("expected beta to be on in the handler")
+		}
+	}))
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Tenant", "tenant-7")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	if len(set) != len(testFlags) || !set["search"] || set["export"] {
+		t.Errorf("unexpected flag set %v", set)
+	}
+	if FlagEnabled(context.Background(), "search") {
+		t.Error("expected flags to be off outside the middleware")
+	}
+}
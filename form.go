@@ -0,0 +1,130 @@
+package toolkit
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"mime"
+	"net/http"
+	"net/url"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// ReadForm decodes the values of a URL encoded or multipart form, or of the query string, into
+// dst, a pointer to a struct whose fields are named by their form tag or else their name. Fields
+// tagged "-" are left out and those without a value are left as they are. Fields may be strings,
+// numbers, booleans, including the "on" of checkboxes, time.Time, in RFC 3339, as a
+// datetime-local input sends it or as a date, types implementing encoding.TextUnmarshaler,
+// pointers to these, or slices of them, filled from repeated values. Values without a field are
+// rejected unless AllowUnknownFields is set. The body is limited to MaxBodySize. Values that
+// cannot be converted, validate tags and Validatable are reported as a ValidationError.
+func (t *Tools) ReadForm(r *http.Request, dst any) error {
+	rv := reflect.ValueOf(dst)
+	if rv.Kind() != reflect.Pointer || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("form: destination must be a pointer to a struct, not %T", dst)
+	}
+
+	if r.Body != nil {
+		r.Body = http.MaxBytesReader(nil, contextBody(r.Context(), r.Body), t.maxBodySize())
+	}
+	var err error
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == "multipart/form-data" {
+		err = r.ParseMultipartForm(t.maxBodySize())
+	} else {
+		err = r.ParseForm()
+	}
+	if err != nil {
+		if r.Context().Err() != nil {
+			return r.Context().Err()
+		}
+		var maxBytesError *http.MaxBytesError
+		if errors.As(err, &maxBytesError) {
+			return &bodyTooLargeError{limit: maxBytesError.Limit}
+		}
+		return fmt.Errorf("body contains badly-formed form data: %w", err)
+	}
+
+	return t.bindValues(r.Form, dst, "form", !t.AllowUnknownFields)
+}
+
+// bindValues sets the fields of the struct dst points to from values, naming fields by their tag,
+// and validates it. With strict set, values without a field are an error.
+func (t *Tools) bindValues(values url.Values, dst any, tag string, strict bool) error {
+	rv := reflect.ValueOf(dst).Elem()
+	fields := valueFields(rv.Type(), tag)
+	if strict {
+		for _, key := range slices.Sorted(maps.Keys(values)) {
+			if _, ok := fields[key]; !ok {
+				return fmt.Errorf("%s contains unknown field %s", tag, key)
+			}
+		}
+	}
+
+	validator := t.NewValidator()
+	for name, index := range fields {
+		vals, ok := values[name]
+		if !ok {
+			continue
+		}
+		if err := t.setFormValue(rv.Field(index), vals); err != nil {
+			validator.AddErrorKey(name, "validation.format", "has an invalid format")
+		}
+	}
+	if !validator.Valid() {
+		return validator.Err()
+	}
+
+	return validator.validate(dst, tag)
+}
+
+// valueFields maps the names of the exported fields of the struct typ, given by their tag, to
+// their index.
+func valueFields(typ reflect.Type, tag string) map[string]int {
+	fields := make(map[string]int, typ.NumField())
+	for i := 0; i < typ.NumField(); i++ {
+		sf := typ.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(sf.Tag.Get(tag), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+		fields[name] = i
+	}
+	return fields
+}
+
+// setFormValue sets v from the form values of its field: every value for slices, other than
+// types that decode text themselves, and the first one otherwise.
+func (t *Tools) setFormValue(v reflect.Value, values []string) error {
+	if v.Kind() != reflect.Slice || v.Addr().Type().Implements(textUnmarshalerType) {
+		return t.setTextValue(v, formText(v.Type(), values[0]))
+	}
+
+	slice := reflect.MakeSlice(v.Type(), len(values), len(values))
+	for i, s := range values {
+		if err := t.setTextValue(slice.Index(i), formText(v.Type().Elem(), s)); err != nil {
+			return err
+		}
+	}
+	v.Set(slice)
+	return nil
+}
+
+// formText turns "on", what browsers send for checked checkboxes without a value, into true for
+// booleans.
+func formText(typ reflect.Type, s string) string {
+	if typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	if typ.Kind() == reflect.Bool && s == "on" {
+		return "true"
+	}
+	return s
+}
@@ -0,0 +1,116 @@
+package toolkit
+
+import (
+	"bytes"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type formSignup struct {
+	Name     string    `form:"name" validate:"required"`
+	Age      int       `form:"age" validate:"min=18"`
+	Terms    bool      `form:"terms"`
+	Birthday time.Time `form:"birthday"`
+	Tags     []string  `form:"tag"`
+	Scores   []int     `form:"score"`
+	Nickname *string   `form:"nickname"`
+	Internal string    `form:"-"`
+}
+
+var readFormTests = []struct {
+	name         string
+	body         string
+	allowUnknown bool
+	errorField   string
+	errorText    string
+}{
+	{name: "valid", body: "name=Ann&age=30&terms=on&birthday=1994-05-02&tag=a&tag=b&score=1&score=2&nickname=annie"},
+	{name: "empty pointer", body: "name=Ann&age=30&nickname="},
+	{name: "invalid int", body: "name=Ann&age=old", errorField: "age"},
+	{name: "invalid slice element", body: "name=Ann&age=30&score=1&score=x", errorField: "score"},
+	{name: "invalid time", body: "name=Ann&age=30&birthday=yesterday", errorField: "birthday"},
+	{name: "validate tag", body: "age=30", errorField: "name"},
+	{name: "validate min", body: "name=Ann&age=12", errorField: "age"},
+	{name: "unknown field", body: "name=Ann&age=30&admin=1", errorText: "form contains unknown field admin"},
+	{name: "unknown field allowed", body: "name=Ann&age=30&admin=1", allowUnknown: true},
+	{name: "ignored field", body: "name=Ann&age=30&Internal=x", errorText: "unknown field Internal"},
+}
+
+func TestTools_ReadForm(t *testing.T) {
+	for _, test := range readFormTests {
+		testTools := Tools{AllowUnknownFields: test.allowUnknown}
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(test.body))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+		var dst formSignup
+		err := testTools.ReadForm(req, &dst)
+
+		var validationError *ValidationError
+		switch {
+		case test.errorField != "":
+			if !errors.As(err, &validationError) || validationError.Fields[test.errorField] == "" {
+				t.Errorf("%s: expected an error for field %s, got %v", test.name, test.errorField, err)
+			}
+		case test.errorText != "":
+			if err == nil || !strings.Contains(err.Error(), test.errorText) {
+				t.Errorf("%s: expected error %q, got %v", test.name, test.errorText, err)
+			}
+		case err != nil:
+			t.Errorf("%s: unexpected error: %s", test.name, err)
+		}
+	}
+}
+
+func TestTools_ReadFormValues(t *testing.T) {
+	var testTools Tools
+	req := httptest.NewRequest(http.MethodPost, "/?tag=query", strings.NewReader("name=Ann&age=30&terms=true&birthday=1994-05-02T10:30&tag=a&tag=b&score=1&score=2&nickname=annie"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	var dst formSignup
+	if err := testTools.ReadForm(req, &dst); err != nil {
+		t.Fatal(err)
+	}
+	if dst.Name != "Ann" || dst.Age != 30 || !dst.Terms || dst.Nickname == nil || *dst.Nickname != "annie" {
+		t.Errorf("unexpected values %+v", dst)
+	}
+	if !dst.Birthday.Equal(time.Date(1994, 5, 2, 10, 30, 0, 0, time.UTC)) {
+		t.Errorf("unexpected birthday %s", dst.Birthday)
+	}
+	if strings.Join(dst.Tags, ",") != "a,b,query" || len(dst.Scores) != 2 || dst.Scores[1] != 2 {
+		t.Errorf("unexpected slices %v %v", dst.Tags, dst.Scores)
+	}
+}
+
+func TestTools_ReadFormMultipart(t *testing.T) {
+	var testTools Tools
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	_ = mw.WriteField("name", "Ann")
+	_ = mw.WriteField("age", "30")
+	fw, _ := mw.CreateFormFile("avatar", "me.png")
+	_, _ = fw.Write([]byte("png"))
+	_ = mw.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+
+	var dst formSignup
+	if err := testTools.ReadForm(req, &dst); err != nil {
+		t.Fatal(err)
+	}
+	if dst.Name != "Ann" || dst.Age != 30 {
+		t.Errorf("unexpected values %+v", dst)
+	}
+
+	testTools.MaxBodySize = 10
+	req = httptest.NewRequest(http.MethodPost, "/", strings.NewReader("name=Ann&age=30&terms=true"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if err := testTools.ReadForm(req, &dst); err == nil || !strings.Contains(err.Error(), "larger than") {
+		t.Errorf("expected size error, got %v", err)
+	}
+}
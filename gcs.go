@@ -0,0 +1,147 @@
+package toolkit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// GCSOptions configures a GCSStore.
+type GCSOptions struct {
+	Bucket string
+	// Token returns an OAuth 2.0 access token with a devstorage scope, e.g. from a
+	// golang.org/x/oauth2/google token source. It is called for every request.
+	Token func(ctx context.Context) (string, error)
+	// Endpoint is the URL of the JSON API, https://storage.googleapis.com when empty.
+	Endpoint string
+	// Client makes the requests, http.DefaultClient when nil.
+	Client *http.Client
+}
+
+// GCSStore is a FileStore in a Google Cloud Storage bucket, using the JSON API.
+type GCSStore struct {
+	opts GCSOptions
+}
+
+// NewGCSStore returns a store keeping files in the bucket of opts. Uploads are streamed, so files
+// of unknown size are not buffered.
+func (t *Tools) NewGCSStore(opts GCSOptions) (*GCSStore, error) {
+	if opts.Bucket == "" {
+		return nil, errors.New("gcs: bucket is required")
+	}
+	if opts.Token == nil {
+		return nil, errors.New("gcs: token source is required")
+	}
+	if opts.Endpoint == "" {
+		opts.Endpoint = "https://storage.googleapis.com"
+	}
+	opts.Endpoint = strings.TrimSuffix(opts.Endpoint, "/")
+	if opts.Client == nil {
+		opts.Client = http.DefaultClient
+	}
+	return &GCSStore{opts: opts}, nil
+}
+
+func (s *GCSStore) Save(ctx context.Context, name string, r io.Reader, size int64) (int64, error) {
+	key, err := objectKey("", name)
+	if err != nil {
+		return 0, err
+	}
+	q := url.Values{"uploadType": {"media"}, "name": {key}}
+	u := fmt.Sprintf("%s/upload/storage/v1/b/%s/o?%s", s.opts.Endpoint, url.PathEscape(s.opts.Bucket), q.Encode())
+
+	body := &countingReader{r: contextReader{ctx, r}}
+	resp, err := s.do(ctx, http.MethodPost, u, body, size)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, objectStoreError("gcs", "upload", key, resp)
+	}
+	return body.n, nil
+}
+
+func (s *GCSStore) Open(ctx context.Context, name string) (io.ReadCloser, error) {
+	key, u, err := s.objectURL(name)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.do(ctx, http.MethodGet, u+"?alt=media", nil, 0)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		return nil, objectStoreError("gcs", "get", key, resp)
+	}
+	return resp.Body, nil
+}
+
+func (s *GCSStore) Delete(ctx context.Context, name string) error {
+	key, u, err := s.objectURL(name)
+	if err != nil {
+		return err
+	}
+	resp, err := s.do(ctx, http.MethodDelete, u, nil, 0)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		return objectStoreError("gcs", "delete", key, resp)
+	}
+	return nil
+}
+
+func (s *GCSStore) Exists(ctx context.Context, name string) (bool, error) {
+	key, u, err := s.objectURL(name)
+	if err != nil {
+		return false, err
+	}
+	resp, err := s.do(ctx, http.MethodGet, u, nil, 0)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusNotFound:
+		return false, nil
+	}
+	return false, objectStoreError("gcs", "get", key, resp)
+}
+
+// objectURL returns the key of name and the JSON API URL of its metadata.
+func (s *GCSStore) objectURL(name string) (string, string, error) {
+	key, err := objectKey("", name)
+	if err != nil {
+		return "", "", err
+	}
+	return key, fmt.Sprintf("%s/storage/v1/b/%s/o/%s", s.opts.Endpoint, url.PathEscape(s.opts.Bucket), url.PathEscape(key)), nil
+}
+
+func (s *GCSStore) do(ctx context.Context, method, u string, body io.Reader, size int64) (*http.Response, error) {
+	token, err := s.opts.Token(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("gcs: token: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, body)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.ContentLength = size
+		if size == 0 {
+			req.Body = http.NoBody
+		}
+		req.Header.Set("Content-Type", "application/octet-stream")
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	return s.opts.Client.Do(req)
+}
@@ -0,0 +1,93 @@
+package toolkit
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestTools_GCSStore(t *testing.T) {
+	var mu sync.Mutex
+	objects := map[string][]byte{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			http.Error(w, `{"error": {"code": 401}}`, http.StatusUnauthorized)
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if r.Method == http.MethodPost && r.URL.Path == "/upload/storage/v1/b/media/o" && r.URL.Query().Get("uploadType") == "media" {
+			objects[r.URL.Query().Get("name")], _ = io.ReadAll(r.Body)
+			_, _ = w.Write([]byte(`{}`))
+			return
+		}
+		name, ok := strings.CutPrefix(r.URL.Path, "/storage/v1/b/media/o/")
+		data, found := objects[name]
+		if !ok || !found {
+			http.Error(w, `{"error": {"code": 404}}`, http.StatusNotFound)
+			return
+		}
+		switch {
+		case r.Method == http.MethodDelete:
+			delete(objects, name)
+			w.WriteHeader(http.StatusNoContent)
+		case r.URL.Query().Get("alt") == "media":
+			_, _ = w.Write(data)
+		default:
+			_, _ = w.Write([]byte(`{"name": "` + name + `"}`))
+		}
+	}))
+	defer srv.Close()
+
+	var testTools Tools
+	ctx := context.Background()
+	token := "token"
+	s, err := testTools.NewGCSStore(GCSOptions{
+		Bucket:   "media",
+		Endpoint: srv.URL,
+		Token:    func(context.Context) (string, error) { return token, nil },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n, err := s.Save(ctx, "docs/a.txt", strings.NewReader("hello"), -1); err != nil || n != 5 {
+		t.Fatalf("unexpected save %d %v", n, err)
+	}
+	if ok, err := s.Exists(ctx, "docs/a.txt"); !ok || err != nil {
+		t.Errorf("expected the object to exist, got %v %v", ok, err)
+	}
+	rc, err := s.Open(ctx, "docs/a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := io.ReadAll(rc)
+	rc.Close()
+	if string(data) != "hello" {
+		t.Errorf("unexpected content %q", data)
+	}
+
+	if err = s.Delete(ctx, "docs/a.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if err = s.Delete(ctx, "docs/a.txt"); err != nil {
+		t.Errorf("expected deleting a missing object to succeed, got %v", err)
+	}
+	if _, err = s.Open(ctx, "docs/a.txt"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected fs.ErrNotExist, got %v", err)
+	}
+
+	token = "expired"
+	if _, err = s.Exists(ctx, "docs/a.txt"); err == nil {
+		t.Error("expected an unauthorized request to fail")
+	}
+	if _, err = testTools.NewGCSStore(GCSOptions{Bucket: "media"}); err == nil {
+		t.Error("expected a missing token source to fail")
+	}
+}
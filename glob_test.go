@@ -0,0 +1,73 @@
+package toolkit
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+var globTests = []struct {
+	pattern  string
+	path     string
+	expected bool
+}{
+	{pattern: "*.go", path: "tools.go", expected: true},
+	{pattern: "*.go", path: "a/b/tools.go", expected: true},
+	{pattern: "a/*.go", path: "a/b/tools.go", expected: false},
+	{pattern: "a/**/*.go", path: "a/tools.go", expected: true},
+	{pattern: "a/**/*.go", path: "a/b/c/tools.go", expected: true},
+	{pattern: "**/testdata/**", path: "x/testdata/img.png", expected: true},
+	{pattern: "**/testdata/**", path: "x/data/img.png", expected: false},
+	{pattern: "static/**", path: "static", expected: true},
+	{pattern: "static/*.css", path: "static/app.css", expected: true},
+	{pattern: "static/*.css", path: "static/app.js", expected: false},
+}
+
+func TestTools_MatchGlob(t *testing.T) {
+	for _, test := range globTests {
+		if matchGlob(test.pattern, test.path) != test.expected {
+			t.Errorf("%s against %s: expected %v", test.pattern, test.path, test.expected)
+		}
+	}
+}
+
+func TestTools_FindFiles(t *testing.T) {
+	var testTools Tools
+	root := t.TempDir()
+	for _, f := range []string{"a.txt", "b.log", "sub/c.txt", "sub/deep/d.txt", "node_modules/e.txt", "sub/node_modules/f.txt"} {
+		p := filepath.Join(root, filepath.FromSlash(f))
+		_ = os.MkdirAll(filepath.Dir(p), 0755)
+		_ = os.WriteFile(p, nil, 0644)
+	}
+
+	var found []string
+	for p, err := range testTools.FindFiles(root, []string{"**/*.txt"}, []string{"node_modules", "sub/deep"}) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		rel, _ := filepath.Rel(root, p)
+		found = append(found, filepath.ToSlash(rel))
+	}
+	sort.Strings(found)
+
+	if strings.Join(found, ",") != "a.txt,sub/c.txt" {
+		t.Errorf("unexpected files found: %v", found)
+	}
+
+	count := 0
+	for range testTools.FindFiles(root, nil, nil) {
+		count++
+		break
+	}
+	if count != 1 {
+		t.Error("iteration did not stop on break")
+	}
+
+	for _, err := range testTools.FindFiles(filepath.Join(root, "missing"), nil, nil) {
+		if err == nil {
+			t.Error("expected error for missing root")
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package toolkit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// HealthCheck reports a problem with a dependency by returning an error. It should give up
+// when ctx is done.
+type HealthCheck func(ctx context.Context) error
+
+// HealthReport is the JSON body served by the health endpoints.
+type HealthReport struct {
+	Status string                       `json:"status"`
+	Checks map[string]HealthCheckResult `json:"checks"`
+}
+
+// HealthCheckResult is the outcome of a single check.
+type HealthCheckResult struct {
+	Status    string  `json:"status"`
+	LatencyMS float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+const (
+	healthOK   = "ok"
+	healthFail = "fail"
+)
+
+type namedCheck struct {
+	name  string
+	check HealthCheck
+}
+
+// Health aggregates named checks and serves them as liveness (/healthz) and readiness (/readyz)
+// endpoints. Liveness checks should only fail when restarting the process helps; readiness checks
+// cover dependencies such as databases, and readiness also runs every liveness check.
+type Health struct {
+	// Timeout bounds each check, 5 seconds when 0.
+	Timeout time.Duration
+
+	tools     *Tools
+	mu        sync.RWMutex
+	liveness  []namedCheck
+	readiness []namedCheck
+}
+
+// NewHealth returns a Health without checks, which reports healthy.
+func (t *Tools) NewHealth() *Health {
+	return &Health{tools: t}
+}
+
+// AddLivenessCheck registers a check run by /healthz and /readyz.
+func (h *Health) AddLivenessCheck(name string, check HealthCheck) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.liveness = append(h.liveness, namedCheck{name, check})
+}
+
+// AddReadinessCheck registers a check run by /readyz.
+func (h *Health) AddReadinessCheck(name string, check HealthCheck) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.readiness = append(h.readiness, namedCheck{name, check})
+}
+
+// Register mounts the liveness and readiness handlers on mux at /healthz and /readyz.
+func (h *Health) Register(mux *http.ServeMux) {
+	mux.Handle("/healthz", h.LivenessHandler())
+	mux.Handle("/readyz", h.ReadinessHandler())
+}
+
+// LivenessHandler serves the result of the liveness checks.
+func (h *Health) LivenessHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h.serve(w, r, false)
+	})
+}
+
+// ReadinessHandler serves the result of the liveness and readiness checks.
+func (h *Health) ReadinessHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h.serve(w, r, true)
+	})
+}
+
+func (h *Health) serve(w http.ResponseWriter, r *http.Request, ready bool) {
+	report := h.Check(r.Context(), ready)
+	status := http.StatusOK
+	if report.Status != healthOK {
+		status = http.StatusServiceUnavailable
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	_ = h.tools.WriteJSON(w, status, report)
+}
+
+// Check runs the liveness checks, plus the readiness checks when ready is true, concurrently and
+// returns the aggregated report.
+func (h *Health) Check(ctx context.Context, ready bool) HealthReport {
+	h.mu.RLock()
+	checks := append([]namedCheck(nil), h.liveness...)
+	if ready {
+		checks = append(checks, h.readiness...)
+	}
+	h.mu.RUnlock()
+
+	timeout := h.Timeout
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+
+	report := HealthReport{Status: healthOK, Checks: make(map[string]HealthCheckResult, len(checks))}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, c := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result := runHealthCheck(ctx, c.check, timeout)
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[c.name] = result
+			if result.Status != healthOK {
+				report.Status = healthFail
+			}
+		}()
+	}
+	wg.Wait()
+	return report
+}
+
+func runHealthCheck(ctx context.Context, check HealthCheck, timeout time.Duration) (result HealthCheckResult) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	errc := make(chan error, 1)
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				errc <- fmt.Errorf("check panicked: %v", p)
+			}
+		}()
+		errc <- check(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-errc:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	result.LatencyMS = float64(time.Since(start).Microseconds()) / 1000
+	result.Status = healthOK
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			err = errors.New("check timed out")
+		}
+		result.Status, result.Error = healthFail, err.Error()
+	}
+	return result
+}
+
+// DiskSpaceCheck returns a check failing when the filesystem holding path has less than min
+// bytes free. It passes on platforms where DiskFree is unsupported.
+func (t *Tools) DiskSpaceCheck(path string, min int64) HealthCheck {
+	return func(ctx context.Context) error {
+		free, err := t.DiskFree(path)
+		if errors.Is(err, errors.ErrUnsupported) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if free < min {
+			return fmt.Errorf("%w: %s free, %s required", ErrInsufficientStorage, t.HumanBytes(free), t.HumanBytes(min))
+		}
+		return nil
+	}
+}
@@ -0,0 +1,78 @@
+package toolkit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTools_Health(t *testing.T) {
+	var testTools Tools
+	health := testTools.NewHealth()
+	health.Timeout = 50 * time.Millisecond
+
+	dbDown := false
+	health.AddLivenessCheck("goroutines", func(ctx context.Context) error { return nil })
+	health.AddReadinessCheck("db", func(ctx context.Context) error {
+		if dbDown {
+			return errors.New("connection refused")
+		}
+		return nil
+	})
+	health.AddReadinessCheck("disk", testTools.DiskSpaceCheck(t.TempDir(), 1))
+
+	mux := http.NewServeMux()
+	health.Register(mux)
+	get := func(path string) (int, HealthReport) {
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+		var report HealthReport
+		if err := json.NewDecoder(rr.Body).Decode(&report); err != nil {
+			t.Fatal(err)
+		}
+		return rr.Code, report
+	}
+
+	if code, report := get("/readyz"); code != http.StatusOK || len(report.Checks) != 3 || report.Status != "ok" {
+		t.Errorf("unexpected readiness %d %+v", code, report)
+	}
+
+	dbDown = true
+	code, report := get("/readyz")
+	if code != http.StatusServiceUnavailable || report.Checks["db"].Error != "connection refused" || report.Checks["disk"].Status != "ok" {
+		t.Errorf("unexpected readiness %d %+v", code, report)
+	}
+	if code, report = get("/healthz"); code != http.StatusOK || len(report.Checks) != 1 {
+		t.Errorf("liveness should ignore readiness checks: %d %+v", code, report)
+	}
+
+	health.AddLivenessCheck("stuck", func(ctx context.Context) error {
+		time.Sleep(time.Second)
+		return nil
+	})
+	health.AddLivenessCheck("panics", func(ctx context.Context) error { panic("bad check") })
+	start := time.Now()
+	code, report = get("/healthz")
+	if code != http.StatusServiceUnavailable || report.Checks["stuck"].Error != "check timed out" || report.Checks["panics"].Status != "fail" {
+		t.Errorf("unexpected liveness %d %+v", code, report)
+	}
+	if time.Since(start) > 500*time.Millisecond {
+		t.Error("timed out check was waited for")
+	}
+}
+
+func TestTools_DiskSpaceCheck(t *testing.T) {
+	var testTools Tools
+	if _, err := testTools.DiskFree(t.TempDir()); errors.Is(err, errors.ErrUnsupported) {
+		t.Skip("DiskFree is not supported on this platform")
+	}
+	err := testTools.DiskSpaceCheck(t.TempDir(), math.MaxInt64)(context.Background())
+	if !errors.Is(err, ErrInsufficientStorage) {
+		t.Error("expected ErrInsufficientStorage, got", err)
+	}
+}
@@ -0,0 +1,91 @@
+package toolkit
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// HTTPSOptions configures HTTPSMiddleware.
+type HTTPSOptions struct {
+	// HSTSMaxAge is the max-age of the Strict-Transport-Security header, one year when 0. A
+	// negative value disables the header.
+	HSTSMaxAge        time.Duration
+	IncludeSubDomains bool
+	Preload           bool
+	// Host, when set, replaces the request host in redirects, e.g. to drop a non-standard port.
+	Host string
+	// Exclude lists path prefixes served over plain HTTP, such as ACME challenges.
+	Exclude []string
+}
+
+// IsSecure reports whether r reached the service over HTTPS, either directly or, when the peer
+// is one of Tools.TrustedProxies, according to the Forwarded or X-Forwarded-Proto header.
+func (t *Tools) IsSecure(r *http.Request) bool {
+	if r.TLS != nil {
+		return true
+	}
+	remote := peerAddr(r)
+	trusted, _ := t.trustedProxies()
+	if !remote.IsValid() || !containsAddr(trusted, remote) {
+		return false
+	}
+
+	// The nearest proxy reports last, and is the one whose word counts.
+	if protos := forwardedParam(r.Header.Values("Forwarded"), "proto"); len(protos) > 0 {
+		return strings.EqualFold(strings.Trim(protos[len(protos)-1], `"`), "https")
+	}
+	if values := r.Header.Values("X-Forwarded-Proto"); len(values) > 0 {
+		protos := strings.Split(values[len(values)-1], ",")
+		return strings.EqualFold(strings.TrimSpace(protos[len(protos)-1]), "https")
+	}
+	return false
+}
+
+// HTTPSMiddleware redirects insecure requests to HTTPS, with 301 for GET and HEAD and 308 for
+// other methods so their body is resent, and adds Strict-Transport-Security to secure responses.
+func (t *Tools) HTTPSMiddleware(opts HTTPSOptions) func(http.Handler) http.Handler {
+	hsts := ""
+	if opts.HSTSMaxAge >= 0 {
+		maxAge := opts.HSTSMaxAge
+		if maxAge == 0 {
+			maxAge = 365 * 24 * time.Hour
+		}
+		hsts = "max-age=" + strconv.FormatInt(int64(maxAge.Seconds()), 10)
+		if opts.IncludeSubDomains {
+			hsts += "; includeSubDomains"
+		}
+		if opts.Preload {
+			hsts += "; preload"
+		}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if t.IsSecure(r) {
+				if hsts != "" {
+					w.Header().Set("Strict-Transport-Security", hsts)
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+			for _, prefix := range opts.Exclude {
+				if strings.HasPrefix(r.URL.Path, prefix) {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+
+			host := opts.Host
+			if host == "" {
+				host = r.Host
+			}
+			status := http.StatusMovedPermanently
+			if r.Method != http.MethodGet && r.Method != http.MethodHead {
+				status = http.StatusPermanentRedirect
+			}
+			http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), status)
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package toolkit
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+var httpsTests = []struct {
+	name     string
+	method   string
+	target   string
+	remote   string
+	tls      bool
+	headers  map[string]string
+	status   int
+	location string
+}{
+	{name: "plain", method: http.MethodGet, target: "http://example.com/a?b=1", status: http.StatusMovedPermanently, location: "https://example.com/a?b=1"},
+	{name: "plain post", method: http.MethodPost, target: "http://example.com/form", status: http.StatusPermanentRedirect, location: "https://example.com/form"},
+	{name: "tls", method: http.MethodGet, target: "https://example.com/", tls: true, status: http.StatusOK},
+	{name: "trusted proxy xfp", method: http.MethodGet, target: "http://example.com/", remote: "10.0.0.1:80", headers: map[string]string{"X-Forwarded-Proto": "https"}, status: http.StatusOK},
+	{name: "trusted proxy forwarded", method: http.MethodGet, target: "http://example.com/", remote: "10.0.0.1:80", headers: map[string]string{"Forwarded": "for=1.2.3.4;proto=https"}, status: http.StatusOK},
+	{name: "trusted proxy http", method: http.MethodGet, target: "http://example.com/", remote: "10.0.0.1:80", headers: map[string]string{"X-Forwarded-Proto": "http"}, status: http.StatusMovedPermanently, location: "https://example.com/"},
+	{name: "spoofed header", method: http.MethodGet, target: "http://example.com/", remote: "203.0.113.9:80", headers: map[string]string{"X-Forwarded-Proto": "https"}, status: http.StatusMovedPermanently, location: "https://example.com/"},
+	{name: "excluded", method: http.MethodGet, target: "http://example.com/.well-known/acme-challenge/x", status: http.StatusOK},
+}
+
+func TestTools_HTTPSMiddleware(t *testing.T) {
+	testTools := Tools{TrustedProxies: []string{"10.0.0.0/8"}}
+	h := testTools.HTTPSMiddleware(HTTPSOptions{
+		HSTSMaxAge:        time.Hour,
+		IncludeSubDomains: true,
+		Exclude:           []string{"/.well-known/acme-challenge/"},
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	for _, test := range httpsTests {
+		req := httptest.NewRequest(test.method, test.target, nil)
+		if test.remote != "" {
+			req.RemoteAddr = test.remote
+		}
+		if test.tls {
+			req.TLS = &tls.ConnectionState{}
+		} else {
+			req.TLS = nil
+		}
+		for k, v := range test.headers {
+			req.Header.Set(k, v)
+		}
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+
+		if rr.Code != test.status || rr.Header().Get("Location") != test.location {
+			t.Errorf("%s: expected %d %q, got %d %q", test.name, test.status, test.location, rr.Code, rr.Header().Get("Location"))
+		}
+		secure := test.status == http.StatusOK && test.name != "excluded"
+		if hsts := rr.Header().Get("Strict-Transport-Security"); secure != (hsts == "max-age=3600; includeSubDomains") {
+			t.Errorf("%s: unexpected HSTS header %q", test.name, hsts)
+		}
+	}
+}
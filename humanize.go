@@ -0,0 +1,140 @@
+package toolkit
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var byteUnits = []string{"B", "KB", "MB", "GB", "TB", "PB", "EB"}
+
+// HumanBytes formats a byte count using binary units, e.g. 98827 -> "96.5 KB".
+func (t *Tools) HumanBytes(n int64) string {
+	if n < 0 {
+		// Negated as a uint64, as -math.MinInt64 overflows back to itself.
+		return "-" + humanBytes(-uint64(n))
+	}
+	return humanBytes(uint64(n))
+}
+
+func humanBytes(n uint64) string {
+	if n < 1024 {
+		return fmt.Sprintf("%d B", n)
+	}
+
+	value, unit := float64(n), 0
+	for value >= 1024 && unit < len(byteUnits)-1 {
+		value /= 1024
+		unit++
+	}
+
+	s := strconv.FormatFloat(value, 'f', 1, 64)
+	s = strings.TrimSuffix(s, ".0")
+	return fmt.Sprintf("%s %s", s, byteUnits[unit])
+}
+
+// ParseBytes parses a byte count such as "512", "10MB" or "1.5 GiB". Units are binary, as in
+// HumanBytes, so "1KB" and "1KiB" are both 1024 bytes.
+func (t *Tools) ParseBytes(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	i := strings.IndexFunc(s, func(r rune) bool { return (r < '0' || r > '9') && r != '.' })
+	number, unit := s, ""
+	if i >= 0 {
+		number, unit = s[:i], strings.ToUpper(strings.TrimSpace(s[i:]))
+	}
+	value, err := strconv.ParseFloat(number, 64)
+	if err != nil || value < 0 {
+		return 0, fmt.Errorf("invalid byte size %q", s)
+	}
+
+	unit = strings.Replace(unit, "IB", "B", 1)
+	if unit == "" {
+		unit = "B"
+	} else if !strings.HasSuffix(unit, "B") {
+		unit += "B"
+	}
+	for exp, u := range byteUnits {
+		if u == unit {
+			bytes := value * float64(uint64(1)<<(10*exp))
+			if bytes > float64(1<<63-1) {
+				return 0, fmt.Errorf("byte size %q out of range", s)
+			}
+			return int64(bytes), nil
+		}
+	}
+	return 0, fmt.Errorf("invalid byte size %q", s)
+}
+
+// HumanDuration formats a duration using its two most significant units, e.g. "1h 5m".
+func (t *Tools) HumanDuration(d time.Duration) string {
+	if d < 0 {
+		// Negated as a uint64, as -math.MinInt64 overflows back to itself.
+		return "-" + humanDuration(-uint64(d))
+	}
+	return humanDuration(uint64(d))
+}
+
+func humanDuration(d uint64) string {
+	if d < uint64(time.Second) {
+		return fmt.Sprintf("%dms", d/uint64(time.Millisecond))
+	}
+
+	parts := []struct {
+		unit  uint64
+		label string
+	}{
+		{uint64(24 * time.Hour), "d"},
+		{uint64(time.Hour), "h"},
+		{uint64(time.Minute), "m"},
+		{uint64(time.Second), "s"},
+	}
+
+	var out []string
+	for _, p := range parts {
+		if d < p.unit && len(out) == 0 {
+			continue
+		}
+		v := d / p.unit
+		d -= v * p.unit
+		if v > 0 {
+			out = append(out, fmt.Sprintf("%d%s", v, p.label))
+		}
+		if len(out) == 2 || (len(out) > 0 && v == 0) {
+			break
+		}
+	}
+	return strings.Join(out, " ")
+}
+
+// Ordinal returns n with its English ordinal suffix, e.g. 1 -> "1st", 12 -> "12th".
+func (t *Tools) Ordinal(n int) string {
+	abs := n
+	if abs < 0 {
+		abs = -abs
+	}
+
+	suffix := "th"
+	if abs%100 < 11 || abs%100 > 13 {
+		switch abs % 10 {
+		case 1:
+			suffix = "st"
+		case 2:
+			suffix = "nd"
+		case 3:
+			suffix = "rd"
+		}
+	}
+	return fmt.Sprintf("%d%s", n, suffix)
+}
+
+// PluralCount returns n followed by the singular or plural form of a noun, e.g. "3 files".
+func (t *Tools) PluralCount(n int, singular string, plural ...string) string {
+	if n == 1 || n == -1 {
+		return fmt.Sprintf("%d %s", n, singular)
+	}
+	if len(plural) > 0 {
+		return fmt.Sprintf("%d %s", n, plural[0])
+	}
+	return fmt.Sprintf("%d %s", n, t.Pluralize(singular))
+}
@@ -0,0 +1,110 @@
+package toolkit
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+var humanBytesTests = []struct {
+	name     string
+	n        int64
+	expected string
+}{
+	{name: "bytes", n: 512, expected: "512 B"},
+	{name: "kilobytes", n: 98827, expected: "96.5 KB"},
+	{name: "exact megabytes", n: 10485760, expected: "10 MB"},
+	{name: "gigabytes", n: 1024 * 1024 * 1024, expected: "1 GB"},
+	{name: "negative", n: -2048, expected: "-2 KB"},
+	{name: "largest", n: math.MaxInt64, expected: "8 EB"},
+	{name: "smallest", n: math.MinInt64, expected: "-8 EB"},
+}
+
+func TestTools_HumanBytes(t *testing.T) {
+	var testTools Tools
+	for _, test := range humanBytesTests {
+		if s := testTools.HumanBytes(test.n); s != test.expected {
+			t.Errorf("%s: expected %s, got %s", test.name, test.expected, s)
+		}
+	}
+}
+
+var parseBytesTests = []struct {
+	s        string
+	expected int64
+	invalid  bool
+}{
+	{s: "512", expected: 512},
+	{s: "512B", expected: 512},
+	{s: "10MB", expected: 10 << 20},
+	{s: "10 mb", expected: 10 << 20},
+	{s: "1.5GiB", expected: 3 << 29},
+	{s: "2K", expected: 2048},
+	{s: "", invalid: true},
+	{s: "-1KB", invalid: true},
+	{s: "10XB", invalid: true},
+	{s: "9000EB", invalid: true},
+}
+
+func TestTools_ParseBytes(t *testing.T) {
+	var testTools Tools
+	for _, test := range parseBytesTests {
+		n, err := testTools.ParseBytes(test.s)
+		if test.invalid {
+			if err == nil {
+				t.Errorf("%q: expected an error", test.s)
+			}
+			continue
+		}
+		if err != nil || n != test.expected {
+			t.Errorf("%q: expected %d, got %d %v", test.s, test.expected, n, err)
+		}
+	}
+}
+
+var humanDurationTests = []struct {
+	name     string
+	d        time.Duration
+	expected string
+}{
+	{name: "milliseconds", d: 250 * time.Millisecond, expected: "250ms"},
+	{name: "seconds", d: 12 * time.Second, expected: "12s"},
+	{name: "hours and minutes", d: time.Hour + 5*time.Minute + 3*time.Second, expected: "1h 5m"},
+	{name: "whole hour", d: time.Hour + 3*time.Second, expected: "1h"},
+	{name: "days", d: 50 * time.Hour, expected: "2d 2h"},
+	{name: "negative", d: -90 * time.Second, expected: "-1m 30s"},
+	{name: "largest", d: math.MaxInt64, expected: "106751d 23h"},
+	{name: "smallest", d: math.MinInt64, expected: "-106751d 23h"},
+}
+
+func TestTools_HumanDuration(t *testing.T) {
+	var testTools Tools
+	for _, test := range humanDurationTests {
+		if s := testTools.HumanDuration(test.d); s != test.expected {
+			t.Errorf("%s: expected %s, got %s", test.name, test.expected, s)
+		}
+	}
+}
+
+func TestTools_Ordinal(t *testing.T) {
+	var testTools Tools
+	expected := map[int]string{1: "1st", 2: "2nd", 3: "3rd", 4: "4th", 11: "11th", 12: "12th", 13: "13th", 21: "21st", 112: "112th", 103: "103rd"}
+	for n, e := range expected {
+		if s := testTools.Ordinal(n); s != e {
+			t.Errorf("expected %s, got %s", e, s)
+		}
+	}
+}
+
+func TestTools_PluralCount(t *testing.T) {
+	var testTools Tools
+	if s := testTools.PluralCount(1, "file"); s != "1 file" {
+		t.Error("wrong singular form:", s)
+	}
+	if s := testTools.PluralCount(3, "file"); s != "3 files" {
+		t.Error("wrong plural form:", s)
+	}
+	if s := testTools.PluralCount(0, "child", "children"); s != "0 children" {
+		t.Error("wrong explicit plural form:", s)
+	}
+}
@@ -0,0 +1,434 @@
+package toolkit
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/BurntSushi/toml"
+)
+
+// I18nOptions configures NewI18n.
+type I18nOptions struct {
+	// FS holds the message bundles, one per language, named by language tag with a .json or
+	// .toml extension, such as "en.json" or "pt-BR.toml".
+	FS fs.FS
+	// Dir is the directory of the bundles in FS, its root when empty.
+	Dir string
+	// DefaultLanguage is used when no language the client accepts has a bundle, and for messages
+	// missing from other bundles. "en" when empty.
+	DefaultLanguage string
+	// QueryParam and Cookie name the query parameter and cookie that override Accept-Language,
+	// both "lang" when empty. Middleware stores a language chosen with the query parameter in the
+	// cookie.
+	QueryParam string
+	Cookie     string
+}
+
+// I18n holds message bundles and picks the language of requests.
+//
+// Bundles map keys to messages, which are text/template strings executed with the arguments of
+// the translation, e.g. "Hello, {{.Name}}". Nested objects or tables make dotted keys. Messages
+// with plural forms are objects of the CLDR categories, "zero", "one", "two", "few", "many" and
+// "other", and are executed with the count as .Count:
+//
+//	{
+//		"greeting": "Hello, {{.Name}}",
+//		"cart": {"items": {"one": "{{.Count}} item", "other": "{{.Count}} items"}}
+//	}
+type I18n struct {
+	opts    I18nOptions
+	bundles map[string]map[string]i18nMessage
+	langs   []string
+}
+
+// i18nMessage maps plural categories to message forms; simple messages only have "other".
+type i18nMessage map[string]*i18nForm
+
+type i18nForm struct {
+	text string
+	tmpl *template.Template
+}
+
+var pluralCategories = []string{"zero", "one", "two", "few", "many", "other"}
+
+// NewI18n loads the bundles in opts.FS, which must include one for the default language.
+func (t *Tools) NewI18n(opts I18nOptions) (*I18n, error) {
+	if opts.FS == nil {
+		return nil, errors.New("i18n needs a file system")
+	}
+	opts.Dir = cmp.Or(opts.Dir, ".")
+	opts.DefaultLanguage = cmp.Or(opts.DefaultLanguage, "en")
+	opts.QueryParam = cmp.Or(opts.QueryParam, "lang")
+	opts.Cookie = cmp.Or(opts.Cookie, "lang")
+
+	entries, err := fs.ReadDir(opts.FS, opts.Dir)
+	if err != nil {
+		return nil, err
+	}
+	i := &I18n{opts: opts, bundles: make(map[string]map[string]i18nMessage)}
+	for _, e := range entries {
+		ext := path.Ext(e.Name())
+		if e.IsDir() || ext != ".json" && ext != ".toml" {
+			continue
+		}
+		lang := strings.TrimSuffix(e.Name(), ext)
+		data, err := fs.ReadFile(opts.FS, path.Join(opts.Dir, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		var raw map[string]any
+		if ext == ".json" {
+			err = json.Unmarshal(data, &raw)
+		} else {
+			err = toml.Unmarshal(data, &raw)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", e.Name(), err)
+		}
+		messages := make(map[string]i18nMessage)
+		if err = flattenMessages(messages, "", raw); err != nil {
+			return nil, fmt.Errorf("%s: %w", e.Name(), err)
+		}
+		i.bundles[lang] = messages
+		i.langs = append(i.langs, lang)
+	}
+	if _, ok := i.bundles[opts.DefaultLanguage]; !ok {
+		return nil, fmt.Errorf("no bundle for the default language %s", opts.DefaultLanguage)
+	}
+	return i, nil
+}
+
+func flattenMessages(dst map[string]i18nMessage, prefix string, raw map[string]any) error {
+	for k, v := range raw {
+		key := prefix + k
+		switch v := v.(type) {
+		case string:
+			form, err := newI18nForm(key, v)
+			if err != nil {
+				return err
+			}
+			dst[key] = i18nMessage{"other": form}
+		case map[string]any:
+			if !isPluralMessage(v) {
+				if err := flattenMessages(dst, key+".", v); err != nil {
+					return err
+				}
+				continue
+			}
+			msg := make(i18nMessage)
+			for category, text := range v {
+				s, ok := text.(string)
+				if !ok {
+					return fmt.Errorf("%s.%s should be a string", key, category)
+				}
+				form, err := newI18nForm(key, s)
+				if err != nil {
+					return err
+				}
+				msg[category] = form
+			}
+			if msg["other"] == nil {
+				return fmt.Errorf("%s has no \"other\" form", key)
+			}
+			dst[key] = msg
+		default:
+			return fmt.Errorf("%s should be a string or an object", key)
+		}
+	}
+	return nil
+}
+
+func isPluralMessage(v map[string]any) bool {
+	for k := range v {
+		if !slices.Contains(pluralCategories, k) {
+			return false
+		}
+	}
+	return len(v) > 0
+}
+
+func newI18nForm(key, text string) (*i18nForm, error) {
+	if !strings.Contains(text, "{{") {
+		return &i18nForm{text: text}, nil
+	}
+	tmpl, err := template.New(key).Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	return &i18nForm{tmpl: tmpl}, nil
+}
+
+// Languages returns the languages with a bundle.
+func (i *I18n) Languages() []string {
+	return slices.Clone(i.langs)
+}
+
+// Match returns the best language with a bundle for an Accept-Language header value, falling
+// back from regional variants to their base language and the other way round, or the default
+// language.
+func (i *I18n) Match(acceptLanguage string) string {
+	type choice struct {
+		tag string
+		q   float64
+	}
+	var choices []choice
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			var err error
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+		if tag != "" && q > 0 {
+			choices = append(choices, choice{tag, q})
+		}
+	}
+	slices.SortStableFunc(choices, func(a, b choice) int { return cmp.Compare(b.q, a.q) })
+
+	for _, c := range choices {
+		if lang, ok := i.lookup(c.tag); ok {
+			return lang
+		}
+	}
+	return i.opts.DefaultLanguage
+}
+
+// lookup finds the bundle for tag, trying its base language and then other variants of it.
+func (i *I18n) lookup(tag string) (string, bool) {
+	base, _, _ := strings.Cut(tag, "-")
+	for _, candidate := range []func(lang string) bool{
+		func(lang string) bool { return strings.EqualFold(lang, tag) },
+		func(lang string) bool { return strings.EqualFold(lang, base) },
+		func(lang string) bool { l, _, _ := strings.Cut(lang, "-"); return strings.EqualFold(l, base) },
+	} {
+		if idx := slices.IndexFunc(i.langs, candidate); idx >= 0 {
+			return i.langs[idx], true
+		}
+	}
+	return "", false
+}
+
+// Language returns the language of r: the one named by the query parameter or cookie when it
+// has a bundle, else the best match for Accept-Language.
+func (i *I18n) Language(r *http.Request) string {
+	if lang, ok := i.lookup(r.URL.Query().Get(i.opts.QueryParam)); ok {
+		return lang
+	}
+	if c, err := r.Cookie(i.opts.Cookie); err == nil {
+		if lang, ok := i.lookup(c.Value); ok {
+			return lang
+		}
+	}
+	return i.Match(r.Header.Get("Accept-Language"))
+}
+
+// Middleware stores a Localizer for the request's language in its context and announces the
+// language in the Content-Language header, which ErrorJSON translates its messages to.
+func (i *I18n) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lang := i.Language(r)
+		if q := r.URL.Query().Get(i.opts.QueryParam); q != "" && strings.EqualFold(q, lang) {
+			http.SetCookie(w, &http.Cookie{
+				Name:     i.opts.Cookie,
+				Value:    lang,
+				Path:     "/",
+				MaxAge:   365 * 24 * 60 * 60,
+				SameSite: http.SameSiteLaxMode,
+			})
+		}
+		w.Header().Set("Content-Language", lang)
+		w.Header().Add("Vary", "Accept-Language")
+		ctx := context.WithValue(r.Context(), localizerKey{}, i.Localizer(lang))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// Localizer returns a Localizer for lang, or the default language when lang has no bundle.
+func (i *I18n) Localizer(lang string) *Localizer {
+	if _, ok := i.bundles[lang]; !ok {
+		lang = i.opts.DefaultLanguage
+	}
+	return &Localizer{i18n: i, lang: lang}
+}
+
+type localizerKey struct{}
+
+// LocalizerFromContext returns the Localizer stored by I18n.Middleware, or nil. A nil Localizer
+// returns keys untranslated.
+func LocalizerFromContext(ctx context.Context) *Localizer {
+	l, _ := ctx.Value(localizerKey{}).(*Localizer)
+	return l
+}
+
+// Localizer translates messages to one language.
+type Localizer struct {
+	i18n *I18n
+	lang string
+}
+
+// Language returns the language of the localizer.
+func (l *Localizer) Language() string {
+	if l == nil {
+		return ""
+	}
+	return l.lang
+}
+
+// T translates key, executing the message with args, given as name and value pairs:
+//
+//	l.T("greeting", "Name", user.Name)
+//
+// Messages missing from the language's bundle are taken from the default language's, and
+// unknown keys are returned as they are.
+func (l *Localizer) T(key string, args ...any) string {
+	return l.translate(key, key, -1, args)
+}
+
+// N translates the plural message key for count, which the message gets as .Count.
+func (l *Localizer) N(key string, count int, args ...any) string {
+	return l.translate(key, key, count, args)
+}
+
+// translate returns fallback when key is in no bundle. A count of -1 is no count.
+func (l *Localizer) translate(key, fallback string, count int, args []any) string {
+	if l == nil {
+		return fallback
+	}
+	msg, ok := l.i18n.bundles[l.lang][key]
+	lang := l.lang
+	if !ok {
+		lang = l.i18n.opts.DefaultLanguage
+		if msg, ok = l.i18n.bundles[lang][key]; !ok {
+			return fallback
+		}
+	}
+
+	form := msg["other"]
+	data := make(map[string]any, len(args)/2+1)
+	for i := 0; i+1 < len(args); i += 2 {
+		data[fmt.Sprint(args[i])] = args[i+1]
+	}
+	if count >= 0 {
+		data["Count"] = count
+		if f := msg[pluralCategory(lang, count)]; f != nil {
+			form = f
+		}
+		if f := msg["zero"]; count == 0 && f != nil {
+			form = f
+		}
+	}
+
+	if form.tmpl == nil {
+		return form.text
+	}
+	var buf bytes.Buffer
+	if err := form.tmpl.Execute(&buf, data); err != nil {
+		return fallback
+	}
+	return buf.String()
+}
+
+// pluralCategory returns the CLDR plural category of the integer n in lang, for the languages
+// whose rules differ from English. Languages without plural forms only have "other".
+func pluralCategory(lang string, n int) string {
+	base, _, _ := strings.Cut(strings.ToLower(lang), "-")
+	n10, n100 := n%10, n%100
+	switch base {
+	case "ja", "zh", "ko", "vi", "th", "id", "ms":
+		return "other"
+	case "fr":
+		if n == 0 || n == 1 {
+			return "one"
+		}
+	case "pl":
+		switch {
+		case n == 1:
+			return "one"
+		case n10 >= 2 && n10 <= 4 && (n100 < 12 || n100 > 14):
+			return "few"
+		default:
+			return "many"
+		}
+	case "ru", "uk":
+		switch {
+		case n10 == 1 && n100 != 11:
+			return "one"
+		case n10 >= 2 && n10 <= 4 && (n100 < 12 || n100 > 14):
+			return "few"
+		default:
+			return "many"
+		}
+	case "cs", "sk":
+		switch {
+		case n == 1:
+			return "one"
+		case n >= 2 && n <= 4:
+			return "few"
+		}
+	default:
+		if n == 1 {
+			return "one"
+		}
+	}
+	return "other"
+}
+
+// LocalizedError is an error whose message ErrorJSON translates to the language of the
+// response. Message is the untranslated text, returned by Error.
+type LocalizedError struct {
+	Key     string
+	Message string
+	Args    []any
+}
+
+// NewLocalizedError returns a LocalizedError translated from key with args, given as name and
+// value pairs.
+func NewLocalizedError(key, message string, args ...any) error {
+	return &LocalizedError{Key: key, Message: message, Args: args}
+}
+
+func (e *LocalizedError) Error() string { return e.Message }
+
+// responseLocalizer returns the Localizer for the language set on w by I18n.Middleware, or nil.
+func (t *Tools) responseLocalizer(w http.ResponseWriter) *Localizer {
+	lang := w.Header().Get("Content-Language")
+	if t.I18n == nil || lang == "" {
+		return nil
+	}
+	return t.I18n.Localizer(lang)
+}
+
+// localizedFields translates the field errors of a Validator.
+func (l *Localizer) localizedFields(fields map[string]string, keys map[string]i18nKey) map[string]string {
+	if l == nil || len(keys) == 0 {
+		return fields
+	}
+	out := make(map[string]string, len(fields))
+	for field, msg := range fields {
+		if k, ok := keys[field]; ok {
+			msg = l.translate(k.key, msg, -1, append([]any{"Field", field}, k.args...))
+		}
+		out[field] = msg
+	}
+	return out
+}
+
+// i18nKey is the message key and arguments a message is translated from.
+type i18nKey struct {
+	key  string
+	args []any
+}
@@ -0,0 +1,203 @@
+package toolkit
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+var i18nFS = fstest.MapFS{
+	"locales/en.json": {Data: []byte(`{
+		"greeting": "Hello, {{.Name}}!",
+		"farewell": "Goodbye",
+		"cart": {"items": {"one": "{{.Count}} item", "other": "{{.Count}} items"}},
+		"validation": {"failed": "validation failed", "required": "{{.Field}} is required"}
+	}`)},
+	"locales/pl.toml": {Data: []byte(`
+greeting = "Cześć, {{.Name}}!"
+
+[cart.items]
+zero = "Koszyk jest pusty"
+one = "{{.Count}} produkt"
+few = "{{.Count}} produkty"
+many = "{{.Count}} produktów"
+other = "{{.Count}} produktu"
+
+[validation]
+failed = "błąd walidacji"
+required = "pole {{.Field}} jest wymagane"
+min_length = "minimum {{.Min}} znaków"
+
+[errors]
+not_found = "nie znaleziono {{.What}}"
+`)},
+	"locales/pt-BR.json": {Data: []byte(`{"greeting": "Olá, {{.Name}}!"}`)},
+	"locales/README.md":  {Data: []byte("not a bundle")},
+}
+
+func newTestI18n(t *testing.T) *I18n {
+	t.Helper()
+	var testTools Tools
+	i, err := testTools.NewI18n(I18nOptions{FS: i18nFS, Dir: "locales"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return i
+}
+
+var acceptLanguageTests = []struct {
+	header   string
+	expected string
+}{
+	{header: "pl-PL,pl;q=0.9,en;q=0.8", expected: "pl"},
+	{header: "de;q=0.9,pl;q=0.5", expected: "pl"},
+	{header: "en;q=0.1,pt;q=0.9", expected: "pt-BR"},
+	{header: "PT-br", expected: "pt-BR"},
+	{header: "pl;q=0,en", expected: "en"},
+	{header: "de, fr", expected: "en"},
+	{header: "", expected: "en"},
+}
+
+func TestTools_I18nMatch(t *testing.T) {
+	i := newTestI18n(t)
+	for _, test := range acceptLanguageTests {
+		if got := i.Match(test.header); got != test.expected {
+			t.Errorf("%q: expected %s, got %s", test.header, test.expected, got)
+		}
+	}
+}
+
+var translateTests = []struct {
+	lang     string
+	key      string
+	count    int
+	args     []any
+	expected string
+}{
+	{lang: "pl", key: "greeting", count: -1, args: []any{"Name", "Ola"}, expected: "Cześć, Ola!"},
+	{lang: "pl", key: "farewell", count: -1, expected: "Goodbye"},
+	{lang: "pl", key: "unknown.key", count: -1, expected: "unknown.key"},
+	{lang: "pl", key: "cart.items", count: 0, expected: "Koszyk jest pusty"},
+	{lang: "pl", key: "cart.items", count: 1, expected: "1 produkt"},
+	{lang: "pl", key: "cart.items", count: 3, expected: "3 produkty"},
+	{lang: "pl", key: "cart.items", count: 12, expected: "12 produktów"},
+	{lang: "pl", key: "cart.items", count: 22, expected: "22 produkty"},
+	{lang: "en", key: "cart.items", count: 1, expected: "1 item"},
+	{lang: "en", key: "cart.items", count: 0, expected: "0 items"},
+	{lang: "de", key: "greeting", count: -1, args: []any{"Name", "Jo"}, expected: "Hello, Jo!"},
+}
+
+func TestTools_I18nTranslate(t *testing.T) {
+	i := newTestI18n(t)
+	for _, test := range translateTests {
+		l := i.Localizer(test.lang)
+		got := l.T(test.key, test.args...)
+		if test.count >= 0 {
+			got = l.N(test.key, test.count, test.args...)
+		}
+		if got != test.expected {
+			t.Errorf("%s %s %d: expected %q, got %q", test.lang, test.key, test.count, test.expected, got)
+		}
+	}
+
+	var l *Localizer
+	if l.T("greeting") != "greeting" {
+		t.Error("expected a nil localizer to return the key")
+	}
+
+	var testTools Tools
+	if _, err := testTools.NewI18n(I18nOptions{FS: i18nFS, Dir: "locales", DefaultLanguage: "de"}); err == nil {
+		t.Error("expected a missing default bundle to fail")
+	}
+	bad := fstest.MapFS{"en.json": {Data: []byte(`{"n": {"one": "x"}}`)}}
+	if _, err := testTools.NewI18n(I18nOptions{FS: bad}); err == nil {
+		t.Error("expected a plural message without other form to fail")
+	}
+}
+
+func TestTools_I18nMiddleware(t *testing.T) {
+	testTools := Tools{I18n: newTestI18n(t)}
+	var lang string
+	h := testTools.I18n.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lang = LocalizerFromContext(r.Context()).Language()
+		switch r.URL.Path {
+		case "/validate":
+			_ = testTools.ErrorJSON(w, testTools.NewValidator().
+				Required("name", "").
+				MinLength("password", "abc", 8).
+				IsEmail("email", "nope").
+				Err())
+		case "/missing":
+			_ = testTools.ErrorJSON(w, NewLocalizedError("errors.not_found", "user not found", "What", "użytkownika"), http.StatusNotFound)
+		}
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/?lang=pl", nil)
+	req.Header.Set("Accept-Language", "en")
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+	if lang != "pl" || rr.Header().Get("Content-Language") != "pl" || !strings.Contains(rr.Header().Get("Set-Cookie"), "lang=pl") {
+		t.Errorf("expected the query to select pl and set the cookie, got %s %v", lang, rr.Header())
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/validate", nil)
+	req.AddCookie(&http.Cookie{Name: "lang", Value: "pl"})
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+	var payload JSONResponse
+	_ = json.NewDecoder(rr.Body).Decode(&payload)
+	expected := map[string]string{
+		"name":     "pole name jest wymagane",
+		"password": "minimum 8 znaków",
+		"email":    "must be a valid email address",
+	}
+	if payload.Message != "błąd walidacji" || len(payload.Errors) != 3 {
+		t.Fatalf("unexpected payload %+v", payload)
+	}
+	for field, msg := range expected {
+		if payload.Errors[field] != msg {
+			t.Errorf("%s: expected %q, got %q", field, msg, payload.Errors[field])
+		}
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/missing", nil)
+	req.Header.Set("Accept-Language", "pl")
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+	_ = json.NewDecoder(rr.Body).Decode(&payload)
+	if payload.Message != "nie znaleziono użytkownika" {
+		t.Errorf("unexpected message %q", payload.Message)
+	}
+
+	rr = httptest.NewRecorder()
+	_ = testTools.ErrorJSON(rr, NewLocalizedError("errors.not_found", "user not found"))
+	_ = json.NewDecoder(rr.Body).Decode(&payload)
+	if payload.Message != "user not found" {
+		t.Errorf("expected the untranslated message outside the middleware, got %q", payload.Message)
+	}
+}
+
+func TestTools_I18nTemplates(t *testing.T) {
+	var testTools Tools
+	i := newTestI18n(t)
+	tp, err := testTools.NewTemplates(TemplateOptions{FS: fstest.MapFS{
+		"pages/cart.tmpl": {Data: []byte(`<p lang="{{.Lang}}">{{.T "greeting" "Name" .Data}} {{.N "cart.items" 5}}</p>`)},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h := i.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = tp.Render(w, r, "cart.tmpl", "Ola")
+	}))
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept-Language", "pl")
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+	if body := rr.Body.String(); body != `<p lang="pl">Cześć, Ola! 5 produktów</p>` {
+		t.Errorf("unexpected page %q", body)
+	}
+}
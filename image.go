@@ -0,0 +1,252 @@
+package toolkit
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/disintegration/imaging"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+	_ "golang.org/x/image/webp"
+)
+
+const defaultMaxImagePixels = 50_000_000
+
+// ImageAnchor is the position of a crop or a watermark within an image.
+type ImageAnchor int
+
+// The anchors follow the order of the imaging package's, which they are converted to.
+const (
+	AnchorCenter ImageAnchor = iota
+	AnchorTopLeft
+	AnchorTop
+	AnchorTopRight
+	AnchorLeft
+	AnchorRight
+	AnchorBottomLeft
+	AnchorBottom
+	AnchorBottomRight
+)
+
+// Image is a decoded image. Its methods return transformed copies, so they can be chained and
+// the original reused, e.g. for several thumbnail sizes.
+type Image struct {
+	img image.Image
+	// format is the name of the decoded format, such as "jpeg", and the default for Encode.
+	format string
+}
+
+// DecodeImage reads a JPEG, PNG, GIF, WebP, BMP or TIFF image from r, rotating it upright
+// according to its EXIF orientation. Only the header is read before the size is checked against
+// MaxImagePixels.
+func (t *Tools) DecodeImage(r io.Reader) (*Image, error) {
+	var head bytes.Buffer
+	cfg, format, err := image.DecodeConfig(io.TeeReader(r, &head))
+	if err != nil {
+		return nil, err
+	}
+	limit := t.MaxImagePixels
+	if limit <= 0 {
+		limit = defaultMaxImagePixels
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 {
+		return nil, fmt.Errorf("image of %dx%d pixels is empty", cfg.Width, cfg.Height)
+	}
+	// The product is taken in 64 bits, as it can overflow int on 32-bit platforms.
+	if int64(cfg.Width)*int64(cfg.Height) > int64(limit) {
+		return nil, fmt.Errorf("image of %dx%d pixels exceeds the limit of %d pixels", cfg.Width, cfg.Height, limit)
+	}
+
+	img, err := imaging.Decode(io.MultiReader(&head, r), imaging.AutoOrientation(true))
+	if err != nil {
+		return nil, err
+	}
+	return &Image{img: img, format: format}, nil
+}
+
+// OpenImage decodes the image file at path with DecodeImage.
+func (t *Tools) OpenImage(path string) (*Image, error) {
+	f, err := t.open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return t.DecodeImage(f)
+}
+
+// NewImage wraps img, which is encoded as PNG by default.
+func NewImage(img image.Image) *Image {
+	return &Image{img: img, format: "png"}
+}
+
+// Image returns the underlying image.
+func (i *Image) Image() image.Image { return i.img }
+
+// Format returns the name of the format the image was decoded from.
+func (i *Image) Format() string { return i.format }
+
+// Width returns the width of the image in pixels.
+func (i *Image) Width() int { return i.img.Bounds().Dx() }
+
+// Height returns the height of the image in pixels.
+func (i *Image) Height() int { return i.img.Bounds().Dy() }
+
+func (i *Image) with(img image.Image) *Image {
+	return &Image{img: img, format: i.format}
+}
+
+// Resize scales the image to width×height. The aspect ratio is kept when either is 0.
+func (i *Image) Resize(width, height int) *Image {
+	return i.with(imaging.Resize(i.img, width, height, imaging.Lanczos))
+}
+
+// Fit scales the image down to fit within width×height, keeping its aspect ratio. Smaller
+// images are left as they are.
+func (i *Image) Fit(width, height int) *Image {
+	return i.with(imaging.Fit(i.img, width, height, imaging.Lanczos))
+}
+
+// Fill scales and crops the image to exactly width×height, keeping the part at anchor.
+func (i *Image) Fill(width, height int, anchor ImageAnchor) *Image {
+	return i.with(imaging.Fill(i.img, width, height, imaging.Anchor(anchor), imaging.Lanczos))
+}
+
+// Crop cuts rect out of the image, clipped to its bounds.
+func (i *Image) Crop(rect image.Rectangle) *Image {
+	return i.with(imaging.Crop(i.img, rect))
+}
+
+// Rotate turns the image counter-clockwise by angle degrees. Corners uncovered by angles other
+// than multiples of 90 are transparent.
+func (i *Image) Rotate(angle float64) *Image {
+	return i.with(imaging.Rotate(i.img, angle, color.Transparent))
+}
+
+// Watermark draws mark over the image at anchor, inset by margin pixels, with opacity from 0 to
+// 1.
+func (i *Image) Watermark(mark image.Image, anchor ImageAnchor, margin int, opacity float64) *Image {
+	return i.with(imaging.Overlay(i.img, mark, anchorPoint(i.img.Bounds(), mark.Bounds(), anchor, margin), opacity))
+}
+
+// WatermarkText draws text over the image at anchor as white lettering with a dark outline,
+// readable on any background. The text is scaled to about a quarter of the image width.
+func (i *Image) WatermarkText(text string, anchor ImageAnchor, opacity float64) *Image {
+	face := basicfont.Face7x13
+	w := font.MeasureString(face, text).Ceil() + 2
+	h := face.Metrics().Height.Ceil() + 2
+	mark := image.NewNRGBA(image.Rect(0, 0, w, h))
+
+	d := &font.Drawer{Dst: mark, Face: face}
+	baseline := fixed.I(1) + face.Metrics().Ascent
+	d.Src = image.NewUniform(color.NRGBA{A: 0xc0})
+	for _, off := range []image.Point{{0, 1}, {2, 1}, {1, 0}, {1, 2}} {
+		d.Dot = fixed.Point26_6{X: fixed.I(off.X), Y: baseline + fixed.I(off.Y-1)}
+		d.DrawString(text)
+	}
+	d.Src = image.White
+	d.Dot = fixed.Point26_6{X: fixed.I(1), Y: baseline}
+	d.DrawString(text)
+
+	// Nearest neighbour keeps the bitmap font crisp when scaled up.
+	scale := max(1, i.Width()/4/w)
+	scaled := imaging.Resize(mark, w*scale, h*scale, imaging.NearestNeighbor)
+	return i.Watermark(scaled, anchor, max(4, i.Width()/50), opacity)
+}
+
+func anchorPoint(bg, fg image.Rectangle, anchor ImageAnchor, margin int) image.Point {
+	minX, maxX := bg.Min.X+margin, bg.Max.X-fg.Dx()-margin
+	minY, maxY := bg.Min.Y+margin, bg.Max.Y-fg.Dy()-margin
+	midX, midY := bg.Min.X+(bg.Dx()-fg.Dx())/2, bg.Min.Y+(bg.Dy()-fg.Dy())/2
+	switch anchor {
+	case AnchorTopLeft:
+		return image.Pt(minX, minY)
+	case AnchorTop:
+		return image.Pt(midX, minY)
+	case AnchorTopRight:
+		return image.Pt(maxX, minY)
+	case AnchorLeft:
+		return image.Pt(minX, midY)
+	case AnchorRight:
+		return image.Pt(maxX, midY)
+	case AnchorBottomLeft:
+		return image.Pt(minX, maxY)
+	case AnchorBottom:
+		return image.Pt(midX, maxY)
+	case AnchorBottomRight:
+		return image.Pt(maxX, maxY)
+	default:
+		return image.Pt(midX, midY)
+	}
+}
+
+// ImageEncodeOptions configures Image.Encode.
+type ImageEncodeOptions struct {
+	// Format is "jpeg", "png", "gif", "bmp" or "tiff". The decoded format is used when empty,
+	// or JPEG for formats that cannot be encoded, such as WebP.
+	Format string
+	// Quality is the JPEG quality from 1 to 100, 85 when 0.
+	Quality int
+}
+
+// Encode writes the image to w.
+func (i *Image) Encode(w io.Writer, opts ...ImageEncodeOptions) error {
+	var o ImageEncodeOptions
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+	if o.Quality <= 0 {
+		o.Quality = 85
+	}
+
+	name := o.Format
+	if name == "" {
+		name = i.format
+	}
+	format, err := imaging.FormatFromExtension(name)
+	if err != nil {
+		if o.Format != "" {
+			return fmt.Errorf("unsupported image format %q", o.Format)
+		}
+		format = imaging.JPEG
+	}
+
+	img := i.img
+	if format == imaging.JPEG {
+		// JPEG has no alpha channel; transparent areas become white rather than black.
+		img = flatten(img)
+	}
+	return imaging.Encode(w, img, format, imaging.JPEGQuality(o.Quality))
+}
+
+// SaveImage encodes img to path, atomically, in the format of its extension. The optional
+// quality applies to JPEG files.
+func (t *Tools) SaveImage(path string, img *Image, quality ...int) error {
+	opts := ImageEncodeOptions{Format: strings.TrimPrefix(filepath.Ext(path), ".")}
+	if len(quality) > 0 {
+		opts.Quality = quality[0]
+	}
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(img.Encode(pw, opts))
+	}()
+	_, err := t.WriteFileAtomic(path, pr, t.fileMode())
+	pr.CloseWithError(err)
+	return err
+}
+
+func flatten(img image.Image) image.Image {
+	if o, ok := img.(interface{ Opaque() bool }); ok && o.Opaque() {
+		return img
+	}
+	dst := image.NewNRGBA(img.Bounds())
+	draw.Draw(dst, dst.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.Draw(dst, dst.Bounds(), img, img.Bounds().Min, draw.Over)
+	return dst
+}
@@ -0,0 +1,181 @@
+package toolkit
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testImage returns a w×h image, red in its left half and blue in its right half.
+func testImage(w, h int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := range h {
+		for x := range w {
+			c := color.NRGBA{R: 0xff, A: 0xff}
+			if x >= w/2 {
+				c = color.NRGBA{B: 0xff, A: 0xff}
+			}
+			img.SetNRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+// withOrientation inserts an EXIF segment with the orientation tag into a JPEG file.
+func withOrientation(jpg []byte, orientation byte) []byte {
+	tiff := []byte{'M', 'M', 0, 42, 0, 0, 0, 8, // big endian header, IFD at offset 8
+		0, 1, // one entry
+		0x01, 0x12, 0, 3, 0, 0, 0, 1, 0, orientation, 0, 0, // orientation, short, count 1
+		0, 0, 0, 0} // no next IFD
+	payload := append([]byte("Exif\x00\x00"), tiff...)
+	segment := append([]byte{0xff, 0xe1, byte((len(payload) + 2) >> 8), byte(len(payload) + 2)}, payload...)
+	return append(append(append([]byte{}, jpg[:2]...), segment...), jpg[2:]...)
+}
+
+func TestTools_DecodeImage(t *testing.T) {
+	var testTools Tools
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, testImage(40, 20), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	img, err := testTools.DecodeImage(bytes.NewReader(withOrientation(buf.Bytes(), 6)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img.Format() != "jpeg" || img.Width() != 20 || img.Height() != 40 {
+		t.Errorf("expected an upright 20x40 jpeg, got %s %dx%d", img.Format(), img.Width(), img.Height())
+	}
+	// Rotated clockwise, the red left half ends up on top.
+	if r, _, b, _ := img.Image().At(10, 5).RGBA(); r < b {
+		t.Error("expected the image to be rotated clockwise")
+	}
+
+	testTools.MaxImagePixels = 500
+	if _, err = testTools.DecodeImage(bytes.NewReader(buf.Bytes())); err == nil {
+		t.Error("expected an image over the pixel limit to fail")
+	}
+	if _, err = testTools.DecodeImage(bytes.NewReader([]byte("not an image"))); err == nil {
+		t.Error("expected garbage to fail")
+	}
+}
+
+// gifHeader returns a GIF file with a w×h logical screen and no image data.
+func gifHeader(w, h uint16) []byte {
+	return []byte{'G', 'I', 'F', '8', '9', 'a', byte(w), byte(w >> 8), byte(h), byte(h >> 8), 0, 0, 0, 0x3b}
+}
+
+func TestTools_DecodeImageCraftedSize(t *testing.T) {
+	var testTools Tools
+
+	// 65535×65535 pixels overflow a 32-bit int to a negative product.
+	_, err := testTools.DecodeImage(bytes.NewReader(gifHeader(0xffff, 0xffff)))
+	if err == nil || !strings.Contains(err.Error(), "exceeds the limit") {
+		t.Errorf("expected the pixel limit to reject the image, got %v", err)
+	}
+	_, err = testTools.DecodeImage(bytes.NewReader(gifHeader(0, 10)))
+	if err == nil || !strings.Contains(err.Error(), "is empty") {
+		t.Errorf("expected an empty image to be rejected, got %v", err)
+	}
+}
+
+var imageTransformTests = []struct {
+	name      string
+	transform func(*Image) *Image
+	width     int
+	height    int
+}{
+	{name: "resize", transform: func(i *Image) *Image { return i.Resize(100, 0) }, width: 100, height: 50},
+	{name: "fit", transform: func(i *Image) *Image { return i.Fit(50, 50) }, width: 50, height: 25},
+	{name: "fit smaller", transform: func(i *Image) *Image { return i.Fit(500, 500) }, width: 200, height: 100},
+	{name: "fill", transform: func(i *Image) *Image { return i.Fill(60, 60, AnchorCenter) }, width: 60, height: 60},
+	{name: "crop", transform: func(i *Image) *Image { return i.Crop(image.Rect(150, 50, 300, 300)) }, width: 50, height: 50},
+	{name: "rotate", transform: func(i *Image) *Image { return i.Rotate(90) }, width: 100, height: 200},
+}
+
+func TestTools_ImageTransforms(t *testing.T) {
+	src := NewImage(testImage(200, 100))
+	for _, test := range imageTransformTests {
+		got := test.transform(src)
+		if got.Width() != test.width || got.Height() != test.height {
+			t.Errorf("%s: expected %dx%d, got %dx%d", test.name, test.width, test.height, got.Width(), got.Height())
+		}
+	}
+	if src.Width() != 200 || src.Height() != 100 {
+		t.Error("expected transforms to leave the source alone")
+	}
+	if r, _, _, _ := src.Fill(60, 60, AnchorLeft).Image().At(30, 30).RGBA(); r != 0xffff {
+		t.Error("expected fill to keep the left, red part")
+	}
+}
+
+func TestTools_ImageWatermark(t *testing.T) {
+	src := NewImage(testImage(200, 100))
+
+	mark := image.NewNRGBA(image.Rect(0, 0, 10, 10))
+	for i := range mark.Pix {
+		mark.Pix[i] = 0xff
+	}
+	got := src.Watermark(mark, AnchorBottomRight, 5, 1).Image()
+	if r, g, b, _ := got.At(190, 90).RGBA(); r != 0xffff || g != 0xffff || b != 0xffff {
+		t.Error("expected the mark in the bottom right corner")
+	}
+	if _, g, _, _ := got.At(180, 80).RGBA(); g != 0 {
+		t.Error("expected the image outside the mark to be unchanged")
+	}
+
+	got = src.WatermarkText("(c) Toolkit", AnchorTopLeft, 1).Image()
+	white := 0
+	for y := range 40 {
+		for x := range 100 {
+			if r, g, b, _ := got.At(x, y).RGBA(); r == 0xffff && g == 0xffff && b == 0xffff {
+				white++
+			}
+		}
+	}
+	if white == 0 {
+		t.Error("expected white lettering in the top left corner")
+	}
+}
+
+func TestTools_ImageEncode(t *testing.T) {
+	var testTools Tools
+	src := NewImage(image.NewNRGBA(image.Rect(0, 0, 10, 10)))
+
+	var buf bytes.Buffer
+	if err := src.Encode(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := png.Decode(&buf); err != nil {
+		t.Errorf("expected PNG by default: %v", err)
+	}
+
+	buf.Reset()
+	if err := src.Encode(&buf, ImageEncodeOptions{Format: "jpg", Quality: 90}); err != nil {
+		t.Fatal(err)
+	}
+	img, err := jpeg.Decode(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r, _, _, _ := img.At(5, 5).RGBA(); r < 0xf000 {
+		t.Error("expected transparency to be flattened to white")
+	}
+	if err = src.Encode(&buf, ImageEncodeOptions{Format: "heic"}); err == nil {
+		t.Error("expected an unsupported format to fail")
+	}
+
+	path := filepath.Join(t.TempDir(), "thumb.jpg")
+	if err = testTools.SaveImage(path, src.Resize(5, 5), 70); err != nil {
+		t.Fatal(err)
+	}
+	saved, err := testTools.OpenImage(path)
+	if err != nil || saved.Format() != "jpeg" || saved.Width() != 5 {
+		t.Errorf("unexpected saved image %v %v", saved, err)
+	}
+}
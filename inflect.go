@@ -0,0 +1,138 @@
+package toolkit
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+type inflectionRule struct {
+	re          *regexp.Regexp
+	replacement string
+}
+
+var uncountableWords = map[string]bool{
+	"equipment": true, "information": true, "rice": true, "money": true, "species": true,
+	"series": true, "fish": true, "sheep": true, "deer": true, "news": true, "metadata": true,
+}
+
+var irregularPlurals = map[string]string{
+	"person": "people", "man": "men", "woman": "women", "child": "children",
+	"tooth": "teeth", "foot": "feet", "mouse": "mice", "goose": "geese", "ox": "oxen",
+	"knife": "knives", "life": "lives", "wife": "wives", "leaf": "leaves", "half": "halves",
+	"wolf": "wolves", "shelf": "shelves", "thief": "thieves", "loaf": "loaves", "calf": "calves",
+	"hero": "heroes", "potato": "potatoes", "tomato": "tomatoes", "echo": "echoes",
+	"datum": "data", "medium": "media", "criterion": "criteria", "movie": "movies",
+}
+
+var irregularSingulars = func() map[string]string {
+	m := make(map[string]string, len(irregularPlurals))
+	for singular, plural := range irregularPlurals {
+		m[plural] = singular
+	}
+	return m
+}()
+
+var pluralRules = []inflectionRule{
+	{regexp.MustCompile(`(quiz)$`), "${1}zes"},
+	{regexp.MustCompile(`(matr|vert|ind)(ix|ex)$`), "${1}ices"},
+	{regexp.MustCompile(`([^aeiouy]|qu)y$`), "${1}ies"},
+	{regexp.MustCompile(`sis$`), "ses"},
+	{regexp.MustCompile(`(alias|status|bus|virus|campus|census)$`), "${1}es"},
+	{regexp.MustCompile(`(s|ss|sh|ch|x|z)$`), "${1}es"},
+	{regexp.MustCompile(`$`), "s"},
+}
+
+var singularRules = []inflectionRule{
+	{regexp.MustCompile(`(quiz)zes$`), "${1}"},
+	{regexp.MustCompile(`(matr)ices$`), "${1}ix"},
+	{regexp.MustCompile(`(vert|ind)ices$`), "${1}ex"},
+	{regexp.MustCompile(`(alias|status|bus|virus|campus|census|gas|atlas|canvas|bonus|lens)es$`), "${1}"},
+	{regexp.MustCompile(`(analy|ba|diagno|parenthe|progno|synop|the)ses$`), "${1}sis"},
+	{regexp.MustCompile(`(x|ch|ss|sh|zz|tz)es$`), "${1}"},
+	{regexp.MustCompile(`([^aeiouy]|qu)ies$`), "${1}y"},
+	{regexp.MustCompile(`ss$`), "ss"},
+	{regexp.MustCompile(`s$`), ""},
+}
+
+// Pluralize returns the English plural form of word. Entries in Tools.PluralOverrides
+// (singular -> plural) take precedence over the built-in rules.
+func (t *Tools) Pluralize(word string) string {
+	if word == "" {
+		return word
+	}
+	lower := strings.ToLower(word)
+
+	for singular, plural := range t.PluralOverrides {
+		if strings.EqualFold(singular, word) {
+			return matchCase(word, plural)
+		}
+		if strings.EqualFold(plural, word) {
+			return word
+		}
+	}
+
+	if uncountableWords[lower] {
+		return word
+	}
+	if plural, ok := irregularPlurals[lower]; ok {
+		return matchCase(word, plural)
+	}
+	if _, ok := irregularSingulars[lower]; ok {
+		return word
+	}
+
+	return matchCase(word, applyInflectionRules(lower, pluralRules))
+}
+
+// Singularize returns the English singular form of word, consulting Tools.PluralOverrides first.
+func (t *Tools) Singularize(word string) string {
+	if word == "" {
+		return word
+	}
+	lower := strings.ToLower(word)
+
+	for singular, plural := range t.PluralOverrides {
+		if strings.EqualFold(plural, word) {
+			return matchCase(word, singular)
+		}
+		if strings.EqualFold(singular, word) {
+			return word
+		}
+	}
+
+	if uncountableWords[lower] {
+		return word
+	}
+	if singular, ok := irregularSingulars[lower]; ok {
+		return matchCase(word, singular)
+	}
+	if _, ok := irregularPlurals[lower]; ok {
+		return word
+	}
+
+	return matchCase(word, applyInflectionRules(lower, singularRules))
+}
+
+func applyInflectionRules(word string, rules []inflectionRule) string {
+	for _, rule := range rules {
+		if rule.re.MatchString(word) {
+			return rule.re.ReplaceAllString(word, rule.replacement)
+		}
+	}
+	return word
+}
+
+// matchCase applies the casing of src (all upper or capitalized) to word.
+func matchCase(src, word string) string {
+	if src == strings.ToUpper(src) && strings.ToLower(src) != src {
+		return strings.ToUpper(word)
+	}
+	r := []rune(src)
+	if unicode.IsUpper(r[0]) {
+		w := []rune(word)
+		w[0] = unicode.ToUpper(w[0])
+		return string(w)
+	}
+	return word
+}
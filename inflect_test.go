@@ -0,0 +1,62 @@
+package toolkit
+
+import "testing"
+
+var inflectTests = []struct {
+	singular string
+	plural   string
+}{
+	{singular: "file", plural: "files"},
+	{singular: "box", plural: "boxes"},
+	{singular: "gas", plural: "gases"},
+	{singular: "bus", plural: "buses"},
+	{singular: "class", plural: "classes"},
+	{singular: "brush", plural: "brushes"},
+	{singular: "church", plural: "churches"},
+	{singular: "waltz", plural: "waltzes"},
+	{singular: "buzz", plural: "buzzes"},
+	{singular: "category", plural: "categories"},
+	{singular: "day", plural: "days"},
+	{singular: "person", plural: "people"},
+	{singular: "child", plural: "children"},
+	{singular: "status", plural: "statuses"},
+	{singular: "analysis", plural: "analyses"},
+	{singular: "matrix", plural: "matrices"},
+	{singular: "index", plural: "indices"},
+	{singular: "knife", plural: "knives"},
+	{singular: "sheep", plural: "sheep"},
+	{singular: "User", plural: "Users"},
+	{singular: "ADDRESS", plural: "ADDRESSES"},
+}
+
+func TestTools_Pluralize(t *testing.T) {
+	var testTools Tools
+	for _, test := range inflectTests {
+		if s := testTools.Pluralize(test.singular); s != test.plural {
+			t.Errorf("%s: expected %s, got %s", test.singular, test.plural, s)
+		}
+	}
+}
+
+func TestTools_Singularize(t *testing.T) {
+	var testTools Tools
+	for _, test := range inflectTests {
+		if s := testTools.Singularize(test.plural); s != test.singular {
+			t.Errorf("%s: expected %s, got %s", test.plural, test.singular, s)
+		}
+	}
+}
+
+func TestTools_PluralOverrides(t *testing.T) {
+	testTools := Tools{PluralOverrides: map[string]string{"cactus": "cacti"}}
+
+	if s := testTools.Pluralize("cactus"); s != "cacti" {
+		t.Error("override not applied when pluralizing:", s)
+	}
+	if s := testTools.Singularize("Cacti"); s != "Cactus" {
+		t.Error("override not applied when singularizing:", s)
+	}
+	if s := testTools.PluralCount(3, "cactus"); s != "3 cacti" {
+		t.Error("override not applied in PluralCount:", s)
+	}
+}
@@ -0,0 +1,182 @@
+package toolkit
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// UploadDirReport summarises the state of an upload directory; it is meant to be returned
+// as is through WriteJSON from an admin endpoint.
+type UploadDirReport struct {
+	Path               string               `json:"path"`
+	GeneratedAt        time.Time            `json:"generated_at"`
+	Files              int                  `json:"files"`
+	Bytes              int64                `json:"bytes"`
+	ByType             map[string]FileStats `json:"by_type"`
+	ByAge              []AgeBucketStats     `json:"by_age"`
+	OrphanedChunks     []string             `json:"orphaned_chunks"`
+	ChecksumFailures   []string             `json:"checksum_failures"`
+	PermissionProblems []string             `json:"permission_problems"`
+}
+
+// FileStats counts files and their total size.
+type FileStats struct {
+	Files int   `json:"files"`
+	Bytes int64 `json:"bytes"`
+}
+
+// AgeBucketStats counts the files last modified within an age range.
+type AgeBucketStats struct {
+	Label string `json:"label"`
+	FileStats
+}
+
+var ageBuckets = []struct {
+	label  string
+	maxAge time.Duration
+}{
+	{"< 1 day", 24 * time.Hour},
+	{"1-7 days", 7 * 24 * time.Hour},
+	{"7-30 days", 30 * 24 * time.Hour},
+	{"> 30 days", 0},
+}
+
+var (
+	atomicTempFile = regexp.MustCompile(`^\..+\.tmp-\d+$`)
+	splitPartFile  = regexp.MustCompile(`^(.+)\.part\d{4}$`)
+)
+
+// staleChunkAge is how old an unfinished temporary file must be before it is reported as orphaned.
+const staleChunkAge = time.Hour
+
+var checksumSidecars = map[string]HashAlgorithm{
+	".md5":    HashMD5,
+	".sha1":   HashSHA1,
+	".sha256": HashSHA256,
+	".sha512": HashSHA512,
+}
+
+// InspectUploadDir walks dir and reports file counts and sizes by MIME type and age, leftover
+// temporary chunks, files not matching their checksum sidecar (file.ext.sha256 and similar, in
+// sha256sum format or a bare digest), and permission problems such as unreadable or world
+// writable entries.
+func (t *Tools) InspectUploadDir(dir string) (*UploadDirReport, error) {
+	now := time.Now()
+	report := &UploadDirReport{
+		Path:               dir,
+		GeneratedAt:        now,
+		ByType:             make(map[string]FileStats),
+		ByAge:              make([]AgeBucketStats, len(ageBuckets)),
+		OrphanedChunks:     []string{},
+		ChecksumFailures:   []string{},
+		PermissionProblems: []string{},
+	}
+	for i, b := range ageBuckets {
+		report.ByAge[i].Label = b.label
+	}
+
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrPermission) {
+				report.PermissionProblems = append(report.PermissionProblems, path)
+				if d != nil && d.IsDir() {
+					return fs.SkipDir
+				}
+				return nil
+			}
+			return err
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		if info.Mode().Perm()&0002 != 0 && d.Type()&fs.ModeSymlink == 0 {
+			report.PermissionProblems = append(report.PermissionProblems, path)
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		name := d.Name()
+		if atomicTempFile.MatchString(name) && now.Sub(info.ModTime()) > staleChunkAge {
+			report.OrphanedChunks = append(report.OrphanedChunks, path)
+		}
+		if m := splitPartFile.FindStringSubmatch(name); m != nil {
+			manifest := filepath.Join(filepath.Dir(path), m[1]+".manifest.json")
+			if _, err := os.Stat(manifest); errors.Is(err, fs.ErrNotExist) {
+				report.OrphanedChunks = append(report.OrphanedChunks, path)
+			}
+		}
+
+		if algo, ok := checksumSidecars[filepath.Ext(name)]; ok {
+			if failed := t.checkSidecar(path, algo); failed != "" {
+				report.ChecksumFailures = append(report.ChecksumFailures, failed)
+			}
+		}
+
+		if f, err := os.Open(path); err != nil {
+			if errors.Is(err, fs.ErrPermission) {
+				report.PermissionProblems = append(report.PermissionProblems, path)
+			}
+		} else {
+			f.Close()
+		}
+
+		report.Files++
+		report.Bytes += info.Size()
+
+		typ := t.MIMEByExtension(filepath.Ext(name))
+		if i := strings.IndexByte(typ, ';'); i >= 0 {
+			typ = typ[:i]
+		}
+		stats := report.ByType[typ]
+		stats.Files++
+		stats.Bytes += info.Size()
+		report.ByType[typ] = stats
+
+		age := now.Sub(info.ModTime())
+		for i, b := range ageBuckets {
+			if b.maxAge == 0 || age < b.maxAge {
+				report.ByAge[i].Files++
+				report.ByAge[i].Bytes += info.Size()
+				break
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+// checkSidecar verifies the file a checksum sidecar belongs to and returns its path when the
+// digest does not match, or "" when it does or the file is missing.
+func (t *Tools) checkSidecar(sidecar string, algo HashAlgorithm) string {
+	target := strings.TrimSuffix(sidecar, filepath.Ext(sidecar))
+	if _, err := os.Stat(target); err != nil {
+		return ""
+	}
+
+	data, err := os.ReadFile(sidecar)
+	if err != nil {
+		return target
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) == 0 {
+		return target
+	}
+
+	sum, err := t.FileChecksum(target, algo)
+	if err != nil || !strings.EqualFold(sum, fields[0]) {
+		return target
+	}
+	return ""
+}
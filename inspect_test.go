@@ -0,0 +1,76 @@
+package toolkit
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestTools_InspectUploadDir(t *testing.T) {
+	var testTools Tools
+	dir := t.TempDir()
+	write := func(name string, data string, age time.Duration) string {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		_ = os.MkdirAll(filepath.Dir(p), 0755)
+		_ = os.WriteFile(p, []byte(data), 0644)
+		modTime := time.Now().Add(-age)
+		_ = os.Chtimes(p, modTime, modTime)
+		return p
+	}
+
+	write("a.png", "png", time.Minute)
+	write("docs/b.pdf", "pdf", 3*24*time.Hour)
+	ok := write("docs/c.txt", "hello", 40*24*time.Hour)
+	write("docs/c.txt.sha256", "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824  c.txt\n", time.Minute)
+	bad := write("d.txt", "tampered", time.Minute)
+	write("d.txt.sha256", "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824", time.Minute)
+	staleTmp := write(".e.png.tmp-12345", "x", 2*time.Hour)
+	write(".f.png.tmp-67890", "x", time.Minute)
+	orphanPart := write("export.bin.part0001", "x", time.Minute)
+	worldWritable := write("g.txt", "x", time.Minute)
+	_ = os.Chmod(worldWritable, 0666)
+
+	report, err := testTools.InspectUploadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if report.Files != 10 {
+		t.Errorf("expected 10 files, got %d", report.Files)
+	}
+	if report.ByType["image/png"].Files != 1 || report.ByType["application/pdf"].Files != 1 {
+		t.Errorf("unexpected type stats %+v", report.ByType)
+	}
+	if report.ByAge[0].Files != 8 || report.ByAge[1].Files != 1 || report.ByAge[3].Files != 1 {
+		t.Errorf("unexpected age stats %+v", report.ByAge)
+	}
+	if len(report.ChecksumFailures) != 1 || report.ChecksumFailures[0] != bad {
+		t.Errorf("unexpected checksum failures %v (ok file %s)", report.ChecksumFailures, ok)
+	}
+	if len(report.OrphanedChunks) != 2 || report.OrphanedChunks[0] != staleTmp && report.OrphanedChunks[1] != staleTmp {
+		t.Errorf("unexpected orphaned chunks %v", report.OrphanedChunks)
+	}
+	found := false
+	for _, c := range report.OrphanedChunks {
+		found = found || c == orphanPart
+	}
+	if !found {
+		t.Error("orphaned split part not reported")
+	}
+	if len(report.PermissionProblems) != 1 || report.PermissionProblems[0] != worldWritable {
+		t.Errorf("unexpected permission problems %v", report.PermissionProblems)
+	}
+
+	rr := httptest.NewRecorder()
+	if err = testTools.WriteJSON(rr, http.StatusOK, report); err != nil {
+		t.Fatal(err)
+	}
+	var decoded map[string]any
+	if err = json.Unmarshal(rr.Body.Bytes(), &decoded); err != nil || decoded["by_type"] == nil {
+		t.Error("report did not serialize as expected:", err)
+	}
+}
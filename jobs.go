@@ -0,0 +1,168 @@
+package toolkit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrQueueFull is returned by Enqueue when the job queue has no room left.
+	ErrQueueFull = errors.New("job queue is full")
+	// ErrJobRunnerClosed is returned by Enqueue once Shutdown was called.
+	ErrJobRunnerClosed = errors.New("job runner is shut down")
+)
+
+// Job is a unit of background work. Its context is cancelled when Shutdown gives up waiting.
+type Job func(ctx context.Context) error
+
+// JobRunnerOptions configures NewJobRunner.
+type JobRunnerOptions struct {
+	// Name labels the runner's metrics and log records, "default" when empty.
+	Name string
+	// Workers is the number of jobs run concurrently, 4 when 0.
+	Workers int
+	// QueueSize is the number of jobs waiting for a worker, 100 when 0.
+	QueueSize int
+	// MaxAttempts is how often a failing job is tried, 1 when 0. Jobs return an error wrapped
+	// with Permanent to fail without retries.
+	MaxAttempts int
+	// Backoff is the delay before the second attempt, doubled for every further one; 1s when 0.
+	Backoff time.Duration
+}
+
+// JobRunner runs jobs on a bounded pool of workers, retrying failed ones and isolating panics.
+type JobRunner struct {
+	opts    JobRunnerOptions
+	tools   *Tools
+	queue   chan Job
+	ctx     context.Context
+	cancel  context.CancelFunc
+	mu      sync.RWMutex
+	closed  bool
+	workers sync.WaitGroup
+}
+
+// NewJobRunner starts a runner's workers. Stop it with Shutdown.
+func (t *Tools) NewJobRunner(opts JobRunnerOptions) *JobRunner {
+	if opts.Name == "" {
+		opts.Name = "default"
+	}
+	if opts.Workers <= 0 {
+		opts.Workers = 4
+	}
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = 100
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = 1
+	}
+	if opts.Backoff <= 0 {
+		opts.Backoff = time.Second
+	}
+
+	j := &JobRunner{opts: opts, tools: t, queue: make(chan Job, opts.QueueSize)}
+	j.ctx, j.cancel = context.WithCancel(context.Background())
+	for i := 0; i < opts.Workers; i++ {
+		j.workers.Add(1)
+		go j.work()
+	}
+	return j
+}
+
+// Enqueue queues job without blocking, failing with ErrQueueFull when the queue is full.
+func (j *JobRunner) Enqueue(job Job) error {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+	if j.closed {
+		return ErrJobRunnerClosed
+	}
+	select {
+	case j.queue <- job:
+		j.tools.Metrics.setJobQueueDepth(j.opts.Name, len(j.queue))
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+// QueueDepth returns the number of jobs waiting for a worker.
+func (j *JobRunner) QueueDepth() int {
+	return len(j.queue)
+}
+
+// Shutdown stops accepting jobs and waits for the queued and running ones to finish. When ctx
+// ends first, the context of running jobs is cancelled, jobs still queued are dropped and
+// ctx.Err() is returned.
+func (j *JobRunner) Shutdown(ctx context.Context) error {
+	j.mu.Lock()
+	if !j.closed {
+		j.closed = true
+		close(j.queue)
+	}
+	j.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		j.workers.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		j.cancel()
+		return nil
+	case <-ctx.Done():
+		j.cancel()
+		<-done
+		return ctx.Err()
+	}
+}
+
+func (j *JobRunner) work() {
+	defer j.workers.Done()
+	for job := range j.queue {
+		j.tools.Metrics.setJobQueueDepth(j.opts.Name, len(j.queue))
+		if j.ctx.Err() != nil {
+			j.tools.Metrics.observeJob(j.opts.Name, "dropped")
+			continue
+		}
+		j.run(job)
+	}
+}
+
+func (j *JobRunner) run(job Job) {
+	attempts := 0
+	err := Retry(j.ctx, RetryPolicy{
+		MaxAttempts: j.opts.MaxAttempts,
+		Backoff:     ExponentialBackoff(j.opts.Backoff, 0),
+		OnRetry: func(int, error, time.Duration) {
+			j.tools.Metrics.observeJob(j.opts.Name, "retry")
+		},
+	}, func() error {
+		attempts++
+		return j.attempt(job)
+	})
+	if err != nil {
+		j.tools.Metrics.observeJob(j.opts.Name, "error")
+		j.tools.logger().Error("job failed", slog.String("runner", j.opts.Name),
+			slog.Int("attempts", attempts), slog.String("error", err.Error()))
+		return
+	}
+	j.tools.Metrics.observeJob(j.opts.Name, "ok")
+}
+
+func (j *JobRunner) attempt(job Job) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			j.tools.logger().Error("job panicked", slog.String("runner", j.opts.Name),
+				slog.Any("panic", p), slog.String("stack", string(debug.Stack())))
+			err = fmt.Errorf("job panicked: %v", p)
+		}
+	}()
+	return job(j.ctx)
+}
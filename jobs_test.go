@@ -0,0 +1,91 @@
+package toolkit
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestTools_JobRunner(t *testing.T) {
+	testTools := Tools{Logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+	m, err := testTools.NewMetrics(MetricsOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	testTools.Metrics = m
+
+	j := testTools.NewJobRunner(JobRunnerOptions{Workers: 2, MaxAttempts: 3, Backoff: time.Millisecond})
+
+	var done, flaky atomic.Int32
+	for i := 0; i < 10; i++ {
+		if err := j.Enqueue(func(ctx context.Context) error {
+			done.Add(1)
+			return nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_ = j.Enqueue(func(ctx context.Context) error {
+		if flaky.Add(1) < 3 {
+			return errors.New("try again")
+		}
+		return nil
+	})
+	_ = j.Enqueue(func(ctx context.Context) error { panic("boom") })
+
+	if err := j.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if done.Load() != 10 || flaky.Load() != 3 {
+		t.Errorf("expected all jobs to run, done %d, flaky attempts %d", done.Load(), flaky.Load())
+	}
+	if err := j.Enqueue(func(context.Context) error { return nil }); !errors.Is(err, ErrJobRunnerClosed) {
+		t.Errorf("expected ErrJobRunnerClosed, got %v", err)
+	}
+
+	if n := testutil.ToFloat64(m.jobs.WithLabelValues("default", "ok")); n != 11 {
+		t.Errorf("expected 11 successful jobs, got %v", n)
+	}
+	if n := testutil.ToFloat64(m.jobs.WithLabelValues("default", "retry")); n != 4 {
+		t.Errorf("expected 4 retries, got %v", n)
+	}
+	if n := testutil.ToFloat64(m.jobs.WithLabelValues("default", "error")); n != 1 {
+		t.Errorf("expected the panicking job to fail, got %v", n)
+	}
+	if n := testutil.ToFloat64(m.jobQueue.WithLabelValues("default")); n != 0 {
+		t.Errorf("expected an empty queue, got %v", n)
+	}
+}
+
+func TestTools_JobRunnerShutdownTimeout(t *testing.T) {
+	testTools := Tools{Logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+	j := testTools.NewJobRunner(JobRunnerOptions{Workers: 1, QueueSize: 1})
+
+	cancelled := make(chan struct{})
+	_ = j.Enqueue(func(ctx context.Context) error {
+		<-ctx.Done()
+		close(cancelled)
+		return ctx.Err()
+	})
+	_ = j.Enqueue(func(context.Context) error { return nil })
+	if err := j.Enqueue(func(context.Context) error { return nil }); !errors.Is(err, ErrQueueFull) {
+		t.Errorf("expected ErrQueueFull, got %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := j.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the shutdown to time out, got %v", err)
+	}
+	select {
+	case <-cancelled:
+	default:
+		t.Error("running job was not cancelled")
+	}
+}
@@ -0,0 +1,69 @@
+package toolkit
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// ReadJSONStream reads a request body holding a JSON array one element at a time, so large
+// batches are never held in memory as a whole. fn is called for every element with the decoder
+// positioned at it and must decode it, typically with dec.Decode(&record). Unknown fields are
+// rejected as by ReadJSON. The body is limited to MaxJSONStreamSize.
+//
+// An error returned by fn, or met decoding an element, stops the reading and is returned with the
+// index of the element; elements before it have been handled.
+func (t *Tools) ReadJSONStream(w http.ResponseWriter, r *http.Request, fn func(dec *json.Decoder) error) error {
+	ctx := r.Context()
+	body := contextBody(ctx, r.Body)
+	if t.MaxJSONStreamSize > 0 {
+		body = http.MaxBytesReader(w, body, t.MaxJSONStreamSize)
+	}
+	r.Body = body
+	dec := json.NewDecoder(body)
+	if !t.AllowUnknownFields {
+		dec.DisallowUnknownFields()
+	}
+
+	decodeError := func(err error) error {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return jsonDecodeError(err)
+	}
+
+	tok, err := dec.Token()
+	if err != nil {
+		return decodeError(err)
+	}
+	if tok != json.Delim('[') {
+		return errors.New("body must contain a JSON array")
+	}
+
+	for i := 0; dec.More(); i++ {
+		offset := dec.InputOffset()
+		if err := fn(dec); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return fmt.Errorf("element %d: %w", i, jsonDecodeError(err))
+		}
+		if dec.InputOffset() == offset {
+			return fmt.Errorf("element %d was not decoded", i)
+		}
+	}
+
+	if _, err := dec.Token(); err != nil {
+		// Older Go releases report an array cut short after an element as io.EOF.
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return decodeError(err)
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return errors.New("body must contain only one JSON value")
+	}
+	return nil
+}
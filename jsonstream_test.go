@@ -0,0 +1,90 @@
+package toolkit
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+var jsonStreamTests = []struct {
+	name          string
+	body          string
+	maxSize       int64
+	allowUnknown  bool
+	expected      []string
+	errorContains string
+}{
+	{name: "array", body: `[{"foo": "a"}, {"foo": "b"}, {"foo": "c"}]`, expected: []string{"a", "b", "c"}},
+	{name: "empty array", body: ` [ ] `},
+	{name: "empty body", body: ``, errorContains: "must not be empty"},
+	{name: "object", body: `{"foo": "a"}`, errorContains: "must contain a JSON array"},
+	{name: "bad element", body: `[{"foo": "a"}, {"foo": 1}]`, expected: []string{"a"}, errorContains: `element 1: body contains incorrect JSON type for field "foo"`},
+	{name: "unknown field", body: `[{"foo": "a", "bar": 1}]`, errorContains: `element 0: body contains unknown key`},
+	{name: "allow unknown field", body: `[{"foo": "a", "bar": 1}]`, allowUnknown: true, expected: []string{"a"}},
+	{name: "truncated", body: `[{"foo": "a"}, {"foo"`, expected: []string{"a"}, errorContains: "element 1: body contains badly-formed JSON"},
+	{name: "unterminated", body: `[{"foo": "a"}`, expected: []string{"a"}, errorContains: "badly-formed JSON"},
+	{name: "trailing value", body: `[{"foo": "a"}] []`, expected: []string{"a"}, errorContains: "only one JSON value"},
+	{name: "too large", body: `[{"foo": "a"}, {"foo": "b"}, {"foo": "c"}]`, maxSize: 20, expected: []string{"a"}, errorContains: "must not be larger than 20 bytes"},
+}
+
+func TestTools_ReadJSONStream(t *testing.T) {
+	for _, test := range jsonStreamTests {
+		var testTools Tools
+		testTools.MaxJSONStreamSize = test.maxSize
+		testTools.AllowUnknownFields = test.allowUnknown
+
+		var got []string
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(test.body))
+		err := testTools.ReadJSONStream(httptest.NewRecorder(), req, func(dec *json.Decoder) error {
+			var record struct {
+				Foo string `json:"foo"`
+			}
+			if err := dec.Decode(&record); err != nil {
+				return err
+			}
+			got = append(got, record.Foo)
+			return nil
+		})
+
+		if test.errorContains == "" && err != nil {
+			t.Errorf("%s: unexpected error %v", test.name, err)
+		}
+		if test.errorContains != "" && (err == nil || !strings.Contains(err.Error(), test.errorContains)) {
+			t.Errorf("%s: expected error containing %q, got %v", test.name, test.errorContains, err)
+		}
+		if strings.Join(got, ",") != strings.Join(test.expected, ",") {
+			t.Errorf("%s: expected elements %v, got %v", test.name, test.expected, got)
+		}
+	}
+}
+
+func TestTools_ReadJSONStreamCallback(t *testing.T) {
+	var testTools Tools
+
+	stop := errors.New("stop")
+	calls := 0
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`[1, 2, 3]`))
+	err := testTools.ReadJSONStream(httptest.NewRecorder(), req, func(dec *json.Decoder) error {
+		calls++
+		var n int
+		if err := dec.Decode(&n); err != nil {
+			return err
+		}
+		if n == 2 {
+			return stop
+		}
+		return nil
+	})
+	if !errors.Is(err, stop) || calls != 2 {
+		t.Errorf("expected to stop at the second element, got %v after %d calls", err, calls)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`[1, 2]`))
+	err = testTools.ReadJSONStream(httptest.NewRecorder(), req, func(dec *json.Decoder) error { return nil })
+	if err == nil || !strings.Contains(err.Error(), "element 0 was not decoded") {
+		t.Errorf("expected an error for an element left undecoded, got %v", err)
+	}
+}
@@ -0,0 +1,290 @@
+package toolkit
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	_ "crypto/sha256" // SHA-256 for the HS256, RS256 and ES256 algorithms
+	_ "crypto/sha512" // SHA-384 and SHA-512 for the others
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrInvalidToken is returned by VerifyJWT for tokens that are malformed, not signed with
+	// the configured key and algorithm, not valid yet, or not meant for this issuer and audience.
+	ErrInvalidToken = errors.New("invalid token")
+	// ErrTokenExpired is returned by VerifyJWT for tokens past their exp.
+	ErrTokenExpired = errors.New("token has expired")
+)
+
+// JWTOptions configures GenerateJWT, VerifyJWT and AuthJWT.
+type JWTOptions struct {
+	// Algorithm is one of HS256, HS384 and HS512, signing with Secret, RS256, RS384 and RS512,
+	// signing with an RSA key, or ES256, ES384 and ES512, signing with an ECDSA key. Tokens
+	// with another algorithm are rejected.
+	Algorithm string
+	// Secret is the key of the HS algorithms, at least 32 bytes.
+	Secret []byte
+	// PrivateKey signs tokens with the RS and ES algorithms, an *rsa.PrivateKey or an
+	// *ecdsa.PrivateKey. Services only verifying tokens can leave it nil.
+	PrivateKey crypto.Signer
+	// PublicKey verifies tokens with the RS and ES algorithms, the public key of PrivateKey
+	// when nil.
+	PublicKey crypto.PublicKey
+	// Issuer and Audience, when set, are added to generated tokens and required in verified ones.
+	Issuer   string
+	Audience string
+	// Leeway tolerates clock skew when checking exp and nbf.
+	Leeway time.Duration
+}
+
+// JWTClaims are the claims of a JSON Web Token. Numbers are float64, as decoded by encoding/json.
+type JWTClaims map[string]any
+
+// Subject returns the sub claim.
+func (c JWTClaims) Subject() string {
+	sub, _ := c["sub"].(string)
+	return sub
+}
+
+// time returns the NumericDate claim name.
+func (c JWTClaims) time(name string) (time.Time, bool) {
+	n, ok := c[name].(float64)
+	if !ok {
+		return time.Time{}, false
+	}
+	return time.Unix(int64(n), 0), true
+}
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Typ string `json:"typ,omitempty"`
+}
+
+// GenerateJWT signs claims as a JSON Web Token with the key and algorithm of Tools.JWT, adding
+// iat, exp after ttl unless ttl is 0, and the configured issuer and audience. claims is not
+// modified.
+func (t *Tools) GenerateJWT(claims JWTClaims, ttl time.Duration) (string, error) {
+	if err := t.checkJWTOptions(); err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	payload := make(JWTClaims, len(claims)+4)
+	for k, v := range claims {
+		payload[k] = v
+	}
+	payload["iat"] = now.Unix()
+	if ttl > 0 {
+		payload["exp"] = now.Add(ttl).Unix()
+	}
+	if t.JWT.Issuer != "" {
+		payload["iss"] = t.JWT.Issuer
+	}
+	if t.JWT.Audience != "" {
+		payload["aud"] = t.JWT.Audience
+	}
+
+	header, err := json.Marshal(jwtHeader{Alg: t.JWT.Algorithm, Typ: "JWT"})
+	if err != nil {
+		return "", err
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(body)
+
+	sig, err := t.signJWT(signingInput)
+	if err != nil {
+		return "", err
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(sig), nil
+}
+
+// VerifyJWT checks the signature, algorithm, exp, nbf, issuer and audience of token and returns
+// its claims. It fails with ErrTokenExpired for expired tokens and ErrInvalidToken otherwise.
+func (t *Tools) VerifyJWT(token string) (JWTClaims, error) {
+	if err := t.checkJWTOptions(); err != nil {
+		return nil, err
+	}
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrInvalidToken
+	}
+	var header jwtHeader
+	if err := decodeJWTPart(parts[0], &header); err != nil || header.Alg != t.JWT.Algorithm {
+		return nil, ErrInvalidToken
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil || !t.verifyJWTSignature(parts[0]+"."+parts[1], sig) {
+		return nil, ErrInvalidToken
+	}
+
+	var claims JWTClaims
+	if err = decodeJWTPart(parts[1], &claims); err != nil || claims == nil {
+		return nil, ErrInvalidToken
+	}
+
+	now := time.Now()
+	if exp, ok := claims.time("exp"); ok && !now.Before(exp.Add(t.JWT.Leeway)) {
+		return nil, ErrTokenExpired
+	} else if !ok && claims["exp"] != nil {
+		return nil, ErrInvalidToken
+	}
+	if nbf, ok := claims.time("nbf"); ok && now.Add(t.JWT.Leeway).Before(nbf) {
+		return nil, ErrInvalidToken
+	}
+	if t.JWT.Issuer != "" && claims["iss"] != t.JWT.Issuer {
+		return nil, ErrInvalidToken
+	}
+	if t.JWT.Audience != "" && !jwtAudience(claims["aud"], t.JWT.Audience) {
+		return nil, ErrInvalidToken
+	}
+	return claims, nil
+}
+
+type jwtClaimsKey struct{}
+
+// AuthJWT lets requests with a valid bearer token through to next, with the token's claims
+// available through JWTClaimsFromContext. Other requests get a 401 JSON error.
+func (t *Tools) AuthJWT(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || strings.TrimSpace(token) == "" {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			_ = t.ErrorJSON(w, errors.New("a bearer token is required"), http.StatusUnauthorized)
+			return
+		}
+
+		claims, err := t.VerifyJWT(strings.TrimSpace(token))
+		if err != nil {
+			w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+			if !errors.Is(err, ErrInvalidToken) && !errors.Is(err, ErrTokenExpired) {
+				err = ErrInvalidToken
+			}
+			_ = t.ErrorJSON(w, err, http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), jwtClaimsKey{}, claims)))
+	})
+}
+
+// JWTClaimsFromContext returns the claims of the token accepted by AuthJWT.
+func JWTClaimsFromContext(ctx context.Context) (JWTClaims, bool) {
+	claims, ok := ctx.Value(jwtClaimsKey{}).(JWTClaims)
+	return claims, ok
+}
+
+func (t *Tools) checkJWTOptions() error {
+	if t.JWT == nil {
+		return errors.New("jwt: Tools.JWT is not configured")
+	}
+	if _, ok := jwtHashes[t.JWT.Algorithm]; !ok {
+		return fmt.Errorf("jwt: unsupported algorithm %q", t.JWT.Algorithm)
+	}
+	if strings.HasPrefix(t.JWT.Algorithm, "HS") && len(t.JWT.Secret) < 32 {
+		return errors.New("jwt: secret should be at least 32 bytes")
+	}
+	return nil
+}
+
+var jwtHashes = map[string]crypto.Hash{
+	"HS256": crypto.SHA256, "HS384": crypto.SHA384, "HS512": crypto.SHA512,
+	"RS256": crypto.SHA256, "RS384": crypto.SHA384, "RS512": crypto.SHA512,
+	"ES256": crypto.SHA256, "ES384": crypto.SHA384, "ES512": crypto.SHA512,
+}
+
+func (t *Tools) signJWT(signingInput string) ([]byte, error) {
+	alg := t.JWT.Algorithm
+	hash := jwtHashes[alg]
+	if strings.HasPrefix(alg, "HS") {
+		mac := hmac.New(hash.New, t.JWT.Secret)
+		mac.Write([]byte(signingInput))
+		return mac.Sum(nil), nil
+	}
+
+	h := hash.New()
+	h.Write([]byte(signingInput))
+	digest := h.Sum(nil)
+	switch key := t.JWT.PrivateKey.(type) {
+	case *rsa.PrivateKey:
+		if strings.HasPrefix(alg, "RS") {
+			return rsa.SignPKCS1v15(rand.Reader, key, hash, digest)
+		}
+	case *ecdsa.PrivateKey:
+		if strings.HasPrefix(alg, "ES") {
+			r, s, err := ecdsa.Sign(rand.Reader, key, digest)
+			if err != nil {
+				return nil, err
+			}
+			size := (key.Curve.Params().BitSize + 7) / 8
+			sig := make([]byte, 2*size)
+			r.FillBytes(sig[:size])
+			s.FillBytes(sig[size:])
+			return sig, nil
+		}
+	}
+	return nil, fmt.Errorf("jwt: %s needs a matching private key, not %T", alg, t.JWT.PrivateKey)
+}
+
+func (t *Tools) verifyJWTSignature(signingInput string, sig []byte) bool {
+	alg := t.JWT.Algorithm
+	hash := jwtHashes[alg]
+	if strings.HasPrefix(alg, "HS") {
+		mac := hmac.New(hash.New, t.JWT.Secret)
+		mac.Write([]byte(signingInput))
+		return hmac.Equal(sig, mac.Sum(nil))
+	}
+
+	h := hash.New()
+	h.Write([]byte(signingInput))
+	digest := h.Sum(nil)
+	publicKey := t.JWT.PublicKey
+	if publicKey == nil && t.JWT.PrivateKey != nil {
+		publicKey = t.JWT.PrivateKey.Public()
+	}
+	switch key := publicKey.(type) {
+	case *rsa.PublicKey:
+		return strings.HasPrefix(alg, "RS") && rsa.VerifyPKCS1v15(key, hash, digest, sig) == nil
+	case *ecdsa.PublicKey:
+		size := (key.Curve.Params().BitSize + 7) / 8
+		if !strings.HasPrefix(alg, "ES") || len(sig) != 2*size {
+			return false
+		}
+		r, s := new(big.Int).SetBytes(sig[:size]), new(big.Int).SetBytes(sig[size:])
+		return ecdsa.Verify(key, digest, r, s)
+	}
+	return false
+}
+
+func decodeJWTPart(part string, v any) error {
+	b, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+// jwtAudience reports whether the aud claim, a string or an array of them, contains audience.
+func jwtAudience(aud any, audience string) bool {
+	switch aud := aud.(type) {
+	case string:
+		return aud == audience
+	case []any:
+		return slices.Contains(aud, any(audience))
+	}
+	return false
+}
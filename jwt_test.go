@@ -0,0 +1,123 @@
+package toolkit
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTools_JWTAlgorithms(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	ecKey384, _ := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	secret := []byte(strings.Repeat("s", 32))
+
+	for _, opts := range []JWTOptions{
+		{Algorithm: "HS256", Secret: secret},
+		{Algorithm: "HS512", Secret: secret},
+		{Algorithm: "RS256", PrivateKey: rsaKey},
+		{Algorithm: "ES256", PrivateKey: ecKey},
+		{Algorithm: "ES384", PrivateKey: ecKey384},
+	} {
+		testTools := Tools{JWT: &opts}
+		token, err := testTools.GenerateJWT(JWTClaims{"sub": "user-1", "role": "admin"}, time.Minute)
+		if err != nil {
+			t.Fatalf("%s: %s", opts.Algorithm, err)
+		}
+		claims, err := testTools.VerifyJWT(token)
+		if err != nil || claims.Subject() != "user-1" || claims["role"] != "admin" {
+			t.Errorf("%s: unexpected claims %v, %v", opts.Algorithm, claims, err)
+		}
+
+		// A token verified with the public key only.
+		verifier := Tools{JWT: &JWTOptions{Algorithm: opts.Algorithm, Secret: opts.Secret}}
+		if opts.PrivateKey != nil {
+			verifier.JWT.PublicKey = opts.PrivateKey.Public()
+		}
+		if _, err = verifier.VerifyJWT(token); err != nil {
+			t.Errorf("%s: verifying with the public key failed: %s", opts.Algorithm, err)
+		}
+	}
+}
+
+func TestTools_VerifyJWTRejects(t *testing.T) {
+	secret := []byte(strings.Repeat("s", 32))
+	testTools := Tools{JWT: &JWTOptions{Algorithm: "HS256", Secret: secret, Issuer: "toolkit", Audience: "api"}}
+	token, err := testTools.GenerateJWT(JWTClaims{"sub": "user-1"}, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := strings.Split(token, ".")
+
+	expired, _ := testTools.GenerateJWT(JWTClaims{"exp": time.Now().Add(-time.Hour).Unix()}, 0)
+	notYet, _ := testTools.GenerateJWT(JWTClaims{"nbf": time.Now().Add(time.Hour).Unix()}, time.Minute)
+	other := Tools{JWT: &JWTOptions{Algorithm: "HS256", Secret: []byte(strings.Repeat("o", 32)), Issuer: "toolkit", Audience: "api"}}
+	forged, _ := other.GenerateJWT(JWTClaims{"sub": "user-1"}, time.Minute)
+	otherAudience := Tools{JWT: &JWTOptions{Algorithm: "HS256", Secret: secret, Issuer: "toolkit", Audience: "admin"}}
+	wrongAudience, _ := otherAudience.GenerateJWT(JWTClaims{"sub": "user-1"}, time.Minute)
+	none := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none"}`)) + "." + parts[1] + "."
+
+	tests := map[string]struct {
+		token string
+		err   error
+	}{
+		"expired":        {expired, ErrTokenExpired},
+		"not yet valid":  {notYet, ErrInvalidToken},
+		"forged":         {forged, ErrInvalidToken},
+		"wrong audience": {wrongAudience, ErrInvalidToken},
+		"alg none":       {none, ErrInvalidToken},
+		"tampered":       {parts[0] + "." + base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"admin"}`)) + "." + parts[2], ErrInvalidToken},
+		"malformed":      {"not-a-token", ErrInvalidToken},
+	}
+	for name, test := range tests {
+		if _, err := testTools.VerifyJWT(test.token); !errors.Is(err, test.err) {
+			t.Errorf("%s: expected %v, got %v", name, test.err, err)
+		}
+	}
+
+	if _, err = (&Tools{}).GenerateJWT(nil, time.Minute); err == nil {
+		t.Error("expected an error without JWT options")
+	}
+	short := Tools{JWT: &JWTOptions{Algorithm: "HS256", Secret: []byte("short")}}
+	if _, err = short.GenerateJWT(nil, time.Minute); err == nil {
+		t.Error("expected an error for a short secret")
+	}
+}
+
+func TestTools_AuthJWT(t *testing.T) {
+	testTools := Tools{JWT: &JWTOptions{Algorithm: "HS256", Secret: []byte(strings.Repeat("s", 32))}}
+	token, _ := testTools.GenerateJWT(JWTClaims{"sub": "user-1"}, time.Minute)
+
+	var subject string
+	h := testTools.AuthJWT(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims, _ := JWTClaimsFromContext(r.Context())
+		subject = claims.Subject()
+	}))
+
+	for header, status := range map[string]int{"Bearer " + token: http.StatusOK, "": http.StatusUnauthorized, "Bearer junk": http.StatusUnauthorized, "Basic dXNlcg==": http.StatusUnauthorized} {
+		subject = ""
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if header != "" {
+			req.Header.Set("Authorization", header)
+		}
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		if rr.Code != status {
+			t.Errorf("%q: expected %d, got %d", header, status, rr.Code)
+		}
+		if status == http.StatusOK && subject != "user-1" {
+			t.Errorf("%q: claims not in context", header)
+		}
+		if status == http.StatusUnauthorized && rr.Header().Get("WWW-Authenticate") == "" {
+			t.Errorf("%q: WWW-Authenticate missing", header)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package toolkit
+
+import (
+	"bufio"
+	"io"
+	"iter"
+	"strings"
+)
+
+const utf8BOM = "\ufeff"
+
+// Lines yields the lines of r without their line endings ("\n" or "\r\n"). Lines may be of any
+// length and a leading UTF-8 byte order mark is dropped. Reading stops when the caller stops
+// iterating; a read error is yielded once as the final element.
+func (t *Tools) Lines(r io.Reader) iter.Seq2[string, error] {
+	return func(yield func(string, error) bool) {
+		br := bufio.NewReaderSize(r, 64*1024)
+		first := true
+
+		for {
+			line, err := br.ReadString('\n')
+			if len(line) > 0 {
+				if first {
+					line = strings.TrimPrefix(line, utf8BOM)
+				}
+				first = false
+
+				line = strings.TrimSuffix(line, "\n")
+				line = strings.TrimSuffix(line, "\r")
+				if !yield(line, nil) {
+					return
+				}
+			}
+			if err == io.EOF {
+				return
+			}
+			if err != nil {
+				yield("", err)
+				return
+			}
+		}
+	}
+}
+
+// ReadLines calls fn for every line of the file at path, streaming it rather than loading it into
+// memory. An error returned by fn stops reading and is returned.
+func (t *Tools) ReadLines(path string, fn func(line string) error) error {
+	f, err := t.open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	for line, err := range t.Lines(f) {
+		if err != nil {
+			return err
+		}
+		if err = fn(line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package toolkit
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var linesTests = []struct {
+	name     string
+	input    string
+	expected []string
+}{
+	{name: "unix endings", input: "a\nb\nc\n", expected: []string{"a", "b", "c"}},
+	{name: "windows endings", input: "a\r\nb\r\n", expected: []string{"a", "b"}},
+	{name: "no final newline", input: "a\nb", expected: []string{"a", "b"}},
+	{name: "bom", input: "\ufeffid,name\n1,x\n", expected: []string{"id,name", "1,x"}},
+	{name: "empty lines", input: "\n\nx\n", expected: []string{"", "", "x"}},
+	{name: "very long line", input: strings.Repeat("x", 1<<20) + "\nend", expected: []string{strings.Repeat("x", 1<<20), "end"}},
+	{name: "empty", input: "", expected: nil},
+}
+
+func TestTools_Lines(t *testing.T) {
+	var testTools Tools
+	for _, test := range linesTests {
+		var got []string
+		for line, err := range testTools.Lines(strings.NewReader(test.input)) {
+			if err != nil {
+				t.Fatalf("%s: %s", test.name, err)
+			}
+			got = append(got, line)
+		}
+		if strings.Join(got, "|") != strings.Join(test.expected, "|") || len(got) != len(test.expected) {
+			t.Errorf("%s: expected %d lines, got %d", test.name, len(test.expected), len(got))
+		}
+	}
+}
+
+func TestTools_ReadLines(t *testing.T) {
+	var testTools Tools
+	path := filepath.Join(t.TempDir(), "data.csv")
+	_ = os.WriteFile(path, []byte("one\ntwo\nthree\n"), 0644)
+
+	count := 0
+	err := testTools.ReadLines(path, func(line string) error {
+		count++
+		return nil
+	})
+	if err != nil || count != 3 {
+		t.Errorf("expected 3 lines, got %d (%v)", count, err)
+	}
+
+	stop := errors.New("stop")
+	count = 0
+	err = testTools.ReadLines(path, func(line string) error {
+		count++
+		if line == "two" {
+			return stop
+		}
+		return nil
+	})
+	if !errors.Is(err, stop) || count != 2 {
+		t.Errorf("expected callback error to stop reading after 2 lines, got %d (%v)", count, err)
+	}
+
+	if err = testTools.ReadLines(path+".missing", func(string) error { return nil }); err == nil {
+		t.Error("expected error for missing file")
+	}
+}
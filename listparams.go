@@ -0,0 +1,228 @@
+package toolkit
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ListOptions configures ParseListParams.
+type ListOptions struct {
+	// DefaultLimit is the page size when none is requested, 20 when 0.
+	DefaultLimit int
+	// MaxLimit caps the requested page size, 100 when 0.
+	MaxLimit int
+	// SortFields lists the fields that may be sorted on.
+	SortFields []string
+	// DefaultSort is used when no sort is requested, in the query syntax, e.g. "-created_at".
+	DefaultSort string
+	// FilterFields lists the fields that may be filtered on.
+	FilterFields []string
+}
+
+// Filter operators accepted by ParseListParams.
+const (
+	FilterEq   = "eq"
+	FilterNe   = "ne"
+	FilterGt   = "gt"
+	FilterGte  = "gte"
+	FilterLt   = "lt"
+	FilterLte  = "lte"
+	FilterLike = "like"
+	FilterIn   = "in"
+)
+
+var filterOps = []string{FilterEq, FilterNe, FilterGt, FilterGte, FilterLt, FilterLte, FilterLike, FilterIn}
+
+// Sort orders of the order query parameter of ParseListParams.
+const (
+	OrderAsc  = "asc"
+	OrderDesc = "desc"
+)
+
+// SortField is a field to sort on.
+type SortField struct {
+	Field string
+	Desc  bool
+}
+
+// Filter is a condition on a field. Values holds the comma separated values of the in operator.
+type Filter struct {
+	Field  string
+	Op     string
+	Value  string
+	Values []string
+}
+
+// ListParams are the paging, sorting and filtering parameters of a list request.
+type ListParams struct {
+	Page    int
+	Limit   int
+	Sort    []SortField
+	Filters []Filter
+}
+
+// Offset is the number of items before the page.
+func (p ListParams) Offset() int {
+	return (p.Page - 1) * p.Limit
+}
+
+// ParseListParams reads list parameters from the query of r:
+//
+//	?page=2&limit=50&sort=-created_at,name&status=active&age[gte]=18&kind[in]=a,b
+//
+// Sorting on a field prefixed with "-" is descending. per_page is accepted in place of limit, and
+// order, asc or desc, sets the direction of the requested sort fields without a prefix, so
+// ?page=2&per_page=50&sort=created_at&order=desc works as well. Filters use a field allowed by opts with an
+// optional operator, eq by default. Unknown fields and malformed values are reported as a
+// *ValidationError, which ErrorJSON renders as a 422; other query parameters are ignored.
+func (t *Tools) ParseListParams(r *http.Request, opts ListOptions) (ListParams, error) {
+	if opts.DefaultLimit <= 0 {
+		opts.DefaultLimit = 20
+	}
+	if opts.MaxLimit <= 0 {
+		opts.MaxLimit = 100
+	}
+
+	q := r.URL.Query()
+	v := t.NewValidator()
+	params := ListParams{Page: 1, Limit: min(opts.DefaultLimit, opts.MaxLimit)}
+
+	if s := q.Get("page"); s != "" {
+		page, err := strconv.Atoi(s)
+		v.Check(err == nil && page >= 1, "page", "must be a positive integer")
+		params.Page = max(page, 1)
+	}
+	limitKey := "limit"
+	if !q.Has("limit") && q.Has("per_page") {
+		limitKey = "per_page"
+	}
+	if s := q.Get(limitKey); s != "" {
+		limit, err := strconv.Atoi(s)
+		v.Check(err == nil && limit >= 1, limitKey, "must be a positive integer")
+		if limit >= 1 {
+			params.Limit = min(limit, opts.MaxLimit)
+		}
+	}
+
+	order := strings.ToLower(q.Get("order"))
+	if order != "" {
+		v.In("order", order, OrderAsc, OrderDesc)
+	}
+	sort := opts.DefaultSort
+	if q.Has("sort") {
+		sort = q.Get("sort")
+	}
+	for _, field := range strings.Split(sort, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		sf := SortField{Field: strings.TrimPrefix(field, "-"), Desc: strings.HasPrefix(field, "-")}
+		if !sf.Desc && q.Has("sort") {
+			sf.Desc = order == OrderDesc
+		}
+		if !slices.Contains(opts.SortFields, sf.Field) {
+			v.AddError("sort", fmt.Sprintf("cannot sort on %q", sf.Field))
+			continue
+		}
+		params.Sort = append(params.Sort, sf)
+	}
+
+	for key, values := range q {
+		field, op := key, FilterEq
+		if i := strings.IndexByte(key, '['); i > 0 && strings.HasSuffix(key, "]") {
+			field, op = key[:i], key[i+1:len(key)-1]
+		}
+		if !slices.Contains(opts.FilterFields, field) {
+			continue
+		}
+		if !slices.Contains(filterOps, op) {
+			v.AddError(key, fmt.Sprintf("unknown operator %q", op))
+			continue
+		}
+		f := Filter{Field: field, Op: op, Value: values[0]}
+		if op == FilterIn {
+			f.Values = strings.Split(f.Value, ",")
+		}
+		params.Filters = append(params.Filters, f)
+	}
+	// Map iteration order is random; keep filters in a stable order.
+	slices.SortFunc(params.Filters, func(a, b Filter) int {
+		if c := strings.Compare(a.Field, b.Field); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Op, b.Op)
+	})
+
+	return params, v.Err()
+}
+
+// PageLinks are the URLs of neighbouring pages of a list; missing pages are empty.
+type PageLinks struct {
+	Self  string `json:"self"`
+	First string `json:"first"`
+	Last  string `json:"last"`
+	Prev  string `json:"prev,omitempty"`
+	Next  string `json:"next,omitempty"`
+}
+
+// Page is the envelope of a list response. LastPage is the same as TotalPages, for clients
+// expecting it.
+type Page[T any] struct {
+	Items      []T       `json:"items"`
+	Page       int       `json:"page"`
+	Limit      int       `json:"limit"`
+	Total      int       `json:"total"`
+	TotalPages int       `json:"total_pages"`
+	LastPage   int       `json:"last_page"`
+	Links      PageLinks `json:"links"`
+}
+
+// NewPage builds the envelope for items, the page of r described by params out of total items.
+// Links keep the other query parameters of r, and the page size under per_page when r used it
+// in place of limit.
+func NewPage[T any](r *http.Request, items []T, params ListParams, total int) Page[T] {
+	if items == nil {
+		items = []T{}
+	}
+	limit := max(params.Limit, 1)
+	pages := max((total+limit-1)/limit, 1)
+	limitKey := "limit"
+	if q := r.URL.Query(); !q.Has("limit") && q.Has("per_page") {
+		limitKey = "per_page"
+	}
+	return Page[T]{
+		Items:      items,
+		Page:       params.Page,
+		Limit:      limit,
+		Total:      total,
+		TotalPages: pages,
+		LastPage:   pages,
+		Links:      pageLinks(r, params.Page, pages, limitKey, limit),
+	}
+}
+
+// pageLinks builds the links of page out of pages, setting the page and the limitKey parameters
+// in the query of r.
+func pageLinks(r *http.Request, page, pages int, limitKey string, limit int) PageLinks {
+	link := func(page int) string {
+		q := r.URL.Query()
+		q.Set("page", strconv.Itoa(page))
+		q.Set(limitKey, strconv.Itoa(limit))
+		u := url.URL{Path: r.URL.Path, RawQuery: q.Encode()}
+		return u.String()
+	}
+
+	links := PageLinks{Self: link(page), First: link(1), Last: link(pages)}
+	if page > 1 {
+		links.Prev = link(min(page-1, pages))
+	}
+	if page < pages {
+		links.Next = link(page + 1)
+	}
+	return links
+}
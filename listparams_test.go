@@ -0,0 +1,105 @@
+package toolkit
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+var listOptions = ListOptions{
+	DefaultLimit: 10,
+	MaxLimit:     50,
+	SortFields:   []string{"name", "created_at"},
+	DefaultSort:  "-created_at",
+	FilterFields: []string{"status", "age", "kind"},
+}
+
+var listParamsTests = []struct {
+	name     string
+	query    string
+	expected ListParams
+	invalid  []string
+}{
+	{name: "defaults", query: "", expected: ListParams{Page: 1, Limit: 10, Sort: []SortField{{Field: "created_at", Desc: true}}}},
+	{name: "paging", query: "page=3&limit=25&sort=", expected: ListParams{Page: 3, Limit: 25}},
+	{name: "limit capped", query: "limit=500&sort=", expected: ListParams{Page: 1, Limit: 50}},
+	{name: "sort", query: "sort=name,-created_at", expected: ListParams{Page: 1, Limit: 10, Sort: []SortField{{Field: "name"}, {Field: "created_at", Desc: true}}}},
+	{name: "filters", query: "sort=&status=active&age[gte]=18&kind[in]=a,b&other=x", expected: ListParams{Page: 1, Limit: 10, Filters: []Filter{
+		{Field: "age", Op: FilterGte, Value: "18"},
+		{Field: "kind", Op: FilterIn, Value: "a,b", Values: []string{"a", "b"}},
+		{Field: "status", Op: FilterEq, Value: "active"},
+	}}},
+	{name: "per page", query: "page=2&per_page=500&sort=", expected: ListParams{Page: 2, Limit: 50}},
+	{name: "limit over per page", query: "limit=5&per_page=25&sort=", expected: ListParams{Page: 1, Limit: 5}},
+	{name: "order", query: "sort=name,-created_at&order=DESC", expected: ListParams{Page: 1, Limit: 10, Sort: []SortField{{Field: "name", Desc: true}, {Field: "created_at", Desc: true}}}},
+	{name: "order asc", query: "sort=name&order=asc", expected: ListParams{Page: 1, Limit: 10, Sort: []SortField{{Field: "name"}}}},
+	{name: "invalid", query: "page=0&limit=x&sort=password&age[regex]=.*", invalid: []string{"page", "limit", "sort", "age[regex]"}},
+	{name: "invalid aliases", query: "per_page=0&order=random", invalid: []string{"per_page", "order"}},
+}
+
+func TestTools_ParseListParams(t *testing.T) {
+	var testTools Tools
+	for _, test := range listParamsTests {
+		req := httptest.NewRequest(http.MethodGet, "/items?"+test.query, nil)
+		params, err := testTools.ParseListParams(req, listOptions)
+
+		if test.invalid != nil {
+			var verr *ValidationError
+			if !errors.As(err, &verr) || len(verr.Fields) != len(test.invalid) {
+				t.Errorf("%s: expected errors for %v, got %v", test.name, test.invalid, err)
+				continue
+			}
+			for _, field := range test.invalid {
+				if _, ok := verr.Fields[field]; !ok {
+					t.Errorf("%s: expected an error for %s", test.name, field)
+				}
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", test.name, err)
+		}
+		if !reflect.DeepEqual(params, test.expected) {
+			t.Errorf("%s: expected %+v, got %+v", test.name, test.expected, params)
+		}
+	}
+}
+
+func TestTools_NewPage(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/items?page=2&limit=10&status=active", nil)
+	page := NewPage(req, []string{"a", "b"}, ListParams{Page: 2, Limit: 10}, 25)
+
+	if page.TotalPages != 3 || page.LastPage != 3 || page.Total != 25 || page.Page != 2 {
+		t.Errorf("unexpected page %+v", page)
+	}
+	expected := PageLinks{
+		Self:  "/items?limit=10&page=2&status=active",
+		First: "/items?limit=10&page=1&status=active",
+		Last:  "/items?limit=10&page=3&status=active",
+		Prev:  "/items?limit=10&page=1&status=active",
+		Next:  "/items?limit=10&page=3&status=active",
+	}
+	if page.Links != expected {
+		t.Errorf("unexpected links %+v", page.Links)
+	}
+
+	empty := NewPage[string](req, nil, ListParams{Page: 1, Limit: 10}, 0)
+	if empty.Items == nil || empty.TotalPages != 1 || empty.LastPage != 1 || empty.Links.Next != "" || empty.Links.Prev != "" {
+		t.Errorf("unexpected empty page %+v", empty)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/items?page=2&per_page=10&sort=name", nil)
+	page = NewPage(req, []string{"a"}, ListParams{Page: 2, Limit: 10}, 25)
+	expected = PageLinks{
+		Self:  "/items?page=2&per_page=10&sort=name",
+		First: "/items?page=1&per_page=10&sort=name",
+		Last:  "/items?page=3&per_page=10&sort=name",
+		Prev:  "/items?page=1&per_page=10&sort=name",
+		Next:  "/items?page=3&per_page=10&sort=name",
+	}
+	if page.Links != expected {
+		t.Errorf("unexpected per_page links %+v", page.Links)
+	}
+}
@@ -0,0 +1,72 @@
+package toolkit
+
+import (
+	"errors"
+	"os"
+	"sync"
+)
+
+// ErrLocked is returned by TryLockFile when another process holds the lock.
+var ErrLocked = errors.New("file is locked by another process")
+
+// FileLock is an exclusive advisory lock on a file, shared between processes.
+type FileLock struct {
+	Path string
+
+	mu sync.Mutex
+	f  *os.File
+}
+
+// Unlock releases the lock. Calling it on an already released lock is a no-op.
+func (l *FileLock) Unlock() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.f == nil {
+		return nil
+	}
+	err := unlockFile(l.f)
+	if closeErr := l.f.Close(); err == nil {
+		err = closeErr
+	}
+	l.f = nil
+	return err
+}
+
+// LockFile acquires an exclusive lock on path, creating the file if needed, and blocks until
+// the lock is available.
+func (t *Tools) LockFile(path string) (*FileLock, error) {
+	return t.acquireFileLock(path, true)
+}
+
+// TryLockFile acquires an exclusive lock on path without waiting. It returns ErrLocked when
+// the lock is held elsewhere.
+func (t *Tools) TryLockFile(path string) (*FileLock, error) {
+	return t.acquireFileLock(path, false)
+}
+
+// WithLock runs fn while holding the lock on path.
+func (t *Tools) WithLock(path string, fn func() error) error {
+	lock, err := t.LockFile(path)
+	if err != nil {
+		return err
+	}
+
+	err = fn()
+	if unlockErr := lock.Unlock(); err == nil {
+		err = unlockErr
+	}
+	return err
+}
+
+func (t *Tools) acquireFileLock(path string, block bool) (*FileLock, error) {
+	f, err := t.openFile(path, os.O_RDWR|os.O_CREATE, t.fileMode())
+	if err != nil {
+		return nil, err
+	}
+	if err = lockFile(f, block); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &FileLock{Path: path, f: f}, nil
+}
@@ -0,0 +1,16 @@
+//go:build !(darwin || dragonfly || freebsd || illumos || linux || netbsd || openbsd || solaris || windows)
+
+package toolkit
+
+import (
+	"errors"
+	"os"
+)
+
+func lockFile(*os.File, bool) error {
+	return errors.ErrUnsupported
+}
+
+func unlockFile(*os.File) error {
+	return errors.ErrUnsupported
+}
@@ -0,0 +1,70 @@
+package toolkit
+
+import (
+	"errors"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestTools_TryLockFile(t *testing.T) {
+	var testTools Tools
+	path := filepath.Join(t.TempDir(), "janitor.lock")
+
+	lock, err := testTools.TryLockFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = testTools.TryLockFile(path); !errors.Is(err, ErrLocked) {
+		t.Errorf("expected ErrLocked, got %v", err)
+	}
+
+	if err = lock.Unlock(); err != nil {
+		t.Fatal(err)
+	}
+	if err = lock.Unlock(); err != nil {
+		t.Error("second unlock failed:", err)
+	}
+
+	lock, err = testTools.TryLockFile(path)
+	if err != nil {
+		t.Fatal("lock not released:", err)
+	}
+	_ = lock.Unlock()
+}
+
+func TestTools_WithLock(t *testing.T) {
+	var testTools Tools
+	path := filepath.Join(t.TempDir(), "assembly.lock")
+
+	var running, overlaps int32
+	done := make(chan error, 3)
+	for i := 0; i < 3; i++ {
+		go func() {
+			done <- testTools.WithLock(path, func() error {
+				if atomic.AddInt32(&running, 1) > 1 {
+					atomic.AddInt32(&overlaps, 1)
+				}
+				time.Sleep(20 * time.Millisecond)
+				atomic.AddInt32(&running, -1)
+				return nil
+			})
+		}()
+	}
+
+	for i := 0; i < 3; i++ {
+		if err := <-done; err != nil {
+			t.Error(err)
+		}
+	}
+	if overlaps != 0 {
+		t.Errorf("critical section entered concurrently %d times", overlaps)
+	}
+
+	expected := errors.New("task failed")
+	if err := testTools.WithLock(path, func() error { return expected }); !errors.Is(err, expected) {
+		t.Error("error from fn not returned:", err)
+	}
+}
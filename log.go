@@ -0,0 +1,67 @@
+package toolkit
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// LogOptions configures NewLogger.
+type LogOptions struct {
+	// Format is "json" or "text", "json" when empty.
+	Format string
+	// Level is the minimum level logged: "debug", "info", "warn" or "error", optionally with an
+	// offset such as "info+2". "info" when empty.
+	Level string
+	// Output receives the records, os.Stderr when nil and File is empty.
+	Output io.Writer
+	// File, when set, writes records to a RotatingWriter at this path instead of Output.
+	File   string
+	Rotate RotateOptions
+	// AddSource adds the source position of the log call to records.
+	AddSource bool
+}
+
+// NewLogger builds the logger of a service from opts, typically loaded with LoadConfig. Records
+// logged with a request context get request_id and trace_id attributes. The returned Closer
+// closes the log file, if any. Assign the logger to Tools.Logger so the toolkit's middleware and
+// background work log through it, and to slog.SetDefault for the rest of the application.
+func (t *Tools) NewLogger(opts LogOptions) (*slog.Logger, io.Closer, error) {
+	var level slog.Level
+	if opts.Level != "" {
+		if err := level.UnmarshalText([]byte(opts.Level)); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	out, closer := opts.Output, io.Closer(nopCloser{})
+	if opts.File != "" {
+		w, err := t.NewRotatingWriter(opts.File, opts.Rotate)
+		if err != nil {
+			return nil, nil, err
+		}
+		out, closer = w, w
+	}
+	if out == nil {
+		out = os.Stderr
+	}
+
+	handlerOpts := &slog.HandlerOptions{Level: level, AddSource: opts.AddSource}
+	var h slog.Handler
+	switch strings.ToLower(opts.Format) {
+	case "", "json":
+		h = slog.NewJSONHandler(out, handlerOpts)
+	case "text":
+		h = slog.NewTextHandler(out, handlerOpts)
+	default:
+		_ = closer.Close()
+		return nil, nil, fmt.Errorf("unknown log format %q", opts.Format)
+	}
+	return slog.New(NewRequestIDHandler(h)), closer, nil
+}
+
+type nopCloser struct{}
+
+func (nopCloser) Close() error { return nil }
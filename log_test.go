@@ -0,0 +1,80 @@
+package toolkit
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var traceparentTests = []struct {
+	header   string
+	expected string
+}{
+	{header: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", expected: "4bf92f3577b34da6a3ce929d0e0e4736"},
+	{header: "00-00000000000000000000000000000000-00f067aa0ba902b7-01"},
+	{header: "00-not-hex-01"},
+	{header: ""},
+}
+
+func TestTools_NewLogger(t *testing.T) {
+	var testTools Tools
+	var buf bytes.Buffer
+	logger, closer, err := testTools.NewLogger(LogOptions{Format: "text", Level: "warn", Output: &buf})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closer.Close()
+	testTools.Logger = logger
+
+	for _, test := range traceparentTests {
+		buf.Reset()
+		h := testTools.RequestIDMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			testTools.logger().InfoContext(r.Context(), "hidden")
+			testTools.logger().WarnContext(r.Context(), "shown")
+		}))
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(RequestIDHeader, "req-1")
+		req.Header.Set("traceparent", test.header)
+		h.ServeHTTP(httptest.NewRecorder(), req)
+
+		out := buf.String()
+		if strings.Contains(out, "hidden") || !strings.Contains(out, "level=WARN msg=shown request_id=req-1") {
+			t.Errorf("%q: unexpected output %q", test.header, out)
+		}
+		if hasTrace := strings.Contains(out, "trace_id="+test.expected); test.expected != "" && !hasTrace || test.expected == "" && strings.Contains(out, "trace_id") {
+			t.Errorf("%q: unexpected trace id in %q", test.header, out)
+		}
+	}
+
+	if _, _, err = testTools.NewLogger(LogOptions{Level: "loud"}); err == nil {
+		t.Error("expected an invalid level to fail")
+	}
+	if _, _, err = testTools.NewLogger(LogOptions{Format: "xml"}); err == nil {
+		t.Error("expected an invalid format to fail")
+	}
+}
+
+func TestTools_NewLoggerFile(t *testing.T) {
+	var testTools Tools
+	path := filepath.Join(t.TempDir(), "logs", "app.log")
+	logger, closer, err := testTools.NewLogger(LogOptions{File: path, Rotate: RotateOptions{MaxSize: 1 << 20}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.Info("started", "port", 8080)
+	if err = closer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"msg":"started","port":8080`) {
+		t.Errorf("unexpected log file %q", data)
+	}
+}
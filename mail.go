@@ -0,0 +1,482 @@
+package toolkit
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"io/fs"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"path/filepath"
+	"strconv"
+	"strings"
+	texttemplate "text/template"
+	"time"
+)
+
+// Attachment is a file attached to a Message. Inline attachments are shown within the HTML body,
+// which refers to them as cid:ContentID, e.g. <img src="cid:logo">.
+type Attachment struct {
+	Filename    string
+	ContentType string
+	Data        []byte
+	Inline      bool
+	ContentID   string
+}
+
+// AttachReader reads r into an attachment named filename, with a content type guessed from its
+// extension.
+func (t *Tools) AttachReader(filename string, r io.Reader) (Attachment, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return Attachment{}, err
+	}
+	return Attachment{Filename: filename, ContentType: t.MIMEByExtension(filepath.Ext(filename)), Data: data}, nil
+}
+
+// AttachUploadedFile attaches a file stored by UploadFiles in uploadDir under its original name,
+// decrypting it when encryption is enabled.
+func (t *Tools) AttachUploadedFile(uploadDir string, file *UploadedFile) (Attachment, error) {
+	dir, err := t.uploadPath(uploadDir)
+	if err != nil {
+		return Attachment{}, err
+	}
+	p, err := t.SecureJoin(dir, file.NewFileName)
+	if err != nil {
+		return Attachment{}, err
+	}
+
+	var r io.ReadCloser
+	if t.encryptionEnabled() {
+		r, err = t.OpenDecrypted(p)
+	}
+	if !t.encryptionEnabled() || errors.Is(err, ErrNotEncrypted) {
+		r, err = t.open(p)
+	}
+	if err != nil {
+		return Attachment{}, err
+	}
+	defer r.Close()
+	return t.AttachReader(file.OriginalFileName, r)
+}
+
+// Message is an e-mail. From defaults to the Mailer's sender address.
+type Message struct {
+	From        string
+	To          []string
+	Cc          []string
+	Bcc         []string
+	ReplyTo     string
+	Subject     string
+	Text        string
+	HTML        string
+	Headers     map[string]string
+	Attachments []Attachment
+}
+
+// Recipients returns the addresses of To, Cc and Bcc.
+func (m *Message) Recipients() ([]string, error) {
+	var rcpt []string
+	for _, list := range [][]string{m.To, m.Cc, m.Bcc} {
+		for _, s := range list {
+			addr, err := mail.ParseAddress(s)
+			if err != nil {
+				return nil, fmt.Errorf("invalid recipient %q: %w", s, err)
+			}
+			rcpt = append(rcpt, addr.Address)
+		}
+	}
+	if len(rcpt) == 0 {
+		return nil, errors.New("message has no recipients")
+	}
+	return rcpt, nil
+}
+
+// Bytes renders the message in MIME format. Bcc recipients are left out of the headers.
+func (m *Message) Bytes() ([]byte, error) {
+	var buf bytes.Buffer
+	header := func(name, value string) {
+		fmt.Fprintf(&buf, "%s: %s\r\n", name, value)
+	}
+
+	from, err := mail.ParseAddress(m.From)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sender %q: %w", m.From, err)
+	}
+	header("From", from.String())
+	for _, field := range []struct {
+		name string
+		list []string
+	}{{"To", m.To}, {"Cc", m.Cc}} {
+		if len(field.list) == 0 {
+			continue
+		}
+		addrs := make([]string, len(field.list))
+		for i, s := range field.list {
+			addr, err := mail.ParseAddress(s)
+			if err != nil {
+				return nil, fmt.Errorf("invalid recipient %q: %w", s, err)
+			}
+			addrs[i] = addr.String()
+		}
+		header(field.name, strings.Join(addrs, ", "))
+	}
+	if m.ReplyTo != "" {
+		// Reply-To often holds the address a visitor typed in, so it is parsed like the others
+		// rather than written as it is, which would let line breaks add headers.
+		replyTo, err := mail.ParseAddress(m.ReplyTo)
+		if err != nil {
+			return nil, fmt.Errorf("invalid reply-to address %q: %w", m.ReplyTo, err)
+		}
+		header("Reply-To", replyTo.String())
+	}
+	header("Subject", mime.QEncoding.Encode("utf-8", m.Subject))
+	header("Date", time.Now().Format(time.RFC1123Z))
+	_, domain, _ := strings.Cut(from.Address, "@")
+	header("Message-ID", "<"+newRequestID()+"@"+domain+">")
+	header("MIME-Version", "1.0")
+	for name, value := range m.Headers {
+		header(textproto.CanonicalMIMEHeaderKey(name), mime.QEncoding.Encode("utf-8", value))
+	}
+
+	var inline, attached []Attachment
+	for _, a := range m.Attachments {
+		if a.Inline {
+			inline = append(inline, a)
+		} else {
+			attached = append(attached, a)
+		}
+	}
+
+	// The body nests as multipart/mixed > multipart/related > multipart/alternative, leaving out
+	// the levels a message does not need.
+	body := func(w io.Writer) (string, error) {
+		return m.writeAlternative(w)
+	}
+	if len(inline) > 0 {
+		alt := body
+		body = func(w io.Writer) (string, error) {
+			return writeMultipart(w, "related", alt, inline)
+		}
+	}
+	if len(attached) > 0 {
+		related := body
+		body = func(w io.Writer) (string, error) {
+			return writeMultipart(w, "mixed", related, attached)
+		}
+	}
+
+	var content bytes.Buffer
+	contentType, err := body(&content)
+	if err != nil {
+		return nil, err
+	}
+	header("Content-Type", contentType)
+	if !strings.HasPrefix(contentType, "multipart/") {
+		header("Content-Transfer-Encoding", "quoted-printable")
+	}
+	buf.WriteString("\r\n")
+	buf.Write(content.Bytes())
+	return buf.Bytes(), nil
+}
+
+// writeAlternative writes the text and HTML bodies and returns their content type.
+func (m *Message) writeAlternative(w io.Writer) (string, error) {
+	if m.HTML == "" || m.Text == "" {
+		typ, content := "text/plain; charset=utf-8", m.Text
+		if m.HTML != "" {
+			typ, content = "text/html; charset=utf-8", m.HTML
+		}
+		qp := quotedprintable.NewWriter(w)
+		if _, err := io.WriteString(qp, content); err != nil {
+			return "", err
+		}
+		return typ, qp.Close()
+	}
+
+	mw := multipart.NewWriter(w)
+	for _, part := range []struct{ typ, content string }{{"text/plain; charset=utf-8", m.Text}, {"text/html; charset=utf-8", m.HTML}} {
+		pw, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.typ},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return "", err
+		}
+		qp := quotedprintable.NewWriter(pw)
+		if _, err = io.WriteString(qp, part.content); err != nil {
+			return "", err
+		}
+		if err = qp.Close(); err != nil {
+			return "", err
+		}
+	}
+	return "multipart/alternative; boundary=" + mw.Boundary(), mw.Close()
+}
+
+// writeMultipart writes a multipart body whose first part is written by first, followed by
+// attachments, and returns its content type.
+func writeMultipart(w io.Writer, subtype string, first func(io.Writer) (string, error), attachments []Attachment) (string, error) {
+	mw := multipart.NewWriter(w)
+
+	var inner bytes.Buffer
+	typ, err := first(&inner)
+	if err != nil {
+		return "", err
+	}
+	h := textproto.MIMEHeader{"Content-Type": {typ}}
+	if !strings.HasPrefix(typ, "multipart/") {
+		h.Set("Content-Transfer-Encoding", "quoted-printable")
+	}
+	pw, err := mw.CreatePart(h)
+	if err != nil {
+		return "", err
+	}
+	if _, err = pw.Write(inner.Bytes()); err != nil {
+		return "", err
+	}
+
+	for _, a := range attachments {
+		contentType, params, err := mime.ParseMediaType(a.ContentType)
+		if err != nil {
+			contentType, params = "application/octet-stream", nil
+		}
+		if params == nil {
+			params = make(map[string]string)
+		}
+		params["name"] = a.Filename
+		disposition := "attachment"
+		if a.Inline {
+			disposition = "inline"
+		}
+		h := textproto.MIMEHeader{
+			"Content-Type":              {mime.FormatMediaType(contentType, params)},
+			"Content-Disposition":       {mime.FormatMediaType(disposition, map[string]string{"filename": a.Filename})},
+			"Content-Transfer-Encoding": {"base64"},
+		}
+		if a.ContentID != "" {
+			h.Set("Content-ID", "<"+a.ContentID+">")
+		}
+		pw, err := mw.CreatePart(h)
+		if err != nil {
+			return "", err
+		}
+		if err = writeBase64Lines(pw, a.Data); err != nil {
+			return "", err
+		}
+	}
+	return "multipart/" + subtype + "; boundary=" + mw.Boundary(), mw.Close()
+}
+
+// writeBase64Lines writes data base64 encoded in lines of 76 characters, as RFC 2045 requires.
+func writeBase64Lines(w io.Writer, data []byte) error {
+	encoded := base64.StdEncoding.EncodeToString(data)
+	for len(encoded) > 0 {
+		n := min(len(encoded), 76)
+		if _, err := io.WriteString(w, encoded[:n]+"\r\n"); err != nil {
+			return err
+		}
+		encoded = encoded[n:]
+	}
+	return nil
+}
+
+// Sender delivers messages. SMTPSender talks SMTP; implementations for HTTP APIs of mail
+// providers can use the structured Message or its Bytes.
+type Sender interface {
+	Send(ctx context.Context, msg *Message) error
+}
+
+// SMTPSender delivers messages to an SMTP server, upgrading the connection with STARTTLS when the
+// server offers it.
+type SMTPSender struct {
+	Host string
+	// Port is 587 when 0. With 465, TLS is used from the start instead of STARTTLS.
+	Port     int
+	Username string
+	Password string
+	// TLSConfig configures TLS, verifying Host when nil.
+	TLSConfig *tls.Config
+	// RequireTLS refuses to send, and to authenticate, over connections without TLS.
+	RequireTLS bool
+}
+
+// Send delivers msg. The deadline of ctx, if any, bounds the whole SMTP session.
+func (s *SMTPSender) Send(ctx context.Context, msg *Message) error {
+	rcpt, err := msg.Recipients()
+	if err != nil {
+		return err
+	}
+	from, err := mail.ParseAddress(msg.From)
+	if err != nil {
+		return fmt.Errorf("invalid sender %q: %w", msg.From, err)
+	}
+	data, err := msg.Bytes()
+	if err != nil {
+		return err
+	}
+
+	port := s.Port
+	if port == 0 {
+		port = 587
+	}
+	tlsConfig := s.TLSConfig
+	if tlsConfig == nil {
+		tlsConfig = &tls.Config{ServerName: s.Host}
+	}
+	addr := net.JoinHostPort(s.Host, strconv.Itoa(port))
+
+	var conn net.Conn
+	if port == 465 {
+		conn, err = (&tls.Dialer{Config: tlsConfig}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = (&net.Dialer{}).DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	c, err := smtp.NewClient(conn, s.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	if port != 465 {
+		if ok, _ := c.Extension("STARTTLS"); ok {
+			if err = c.StartTLS(tlsConfig); err != nil {
+				return err
+			}
+		} else if s.RequireTLS {
+			return errors.New("smtp server does not support STARTTLS")
+		}
+	}
+	if s.Username != "" {
+		if err = c.Auth(smtp.PlainAuth("", s.Username, s.Password, s.Host)); err != nil {
+			return err
+		}
+	}
+	if err = c.Mail(from.Address); err != nil {
+		return err
+	}
+	for _, r := range rcpt {
+		if err = c.Rcpt(r); err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err = w.Write(data); err != nil {
+		return err
+	}
+	if err = w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// Mailer sends messages, optionally rendered from templates, through a Sender.
+type Mailer struct {
+	Sender Sender
+	// From is the sender of messages that do not set one.
+	From string
+	// Templates holds the message templates used by SendTemplate.
+	Templates fs.FS
+	// Jobs, when set, runs SendAsync deliveries; it should be configured with retries.
+	Jobs *JobRunner
+
+	tools *Tools
+}
+
+// NewMailer returns a Mailer sending through sender as from.
+func (t *Tools) NewMailer(sender Sender, from string) *Mailer {
+	return &Mailer{Sender: sender, From: from, tools: t}
+}
+
+// Send delivers msg.
+func (m *Mailer) Send(ctx context.Context, msg *Message) error {
+	if msg.From == "" {
+		msg.From = m.From
+	}
+	return m.Sender.Send(ctx, msg)
+}
+
+// SendAsync queues the delivery of msg on the Mailer's job runner. Attachment data is already in
+// memory, so the message can be sent after the request that built it is gone.
+func (m *Mailer) SendAsync(msg *Message) error {
+	if m.Jobs == nil {
+		return errors.New("mailer has no job runner")
+	}
+	return m.Jobs.Enqueue(func(ctx context.Context) error {
+		return m.Send(ctx, msg)
+	})
+}
+
+// Render fills the subject and bodies of msg from the template file name in Templates, which
+// defines the templates "subject", "text" and "html"; "text" or "html" may be left out. The HTML
+// body is escaped with html/template.
+func (m *Mailer) Render(msg *Message, name string, data any) error {
+	if m.Templates == nil {
+		return errors.New("mailer has no templates")
+	}
+
+	text, err := texttemplate.ParseFS(m.Templates, name)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	for _, part := range []struct {
+		name string
+		dst  *string
+	}{{"subject", &msg.Subject}, {"text", &msg.Text}} {
+		if text.Lookup(part.name) == nil {
+			continue
+		}
+		buf.Reset()
+		if err = text.ExecuteTemplate(&buf, part.name, data); err != nil {
+			return err
+		}
+		*part.dst = strings.TrimSpace(buf.String())
+	}
+
+	html, err := htmltemplate.ParseFS(m.Templates, name)
+	if err != nil {
+		return err
+	}
+	if html.Lookup("html") != nil {
+		buf.Reset()
+		if err = html.ExecuteTemplate(&buf, "html", data); err != nil {
+			return err
+		}
+		msg.HTML = buf.String()
+	}
+
+	if msg.Subject == "" || msg.Text == "" && msg.HTML == "" {
+		return fmt.Errorf("template %s needs a subject and a text or html body", name)
+	}
+	return nil
+}
+
+// SendTemplate renders msg from the template name and sends it.
+func (m *Mailer) SendTemplate(ctx context.Context, msg *Message, name string, data any) error {
+	if err := m.Render(msg, name, data); err != nil {
+		return err
+	}
+	return m.Send(ctx, msg)
+}
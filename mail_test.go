@@ -0,0 +1,254 @@
+package toolkit
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestTools_MessageBytes(t *testing.T) {
+	var testTools Tools
+	doc, _ := testTools.AttachReader("report.pdf", strings.NewReader("%PDF-1.4"))
+	msg := &Message{
+		From:    "Toolkit <noreply@example.com>",
+		To:      []string{"Zażółć <a@example.com>"},
+		Bcc:     []string{"hidden@example.com"},
+		Subject: "Raport miesięczny",
+		Text:    "Hello",
+		HTML:    `<p>Hello</p><img src="cid:logo">`,
+		Attachments: []Attachment{
+			{Filename: "logo.png", ContentType: "image/png", Data: []byte{0x89, 'P', 'N', 'G'}, Inline: true, ContentID: "logo"},
+			doc,
+		},
+	}
+
+	raw, err := msg.Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.Header.Get("Bcc") != "" || strings.Contains(string(raw), "hidden@example.com") {
+		t.Error("bcc recipient leaked into the message")
+	}
+	if subject, _ := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject")); subject != msg.Subject {
+		t.Errorf("unexpected subject %q", subject)
+	}
+
+	// Walk mixed > related > alternative and collect the leaf content types.
+	var leaves []string
+	var walk func(contentType string, body io.Reader)
+	walk = func(contentType string, body io.Reader) {
+		typ, params, err := mime.ParseMediaType(contentType)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(typ, "multipart/") {
+			leaves = append(leaves, typ)
+			return
+		}
+		leaves = append(leaves, typ)
+		mr := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := mr.NextPart()
+			if err == io.EOF {
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			walk(part.Header.Get("Content-Type"), part)
+		}
+	}
+	walk(parsed.Header.Get("Content-Type"), parsed.Body)
+
+	expected := "multipart/mixed multipart/related multipart/alternative text/plain text/html image/png application/pdf"
+	if got := strings.Join(leaves, " "); got != expected {
+		t.Errorf("unexpected structure %q", got)
+	}
+
+	rcpt, err := msg.Recipients()
+	if err != nil || strings.Join(rcpt, ",") != "a@example.com,hidden@example.com" {
+		t.Errorf("unexpected recipients %v %v", rcpt, err)
+	}
+}
+
+func TestTools_MessageReplyTo(t *testing.T) {
+	msg := &Message{From: "noreply@example.com", To: []string{"a@example.com"}, Subject: "Contact", Text: "Hi",
+		ReplyTo: "Visitor <visitor@example.com>"}
+	raw, err := msg.Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, _ := mail.ReadMessage(bytes.NewReader(raw))
+	if replyTo := parsed.Header.Get("Reply-To"); replyTo != `"Visitor" <visitor@example.com>` {
+		t.Errorf("unexpected reply-to %q", replyTo)
+	}
+
+	for _, replyTo := range []string{"a@example.com\r\nBcc: x@example.com", "a@example.com\nBcc: x@example.com", "not an address"} {
+		msg.ReplyTo = replyTo
+		raw, err := msg.Bytes()
+		if err == nil {
+			t.Errorf("%q: expected an error", replyTo)
+		}
+		if strings.Contains(string(raw), "Bcc:") {
+			t.Errorf("%q: header injected", replyTo)
+		}
+	}
+}
+
+func TestTools_AttachUploadedFile(t *testing.T) {
+	dir := t.TempDir()
+	testTools := Tools{EncryptionKeys: map[uint32][]byte{1: bytes.Repeat([]byte{7}, 32)}, EncryptionKeyVersion: 1}
+
+	f, err := os.Create(filepath.Join(dir, "stored.bin"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, _ := testTools.EncryptWriter(f)
+	_, _ = w.Write([]byte("secret notes"))
+	_ = w.Close()
+	_ = f.Close()
+
+	a, err := testTools.AttachUploadedFile(dir, &UploadedFile{NewFileName: "stored.bin", OriginalFileName: "notes.txt"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Filename != "notes.txt" || string(a.Data) != "secret notes" || !strings.HasPrefix(a.ContentType, "text/plain") {
+		t.Errorf("unexpected attachment %+v", a)
+	}
+}
+
+var mailTemplates = fstest.MapFS{
+	"welcome.tmpl": {Data: []byte(`{{define "subject"}}Welcome {{.Name}}{{end}}
+{{define "text"}}Hi {{.Name}}!{{end}}
+{{define "html"}}<p>Hi {{.Name}}!</p>{{end}}`)},
+	"broken.tmpl": {Data: []byte(`{{define "text"}}no subject{{end}}`)},
+}
+
+type recordingSender struct {
+	mu   sync.Mutex
+	sent []*Message
+}
+
+func (s *recordingSender) Send(_ context.Context, msg *Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sent = append(s.sent, msg)
+	return nil
+}
+
+func TestTools_Mailer(t *testing.T) {
+	testTools := Tools{Logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+	sender := &recordingSender{}
+	m := testTools.NewMailer(sender, "noreply@example.com")
+	m.Templates = mailTemplates
+
+	err := m.SendTemplate(context.Background(), &Message{To: []string{"a@example.com"}}, "welcome.tmpl", map[string]string{"Name": "<Ann>"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := sender.sent[0]
+	if got.From != "noreply@example.com" || got.Subject != "Welcome <Ann>" || got.Text != "Hi <Ann>!" || got.HTML != "<p>Hi &lt;Ann&gt;!</p>" {
+		t.Errorf("unexpected rendered message %+v", got)
+	}
+	if err = m.Render(&Message{}, "broken.tmpl", nil); err == nil {
+		t.Error("expected a template without subject to fail")
+	}
+
+	if err = m.SendAsync(&Message{To: []string{"b@example.com"}}); err == nil {
+		t.Error("expected SendAsync to fail without a job runner")
+	}
+	m.Jobs = testTools.NewJobRunner(JobRunnerOptions{Workers: 1})
+	if err = m.SendAsync(&Message{To: []string{"b@example.com"}, Subject: "s", Text: "t"}); err != nil {
+		t.Fatal(err)
+	}
+	_ = m.Jobs.Shutdown(context.Background())
+	if len(sender.sent) != 2 {
+		t.Errorf("expected the queued message to be sent, have %d", len(sender.sent))
+	}
+}
+
+// fakeSMTPServer accepts one message without TLS or authentication and returns its data.
+func fakeSMTPServer(t *testing.T) (port int, data <-chan string) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+
+	ch := make(chan string, 1)
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r, w := bufio.NewReader(conn), conn
+		_, _ = io.WriteString(w, "220 localhost ESMTP\r\n")
+		var body strings.Builder
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			switch cmd := strings.ToUpper(strings.TrimSpace(line)); {
+			case strings.HasPrefix(cmd, "EHLO"):
+				_, _ = io.WriteString(w, "250-localhost\r\n250 8BITMIME\r\n")
+			case cmd == "DATA":
+				_, _ = io.WriteString(w, "354 go ahead\r\n")
+				for {
+					line, err := r.ReadString('\n')
+					if err != nil || line == ".\r\n" {
+						break
+					}
+					body.WriteString(line)
+				}
+				ch <- body.String()
+				_, _ = io.WriteString(w, "250 queued\r\n")
+			case cmd == "QUIT":
+				_, _ = io.WriteString(w, "221 bye\r\n")
+				return
+			default:
+				_, _ = io.WriteString(w, "250 ok\r\n")
+			}
+		}
+	}()
+	return l.Addr().(*net.TCPAddr).Port, ch
+}
+
+func TestTools_SMTPSender(t *testing.T) {
+	port, data := fakeSMTPServer(t)
+	sender := &SMTPSender{Host: "127.0.0.1", Port: port}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err := sender.Send(ctx, &Message{From: "noreply@example.com", To: []string{"a@example.com"}, Subject: "Hi", Text: "Hello"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body := <-data; !strings.Contains(body, "Subject: Hi") || !strings.Contains(body, "Hello") {
+		t.Errorf("unexpected message data %q", body)
+	}
+
+	port, _ = fakeSMTPServer(t)
+	sender = &SMTPSender{Host: "127.0.0.1", Port: port, RequireTLS: true}
+	if err = sender.Send(ctx, &Message{From: "noreply@example.com", To: []string{"a@example.com"}, Subject: "Hi", Text: "Hello"}); err == nil {
+		t.Error("expected RequireTLS to refuse a server without STARTTLS")
+	}
+}
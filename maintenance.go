@@ -0,0 +1,133 @@
+package toolkit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/netip"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// MaintenanceOptions configures a Maintenance switch.
+type MaintenanceOptions struct {
+	// Message is returned in the 503 JSON error, a generic notice when empty.
+	Message string
+	// RetryAfter is sent in the Retry-After header when greater than 0.
+	RetryAfter time.Duration
+	// AllowPaths lists path prefixes served normally during maintenance, such as health checks.
+	AllowPaths []string
+	// AllowIPs lists addresses and CIDR ranges, matched against ClientIP, served normally
+	// during maintenance.
+	AllowIPs []string
+}
+
+// Maintenance is a runtime switch for maintenance mode. While it is enabled, Middleware answers
+// requests with a 503 JSON error, except for allowed paths and client addresses.
+type Maintenance struct {
+	opts    MaintenanceOptions
+	allow   []netip.Prefix
+	tools   *Tools
+	enabled atomic.Bool
+}
+
+// NewMaintenance returns a disabled maintenance switch.
+func (t *Tools) NewMaintenance(opts MaintenanceOptions) (*Maintenance, error) {
+	allow, err := parsePrefixes(opts.AllowIPs)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Message == "" {
+		opts.Message = "the service is down for maintenance"
+	}
+	return &Maintenance{opts: opts, allow: allow, tools: t}, nil
+}
+
+// Enable turns maintenance mode on.
+func (m *Maintenance) Enable() { m.enabled.Store(true) }
+
+// Disable turns maintenance mode off.
+func (m *Maintenance) Disable() { m.enabled.Store(false) }
+
+// Enabled reports whether maintenance mode is on.
+func (m *Maintenance) Enabled() bool { return m.enabled.Load() }
+
+// Middleware rejects requests with 503 while maintenance mode is on.
+func (m *Maintenance) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !m.Enabled() || m.allowed(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if m.opts.RetryAfter > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(max(ceilSeconds(m.opts.RetryAfter), 1)))
+		}
+		_ = m.tools.ErrorJSON(w, errors.New(m.opts.Message), http.StatusServiceUnavailable)
+	})
+}
+
+func (m *Maintenance) allowed(r *http.Request) bool {
+	for _, p := range m.opts.AllowPaths {
+		if strings.HasPrefix(r.URL.Path, p) {
+			return true
+		}
+	}
+	if len(m.allow) > 0 {
+		addr, err := netip.ParseAddr(m.tools.ClientIP(r))
+		return err == nil && containsAddr(m.allow, addr)
+	}
+	return false
+}
+
+// ControlHandler returns a handler reporting the state as {"enabled": bool} on GET and changing it
+// from the same JSON body on POST or PUT. It must be mounted behind authentication, for example
+// BasicAuthMiddleware, and on a path listed in AllowPaths so it stays reachable.
+func (m *Maintenance) ControlHandler() http.Handler {
+	type state struct {
+		Enabled bool `json:"enabled"`
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead:
+		case http.MethodPost, http.MethodPut:
+			var s state
+			if err := m.tools.ReadJSON(w, r, &s); err != nil {
+				_ = m.tools.ErrorJSON(w, err)
+				return
+			}
+			m.enabled.Store(s.Enabled)
+		default:
+			w.Header().Set("Allow", "GET, HEAD, POST, PUT")
+			_ = m.tools.ErrorJSON(w, errors.New("method not allowed"), http.StatusMethodNotAllowed)
+			return
+		}
+		_ = m.tools.WriteJSON(w, http.StatusOK, state{Enabled: m.Enabled()})
+	})
+}
+
+// ToggleOnSignal flips maintenance mode each time one of sigs is received, e.g. syscall.SIGUSR1,
+// until ctx is done.
+func (m *Maintenance) ToggleOnSignal(ctx context.Context, sigs ...os.Signal) {
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, sigs...)
+	go func() {
+		defer signal.Stop(ch)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ch:
+				for {
+					old := m.enabled.Load()
+					if m.enabled.CompareAndSwap(old, !old) {
+						break
+					}
+				}
+			}
+		}
+	}()
+}
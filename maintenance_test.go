@@ -0,0 +1,62 @@
+package toolkit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTools_Maintenance(t *testing.T) {
+	var testTools Tools
+	m, err := testTools.NewMaintenance(MaintenanceOptions{
+		RetryAfter: 90 * time.Second,
+		AllowPaths: []string{"/healthz", "/admin/maintenance"},
+		AllowIPs:   []string{"198.51.100.0/24"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/admin/maintenance", m.ControlHandler())
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {})
+	h := m.Middleware(mux)
+
+	send := func(method, path, remote, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if remote != "" {
+			req.RemoteAddr = remote
+		}
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		return rr
+	}
+
+	if rr := send(http.MethodGet, "/orders", "", ""); rr.Code != http.StatusOK {
+		t.Fatal("expected requests to pass while disabled, got", rr.Code)
+	}
+	if rr := send(http.MethodPost, "/admin/maintenance", "", `{"enabled":true}`); rr.Code != http.StatusOK || !m.Enabled() {
+		t.Fatal("control endpoint did not enable maintenance:", rr.Code, rr.Body.String())
+	}
+
+	rr := send(http.MethodGet, "/orders", "", "")
+	if rr.Code != http.StatusServiceUnavailable || rr.Header().Get("Retry-After") != "90" {
+		t.Errorf("expected 503 with Retry-After, got %d %v", rr.Code, rr.Header())
+	}
+	if rr = send(http.MethodGet, "/healthz", "", ""); rr.Code != http.StatusOK {
+		t.Error("allowed path was blocked:", rr.Code)
+	}
+	if rr = send(http.MethodGet, "/orders", "198.51.100.10:5000", ""); rr.Code != http.StatusOK {
+		t.Error("allowed address was blocked:", rr.Code)
+	}
+	if rr = send(http.MethodGet, "/admin/maintenance", "", ""); !strings.Contains(rr.Body.String(), `"enabled":true`) {
+		t.Error("unexpected state:", rr.Body.String())
+	}
+
+	m.Disable()
+	if rr = send(http.MethodGet, "/orders", "", ""); rr.Code != http.StatusOK {
+		t.Error("expected requests to pass after Disable, got", rr.Code)
+	}
+}
@@ -0,0 +1,28 @@
+//go:build unix
+
+package toolkit
+
+import (
+	"context"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestTools_MaintenanceToggleOnSignal(t *testing.T) {
+	var testTools Tools
+	m, _ := testTools.NewMaintenance(MaintenanceOptions{})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	m.ToggleOnSignal(ctx, syscall.SIGUSR1)
+	_ = syscall.Kill(syscall.Getpid(), syscall.SIGUSR1)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for !m.Enabled() && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if !m.Enabled() {
+		t.Error("signal did not toggle maintenance mode")
+	}
+}
@@ -0,0 +1,81 @@
+package toolkit
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"sync"
+)
+
+// MemoryStore is a FileStore keeping files in memory, for tests and for uploads that are
+// processed and discarded without ever reaching a disk. The zero value is an empty store.
+type MemoryStore struct {
+	mu    sync.RWMutex
+	files map[string][]byte
+}
+
+// NewMemoryStore returns an empty MemoryStore.
+func (t *Tools) NewMemoryStore() *MemoryStore {
+	return &MemoryStore{files: make(map[string][]byte)}
+}
+
+// Save reads r to the end and keeps its content as name. Names are cleaned as object keys, so
+// "a/../b.txt" and "/b.txt" are both stored as "b.txt".
+func (s *MemoryStore) Save(ctx context.Context, name string, r io.Reader, size int64) (int64, error) {
+	key, err := objectKey("", name)
+	if err != nil {
+		return 0, err
+	}
+	var buf bytes.Buffer
+	n, err := buf.ReadFrom(contextReader{ctx, r})
+	if err != nil {
+		return n, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.files == nil {
+		s.files = make(map[string][]byte)
+	}
+	s.files[key] = buf.Bytes()
+	return n, nil
+}
+
+func (s *MemoryStore) Open(ctx context.Context, name string) (io.ReadCloser, error) {
+	key, err := objectKey("", name)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	data, ok := s.files[key]
+	if !ok {
+		return nil, fmt.Errorf("memory store: open %s: %w", key, fs.ErrNotExist)
+	}
+	// Saved content is never modified in place, so readers can share it.
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (s *MemoryStore) Delete(ctx context.Context, name string) error {
+	key, err := objectKey("", name)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.files, key)
+	return nil
+}
+
+func (s *MemoryStore) Exists(ctx context.Context, name string) (bool, error) {
+	key, err := objectKey("", name)
+	if err != nil {
+		return false, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	_, ok := s.files[key]
+	return ok, nil
+}
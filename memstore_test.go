@@ -0,0 +1,101 @@
+package toolkit
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestTools_MemoryStore(t *testing.T) {
+	var testTools Tools
+	ctx := context.Background()
+	store := testTools.NewMemoryStore()
+
+	if n, err := store.Save(ctx, "a/b/c.txt", strings.NewReader("hello"), -1); err != nil || n != 5 {
+		t.Fatalf("unexpected save %d %v", n, err)
+	}
+	if ok, err := store.Exists(ctx, "/a/x/../b/c.txt"); !ok || err != nil {
+		t.Errorf("expected the file to exist under its clean name, got %v %v", ok, err)
+	}
+	rc, err := store.Open(ctx, "a/b/c.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := io.ReadAll(rc)
+	rc.Close()
+	if string(data) != "hello" {
+		t.Errorf("unexpected content %q", data)
+	}
+
+	if _, err = store.Save(ctx, "..", strings.NewReader("x"), 1); err == nil {
+		t.Error("expected an empty name to fail")
+	}
+
+	if err = store.Delete(ctx, "a/b/c.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if err = store.Delete(ctx, "a/b/c.txt"); err != nil {
+		t.Errorf("expected deleting a missing file to succeed, got %v", err)
+	}
+	if ok, _ := store.Exists(ctx, "a/b/c.txt"); ok {
+		t.Error("expected the file to be gone")
+	}
+	if _, err = store.Open(ctx, "a/b/c.txt"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected fs.ErrNotExist, got %v", err)
+	}
+
+	var zero MemoryStore
+	if _, err = zero.Save(ctx, "z.txt", strings.NewReader("z"), 1); err != nil {
+		t.Errorf("expected the zero value to be usable, got %v", err)
+	}
+}
+
+func TestTools_MemoryStoreConcurrent(t *testing.T) {
+	var testTools Tools
+	ctx := context.Background()
+	store := testTools.NewMemoryStore()
+
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			name := string(rune('a' + i))
+			_, _ = store.Save(ctx, name, strings.NewReader(name), 1)
+			_, _ = store.Exists(ctx, name)
+			_ = store.Delete(ctx, name)
+		}()
+	}
+	wg.Wait()
+}
+
+func TestTools_UploadFilesMemoryStore(t *testing.T) {
+	for _, stream := range []bool{false, true} {
+		testTools := Tools{StreamUploads: stream}
+		store := testTools.NewMemoryStore()
+		testTools.Storage = store
+
+		content := map[string][]byte{"img.png": testPNG(t), "other.png": testPNG(t)}
+		files, err := testTools.UploadFiles(newUploadRequest(t, content), "avatars", false)
+		if err != nil || len(files) != 2 {
+			t.Fatalf("stream %v: expected two files, got %v %v", stream, files, err)
+		}
+		for _, file := range files {
+			rc, err := store.Open(context.Background(), "avatars/"+file.NewFileName)
+			if err != nil {
+				t.Errorf("stream %v: expected %s in the store, got %v", stream, file.NewFileName, err)
+				continue
+			}
+			data, _ := io.ReadAll(rc)
+			rc.Close()
+			if !bytes.Equal(data, content[file.OriginalFileName]) || file.FileSize != int64(len(data)) {
+				t.Errorf("stream %v: unexpected content of %s", stream, file.NewFileName)
+			}
+		}
+	}
+}
@@ -0,0 +1,223 @@
+package toolkit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// MetricsOptions configures NewMetrics.
+type MetricsOptions struct {
+	// Namespace prefixes every metric name, "toolkit" when empty.
+	Namespace string
+	// Registerer and Gatherer are where metrics are registered and read from by Handler. A new
+	// registry is used when Registerer is nil; pass prometheus.DefaultRegisterer and
+	// prometheus.DefaultGatherer to share the global one.
+	Registerer prometheus.Registerer
+	Gatherer   prometheus.Gatherer
+	// Buckets are the request duration histogram buckets in seconds, prometheus.DefBuckets when nil.
+	Buckets []float64
+}
+
+// Metrics holds the Prometheus collectors of the HTTP middleware and, when assigned to
+// Tools.Metrics, of uploads, downloads, remote calls and background jobs. A nil *Metrics records
+// nothing.
+type Metrics struct {
+	requests     *prometheus.CounterVec
+	duration     *prometheus.HistogramVec
+	responseSize *prometheus.HistogramVec
+	inFlight     prometheus.Gauge
+
+	uploads       *prometheus.CounterVec
+	uploadBytes   prometheus.Counter
+	downloads     *prometheus.CounterVec
+	remoteCalls   *prometheus.CounterVec
+	remoteRetries *prometheus.CounterVec
+	remoteLatency *prometheus.HistogramVec
+	jobs          *prometheus.CounterVec
+	jobQueue      *prometheus.GaugeVec
+
+	gatherer prometheus.Gatherer
+}
+
+// NewMetrics creates and registers the toolkit's collectors.
+func (t *Tools) NewMetrics(opts MetricsOptions) (*Metrics, error) {
+	if opts.Namespace == "" {
+		opts.Namespace = "toolkit"
+	}
+	if opts.Registerer == nil {
+		registry := prometheus.NewRegistry()
+		opts.Registerer, opts.Gatherer = registry, registry
+	}
+	if opts.Gatherer == nil {
+		opts.Gatherer = prometheus.DefaultGatherer
+	}
+	if opts.Buckets == nil {
+		opts.Buckets = prometheus.DefBuckets
+	}
+
+	ns := opts.Namespace
+	m := &Metrics{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: ns, Name: "http_requests_total", Help: "HTTP requests by route, method and status class.",
+		}, []string{"route", "method", "status"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: ns, Name: "http_request_duration_seconds", Help: "HTTP request duration.", Buckets: opts.Buckets,
+		}, []string{"route", "method", "status"}),
+		responseSize: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: ns, Name: "http_response_size_bytes", Help: "HTTP response body size.",
+			Buckets: prometheus.ExponentialBuckets(100, 10, 7),
+		}, []string{"route", "method", "status"}),
+		inFlight: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: ns, Name: "http_requests_in_flight", Help: "HTTP requests being served.",
+		}),
+		uploads: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: ns, Name: "uploads_total", Help: "Uploaded files by result, ok or the reason they failed.",
+		}, []string{"result"}),
+		uploadBytes: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: ns, Name: "upload_bytes_total", Help: "Bytes stored by uploads.",
+		}),
+		downloads: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: ns, Name: "downloads_total", Help: "Downloads served by status class.",
+		}, []string{"status"}),
+		remoteCalls: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: ns, Name: "remote_calls_total", Help: "Outgoing remote calls by host and status class.",
+		}, []string{"host", "status"}),
+		remoteRetries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: ns, Name: "remote_retries_total", Help: "Outgoing remote calls repeated by a retry policy, by host.",
+		}, []string{"host"}),
+		remoteLatency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: ns, Name: "remote_call_duration_seconds", Help: "Outgoing remote call duration.", Buckets: opts.Buckets,
+		}, []string{"host"}),
+		jobs: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: ns, Name: "jobs_total", Help: "Background job attempts by runner and result.",
+		}, []string{"runner", "result"}),
+		jobQueue: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: ns, Name: "jobs_queue_depth", Help: "Background jobs waiting for a worker.",
+		}, []string{"runner"}),
+		gatherer: opts.Gatherer,
+	}
+
+	for _, c := range []prometheus.Collector{m.requests, m.duration, m.responseSize, m.inFlight,
+		m.uploads, m.uploadBytes, m.downloads, m.remoteCalls, m.remoteRetries, m.remoteLatency, m.jobs, m.jobQueue} {
+		if err := opts.Registerer.Register(c); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// Handler serves the gathered metrics, typically mounted at /metrics.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.gatherer, promhttp.HandlerOpts{})
+}
+
+// Middleware records request counts, durations, response sizes and in-flight requests. Requests
+// are labelled with the http.ServeMux pattern that matched them rather than the raw path, which
+// keeps label cardinality bounded; requests no pattern matched are labelled "unmatched".
+func (m *Metrics) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		m.inFlight.Inc()
+		defer m.inFlight.Dec()
+
+		start := time.Now()
+		sw := &statusWriter{ResponseWriter: w}
+		next.ServeHTTP(sw, r)
+
+		route := r.Pattern
+		if route == "" {
+			route = "unmatched"
+		}
+		labels := prometheus.Labels{"route": route, "method": r.Method, "status": statusClass(sw.code())}
+		m.requests.With(labels).Inc()
+		m.duration.With(labels).Observe(time.Since(start).Seconds())
+		m.responseSize.With(labels).Observe(float64(sw.bytes))
+	})
+}
+
+func (m *Metrics) observeUpload(size int64, err error) {
+	if m == nil {
+		return
+	}
+	if err != nil {
+		m.uploads.WithLabelValues(uploadFailureReason(err)).Inc()
+		return
+	}
+	m.uploads.WithLabelValues("ok").Inc()
+	m.uploadBytes.Add(float64(size))
+}
+
+// uploadError is an upload rejected for reason, the result uploads_total counts it under.
+type uploadError struct {
+	reason string
+	err    error
+}
+
+func (e *uploadError) Error() string { return e.err.Error() }
+
+func (e *uploadError) Unwrap() error { return e.err }
+
+// uploadFailureReason is the result uploads_total counts the failed upload err under.
+func uploadFailureReason(err error) string {
+	var uploadErr *uploadError
+	switch {
+	case errors.As(err, &uploadErr):
+		return uploadErr.reason
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return "canceled"
+	case errors.Is(err, ErrInsufficientStorage):
+		return "insufficient_storage"
+	case errors.Is(err, ErrChecksumMismatch):
+		return "checksum_mismatch"
+	}
+	return "error"
+}
+
+func (m *Metrics) observeDownload(status int) {
+	if m == nil {
+		return
+	}
+	m.downloads.WithLabelValues(statusClass(status)).Inc()
+}
+
+func (m *Metrics) observeRemoteCall(host string, status int, d time.Duration) {
+	if m == nil {
+		return
+	}
+	class := "error"
+	if status > 0 {
+		class = statusClass(status)
+	}
+	m.remoteCalls.WithLabelValues(host, class).Inc()
+	m.remoteLatency.WithLabelValues(host).Observe(d.Seconds())
+}
+
+func (m *Metrics) observeRemoteRetry(host string) {
+	if m == nil {
+		return
+	}
+	m.remoteRetries.WithLabelValues(host).Inc()
+}
+
+func (m *Metrics) observeJob(runner, result string) {
+	if m == nil {
+		return
+	}
+	m.jobs.WithLabelValues(runner, result).Inc()
+}
+
+func (m *Metrics) setJobQueueDepth(runner string, depth int) {
+	if m == nil {
+		return
+	}
+	m.jobQueue.WithLabelValues(runner).Set(float64(depth))
+}
+
+func statusClass(status int) string {
+	return strconv.Itoa(status/100) + "xx"
+}
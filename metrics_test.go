@@ -0,0 +1,89 @@
+package toolkit
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestTools_Metrics(t *testing.T) {
+	var testTools Tools
+	metrics, err := testTools.NewMetrics(MetricsOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	testTools.Metrics = metrics
+
+	dir := t.TempDir()
+	_ = os.WriteFile(filepath.Join(dir, "report.txt"), []byte("report"), 0644)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /orders/{id}", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("order"))
+	})
+	mux.HandleFunc("GET /files/{name}", func(w http.ResponseWriter, r *http.Request) {
+		testTools.DownloadStaticFile(w, r, dir, r.PathValue("name"), r.PathValue("name"))
+	})
+	h := metrics.Middleware(mux)
+
+	for _, path := range []string{"/orders/1", "/orders/2", "/files/report.txt", "/files/missing.txt", "/nowhere"} {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	if _, err = testTools.UploadFiles(newUploadRequest(t, map[string][]byte{"img.png": testPNG(t)}), t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+
+	testTools.AllowedFileTypes = []string{"image/png"}
+	testTools.ContinueOnUploadError = true
+	_, _ = testTools.UploadFiles(newUploadRequest(t, map[string][]byte{"notes.txt": []byte("plain text")}), t.TempDir())
+	testTools.AllowedFileTypes = nil
+
+	calls := 0
+	client := NewTestClient(func(req *http.Request) *http.Response {
+		if calls++; calls == 1 {
+			return &http.Response{StatusCode: http.StatusServiceUnavailable, Body: io.NopCloser(bytes.NewBufferString("busy")), Header: make(http.Header)}
+		}
+		return &http.Response{StatusCode: http.StatusCreated, Body: io.NopCloser(bytes.NewBufferString("ok")), Header: make(http.Header)}
+	})
+	testTools.RemoteRetry = &RetryPolicy{Backoff: ConstantBackoff(time.Millisecond)}
+	_, _, _ = testTools.PushJSONToRemote("http://remote.example/hook", map[string]string{"a": "b"}, client)
+
+	rr := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := rr.Body.String()
+	for _, want := range []string{
+		`toolkit_http_requests_total{method="GET",route="GET /orders/{id}",status="2xx"} 2`,
+		`toolkit_http_requests_total{method="GET",route="unmatched",status="4xx"} 1`,
+		`toolkit_http_request_duration_seconds_count{method="GET",route="GET /files/{name}",status="4xx"} 1`,
+		`toolkit_http_response_size_bytes_sum{method="GET",route="GET /orders/{id}",status="2xx"} 10`,
+		`toolkit_http_requests_in_flight 0`,
+		`toolkit_downloads_total{status="2xx"} 1`,
+		`toolkit_downloads_total{status="4xx"} 1`,
+		`toolkit_uploads_total{result="ok"} 1`,
+		`toolkit_uploads_total{result="type_not_allowed"} 1`,
+		`toolkit_remote_calls_total{host="remote.example",status="2xx"} 1`,
+		`toolkit_remote_calls_total{host="remote.example",status="5xx"} 1`,
+		`toolkit_remote_retries_total{host="remote.example"} 1`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics missing %s", want)
+		}
+	}
+
+	registry := prometheus.NewRegistry()
+	if _, err = testTools.NewMetrics(MetricsOptions{Registerer: registry, Gatherer: registry}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = testTools.NewMetrics(MetricsOptions{Registerer: registry, Gatherer: registry}); err == nil {
+		t.Error("expected registering the same collectors twice to fail")
+	}
+}
@@ -0,0 +1,93 @@
+package toolkit
+
+import (
+	"mime"
+	"strings"
+)
+
+var builtinMIMETypes = map[string]string{
+	".7z":     "application/x-7z-compressed",
+	".avif":   "image/avif",
+	".bmp":    "image/bmp",
+	".css":    "text/css; charset=utf-8",
+	".csv":    "text/csv; charset=utf-8",
+	".doc":    "application/msword",
+	".docx":   "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+	".gif":    "image/gif",
+	".gz":     "application/gzip",
+	".heic":   "image/heic",
+	".htm":    "text/html; charset=utf-8",
+	".html":   "text/html; charset=utf-8",
+	".ico":    "image/x-icon",
+	".jpeg":   "image/jpeg",
+	".jpg":    "image/jpeg",
+	".js":     "text/javascript; charset=utf-8",
+	".json":   "application/json",
+	".md":     "text/markdown; charset=utf-8",
+	".mjs":    "text/javascript; charset=utf-8",
+	".mov":    "video/quicktime",
+	".mp3":    "audio/mpeg",
+	".mp4":    "video/mp4",
+	".ndjson": "application/x-ndjson",
+	".odt":    "application/vnd.oasis.opendocument.text",
+	".ods":    "application/vnd.oasis.opendocument.spreadsheet",
+	".ogg":    "audio/ogg",
+	".pdf":    "application/pdf",
+	".png":    "image/png",
+	".ppt":    "application/vnd.ms-powerpoint",
+	".pptx":   "application/vnd.openxmlformats-officedocument.presentationml.presentation",
+	".rar":    "application/vnd.rar",
+	".svg":    "image/svg+xml",
+	".tar":    "application/x-tar",
+	".tgz":    "application/gzip",
+	".tif":    "image/tiff",
+	".tiff":   "image/tiff",
+	".txt":    "text/plain; charset=utf-8",
+	".wasm":   "application/wasm",
+	".wav":    "audio/wav",
+	".webm":   "video/webm",
+	".webp":   "image/webp",
+	".woff":   "font/woff",
+	".woff2":  "font/woff2",
+	".xls":    "application/vnd.ms-excel",
+	".xlsx":   "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	".xml":    "application/xml; charset=utf-8",
+	".yaml":   "application/yaml",
+	".yml":    "application/yaml",
+	".zip":    "application/zip",
+}
+
+// MIMEByExtension returns the MIME type for a file extension such as ".png" (the leading dot is
+// optional). Types registered with RegisterMIME come first, then the built-in table, then the
+// system MIME database; unknown extensions map to "application/octet-stream".
+func (t *Tools) MIMEByExtension(ext string) string {
+	ext = normalizeExtension(ext)
+
+	if typ, ok := t.MIMETypes[ext]; ok {
+		return typ
+	}
+	if typ, ok := builtinMIMETypes[ext]; ok {
+		return typ
+	}
+	if typ := mime.TypeByExtension(ext); typ != "" {
+		return typ
+	}
+	return "application/octet-stream"
+}
+
+// RegisterMIME maps ext to typ for this Tools value, overriding the built-in table. It is meant to
+// be called while configuring Tools, before it is used concurrently.
+func (t *Tools) RegisterMIME(ext, typ string) {
+	if t.MIMETypes == nil {
+		t.MIMETypes = make(map[string]string)
+	}
+	t.MIMETypes[normalizeExtension(ext)] = typ
+}
+
+func normalizeExtension(ext string) string {
+	ext = strings.ToLower(ext)
+	if ext != "" && !strings.HasPrefix(ext, ".") {
+		ext = "." + ext
+	}
+	return ext
+}
@@ -0,0 +1,56 @@
+package toolkit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var mimeTests = []struct {
+	ext      string
+	expected string
+}{
+	{ext: ".png", expected: "image/png"},
+	{ext: "PNG", expected: "image/png"},
+	{ext: ".docx", expected: "application/vnd.openxmlformats-officedocument.wordprocessingml.document"},
+	{ext: ".csv", expected: "text/csv; charset=utf-8"},
+	{ext: ".unknown-ext", expected: "application/octet-stream"},
+	{ext: "", expected: "application/octet-stream"},
+}
+
+func TestTools_MIMEByExtension(t *testing.T) {
+	var testTools Tools
+	for _, test := range mimeTests {
+		if typ := testTools.MIMEByExtension(test.ext); typ != test.expected {
+			t.Errorf("%s: expected %s, got %s", test.ext, test.expected, typ)
+		}
+	}
+}
+
+func TestTools_RegisterMIME(t *testing.T) {
+	var testTools Tools
+	testTools.RegisterMIME("tkx", "image/vnd.dwg")
+	testTools.RegisterMIME(".PNG", "image/x-custom-png")
+
+	if typ := testTools.MIMEByExtension(".tkx"); typ != "image/vnd.dwg" {
+		t.Error("custom type not registered:", typ)
+	}
+	if typ := testTools.MIMEByExtension(".png"); typ != "image/x-custom-png" {
+		t.Error("built-in type not overridden:", typ)
+	}
+
+	var other Tools
+	if typ := other.MIMEByExtension(".tkx"); typ != "application/octet-stream" {
+		t.Error("registration leaked into another Tools value:", typ)
+	}
+
+	dir := t.TempDir()
+	_ = os.WriteFile(filepath.Join(dir, "plan.tkx"), []byte("drawing"), 0644)
+	rr := httptest.NewRecorder()
+	testTools.DownloadStaticFile(rr, httptest.NewRequest(http.MethodGet, "/", nil), dir, "plan.tkx", "plan.tkx")
+	if ct := rr.Header().Get("Content-Type"); ct != "image/vnd.dwg" {
+		t.Error("download used wrong content type:", ct)
+	}
+}
@@ -0,0 +1,127 @@
+package toolkit
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+)
+
+// encodeMsgPack writes data to w in MessagePack. data is first marshaled to JSON, so json tags
+// and MarshalJSON methods shape the output as they do for WriteJSON, and then re-encoded; map keys
+// are written in sorted order.
+func encodeMsgPack(w io.Writer, data any) error {
+	out, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(out))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return err
+	}
+	b, err := appendMsgPack(nil, v)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(b)
+	return err
+}
+
+// appendMsgPack appends the encoding of v, a value decoded from JSON with UseNumber, to b.
+func appendMsgPack(b []byte, v any) ([]byte, error) {
+	var err error
+	switch v := v.(type) {
+	case nil:
+		return append(b, 0xc0), nil
+	case bool:
+		if v {
+			return append(b, 0xc3), nil
+		}
+		return append(b, 0xc2), nil
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return appendMsgPackInt(b, n), nil
+		}
+		if n, err := strconv.ParseUint(string(v), 10, 64); err == nil {
+			return binary.BigEndian.AppendUint64(append(b, 0xcf), n), nil
+		}
+		f, err := v.Float64()
+		if err != nil {
+			return nil, err
+		}
+		return binary.BigEndian.AppendUint64(append(b, 0xcb), math.Float64bits(f)), nil
+	case string:
+		b = appendMsgPackHeader(b, len(v), 0xa0, 32, 0xd9, 0xda, 0xdb)
+		return append(b, v...), nil
+	case []any:
+		b = appendMsgPackHeader(b, len(v), 0x90, 16, 0, 0xdc, 0xdd)
+		for _, e := range v {
+			if b, err = appendMsgPack(b, e); err != nil {
+				return nil, err
+			}
+		}
+		return b, nil
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		b = appendMsgPackHeader(b, len(v), 0x80, 16, 0, 0xde, 0xdf)
+		for _, k := range keys {
+			if b, err = appendMsgPack(b, k); err != nil {
+				return nil, err
+			}
+			if b, err = appendMsgPack(b, v[k]); err != nil {
+				return nil, err
+			}
+		}
+		return b, nil
+	}
+	return nil, fmt.Errorf("msgpack: unsupported type %T", v)
+}
+
+// appendMsgPackInt appends n in the shortest integer format.
+func appendMsgPackInt(b []byte, n int64) []byte {
+	switch {
+	case n >= 0 && n <= math.MaxInt8:
+		return append(b, byte(n))
+	case n < 0 && n >= -32:
+		return append(b, byte(n))
+	case n >= 0 && n <= math.MaxUint8:
+		return append(b, 0xcc, byte(n))
+	case n >= 0 && n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(b, 0xcd), uint16(n))
+	case n >= 0 && n <= math.MaxUint32:
+		return binary.BigEndian.AppendUint32(append(b, 0xce), uint32(n))
+	case n >= 0:
+		return binary.BigEndian.AppendUint64(append(b, 0xcf), uint64(n))
+	case n >= math.MinInt8:
+		return append(b, 0xd0, byte(n))
+	case n >= math.MinInt16:
+		return binary.BigEndian.AppendUint16(append(b, 0xd1), uint16(n))
+	case n >= math.MinInt32:
+		return binary.BigEndian.AppendUint32(append(b, 0xd2), uint32(n))
+	}
+	return binary.BigEndian.AppendUint64(append(b, 0xd3), uint64(n))
+}
+
+// appendMsgPackHeader appends the header of a string, array or map of n items: the fix format
+// with fixMax items at most, then those with 8 bit, when it exists, 16 bit and 32 bit lengths.
+func appendMsgPackHeader(b []byte, n int, fix byte, fixMax int, f8, f16, f32 byte) []byte {
+	switch {
+	case n < fixMax:
+		return append(b, fix|byte(n))
+	case f8 != 0 && n <= math.MaxUint8:
+		return append(b, f8, byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(b, f16), uint16(n))
+	}
+	return binary.BigEndian.AppendUint32(append(b, f32), uint32(n))
+}
@@ -0,0 +1,56 @@
+package toolkit
+
+import (
+	"bytes"
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+var msgPackTests = []struct {
+	name     string
+	data     any
+	expected string
+}{
+	{name: "nil", data: nil, expected: "c0"},
+	{name: "bools", data: []bool{true, false}, expected: "92c3c2"},
+	{name: "fixint", data: 127, expected: "7f"},
+	{name: "negative fixint", data: -32, expected: "e0"},
+	{name: "uint8", data: 200, expected: "ccc8"},
+	{name: "uint16", data: 1000, expected: "cd03e8"},
+	{name: "uint32", data: 70000, expected: "ce00011170"},
+	{name: "int8", data: -100, expected: "d09c"},
+	{name: "int16", data: -1000, expected: "d1fc18"},
+	{name: "int64", data: int64(-1) << 40, expected: "d3ffffff0000000000"},
+	{name: "uint64", data: uint64(1) << 63, expected: "cf8000000000000000"},
+	{name: "float", data: 1.5, expected: "cb3ff8000000000000"},
+	{name: "fixstr", data: "abc", expected: "a3616263"},
+	{name: "str8", data: strings.Repeat("a", 32), expected: "d920" + strings.Repeat("61", 32)},
+	{name: "struct", data: struct {
+		B string `json:"b"`
+		A []int  `json:"a"`
+	}{B: "x", A: []int{1}}, expected: "82a1619101a162a178"},
+}
+
+func TestTools_EncodeMsgPack(t *testing.T) {
+	for _, test := range msgPackTests {
+		var buf bytes.Buffer
+		if err := encodeMsgPack(&buf, test.data); err != nil {
+			t.Errorf("%s: unexpected error %v", test.name, err)
+			continue
+		}
+		if got := hex.EncodeToString(buf.Bytes()); got != test.expected {
+			t.Errorf("%s: expected %s, got %s", test.name, test.expected, got)
+		}
+	}
+
+	long := make([]int, 16)
+	var buf bytes.Buffer
+	if err := encodeMsgPack(&buf, long); err != nil || !bytes.HasPrefix(buf.Bytes(), []byte{0xdc, 0, 16}) || buf.Len() != 19 {
+		t.Errorf("expected an array16 of 16 items, got %x %v", buf.Bytes(), err)
+	}
+
+	if err := encodeMsgPack(&buf, make(chan int)); err == nil {
+		t.Error("expected an error for a value JSON cannot encode")
+	}
+}
@@ -0,0 +1,233 @@
+package toolkit
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"unicode"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Password hashing algorithms of PasswordOptions.
+const (
+	PasswordBcrypt   = "bcrypt"
+	PasswordArgon2id = "argon2id"
+)
+
+// ErrPasswordMismatch is returned by ComparePassword when the password does not match the hash.
+var ErrPasswordMismatch = errors.New("password does not match")
+
+// PasswordOptions configures HashPassword. ComparePassword reads the algorithm and parameters
+// from the hash, so changing them keeps existing hashes working.
+type PasswordOptions struct {
+	// Algorithm is PasswordBcrypt or PasswordArgon2id, bcrypt when empty.
+	Algorithm string
+	// BcryptCost is the bcrypt work factor, bcrypt.DefaultCost when 0.
+	BcryptCost int
+	// Argon2Time is the number of argon2id passes, 3 when 0.
+	Argon2Time uint32
+	// Argon2Memory is the memory argon2id uses, in KiB, 64 MiB when 0.
+	Argon2Memory uint32
+	// Argon2Threads is the parallelism of argon2id, 4 when 0.
+	Argon2Threads uint8
+}
+
+// HashPassword hashes password with the algorithm of Tools.Password, bcrypt with the default
+// cost when it is nil. Argon2id hashes are encoded in the PHC string format:
+//
+//	$argon2id$v=19$m=65536,t=3,p=4$<salt>$<hash>
+//
+// bcrypt only uses the first 72 bytes of a password and fails for longer ones.
+func (t *Tools) HashPassword(password string) (string, error) {
+	var opts PasswordOptions
+	if t.Password != nil {
+		opts = *t.Password
+	}
+
+	switch opts.Algorithm {
+	case "", PasswordBcrypt:
+		cost := opts.BcryptCost
+		if cost == 0 {
+			cost = bcrypt.DefaultCost
+		}
+		hash, err := bcrypt.GenerateFromPassword([]byte(password), cost)
+		if err != nil {
+			return "", fmt.Errorf("password: %w", err)
+		}
+		return string(hash), nil
+	case PasswordArgon2id:
+		p := argon2Params{time: opts.Argon2Time, memory: opts.Argon2Memory, threads: opts.Argon2Threads}
+		if p.time == 0 {
+			p.time = 3
+		}
+		if p.memory == 0 {
+			p.memory = 64 * 1024
+		}
+		if p.threads == 0 {
+			p.threads = 4
+		}
+		salt := make([]byte, 16)
+		if _, err := rand.Read(salt); err != nil {
+			return "", err
+		}
+		key := argon2.IDKey([]byte(password), salt, p.time, p.memory, p.threads, 32)
+		return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version, p.memory, p.time, p.threads,
+			base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+	}
+	return "", fmt.Errorf("password: unsupported algorithm %q", opts.Algorithm)
+}
+
+// ComparePassword checks password against hash, made by HashPassword with either algorithm. It
+// returns ErrPasswordMismatch when they do not match and another error when hash is malformed.
+func (t *Tools) ComparePassword(hash, password string) error {
+	if strings.HasPrefix(hash, "$argon2id$") {
+		p, salt, key, err := parseArgon2Hash(hash)
+		if err != nil {
+			return err
+		}
+		other := argon2.IDKey([]byte(password), salt, p.time, p.memory, p.threads, uint32(len(key)))
+		if subtle.ConstantTimeCompare(key, other) != 1 {
+			return ErrPasswordMismatch
+		}
+		return nil
+	}
+
+	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return ErrPasswordMismatch
+	}
+	if err != nil {
+		return fmt.Errorf("password: %w", err)
+	}
+	return nil
+}
+
+type argon2Params struct {
+	time    uint32
+	memory  uint32
+	threads uint8
+}
+
+// parseArgon2Hash decodes an argon2id hash in the PHC string format.
+func parseArgon2Hash(hash string) (p argon2Params, salt, key []byte, err error) {
+	malformed := errors.New("password: malformed argon2id hash")
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 {
+		return p, nil, nil, malformed
+	}
+	var version int
+	if _, err = fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return p, nil, nil, malformed
+	}
+	if _, err = fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.memory, &p.time, &p.threads); err != nil || p.time == 0 || p.threads == 0 {
+		return p, nil, nil, malformed
+	}
+	if salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+		return p, nil, nil, malformed
+	}
+	if key, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil || len(key) == 0 {
+		return p, nil, nil, malformed
+	}
+	return p, salt, key, nil
+}
+
+// Password strength scores of PasswordStrength.
+const (
+	PasswordVeryWeak = iota
+	PasswordWeak
+	PasswordFair
+	PasswordStrong
+	PasswordVeryStrong
+)
+
+// commonPasswords are passwords too common to be worth more than PasswordVeryWeak, compared in
+// lower case.
+var commonPasswords = map[string]bool{
+	"123456": true, "12345678": true, "123456789": true, "1234567890": true, "password": true,
+	"password1": true, "password123": true, "qwerty": true, "qwerty123": true, "qwertyuiop": true,
+	"abc123": true, "111111": true, "letmein": true, "welcome": true, "welcome1": true,
+	"iloveyou": true, "admin": true, "admin123": true, "monkey": true, "dragon": true,
+	"football": true, "baseball": true, "sunshine": true, "princess": true, "trustno1": true,
+	"passw0rd": true, "p@ssw0rd": true, "changeme": true, "secret": true, "superman": true,
+}
+
+// PasswordStrength scores password from PasswordVeryWeak to PasswordVeryStrong by estimating its
+// entropy from its length and the kinds of characters it uses. Repeated characters and runs such
+// as "abc" or "321" count as one, and common passwords and passwords containing one of related,
+// such as the user name or e-mail address, score PasswordVeryWeak.
+func PasswordStrength(password string, related ...string) int {
+	lower := strings.ToLower(password)
+	if commonPasswords[lower] {
+		return PasswordVeryWeak
+	}
+	for _, s := range related {
+		if s = strings.ToLower(strings.TrimSpace(s)); len(s) >= 3 && strings.Contains(lower, s) {
+			return PasswordVeryWeak
+		}
+	}
+
+	var lowers, uppers, digits, symbols, others bool
+	length, step, inRun := 0, rune(0), false
+	var prev rune
+	for i, r := range []rune(password) {
+		switch {
+		case 'a' <= r && r <= 'z':
+			lowers = true
+		case 'A' <= r && r <= 'Z':
+			uppers = true
+		case '0' <= r && r <= '9':
+			digits = true
+		case r <= unicode.MaxASCII:
+			symbols = true
+		default:
+			others = true
+		}
+
+		// Characters continuing a repetition or a run of consecutive ones add nothing.
+		if d := r - prev; i > 0 && d >= -1 && d <= 1 && (!inRun || d == step) {
+			inRun, step = true, d
+		} else {
+			inRun = false
+			length++
+		}
+		prev = r
+	}
+
+	pool := 0
+	for _, class := range []struct {
+		used bool
+		size int
+	}{{lowers, 26}, {uppers, 26}, {digits, 10}, {symbols, 33}, {others, 100}} {
+		if class.used {
+			pool += class.size
+		}
+	}
+	if pool == 0 {
+		return PasswordVeryWeak
+	}
+
+	switch bits := float64(length) * math.Log2(float64(pool)); {
+	case bits < 28:
+		return PasswordVeryWeak
+	case bits < 36:
+		return PasswordWeak
+	case bits < 60:
+		return PasswordFair
+	case bits < 80:
+		return PasswordStrong
+	}
+	return PasswordVeryStrong
+}
+
+// StrongPassword checks that value scores at least PasswordStrong with PasswordStrength, given
+// the related inputs of the user. Empty values pass, so use Required for mandatory passwords.
+func (v *Validator) StrongPassword(field, value string, related ...string) *Validator {
+	return v.checkKey(value == "" || PasswordStrength(value, related...) >= PasswordStrong, field,
+		"validation.password", "is too weak")
+}
@@ -0,0 +1,87 @@
+package toolkit
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestTools_HashPassword(t *testing.T) {
+	for _, opts := range []*PasswordOptions{
+		nil,
+		{Algorithm: PasswordBcrypt, BcryptCost: bcrypt.MinCost},
+		{Algorithm: PasswordArgon2id, Argon2Time: 1, Argon2Memory: 1024, Argon2Threads: 1},
+	} {
+		testTools := Tools{Password: opts}
+		hash, err := testTools.HashPassword("correct horse battery staple")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = testTools.ComparePassword(hash, "correct horse battery staple"); err != nil {
+			t.Errorf("%s: expected the password to match, got %s", hash, err)
+		}
+		if err = testTools.ComparePassword(hash, "Correct horse battery staple"); !errors.Is(err, ErrPasswordMismatch) {
+			t.Errorf("%s: expected ErrPasswordMismatch, got %v", hash, err)
+		}
+		// Hashes stay comparable after the algorithm changes.
+		if err = (&Tools{}).ComparePassword(hash, "correct horse battery staple"); err != nil {
+			t.Errorf("%s: expected the password to match with other options, got %s", hash, err)
+		}
+	}
+
+	argon := Tools{Password: &PasswordOptions{Algorithm: PasswordArgon2id, Argon2Time: 1, Argon2Memory: 1024, Argon2Threads: 1}}
+	hash, _ := argon.HashPassword("secret")
+	if !strings.HasPrefix(hash, "$argon2id$v=19$m=1024,t=1,p=1$") {
+		t.Errorf("unexpected argon2id hash %q", hash)
+	}
+	if other, _ := argon.HashPassword("secret"); other == hash {
+		t.Error("expected a new salt for each hash")
+	}
+
+	var testTools Tools
+	for _, malformed := range []string{"", "plain", "$argon2id$v=19$m=1024$salt$key", "$argon2id$v=18$m=1024,t=1,p=1$c2FsdA$a2V5"} {
+		if err := testTools.ComparePassword(malformed, "secret"); err == nil || errors.Is(err, ErrPasswordMismatch) {
+			t.Errorf("%q: expected a malformed hash error, got %v", malformed, err)
+		}
+	}
+	if _, err := testTools.HashPassword(strings.Repeat("a", 73)); err == nil {
+		t.Error("expected an error for a password longer than bcrypt accepts")
+	}
+	if _, err := (&Tools{Password: &PasswordOptions{Algorithm: "md5"}}).HashPassword("secret"); err == nil {
+		t.Error("expected an error for an unsupported algorithm")
+	}
+}
+
+var passwordStrengthTests = []struct {
+	password string
+	related  []string
+	score    int
+}{
+	{password: "", score: PasswordVeryWeak},
+	{password: "Password", score: PasswordVeryWeak},
+	{password: "abcdefghijkl", score: PasswordVeryWeak},
+	{password: "aaaaaaaaaaaaaaaa", score: PasswordVeryWeak},
+	{password: "kx7q", score: PasswordVeryWeak},
+	{password: "kx7qm2", score: PasswordWeak},
+	{password: "kx7qm2vb", score: PasswordFair},
+	{password: "Kx7q!m2vB9", score: PasswordStrong},
+	{password: "correct horse battery staple", score: PasswordVeryStrong},
+	{password: "JohnSmith-Kx7q!m2vB9", related: []string{"johnsmith"}, score: PasswordVeryWeak},
+	{password: "Kx7q!m2vB9", related: []string{"", "x"}, score: PasswordStrong},
+}
+
+func TestPasswordStrength(t *testing.T) {
+	for _, test := range passwordStrengthTests {
+		if score := PasswordStrength(test.password, test.related...); score != test.score {
+			t.Errorf("%q: expected score %d, got %d", test.password, test.score, score)
+		}
+	}
+
+	var testTools Tools
+	v := testTools.NewValidator().StrongPassword("password", "kx7qm2vb").StrongPassword("other", "")
+	if v.Errors["password"] != "is too weak" || len(v.Errors) != 1 {
+		t.Errorf("unexpected errors %v", v.Errors)
+	}
+}
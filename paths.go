@@ -0,0 +1,41 @@
+package toolkit
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// dirMode is the mode for directories created by the toolkit: Tools.DirMode or 0755.
+func (t *Tools) dirMode() fs.FileMode {
+	if t.DirMode != 0 {
+		return t.DirMode
+	}
+	return 0755
+}
+
+// fileMode is the mode for files created by the toolkit: Tools.FileMode or 0644.
+func (t *Tools) fileMode() fs.FileMode {
+	if t.FileMode != 0 {
+		return t.FileMode
+	}
+	return 0644
+}
+
+// tempRoot is the directory temporary files and directories are created in: Tools.TempRoot or
+// the system default.
+func (t *Tools) tempRoot() string {
+	if t.TempRoot != "" {
+		return t.TempRoot
+	}
+	return os.TempDir()
+}
+
+// uploadPath resolves dir against Tools.BaseUploadDir. Absolute directories are used as they are,
+// relative ones (including "") are confined below BaseUploadDir with SecureJoin.
+func (t *Tools) uploadPath(dir string) (string, error) {
+	if t.BaseUploadDir == "" || filepath.IsAbs(dir) {
+		return dir, nil
+	}
+	return t.SecureJoin(t.BaseUploadDir, dir)
+}
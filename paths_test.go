@@ -0,0 +1,64 @@
+package toolkit
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTools_BaseUploadDir(t *testing.T) {
+	base := t.TempDir()
+	testTools := Tools{BaseUploadDir: base, FileMode: 0600, DirMode: 0700}
+
+	uploadedFiles, err := testTools.UploadFiles(newUploadRequest(t, map[string][]byte{"img.png": testPNG(t)}), "avatars", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := os.Stat(filepath.Join(base, "avatars", uploadedFiles[0].NewFileName))
+	if err != nil {
+		t.Fatal("expected file below BaseUploadDir:", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("expected FileMode to be applied, got %v", info.Mode().Perm())
+	}
+	if info, _ = os.Stat(filepath.Join(base, "avatars")); info.Mode().Perm() != 0700 {
+		t.Errorf("expected DirMode to be applied, got %v", info.Mode().Perm())
+	}
+
+	if p, _ := testTools.uploadPath("../../etc"); p != filepath.Join(base, "etc") {
+		t.Error("relative upload dir escaped BaseUploadDir:", p)
+	}
+	if p, _ := testTools.uploadPath("/srv/uploads"); p != "/srv/uploads" {
+		t.Error("absolute upload dir should be used as is:", p)
+	}
+}
+
+func TestTools_TempRootConfig(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "tmp")
+	testTools := Tools{TempRoot: root}
+
+	dir, err := testTools.TempDir("job-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dir.Close()
+
+	if !strings.HasPrefix(dir.Path, root) {
+		t.Errorf("expected temp dir inside %s, got %s", root, dir.Path)
+	}
+}
+
+func TestTools_CopyFileFileMode(t *testing.T) {
+	testTools := Tools{FileMode: 0640}
+	dir := t.TempDir()
+	_ = os.WriteFile(filepath.Join(dir, "a.txt"), []byte("a"), 0600)
+
+	if _, err := testTools.CopyFile(filepath.Join(dir, "a.txt"), filepath.Join(dir, "b.txt")); err != nil {
+		t.Fatal(err)
+	}
+	if info, _ := os.Stat(filepath.Join(dir, "b.txt")); info.Mode().Perm() != 0640 {
+		t.Errorf("expected FileMode to be applied, got %v", info.Mode().Perm())
+	}
+}
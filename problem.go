@@ -0,0 +1,72 @@
+package toolkit
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"net/http"
+)
+
+var (
+	// ErrNotFound marks errors about a missing resource. ErrorJSON responds to them with 404 Not
+	// Found, as it does to those matching fs.ErrNotExist and ErrUploadNotFound.
+	ErrNotFound = errors.New("not found")
+	// ErrConflict marks errors about a request conflicting with the state of a resource, such
+	// as a duplicate. ErrorJSON responds to them with 409 Conflict.
+	ErrConflict = errors.New("conflict")
+)
+
+// ProblemDetails is an RFC 7807 problem+json response body, extended with the field errors of a
+// failed validation and the request ID.
+type ProblemDetails struct {
+	Type      string            `json:"type"`
+	Title     string            `json:"title"`
+	Status    int               `json:"status"`
+	Detail    string            `json:"detail,omitempty"`
+	Instance  string            `json:"instance,omitempty"`
+	Errors    map[string]string `json:"errors,omitempty"`
+	RequestID string            `json:"request_id,omitempty"`
+}
+
+// WriteProblem writes an application/problem+json response. title defaults to the status text,
+// detail explains this occurrence of the problem and fields, when not empty, are field errors
+// like those of ErrorsJSON.
+func (t *Tools) WriteProblem(w http.ResponseWriter, status int, title, detail string, fields map[string]string) error {
+	if title == "" {
+		title = http.StatusText(status)
+	}
+	problem := ProblemDetails{
+		Type:      "about:blank",
+		Title:     title,
+		Status:    status,
+		Detail:    detail,
+		Errors:    fields,
+		RequestID: w.Header().Get(RequestIDHeader),
+	}
+
+	out, err := json.Marshal(problem)
+	if err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(status)
+	_, err = w.Write(out)
+	return err
+}
+
+// errorStatus returns the status ErrorJSON responds to err with when it is given none.
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrNotFound), errors.Is(err, fs.ErrNotExist), errors.Is(err, ErrUploadNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrConflict):
+		return http.StatusConflict
+	case errors.Is(err, ErrCircuitOpen):
+		return http.StatusServiceUnavailable
+	case errors.Is(err, ErrInsufficientStorage):
+		return http.StatusInsufficientStorage
+	case isBodyTooLarge(err):
+		return http.StatusRequestEntityTooLarge
+	}
+	return http.StatusBadRequest
+}
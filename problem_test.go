@@ -0,0 +1,86 @@
+package toolkit
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTools_WriteProblem(t *testing.T) {
+	var testTools Tools
+
+	rr := httptest.NewRecorder()
+	rr.Header().Set(RequestIDHeader, "abc")
+	if err := testTools.WriteProblem(rr, http.StatusConflict, "", "email is taken", map[string]string{"email": "is taken"}); err != nil {
+		t.Fatal(err)
+	}
+	if rr.Code != http.StatusConflict || rr.Header().Get("Content-Type") != "application/problem+json" {
+		t.Errorf("unexpected response %d %q", rr.Code, rr.Header().Get("Content-Type"))
+	}
+	var problem ProblemDetails
+	if err := json.NewDecoder(rr.Body).Decode(&problem); err != nil {
+		t.Fatal(err)
+	}
+	expected := ProblemDetails{Type: "about:blank", Title: "Conflict", Status: 409, Detail: "email is taken",
+		Errors: map[string]string{"email": "is taken"}, RequestID: "abc"}
+	if fmt.Sprint(problem) != fmt.Sprint(expected) {
+		t.Errorf("expected %+v, got %+v", expected, problem)
+	}
+}
+
+var errorStatusTests = []struct {
+	name     string
+	err      error
+	status   []int
+	expected int
+}{
+	{name: "plain", err: errors.New("bad"), expected: http.StatusBadRequest},
+	{name: "not found", err: fmt.Errorf("user 1: %w", ErrNotFound), expected: http.StatusNotFound},
+	{name: "not exist", err: fs.ErrNotExist, expected: http.StatusNotFound},
+	{name: "conflict", err: fmt.Errorf("user 1: %w", ErrConflict), expected: http.StatusConflict},
+	{name: "storage", err: ErrInsufficientStorage, expected: http.StatusInsufficientStorage},
+	{name: "too large", err: &bodyTooLargeError{limit: 1}, expected: http.StatusRequestEntityTooLarge},
+	{name: "validation", err: &ValidationError{Fields: map[string]string{"a": "b"}}, expected: http.StatusUnprocessableEntity},
+	{name: "explicit", err: ErrNotFound, status: []int{http.StatusGone}, expected: http.StatusGone},
+}
+
+func TestTools_ErrorJSONStatus(t *testing.T) {
+	for _, problemJSON := range []bool{false, true} {
+		testTools := Tools{ProblemJSON: problemJSON}
+		for _, test := range errorStatusTests {
+			rr := httptest.NewRecorder()
+			_ = testTools.ErrorJSON(rr, test.err, test.status...)
+			if rr.Code != test.expected {
+				t.Errorf("%s (problem %v): expected status %d, got %d", test.name, problemJSON, test.expected, rr.Code)
+			}
+		}
+	}
+}
+
+func TestTools_ErrorJSONProblem(t *testing.T) {
+	testTools := Tools{ProblemJSON: true}
+
+	rr := httptest.NewRecorder()
+	_ = testTools.ErrorJSON(rr, fmt.Errorf("user 1: %w", ErrNotFound))
+	var problem ProblemDetails
+	if err := json.NewDecoder(rr.Body).Decode(&problem); err != nil {
+		t.Fatal(err)
+	}
+	if rr.Header().Get("Content-Type") != "application/problem+json" || problem.Title != "Not Found" || problem.Detail != "user 1: not found" || problem.Status != 404 {
+		t.Errorf("unexpected problem %+v", problem)
+	}
+
+	rr = httptest.NewRecorder()
+	_ = testTools.ErrorJSON(rr, testTools.NewValidator().Required("email", "").Err())
+	problem = ProblemDetails{}
+	if err := json.NewDecoder(rr.Body).Decode(&problem); err != nil {
+		t.Fatal(err)
+	}
+	if problem.Title != "validation failed" || problem.Errors["email"] != "must be provided" {
+		t.Errorf("unexpected problem %+v", problem)
+	}
+}
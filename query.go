@@ -0,0 +1,59 @@
+package toolkit
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// QueryInt returns the query parameter key of r as an int, or def when it is missing or not an
+// integer.
+func (t *Tools) QueryInt(r *http.Request, key string, def int) int {
+	n, err := strconv.Atoi(r.URL.Query().Get(key))
+	if err != nil {
+		return def
+	}
+	return n
+}
+
+// QueryBool returns the query parameter key of r as a bool, or def when it is missing or not a
+// boolean. A parameter without a value, as in ?verbose, is true.
+func (t *Tools) QueryBool(r *http.Request, key string, def bool) bool {
+	q := r.URL.Query()
+	if !q.Has(key) {
+		return def
+	}
+	s := q.Get(key)
+	if s == "" || s == "on" {
+		return true
+	}
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		return def
+	}
+	return b
+}
+
+// QueryTime returns the query parameter key of r as a time, in RFC 3339, as a datetime-local
+// input sends it or as a date, or def when it is missing or not a time.
+func (t *Tools) QueryTime(r *http.Request, key string, def time.Time) time.Time {
+	tm, err := parseTimeText(r.URL.Query().Get(key))
+	if err != nil {
+		return def
+	}
+	return tm
+}
+
+// BindQuery decodes the query parameters of r into dst, a pointer to a struct whose fields are
+// named by their query tag or else their name, like ReadForm does for forms. Other query
+// parameters are ignored, as ParseListParams does. Values that cannot be converted, validate
+// tags and Validatable are reported as a ValidationError, which ErrorJSON renders as a 422.
+func (t *Tools) BindQuery(r *http.Request, dst any) error {
+	rv := reflect.ValueOf(dst)
+	if rv.Kind() != reflect.Pointer || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("query: destination must be a pointer to a struct, not %T", dst)
+	}
+	return t.bindValues(r.URL.Query(), dst, "query", false)
+}
@@ -0,0 +1,83 @@
+package toolkit
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+var queryAccessorTests = []struct {
+	query   string
+	wantInt int
+	wantOK  bool
+	wantDay int
+}{
+	{query: "", wantInt: 10, wantOK: false, wantDay: 1},
+	{query: "?n=42&ok=true&at=2024-03-05", wantInt: 42, wantOK: true, wantDay: 5},
+	{query: "?n=x&ok=maybe&at=soon", wantInt: 10, wantOK: false, wantDay: 1},
+	{query: "?n=-3&ok&at=2024-03-07T10:00:00Z", wantInt: -3, wantOK: true, wantDay: 7},
+	{query: "?ok=on", wantInt: 10, wantOK: true, wantDay: 1},
+}
+
+func TestTools_QueryAccessors(t *testing.T) {
+	var testTools Tools
+	def := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	for _, test := range queryAccessorTests {
+		r := httptest.NewRequest(http.MethodGet, "/"+test.query, nil)
+		if got := testTools.QueryInt(r, "n", 10); got != test.wantInt {
+			t.Errorf("%q: expected QueryInt %d, got %d", test.query, test.wantInt, got)
+		}
+		if got := testTools.QueryBool(r, "ok", false); got != test.wantOK {
+			t.Errorf("%q: expected QueryBool %t, got %t", test.query, test.wantOK, got)
+		}
+		if got := testTools.QueryTime(r, "at", def); got.Day() != test.wantDay {
+			t.Errorf("%q: expected QueryTime day %d, got %s", test.query, test.wantDay, got)
+		}
+	}
+}
+
+type querySearch struct {
+	Term   string     `query:"q" validate:"required"`
+	Limit  int        `query:"limit" validate:"max=100"`
+	Exact  bool       `query:"exact"`
+	Since  *time.Time `query:"since"`
+	Status []string   `query:"status"`
+}
+
+func TestTools_BindQuery(t *testing.T) {
+	var testTools Tools
+
+	r := httptest.NewRequest(http.MethodGet, "/?q=shoes&limit=20&exact=1&since=2024-01-02&status=new&status=paid&utm_source=mail", nil)
+	dst := querySearch{Limit: 10}
+	if err := testTools.BindQuery(r, &dst); err != nil {
+		t.Fatal(err)
+	}
+	if dst.Term != "shoes" || dst.Limit != 20 || !dst.Exact || dst.Since == nil || dst.Since.Day() != 2 {
+		t.Errorf("unexpected values %+v", dst)
+	}
+	if strings.Join(dst.Status, ",") != "new,paid" {
+		t.Errorf("unexpected status %v", dst.Status)
+	}
+
+	r = httptest.NewRequest(http.MethodGet, "/?q=shoes", nil)
+	dst = querySearch{Limit: 10}
+	if err := testTools.BindQuery(r, &dst); err != nil || dst.Limit != 10 {
+		t.Errorf("missing parameter should keep the default, got %d, %v", dst.Limit, err)
+	}
+
+	for _, query := range []string{"?limit=20", "?q=shoes&limit=many", "?q=shoes&limit=500"} {
+		r = httptest.NewRequest(http.MethodGet, "/"+query, nil)
+		var validationError *ValidationError
+		if err := testTools.BindQuery(r, &querySearch{}); !errors.As(err, &validationError) {
+			t.Errorf("%s: expected a validation error, got %v", query, err)
+		}
+	}
+
+	if err := testTools.BindQuery(r, querySearch{}); err == nil {
+		t.Error("expected error for a non-pointer destination")
+	}
+}
@@ -0,0 +1,67 @@
+package toolkit
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"math"
+)
+
+// RandomStringFrom returns a string of n characters picked uniformly at random from alphabet with
+// crypto/rand, so it is fit for secrets. Random values that would favour some characters are
+// rejected rather than folded into the range. It panics when alphabet is empty or has more than
+// 65536 characters.
+func (t *Tools) RandomStringFrom(alphabet string, n int) string {
+	chars := []rune(alphabet)
+	size := len(chars)
+	if size == 0 || size > math.MaxUint16+1 {
+		panic("toolkit: alphabet must have between 1 and 65536 characters")
+	}
+
+	// Characters are picked from one random byte, or two for alphabets of more than 256, whose
+	// values at or above limit are discarded.
+	width, span := 1, 1<<8
+	if size > 1<<8 {
+		width, span = 2, 1<<16
+	}
+	limit := span - span%size
+
+	s := make([]rune, 0, n)
+	buf := make([]byte, width*max(n+n/4, 16))
+	for len(s) < n {
+		readRandom(buf)
+		for i := 0; i+width <= len(buf) && len(s) < n; i += width {
+			v := int(buf[i])
+			if width == 2 {
+				v = int(binary.BigEndian.Uint16(buf[i:]))
+			}
+			if v < limit {
+				s = append(s, chars[v%size])
+			}
+		}
+	}
+	return string(s)
+}
+
+// RandomBytes returns n bytes from crypto/rand.
+func (t *Tools) RandomBytes(n int) []byte {
+	b := make([]byte, n)
+	readRandom(b)
+	return b
+}
+
+// readRandom fills b from crypto/rand, the random source of every token the toolkit generates.
+// It panics when the system's source fails, as crypto/rand itself does from Go 1.24 on, rather
+// than hand out predictable bytes.
+func readRandom(b []byte) {
+	if _, err := rand.Read(b); err != nil {
+		panic("toolkit: reading random bytes failed: " + err.Error())
+	}
+}
+
+// RandomURLToken returns n random bytes encoded with unpadded base64url, a token safe in URLs,
+// cookies and headers, such as a session ID or an API key. 32 bytes give 256 bits of entropy in
+// 43 characters.
+func (t *Tools) RandomURLToken(n int) string {
+	return base64.RawURLEncoding.EncodeToString(t.RandomBytes(n))
+}
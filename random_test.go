@@ -0,0 +1,64 @@
+package toolkit
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestTools_RandomStringFrom(t *testing.T) {
+	var testTools Tools
+
+	for _, alphabet := range []string{"a", "01", "abc", "żółw", strings.Repeat("x", 300) + "y"} {
+		s := testTools.RandomStringFrom(alphabet, 200)
+		if utf8.RuneCountInString(s) != 200 {
+			t.Errorf("%q: expected 200 characters, got %d", alphabet, utf8.RuneCountInString(s))
+		}
+		for _, r := range s {
+			if !strings.ContainsRune(alphabet, r) {
+				t.Errorf("%q: unexpected character %q", alphabet, r)
+			}
+		}
+	}
+
+	// Every character of a 3 character alphabet, which does not divide 256, should come up about
+	// equally often.
+	counts := map[rune]int{}
+	for _, r := range testTools.RandomStringFrom("abc", 30000) {
+		counts[r]++
+	}
+	for r, n := range counts {
+		if n < 9000 || n > 11000 {
+			t.Errorf("character %q picked %d times out of 30000", r, n)
+		}
+	}
+
+	if s := testTools.RandomStringFrom("abc", 0); s != "" {
+		t.Errorf("expected an empty string, got %q", s)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected an empty alphabet to panic")
+		}
+	}()
+	testTools.RandomStringFrom("", 1)
+}
+
+func TestTools_RandomURLToken(t *testing.T) {
+	var testTools Tools
+
+	if b := testTools.RandomBytes(16); len(b) != 16 {
+		t.Errorf("expected 16 bytes, got %d", len(b))
+	}
+
+	token := testTools.RandomURLToken(32)
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || len(b) != 32 || len(token) != 43 {
+		t.Errorf("unexpected token %q: %v", token, err)
+	}
+	if token == testTools.RandomURLToken(32) {
+		t.Error("expected tokens to differ")
+	}
+}
@@ -0,0 +1,280 @@
+package toolkit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RateLimit is a token bucket: Requests tokens are added every Per, up to Burst (Requests when 0).
+// Every request takes one token.
+type RateLimit struct {
+	Requests int
+	Per      time.Duration
+	Burst    int
+}
+
+func (l RateLimit) burst() int {
+	if l.Burst > 0 {
+		return l.Burst
+	}
+	return l.Requests
+}
+
+// rate is the number of tokens added per second.
+func (l RateLimit) rate() float64 {
+	return float64(l.Requests) / l.Per.Seconds()
+}
+
+// RateLimitResult is the outcome of taking a token from a bucket.
+type RateLimitResult struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// Reset is the time until the bucket is full again.
+	Reset time.Duration
+	// RetryAfter is the time until the next token is available when the request was not allowed.
+	RetryAfter time.Duration
+}
+
+func bucketResult(allowed bool, tokens float64, l RateLimit) RateLimitResult {
+	rate := l.rate()
+	res := RateLimitResult{
+		Allowed:   allowed,
+		Limit:     l.burst(),
+		Remaining: int(math.Floor(tokens)),
+		Reset:     time.Duration((float64(l.burst()) - tokens) / rate * float64(time.Second)),
+	}
+	if !allowed {
+		res.RetryAfter = time.Duration((1 - tokens) / rate * float64(time.Second))
+	}
+	return res
+}
+
+// RateLimitStore keeps token buckets. The in-memory store is used by default; a shared store
+// such as RedisRateLimitStore lets several instances enforce a common limit.
+type RateLimitStore interface {
+	Take(ctx context.Context, key string, limit RateLimit) (RateLimitResult, error)
+}
+
+// RateLimitKeyFunc selects the bucket a request is counted against. An empty key exempts the
+// request from limiting.
+type RateLimitKeyFunc func(r *http.Request) string
+
+// RateLimitByIP keys requests by the peer address of the connection. Behind a reverse proxy use
+// Tools.ClientIP instead, which is also the default key of RateLimitMiddleware.
+func RateLimitByIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// RateLimitByHeader keys requests by the value of a header such as X-API-Key.
+func RateLimitByHeader(name string) RateLimitKeyFunc {
+	return func(r *http.Request) string {
+		return r.Header.Get(name)
+	}
+}
+
+// RateLimitByContext keys requests by a context value, typically the user stored by an
+// authentication middleware.
+func RateLimitByContext(key any) RateLimitKeyFunc {
+	return func(r *http.Request) string {
+		v := r.Context().Value(key)
+		if v == nil {
+			return ""
+		}
+		return fmt.Sprint(v)
+	}
+}
+
+// RateLimitOptions configures RateLimitMiddleware.
+type RateLimitOptions struct {
+	Limit RateLimit
+	// Key selects the bucket, Tools.ClientIP when nil.
+	Key RateLimitKeyFunc
+	// Store keeps the buckets, a new MemoryRateLimitStore when nil.
+	Store RateLimitStore
+}
+
+// RateLimitMiddleware limits requests with a token bucket per key. Responses carry the
+// RateLimit-Limit, RateLimit-Remaining and RateLimit-Reset headers; rejected requests get a 429
+// JSON error with Retry-After. When the store fails the request is let through and the error logged.
+func (t *Tools) RateLimitMiddleware(opts RateLimitOptions) (func(http.Handler) http.Handler, error) {
+	if opts.Limit.Requests <= 0 || opts.Limit.Per <= 0 {
+		return nil, errors.New("rate limit requests and period should be greater than 0")
+	}
+	if opts.Key == nil {
+		opts.Key = t.ClientIP
+	}
+	if opts.Store == nil {
+		opts.Store = NewMemoryRateLimitStore()
+	}
+	policy := rateLimitPolicy(opts.Limit)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := opts.Key(r)
+			if key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			res, err := opts.Store.Take(r.Context(), key, opts.Limit)
+			if err != nil {
+				t.logger().ErrorContext(r.Context(), "rate limit store failed", slog.String("error", err.Error()))
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if !t.applyRateLimit(w, policy, res) {
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}, nil
+}
+
+func rateLimitPolicy(l RateLimit) string {
+	return fmt.Sprintf("%d;w=%d", l.burst(), int(math.Ceil(l.Per.Seconds())))
+}
+
+// applyRateLimit sets the rate limit headers of res and rejects the request with a 429 when it
+// was not allowed, reporting whether the request may proceed.
+func (t *Tools) applyRateLimit(w http.ResponseWriter, policy string, res RateLimitResult) bool {
+	w.Header().Set("RateLimit-Policy", policy)
+	w.Header().Set("RateLimit-Limit", strconv.Itoa(res.Limit))
+	w.Header().Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+	w.Header().Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(res.Reset)))
+	if !res.Allowed {
+		w.Header().Set("Retry-After", strconv.Itoa(max(ceilSeconds(res.RetryAfter), 1)))
+		_ = t.ErrorJSON(w, errors.New("too many requests"), http.StatusTooManyRequests)
+		return false
+	}
+	return true
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+// MemoryRateLimitStore keeps token buckets in memory. Buckets that have refilled completely are
+// dropped periodically, so memory use follows the number of active clients.
+type MemoryRateLimitStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+	fullAt time.Time
+}
+
+// NewMemoryRateLimitStore returns an empty in-memory store.
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{buckets: make(map[string]*tokenBucket), now: time.Now}
+}
+
+// Take takes a token from the bucket for key.
+func (s *MemoryRateLimitStore) Take(_ context.Context, key string, limit RateLimit) (RateLimitResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if now.Sub(s.lastSweep) > time.Minute {
+		for k, b := range s.buckets {
+			if now.After(b.fullAt) {
+				delete(s.buckets, k)
+			}
+		}
+		s.lastSweep = now
+	}
+
+	burst, rate := float64(limit.burst()), limit.rate()
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &tokenBucket{tokens: burst, last: now}
+		s.buckets[key] = b
+	}
+	b.tokens = math.Min(burst, b.tokens+now.Sub(b.last).Seconds()*rate)
+	b.last = now
+
+	allowed := b.tokens >= 1
+	if allowed {
+		b.tokens--
+	}
+	b.fullAt = now.Add(time.Duration((burst - b.tokens) / rate * float64(time.Second)))
+	return bucketResult(allowed, b.tokens, limit), nil
+}
+
+// RedisEvaler is the subset of a Redis client RedisRateLimitStore needs. With go-redis it is
+// a small adapter around client.Eval(ctx, script, keys, args...).Result().
+type RedisEvaler interface {
+	Eval(ctx context.Context, script string, keys []string, args ...any) (any, error)
+}
+
+// RedisRateLimitStore keeps token buckets in Redis hashes, updated atomically by a Lua script,
+// so every instance of an application shares the same limits.
+type RedisRateLimitStore struct {
+	Client RedisEvaler
+	// Prefix is prepended to bucket keys, "ratelimit:" when empty.
+	Prefix string
+}
+
+const redisTokenBucketScript = `
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local b = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(b[1]) or burst
+local ts = tonumber(b[2]) or now
+tokens = math.min(burst, tokens + math.max(0, now - ts) / 1000 * rate)
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', now)
+redis.call('PEXPIRE', KEYS[1], math.ceil((burst - tokens) / rate * 1000) + 1000)
+return {allowed, tostring(tokens)}
+`
+
+// Take takes a token from the bucket for key.
+func (s *RedisRateLimitStore) Take(ctx context.Context, key string, limit RateLimit) (RateLimitResult, error) {
+	prefix := s.Prefix
+	if prefix == "" {
+		prefix = "ratelimit:"
+	}
+
+	reply, err := s.Client.Eval(ctx, redisTokenBucketScript, []string{prefix + key},
+		strconv.FormatFloat(limit.rate(), 'f', -1, 64), limit.burst(), time.Now().UnixMilli())
+	if err != nil {
+		return RateLimitResult{}, err
+	}
+
+	values, ok := reply.([]any)
+	if !ok || len(values) != 2 {
+		return RateLimitResult{}, fmt.Errorf("unexpected rate limit script reply %v", reply)
+	}
+	allowed, ok := values[0].(int64)
+	if !ok {
+		return RateLimitResult{}, fmt.Errorf("unexpected rate limit script reply %v", reply)
+	}
+	tokens, err := strconv.ParseFloat(fmt.Sprint(values[1]), 64)
+	if err != nil {
+		return RateLimitResult{}, fmt.Errorf("unexpected rate limit script reply %v", reply)
+	}
+	return bucketResult(allowed == 1, tokens, limit), nil
+}
@@ -0,0 +1,123 @@
+package toolkit
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTools_MemoryRateLimitStore(t *testing.T) {
+	store := NewMemoryRateLimitStore()
+	clock := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	store.now = func() time.Time { return clock }
+	limit := RateLimit{Requests: 2, Per: time.Second, Burst: 3}
+
+	for i := 0; i < 3; i++ {
+		if res, _ := store.Take(context.Background(), "a", limit); !res.Allowed || res.Remaining != 2-i {
+			t.Fatalf("request %d: unexpected result %+v", i, res)
+		}
+	}
+	res, _ := store.Take(context.Background(), "a", limit)
+	if res.Allowed || res.RetryAfter != 500*time.Millisecond {
+		t.Errorf("expected request to be limited for 500ms, got %+v", res)
+	}
+	if res, _ = store.Take(context.Background(), "b", limit); !res.Allowed {
+		t.Error("buckets are not separated by key")
+	}
+
+	clock = clock.Add(500 * time.Millisecond)
+	if res, _ = store.Take(context.Background(), "a", limit); !res.Allowed || res.Remaining != 0 {
+		t.Errorf("expected a refilled token, got %+v", res)
+	}
+
+	clock = clock.Add(2 * time.Minute)
+	_, _ = store.Take(context.Background(), "c", limit)
+	if len(store.buckets) != 1 {
+		t.Errorf("expected idle buckets to be swept, have %d", len(store.buckets))
+	}
+}
+
+func TestTools_RateLimitMiddleware(t *testing.T) {
+	testTools := Tools{Logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+	mw, err := testTools.RateLimitMiddleware(RateLimitOptions{
+		Limit: RateLimit{Requests: 1, Per: time.Minute},
+		Key:   RateLimitByHeader("X-API-Key"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	send := func(key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if key != "" {
+			req.Header.Set("X-API-Key", key)
+		}
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		return rr
+	}
+
+	if rr := send("k1"); rr.Code != http.StatusOK || rr.Header().Get("RateLimit-Remaining") != "0" || rr.Header().Get("RateLimit-Policy") != "1;w=60" {
+		t.Errorf("unexpected first response %d %v", rr.Code, rr.Header())
+	}
+	rr := send("k1")
+	if rr.Code != http.StatusTooManyRequests || rr.Header().Get("Retry-After") != "60" {
+		t.Errorf("expected 429 with Retry-After 60, got %d %v", rr.Code, rr.Header())
+	}
+	if rr = send(""); rr.Code != http.StatusOK || rr.Header().Get("RateLimit-Limit") != "" {
+		t.Error("requests without a key should not be limited")
+	}
+
+	if _, err = testTools.RateLimitMiddleware(RateLimitOptions{}); err == nil {
+		t.Error("expected an error for an empty limit")
+	}
+}
+
+type fakeRedis struct {
+	keys  []string
+	args  []any
+	reply any
+	err   error
+}
+
+func (f *fakeRedis) Eval(_ context.Context, _ string, keys []string, args ...any) (any, error) {
+	f.keys, f.args = keys, args
+	return f.reply, f.err
+}
+
+func TestTools_RedisRateLimitStore(t *testing.T) {
+	client := &fakeRedis{reply: []any{int64(0), "0.25"}}
+	store := &RedisRateLimitStore{Client: client}
+	limit := RateLimit{Requests: 10, Per: 10 * time.Second}
+
+	res, err := store.Take(context.Background(), "1.2.3.4", limit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if client.keys[0] != "ratelimit:1.2.3.4" || client.args[0] != "1" || client.args[1] != 10 {
+		t.Errorf("unexpected script call %v %v", client.keys, client.args)
+	}
+	if res.Allowed || res.RetryAfter != 750*time.Millisecond || res.Limit != 10 {
+		t.Errorf("unexpected result %+v", res)
+	}
+
+	client.reply = "garbage"
+	if _, err = store.Take(context.Background(), "x", limit); err == nil {
+		t.Error("expected an error for a malformed reply")
+	}
+
+	testTools := Tools{Logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+	client.err = errors.New("connection refused")
+	mw, _ := testTools.RateLimitMiddleware(RateLimitOptions{Limit: limit, Store: store})
+	rr := httptest.NewRecorder()
+	mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rr.Code != http.StatusOK {
+		t.Error("expected requests to pass when the store fails, got", rr.Code)
+	}
+}
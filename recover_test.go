@@ -0,0 +1,62 @@
+package toolkit
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestTools_RecoverMiddleware(t *testing.T) {
+	var buf bytes.Buffer
+	testTools := Tools{Logger: slog.New(slog.NewJSONHandler(&buf, nil))}
+
+	h := testTools.RequestIDMiddleware(testTools.RecoverMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var m map[string]int
+		m["boom"]++
+	})))
+	req := httptest.NewRequest(http.MethodGet, "/crash", nil)
+	req.Header.Set(RequestIDHeader, "req-7")
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusInternalServerError {
+		t.Errorf("expected 500, got %d", rr.Code)
+	}
+	var payload JSONResponse
+	if err := json.NewDecoder(rr.Body).Decode(&payload); err != nil || !payload.Error || payload.RequestID != "req-7" {
+		t.Errorf("unexpected payload %+v (%v)", payload, err)
+	}
+	for _, want := range []string{`"request_id":"req-7"`, "assignment to entry in nil map", "recover_test.go"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("log output missing %q", want)
+		}
+	}
+}
+
+func TestTools_RecoverMiddlewareStartedResponse(t *testing.T) {
+	testTools := Tools{Logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+	h := testTools.RecoverMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+		panic("late")
+	}))
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rr.Code != http.StatusAccepted || rr.Body.Len() != 0 {
+		t.Errorf("expected the started response to be left alone, got %d %q", rr.Code, rr.Body.String())
+	}
+
+	defer func() {
+		if rec := recover(); rec != http.ErrAbortHandler {
+			t.Error("expected ErrAbortHandler to be re-panicked, got", rec)
+		}
+	}()
+	h = testTools.RecoverMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	}))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+}
@@ -0,0 +1,173 @@
+package toolkit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// RemoteOption configures a call of CallRemote.
+type RemoteOption func(*remoteCall)
+
+type remoteCall struct {
+	header  http.Header
+	query   url.Values
+	timeout time.Duration
+	client  *http.Client
+}
+
+// WithHeader sets a request header.
+func WithHeader(key, value string) RemoteOption {
+	return func(c *remoteCall) { c.header.Set(key, value) }
+}
+
+// WithBearerToken authenticates the request with an OAuth 2.0 bearer token.
+func WithBearerToken(token string) RemoteOption {
+	return func(c *remoteCall) { c.header.Set("Authorization", "Bearer "+token) }
+}
+
+// WithBasicAuth authenticates the request with HTTP basic authentication.
+func WithBasicAuth(username, password string) RemoteOption {
+	return func(c *remoteCall) {
+		r := http.Request{Header: c.header}
+		r.SetBasicAuth(username, password)
+	}
+}
+
+// WithQuery adds a query parameter to the URL.
+func WithQuery(key, value string) RemoteOption {
+	return func(c *remoteCall) { c.query.Add(key, value) }
+}
+
+// WithTimeout limits the call, with its retries and the reading of the response, to d.
+func WithTimeout(d time.Duration) RemoteOption {
+	return func(c *remoteCall) { c.timeout = d }
+}
+
+// WithHTTPClient sends the request with client instead of http.DefaultClient.
+func WithHTTPClient(client *http.Client) RemoteOption {
+	return func(c *remoteCall) { c.client = client }
+}
+
+// maxRemoteErrorBody is how much of the body of a failed call a RemoteError keeps.
+const maxRemoteErrorBody = 4096
+
+// RemoteError is returned by CallRemote for responses with a status other than 2xx. It matches
+// ErrNotFound for 404 and ErrConflict for 409 responses, so ErrorJSON passes those on.
+type RemoteError struct {
+	StatusCode int
+	// Body is the start of the response body.
+	Body []byte
+}
+
+func (e *RemoteError) Error() string {
+	msg := fmt.Sprintf("remote responded with %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	if body := strings.TrimSpace(string(e.Body)); body != "" {
+		msg += ": " + body
+	}
+	return msg
+}
+
+func (e *RemoteError) Unwrap() error {
+	switch e.StatusCode {
+	case http.StatusNotFound:
+		return ErrNotFound
+	case http.StatusConflict:
+		return ErrConflict
+	}
+	return nil
+}
+
+// CallRemote sends a request to a remote service and decodes its JSON response into out, unless
+// out is nil. body is sent as it is when it is an io.Reader or []byte, and as JSON otherwise; nil
+// sends no body. Failed calls and responses with a retryable status are retried with
+// RemoteRetry, except for io.Reader bodies, which cannot be sent twice. Responses with a status
+// other than 2xx return a *RemoteError. The status and headers of the response are returned
+// whenever there is one.
+func (t *Tools) CallRemote(ctx context.Context, method, uri string, body, out any, opts ...RemoteOption) (int, http.Header, error) {
+	call := remoteCall{header: make(http.Header), query: make(url.Values), client: http.DefaultClient}
+	for _, opt := range opts {
+		opt(&call)
+	}
+	if call.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, call.timeout)
+		defer cancel()
+	}
+
+	u, err := url.Parse(uri)
+	if err != nil {
+		return 0, nil, err
+	}
+	if len(call.query) > 0 {
+		q := u.Query()
+		for key, values := range call.query {
+			q[key] = append(q[key], values...)
+		}
+		u.RawQuery = q.Encode()
+	}
+
+	var payload []byte
+	var stream io.Reader
+	contentType := ""
+	switch b := body.(type) {
+	case nil:
+	case []byte:
+		payload = b
+	case io.Reader:
+		stream = b
+	default:
+		if payload, err = json.Marshal(body); err != nil {
+			return 0, nil, err
+		}
+		contentType = "application/json"
+	}
+
+	newRequest := func() (*http.Request, error) {
+		var r io.Reader = stream
+		if payload != nil {
+			r = bytes.NewReader(payload)
+		}
+		req, err := http.NewRequestWithContext(ctx, method, u.String(), r)
+		if err != nil {
+			return nil, err
+		}
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+		if out != nil {
+			req.Header.Set("Accept", "application/json")
+		}
+		for key, values := range call.header {
+			req.Header[key] = values
+		}
+		return req, nil
+	}
+
+	retry := t.RemoteRetry
+	if stream != nil {
+		retry = nil
+	}
+	resp, err := t.doRemote(ctx, call.client, retry, newRequest)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		errBody, _ := io.ReadAll(io.LimitReader(resp.Body, maxRemoteErrorBody))
+		return resp.StatusCode, resp.Header, &RemoteError{StatusCode: resp.StatusCode, Body: errBody}
+	}
+	if out != nil && resp.StatusCode != http.StatusNoContent {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil && err != io.EOF {
+			return resp.StatusCode, resp.Header, fmt.Errorf("decoding response: %w", err)
+		}
+	}
+	return resp.StatusCode, resp.Header, nil
+}
@@ -0,0 +1,147 @@
+package toolkit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTools_CallRemote(t *testing.T) {
+	var testTools Tools
+
+	var got *http.Request
+	var gotBody string
+	client := NewTestClient(func(req *http.Request) *http.Response {
+		got = req
+		body, _ := io.ReadAll(req.Body)
+		gotBody = string(body)
+		return &http.Response{
+			StatusCode: http.StatusCreated,
+			Body:       io.NopCloser(bytes.NewBufferString(`{"id":7}`)),
+			Header:     http.Header{"Location": {"/items/7"}},
+		}
+	})
+
+	var out struct {
+		ID int `json:"id"`
+	}
+	status, header, err := testTools.CallRemote(context.Background(), http.MethodPut, "http://remote.example/items?a=1",
+		map[string]string{"name": "x"}, &out,
+		WithHTTPClient(client), WithHeader("X-Trace", "t1"), WithBearerToken("tok"), WithQuery("b", "2"))
+	if err != nil || status != http.StatusCreated || header.Get("Location") != "/items/7" || out.ID != 7 {
+		t.Fatalf("unexpected result %d %v %+v %v", status, header, out, err)
+	}
+	if got.Method != http.MethodPut || got.URL.Query().Get("a") != "1" || got.URL.Query().Get("b") != "2" {
+		t.Errorf("unexpected request %s %s", got.Method, got.URL)
+	}
+	if got.Header.Get("Authorization") != "Bearer tok" || got.Header.Get("X-Trace") != "t1" ||
+		got.Header.Get("Content-Type") != "application/json" || got.Header.Get("Accept") != "application/json" {
+		t.Errorf("unexpected headers %v", got.Header)
+	}
+	if gotBody != `{"name":"x"}` {
+		t.Errorf("unexpected body %q", gotBody)
+	}
+
+	_, _, err = testTools.CallRemote(context.Background(), http.MethodPost, "http://remote.example", strings.NewReader("raw"), nil,
+		WithHTTPClient(client), WithBasicAuth("user", "pass"))
+	if user, pass, ok := got.BasicAuth(); err != nil || !ok || user != "user" || pass != "pass" || gotBody != "raw" || got.Header.Get("Content-Type") != "" {
+		t.Errorf("unexpected request %v %q %v", got.Header, gotBody, err)
+	}
+}
+
+func TestTools_CallRemoteError(t *testing.T) {
+	var testTools Tools
+
+	client := NewTestClient(func(req *http.Request) *http.Response {
+		return &http.Response{StatusCode: http.StatusNotFound, Body: io.NopCloser(bytes.NewBufferString("no such item\n")), Header: make(http.Header)}
+	})
+	status, _, err := testTools.CallRemote(context.Background(), http.MethodGet, "http://remote.example/items/1", nil, &struct{}{}, WithHTTPClient(client))
+	var remoteErr *RemoteError
+	if status != http.StatusNotFound || !errors.As(err, &remoteErr) || !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected a not found remote error, got %d %v", status, err)
+	}
+	if err.Error() != "remote responded with 404 Not Found: no such item" {
+		t.Errorf("unexpected message %q", err.Error())
+	}
+}
+
+func TestTools_CallRemoteRetry(t *testing.T) {
+	calls := 0
+	client := NewTestClient(func(req *http.Request) *http.Response {
+		calls++
+		body, _ := io.ReadAll(req.Body)
+		status := http.StatusServiceUnavailable
+		if calls == 2 {
+			status = http.StatusOK
+		}
+		if string(body) != `"x"` && string(body) != "raw" {
+			t.Errorf("unexpected body %q", body)
+		}
+		return &http.Response{StatusCode: status, Body: io.NopCloser(bytes.NewBufferString("")), Header: make(http.Header)}
+	})
+
+	testTools := Tools{RemoteRetry: &RetryPolicy{MaxAttempts: 3, Backoff: ConstantBackoff(time.Millisecond)}}
+	if status, _, err := testTools.CallRemote(context.Background(), http.MethodPost, "http://remote.example", "x", nil, WithHTTPClient(client)); err != nil || status != http.StatusOK || calls != 2 {
+		t.Errorf("expected success on the second attempt, got %d %v after %d calls", status, err, calls)
+	}
+
+	calls = 0
+	if status, _, _ := testTools.CallRemote(context.Background(), http.MethodPost, "http://remote.example", strings.NewReader("raw"), nil, WithHTTPClient(client)); status != http.StatusServiceUnavailable || calls != 1 {
+		t.Errorf("expected a reader body not to be retried, got %d after %d calls", status, calls)
+	}
+}
+
+func TestTools_CallRemoteTimeout(t *testing.T) {
+	var testTools Tools
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+		_ = json.NewEncoder(w).Encode("late")
+	}))
+	defer srv.Close()
+
+	start := time.Now()
+	_, _, err := testTools.CallRemote(context.Background(), http.MethodGet, srv.URL, nil, nil, WithHTTPClient(srv.Client()), WithTimeout(50*time.Millisecond))
+	if !errors.Is(err, context.DeadlineExceeded) || time.Since(start) > 2*time.Second {
+		t.Errorf("expected the call to time out, got %v after %s", err, time.Since(start))
+	}
+}
+
+func TestTools_CallRemoteLogsFailures(t *testing.T) {
+	var buf bytes.Buffer
+	testTools := Tools{Logger: slog.New(slog.NewTextHandler(&buf, nil))}
+
+	client := NewTestClient(func(req *http.Request) *http.Response {
+		return &http.Response{StatusCode: http.StatusServiceUnavailable, Body: io.NopCloser(strings.NewReader("down")), Header: make(http.Header)}
+	})
+	_, _, _ = testTools.CallRemote(context.Background(), http.MethodGet, "http://remote.example/items", nil, nil, WithHTTPClient(client))
+	for _, want := range []string{"level=WARN", `msg="remote call failed"`, "host=remote.example", "status=503"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("log output missing %q: %s", want, buf.String())
+		}
+	}
+
+	buf.Reset()
+	client = &http.Client{Transport: failingTransport{}}
+	_, _, _ = testTools.CallRemote(context.Background(), http.MethodGet, "http://remote.example/items", nil, nil, WithHTTPClient(client))
+	if !strings.Contains(buf.String(), "connection refused") {
+		t.Errorf("transport error not logged: %s", buf.String())
+	}
+}
+
+type failingTransport struct{}
+
+func (failingTransport) RoundTrip(*http.Request) (*http.Response, error) {
+	return nil, errors.New("connection refused")
+}
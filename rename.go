@@ -0,0 +1,36 @@
+package toolkit
+
+import (
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// RandomFileName returns a random name with the extension of original. It is how UploadFiles
+// renames files when Tools.RenameFunc is not set.
+func (t *Tools) RandomFileName(original string) string {
+	return t.RandomString(25) + filepath.Ext(original)
+}
+
+// UUIDFileName returns a random version 4 UUID with the extension of original, such as
+// 9b2e4c1a-7f3d-4e8b-a1c5-2d6f8e0b3a97.png.
+func (t *Tools) UUIDFileName(original string) string {
+	return t.UUIDv4() + filepath.Ext(original)
+}
+
+// DateShardedFileName returns a random name with the extension of original in a directory for
+// the current year and month, such as 2024/06/Xq1....png, so no directory collects all uploads.
+func (t *Tools) DateShardedFileName(original string) string {
+	return time.Now().Format("2006/01/") + t.RandomFileName(original)
+}
+
+// SlugFileName returns a slug of original, followed by a short random suffix that keeps names
+// unique, and the extension of original, such as annual-report-Xq1ab3Kd.pdf.
+func (t *Tools) SlugFileName(original string) string {
+	ext := filepath.Ext(original)
+	slug, err := t.Slugify(strings.TrimSuffix(original, ext))
+	if err != nil {
+		return t.RandomFileName(original)
+	}
+	return slug + "-" + t.RandomString(8) + strings.ToLower(ext)
+}
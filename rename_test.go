@@ -0,0 +1,52 @@
+package toolkit
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+)
+
+var renameTests = []struct {
+	name     string
+	rename   func(t *Tools) func(string) string
+	original string
+	pattern  string
+}{
+	{name: "random", rename: func(t *Tools) func(string) string { return t.RandomFileName }, original: "Photo.JPG", pattern: `^[^/]{25}\.JPG$`},
+	{name: "date sharded", rename: func(t *Tools) func(string) string { return t.DateShardedFileName }, original: "a.png", pattern: `^` + time.Now().Format("2006/01") + `/[^/]{25}\.png$`},
+	{name: "uuid", rename: func(t *Tools) func(string) string { return t.UUIDFileName }, original: "a.png", pattern: `^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}\.png$`},
+	{name: "slug", rename: func(t *Tools) func(string) string { return t.SlugFileName }, original: "Annual Report 2024.PDF", pattern: `^annual-report-2024-[^/]{8}\.pdf$`},
+	{name: "slug fallback", rename: func(t *Tools) func(string) string { return t.SlugFileName }, original: "日本.txt", pattern: `^[^/]{25}\.txt$`},
+	{name: "slug transliterated", rename: func(t *Tools) func(string) string { return t.SlugFileName }, original: "Źółć.txt", pattern: `^zolc-[^/]{8}\.txt$`},
+}
+
+func TestTools_RenameFunc(t *testing.T) {
+	for _, test := range renameTests {
+		var testTools Tools
+		testTools.RenameFunc = test.rename(&testTools)
+		dir := t.TempDir()
+
+		files, err := testTools.UploadFiles(newUploadRequest(t, map[string][]byte{test.original: []byte("content")}), dir)
+		if err != nil || len(files) != 1 {
+			t.Fatalf("%s: expected one file, got %v %v", test.name, files, err)
+		}
+		if !regexp.MustCompile(test.pattern).MatchString(files[0].NewFileName) {
+			t.Errorf("%s: %q does not match %s", test.name, files[0].NewFileName, test.pattern)
+		}
+		if _, err = os.Stat(filepath.Join(dir, files[0].NewFileName)); err != nil {
+			t.Errorf("%s: %v", test.name, err)
+		}
+	}
+
+	testTools := Tools{RenameFunc: func(string) string { return "../../outside.txt" }}
+	dir := t.TempDir()
+	files, err := testTools.UploadFiles(newUploadRequest(t, map[string][]byte{"a.txt": []byte("content")}), filepath.Join(dir, "uploads"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = os.Stat(filepath.Join(dir, "uploads", "outside.txt")); err != nil || files[0].NewFileName != "outside.txt" {
+		t.Errorf("expected %s to stay in the upload directory, got %v", files[0].NewFileName, err)
+	}
+}
@@ -0,0 +1,129 @@
+package toolkit
+
+import (
+	"context"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+// RequestIDHeader is the header request IDs are read from and echoed in.
+const RequestIDHeader = "X-Request-ID"
+
+const maxRequestIDLength = 128
+
+type requestIDKey struct{}
+
+type traceIDKey struct{}
+
+// RequestIDMiddleware takes the request ID from the X-Request-ID header, or generates one when it
+// is missing or malformed, stores it in the request context and sets it on the response. ErrorJSON
+// includes the ID in its payload, and loggers wrapped with NewRequestIDHandler add it to records.
+// The trace ID of a W3C traceparent header is stored in the context as well.
+func (t *Tools) RequestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+
+		w.Header().Set(RequestIDHeader, id)
+		ctx := ContextWithRequestID(r.Context(), id)
+		if traceID, ok := parseTraceparent(r.Header.Get("traceparent")); ok {
+			ctx = ContextWithTraceID(ctx, traceID)
+		}
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// ContextWithRequestID returns a copy of ctx carrying id, for propagating a request ID to
+// background work or outgoing calls.
+func ContextWithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDFromContext returns the request ID stored by RequestIDMiddleware, or "".
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// ContextWithTraceID returns a copy of ctx carrying a distributed tracing trace ID.
+func ContextWithTraceID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, traceIDKey{}, id)
+}
+
+// TraceIDFromContext returns the trace ID stored by RequestIDMiddleware or ContextWithTraceID,
+// or "".
+func TraceIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(traceIDKey{}).(string)
+	return id
+}
+
+// parseTraceparent returns the trace ID of a W3C traceparent header,
+// version-traceid-parentid-flags, rejecting the all-zero ID.
+func parseTraceparent(h string) (string, bool) {
+	parts := strings.Split(h, "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || len(parts[1]) != 32 || parts[0] == "ff" {
+		return "", false
+	}
+	if _, err := hex.DecodeString(parts[1]); err != nil || parts[1] == strings.Repeat("0", 32) {
+		return "", false
+	}
+	return strings.ToLower(parts[1]), true
+}
+
+// NewRequestIDHandler wraps h so that records logged with a context carrying a request ID or a
+// trace ID get request_id and trace_id attributes, e.g.
+// slog.New(toolkit.NewRequestIDHandler(slog.NewJSONHandler(os.Stderr, nil))). Loggers made by
+// NewLogger are wrapped already.
+func NewRequestIDHandler(h slog.Handler) slog.Handler {
+	return requestIDHandler{h}
+}
+
+type requestIDHandler struct {
+	slog.Handler
+}
+
+func (h requestIDHandler) Handle(ctx context.Context, record slog.Record) error {
+	if id := RequestIDFromContext(ctx); id != "" {
+		record.AddAttrs(slog.String("request_id", id))
+	}
+	if id := TraceIDFromContext(ctx); id != "" {
+		record.AddAttrs(slog.String("trace_id", id))
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h requestIDHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return requestIDHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h requestIDHandler) WithGroup(name string) slog.Handler {
+	return requestIDHandler{h.Handler.WithGroup(name)}
+}
+
+// validRequestID accepts client supplied IDs made of letters, digits and -_.: only, so they are
+// safe to echo in headers and log lines.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-', c == '_', c == '.', c == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// newRequestID returns 16 random bytes in hex.
+func newRequestID() string {
+	b := make([]byte, 16)
+	readRandom(b)
+	return hex.EncodeToString(b)
+}
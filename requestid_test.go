@@ -0,0 +1,73 @@
+package toolkit
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+var requestIDTests = []struct {
+	name     string
+	incoming string
+	keep     bool
+}{
+	{name: "missing", incoming: "", keep: false},
+	{name: "valid", incoming: "abc-123_x.y:z", keep: true},
+	{name: "injection", incoming: "abc\nlevel=ERROR", keep: false},
+	{name: "too long", incoming: strings.Repeat("a", 200), keep: false},
+}
+
+func TestTools_RequestIDMiddleware(t *testing.T) {
+	var testTools Tools
+	for _, test := range requestIDTests {
+		var seen string
+		h := testTools.RequestIDMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			seen = RequestIDFromContext(r.Context())
+		}))
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if test.incoming != "" {
+			req.Header.Set(RequestIDHeader, test.incoming)
+		}
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+
+		if seen == "" || rr.Header().Get(RequestIDHeader) != seen {
+			t.Errorf("%s: context id %q does not match response header %q", test.name, seen, rr.Header().Get(RequestIDHeader))
+		}
+		if (seen == test.incoming) != test.keep {
+			t.Errorf("%s: unexpected id %q", test.name, seen)
+		}
+	}
+}
+
+func TestTools_ErrorJSONRequestID(t *testing.T) {
+	var testTools Tools
+	var buf bytes.Buffer
+	logger := slog.New(NewRequestIDHandler(slog.NewJSONHandler(&buf, nil))).With("component", "test")
+
+	h := testTools.RequestIDMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		logger.InfoContext(r.Context(), "handling")
+		_ = testTools.ErrorJSON(w, errors.New("boom"))
+	}))
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(RequestIDHeader, "req-42")
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+
+	var payload JSONResponse
+	if err := json.NewDecoder(rr.Body).Decode(&payload); err != nil {
+		t.Fatal(err)
+	}
+	if payload.RequestID != "req-42" {
+		t.Error("ErrorJSON did not include the request id:", payload.RequestID)
+	}
+	if !strings.Contains(buf.String(), `"request_id":"req-42"`) {
+		t.Error("log record missing request id:", buf.String())
+	}
+}
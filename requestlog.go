@@ -0,0 +1,37 @@
+package toolkit
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// RequestLogMiddleware logs every request to Tools.Logger once it is served, with its method,
+// path, status, response size, duration, client address and request ID. Server errors are
+// logged at the error level, client errors as warnings and the rest as information. Put it
+// inside RequestIDMiddleware so the ID is known.
+func (t *Tools) RequestLogMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		sw := &statusWriter{ResponseWriter: w}
+		next.ServeHTTP(sw, r)
+
+		status := sw.code()
+		level := slog.LevelInfo
+		switch {
+		case status >= http.StatusInternalServerError:
+			level = slog.LevelError
+		case status >= http.StatusBadRequest:
+			level = slog.LevelWarn
+		}
+		t.logger().LogAttrs(r.Context(), level, "request served",
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+			slog.Int("status", status),
+			slog.Int64("bytes", sw.bytes),
+			slog.Duration("duration", time.Since(start)),
+			slog.String("client_ip", t.ClientIP(r)),
+			slog.String("request_id", RequestIDFromContext(r.Context())),
+		)
+	})
+}
@@ -0,0 +1,52 @@
+package toolkit
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+var requestLogTests = []struct {
+	name   string
+	status int
+	level  string
+}{
+	{name: "ok", status: http.StatusOK, level: "INFO"},
+	{name: "client error", status: http.StatusNotFound, level: "WARN"},
+	{name: "server error", status: http.StatusBadGateway, level: "ERROR"},
+}
+
+func TestTools_RequestLogMiddleware(t *testing.T) {
+	for _, test := range requestLogTests {
+		var buf bytes.Buffer
+		testTools := Tools{Logger: slog.New(slog.NewJSONHandler(&buf, nil))}
+		h := testTools.RequestIDMiddleware(testTools.RequestLogMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(test.status)
+			_, _ = w.Write([]byte("hello"))
+		})))
+
+		req := httptest.NewRequest(http.MethodPost, "/items?x=1", nil)
+		req.Header.Set(RequestIDHeader, "req-9")
+		h.ServeHTTP(httptest.NewRecorder(), req)
+
+		var record map[string]any
+		if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+			t.Fatalf("%s: %s", test.name, err)
+		}
+		expected := map[string]any{
+			"level": test.level, "msg": "request served", "method": "POST", "path": "/items",
+			"status": float64(test.status), "bytes": float64(5), "client_ip": "192.0.2.1", "request_id": "req-9",
+		}
+		for key, value := range expected {
+			if record[key] != value {
+				t.Errorf("%s: expected %s %v, got %v", test.name, key, value, record[key])
+			}
+		}
+		if _, ok := record["duration"]; !ok {
+			t.Errorf("%s: duration missing", test.name)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package toolkit
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// Resource maps the methods of a path to handlers, for services that do not need a router
+// beyond http.ServeMux. Nil handlers are not allowed; HEAD is served by Get.
+type Resource struct {
+	Get    http.HandlerFunc
+	Post   http.HandlerFunc
+	Put    http.HandlerFunc
+	Patch  http.HandlerFunc
+	Delete http.HandlerFunc
+
+	tools *Tools
+}
+
+// NewResource returns a Resource without handlers.
+func (t *Tools) NewResource() *Resource {
+	return &Resource{tools: t}
+}
+
+// Register mounts the resource on mux at pattern, which must not contain a method.
+func (res *Resource) Register(mux *http.ServeMux, pattern string) {
+	mux.Handle(pattern, res)
+}
+
+// ServeHTTP dispatches r to the handler of its method. OPTIONS is answered with 204 and the
+// Allow header; other methods without a handler get a 405 JSON error.
+func (res *Resource) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var h http.HandlerFunc
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		h = res.Get
+	case http.MethodPost:
+		h = res.Post
+	case http.MethodPut:
+		h = res.Put
+	case http.MethodPatch:
+		h = res.Patch
+	case http.MethodDelete:
+		h = res.Delete
+	case http.MethodOptions:
+		w.Header().Set("Allow", res.allow())
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if h == nil {
+		w.Header().Set("Allow", res.allow())
+		t := res.tools
+		if t == nil {
+			t = &Tools{}
+		}
+		_ = t.ErrorJSON(w, errors.New("method not allowed"), http.StatusMethodNotAllowed)
+		return
+	}
+	h(w, r)
+}
+
+func (res *Resource) allow() string {
+	methods := make([]string, 0, 7)
+	if res.Get != nil {
+		methods = append(methods, http.MethodGet, http.MethodHead)
+	}
+	for _, m := range []struct {
+		name string
+		h    http.HandlerFunc
+	}{{http.MethodPost, res.Post}, {http.MethodPut, res.Put}, {http.MethodPatch, res.Patch}, {http.MethodDelete, res.Delete}} {
+		if m.h != nil {
+			methods = append(methods, m.name)
+		}
+	}
+	return strings.Join(append(methods, http.MethodOptions), ", ")
+}
@@ -0,0 +1,64 @@
+package toolkit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+var resourceTests = []struct {
+	method string
+	status int
+	body   string
+}{
+	{method: http.MethodGet, status: http.StatusOK, body: "list"},
+	{method: http.MethodHead, status: http.StatusOK},
+	{method: http.MethodPost, status: http.StatusCreated, body: "created"},
+	{method: http.MethodPut, status: http.StatusMethodNotAllowed},
+	{method: http.MethodPatch, status: http.StatusMethodNotAllowed},
+	{method: http.MethodDelete, status: http.StatusNoContent},
+	{method: http.MethodOptions, status: http.StatusNoContent},
+	{method: "PROPFIND", status: http.StatusMethodNotAllowed},
+}
+
+func TestTools_Resource(t *testing.T) {
+	var testTools Tools
+	res := testTools.NewResource()
+	res.Get = func(w http.ResponseWriter, r *http.Request) { _, _ = w.Write([]byte("list")) }
+	res.Post = func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte("created"))
+	}
+	res.Delete = func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusNoContent) }
+
+	mux := http.NewServeMux()
+	res.Register(mux, "/items")
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	for _, test := range resourceTests {
+		req, _ := http.NewRequest(test.method, srv.URL+"/items", nil)
+		resp, err := srv.Client().Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body := make([]byte, 64)
+		n, _ := resp.Body.Read(body)
+		_ = resp.Body.Close()
+
+		if resp.StatusCode != test.status {
+			t.Errorf("%s: expected %d, got %d", test.method, test.status, resp.StatusCode)
+		}
+		if test.body != "" && string(body[:n]) != test.body {
+			t.Errorf("%s: expected body %q, got %q", test.method, test.body, body[:n])
+		}
+		if test.status == http.StatusMethodNotAllowed || test.method == http.MethodOptions {
+			if allow := resp.Header.Get("Allow"); allow != "GET, HEAD, POST, DELETE, OPTIONS" {
+				t.Errorf("%s: unexpected Allow header %q", test.method, allow)
+			}
+		}
+		if test.status == http.StatusMethodNotAllowed && resp.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("%s: expected a JSON error", test.method)
+		}
+	}
+}
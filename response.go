@@ -0,0 +1,139 @@
+package toolkit
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ResponseEncoder writes data to w in the media type it is registered for.
+type ResponseEncoder func(w io.Writer, data any) error
+
+// defaultEncoderTypes are the media types WriteResponse encodes without ResponseEncoders, in the
+// order of preference when the client accepts several equally.
+var defaultEncoderTypes = []string{"application/json", "application/xml", "text/xml", "application/msgpack", "application/x-msgpack", "application/vnd.msgpack"}
+
+var defaultEncoders = map[string]ResponseEncoder{
+	"application/json":        encodeJSON,
+	"application/xml":         encodeXML,
+	"text/xml":                encodeXML,
+	"application/msgpack":     encodeMsgPack,
+	"application/x-msgpack":   encodeMsgPack,
+	"application/vnd.msgpack": encodeMsgPack,
+}
+
+func encodeJSON(w io.Writer, data any) error {
+	out, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(out)
+	return err
+}
+
+func encodeXML(w io.Writer, data any) error {
+	out, err := xml.Marshal(data)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append([]byte(xml.Header), out...))
+	return err
+}
+
+// WriteResponse writes data with the given status and optional headers in the media type the
+// client prefers in its Accept header: JSON, XML, MessagePack or one of ResponseEncoders. Clients
+// without an Accept header, or accepting none of these, get JSON.
+func (t *Tools) WriteResponse(w http.ResponseWriter, r *http.Request, status int, data any, headers ...http.Header) error {
+	mediaType, encode := t.negotiateEncoder(r.Header.Get("Accept"))
+
+	var buf bytes.Buffer
+	if err := encode(&buf, data); err != nil {
+		return err
+	}
+
+	if len(headers) > 0 {
+		for key, value := range headers[0] {
+			w.Header()[key] = value
+		}
+	}
+	addVary(w.Header(), "Accept")
+	w.Header().Set("Content-Type", mediaType)
+	w.WriteHeader(status)
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// negotiateEncoder returns the media type and encoder accept prefers, each type taking the
+// q-value of the most specific range matching it.
+func (t *Tools) negotiateEncoder(accept string) (string, ResponseEncoder) {
+	types := defaultEncoderTypes
+	if len(t.ResponseEncoders) > 0 {
+		var custom []string
+		for typ := range t.ResponseEncoders {
+			if _, ok := defaultEncoders[typ]; !ok {
+				custom = append(custom, typ)
+			}
+		}
+		sort.Strings(custom)
+		types = append(types[:len(types):len(types)], custom...)
+	}
+	encoder := func(typ string) ResponseEncoder {
+		if enc, ok := t.ResponseEncoders[typ]; ok {
+			return enc
+		}
+		return defaultEncoders[typ]
+	}
+
+	type mediaRange struct {
+		typ, subtype string
+		q            float64
+	}
+	var ranges []mediaRange
+	for _, part := range strings.Split(accept, ",") {
+		typ, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+		main, sub, _ := strings.Cut(typ, "/")
+		ranges = append(ranges, mediaRange{main, sub, q})
+	}
+
+	best, bestQ := "", 0.0
+	for _, typ := range types {
+		main, sub, _ := strings.Cut(typ, "/")
+		q, specificity := 0.0, -1
+		for _, mr := range ranges {
+			s := -1
+			switch {
+			case mr.typ == main && mr.subtype == sub:
+				s = 2
+			case mr.typ == main && mr.subtype == "*":
+				s = 1
+			case mr.typ == "*" && mr.subtype == "*":
+				s = 0
+			}
+			if s > specificity {
+				q, specificity = mr.q, s
+			}
+		}
+		if q > bestQ {
+			best, bestQ = typ, q
+		}
+	}
+	if best == "" {
+		best = "application/json"
+	}
+	return best, encoder(best)
+}
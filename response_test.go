@@ -0,0 +1,69 @@
+package toolkit
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type responsePayload struct {
+	XMLName xml.Name `json:"-" xml:"payload"`
+	Name    string   `json:"name" xml:"name"`
+}
+
+var writeResponseTests = []struct {
+	name        string
+	accept      string
+	contentType string
+	body        string
+}{
+	{name: "no accept", accept: "", contentType: "application/json", body: `{"name":"a"}`},
+	{name: "any", accept: "*/*", contentType: "application/json", body: `{"name":"a"}`},
+	{name: "json", accept: "application/json", contentType: "application/json", body: `{"name":"a"}`},
+	{name: "xml", accept: "application/xml", contentType: "application/xml", body: xml.Header + `<payload><name>a</name></payload>`},
+	{name: "text xml", accept: "text/html, text/xml;q=0.9", contentType: "text/xml", body: xml.Header + `<payload><name>a</name></payload>`},
+	{name: "preferred xml", accept: "application/json;q=0.5, application/xml", contentType: "application/xml", body: xml.Header + `<payload><name>a</name></payload>`},
+	{name: "excluded json", accept: "application/json;q=0, */*", contentType: "application/xml", body: xml.Header + `<payload><name>a</name></payload>`},
+	{name: "msgpack", accept: "application/msgpack", contentType: "application/msgpack", body: "\x81\xa4name\xa1a"},
+	{name: "custom", accept: "text/csv", contentType: "text/csv", body: "name\na\n"},
+	{name: "unknown", accept: "image/png", contentType: "application/json", body: `{"name":"a"}`},
+}
+
+func TestTools_WriteResponse(t *testing.T) {
+	testTools := Tools{ResponseEncoders: map[string]ResponseEncoder{
+		"text/csv": func(w io.Writer, data any) error {
+			_, err := fmt.Fprintf(w, "name\n%s\n", data.(responsePayload).Name)
+			return err
+		},
+	}}
+
+	for _, test := range writeResponseTests {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if test.accept != "" {
+			req.Header.Set("Accept", test.accept)
+		}
+		rr := httptest.NewRecorder()
+		if err := testTools.WriteResponse(rr, req, http.StatusOK, responsePayload{Name: "a"}, http.Header{"Foo": {"bar"}}); err != nil {
+			t.Errorf("%s: unexpected error %v", test.name, err)
+			continue
+		}
+		if ct := rr.Header().Get("Content-Type"); ct != test.contentType {
+			t.Errorf("%s: expected content type %s, got %s", test.name, test.contentType, ct)
+		}
+		if rr.Body.String() != test.body {
+			t.Errorf("%s: expected body %q, got %q", test.name, test.body, rr.Body.String())
+		}
+		if rr.Header().Get("Vary") != "Accept" || rr.Header().Get("Foo") != "bar" {
+			t.Errorf("%s: unexpected headers %v", test.name, rr.Header())
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rr := httptest.NewRecorder()
+	if err := testTools.WriteResponse(rr, req, http.StatusOK, make(chan int)); err == nil || rr.Code != http.StatusOK || rr.Body.Len() != 0 {
+		t.Errorf("expected an encoding error before anything is written, got %v", err)
+	}
+}
@@ -0,0 +1,151 @@
+package toolkit
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"time"
+)
+
+// Backoff returns the delay before retry number attempt, starting at 1.
+type Backoff func(attempt int) time.Duration
+
+// ConstantBackoff waits d before every retry.
+func ConstantBackoff(d time.Duration) Backoff {
+	return func(int) time.Duration { return d }
+}
+
+// ExponentialBackoff waits base before the first retry and doubles the delay for every further
+// one, up to limit when it is set.
+func ExponentialBackoff(base, limit time.Duration) Backoff {
+	return func(attempt int) time.Duration {
+		d := base
+		for i := 1; i < attempt && (limit <= 0 || d < limit); i++ {
+			d *= 2
+		}
+		if limit > 0 {
+			d = min(d, limit)
+		}
+		return d
+	}
+}
+
+// JitteredBackoff is ExponentialBackoff with full jitter: the delay is random between 0 and the
+// exponential one, which spreads out retries of clients that failed together.
+func JitteredBackoff(base, limit time.Duration) Backoff {
+	exp := ExponentialBackoff(base, limit)
+	return func(attempt int) time.Duration {
+		d := exp(attempt)
+		if d <= 0 {
+			return 0
+		}
+		return rand.N(d + 1)
+	}
+}
+
+// RetryPolicy configures Retry.
+type RetryPolicy struct {
+	// MaxAttempts is how often the function is called at most, 3 when 0.
+	MaxAttempts int
+	// Backoff is the delay between attempts, JitteredBackoff(100ms, 10s) when nil.
+	Backoff Backoff
+	// Retryable reports whether an error is worth retrying. Every error not marked with
+	// Permanent is retried when it is nil.
+	Retryable func(err error) bool
+	// OnRetry, when set, is called before waiting for the next attempt.
+	OnRetry func(attempt int, err error, delay time.Duration)
+	// MaxRetryAfter is the longest delay an error marked with RetryAfter may ask for, 1 minute
+	// when 0. Asking for longer ends the retries.
+	MaxRetryAfter time.Duration
+	// RetryOnStatus lists the response statuses PushJSONToRemote retries, 429, 502, 503 and 504
+	// when empty. Their Retry-After header, when present, replaces Backoff.
+	RetryOnStatus []int
+}
+
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent marks err as not worth retrying. Retry returns the wrapped error unchanged.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err}
+}
+
+type retryAfterError struct {
+	err   error
+	delay time.Duration
+}
+
+func (e *retryAfterError) Error() string { return e.err.Error() }
+func (e *retryAfterError) Unwrap() error { return e.err }
+
+// RetryAfter marks err as worth retrying after d instead of the delay of the policy's Backoff.
+// Retry returns the wrapped error when it gives up.
+func RetryAfter(err error, d time.Duration) error {
+	if err == nil {
+		return nil
+	}
+	return &retryAfterError{err, d}
+}
+
+// Retry calls fn until it succeeds, fails with an error that is not retryable, the attempts of
+// policy are used up or ctx ends. It returns the last error of fn, or ctx.Err() when the context
+// ended while waiting.
+func Retry(ctx context.Context, policy RetryPolicy, fn func() error) error {
+	_, err := RetryValue(ctx, policy, func() (struct{}, error) {
+		return struct{}{}, fn()
+	})
+	return err
+}
+
+// RetryValue is Retry for functions returning a value.
+func RetryValue[T any](ctx context.Context, policy RetryPolicy, fn func() (T, error)) (T, error) {
+	if policy.MaxAttempts <= 0 {
+		policy.MaxAttempts = 3
+	}
+	if policy.Backoff == nil {
+		policy.Backoff = JitteredBackoff(100*time.Millisecond, 10*time.Second)
+	}
+	if policy.MaxRetryAfter <= 0 {
+		policy.MaxRetryAfter = time.Minute
+	}
+
+	for attempt := 1; ; attempt++ {
+		v, err := fn()
+		if err == nil {
+			return v, nil
+		}
+		var permanent *permanentError
+		if errors.As(err, &permanent) {
+			return v, permanent.err
+		}
+		delay := policy.Backoff(attempt)
+		var after *retryAfterError
+		if errors.As(err, &after) {
+			delay = after.delay
+			err = after.err
+		}
+		if attempt >= policy.MaxAttempts || policy.Retryable != nil && !policy.Retryable(err) ||
+			after != nil && delay > policy.MaxRetryAfter {
+			return v, err
+		}
+
+		if policy.OnRetry != nil {
+			policy.OnRetry(attempt, err, delay)
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			var zero T
+			return zero, ctx.Err()
+		}
+	}
+}
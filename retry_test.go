@@ -0,0 +1,188 @@
+package toolkit
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+)
+
+var backoffTests = []struct {
+	name     string
+	backoff  Backoff
+	expected []time.Duration
+}{
+	{name: "constant", backoff: ConstantBackoff(time.Second), expected: []time.Duration{time.Second, time.Second, time.Second}},
+	{name: "exponential", backoff: ExponentialBackoff(time.Second, 0), expected: []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second}},
+	{name: "exponential capped", backoff: ExponentialBackoff(time.Second, 3*time.Second), expected: []time.Duration{time.Second, 2 * time.Second, 3 * time.Second, 3 * time.Second}},
+}
+
+func TestTools_Backoff(t *testing.T) {
+	for _, test := range backoffTests {
+		for i, expected := range test.expected {
+			if d := test.backoff(i + 1); d != expected {
+				t.Errorf("%s: attempt %d: expected %s, got %s", test.name, i+1, expected, d)
+			}
+		}
+	}
+
+	jittered := JitteredBackoff(time.Second, 4*time.Second)
+	for attempt := 1; attempt < 10; attempt++ {
+		if d := jittered(attempt); d < 0 || d > 4*time.Second {
+			t.Errorf("jittered delay %s out of range", d)
+		}
+	}
+}
+
+func TestTools_Retry(t *testing.T) {
+	fast := RetryPolicy{MaxAttempts: 4, Backoff: ConstantBackoff(time.Millisecond)}
+
+	calls, retries := 0, 0
+	policy := fast
+	policy.OnRetry = func(attempt int, err error, delay time.Duration) { retries++ }
+	v, err := RetryValue(context.Background(), policy, func() (int, error) {
+		calls++
+		if calls < 3 {
+			return 0, errors.New("temporary")
+		}
+		return 42, nil
+	})
+	if err != nil || v != 42 || calls != 3 || retries != 2 {
+		t.Errorf("expected success on the third attempt, got %d %v after %d calls and %d retries", v, err, calls, retries)
+	}
+
+	calls = 0
+	err = Retry(context.Background(), fast, func() error {
+		calls++
+		return errors.New("always")
+	})
+	if err == nil || err.Error() != "always" || calls != 4 {
+		t.Errorf("expected attempts to be used up, got %v after %d calls", err, calls)
+	}
+
+	calls = 0
+	notFound := errors.New("not found")
+	err = Retry(context.Background(), fast, func() error {
+		calls++
+		return Permanent(notFound)
+	})
+	if err != notFound || calls != 1 {
+		t.Errorf("expected permanent error to stop retries, got %v after %d calls", err, calls)
+	}
+
+	calls = 0
+	policy = fast
+	policy.Retryable = func(err error) bool { return !errors.Is(err, notFound) }
+	if err = Retry(context.Background(), policy, func() error { calls++; return notFound }); calls != 1 {
+		t.Errorf("expected classified error to stop retries, got %v after %d calls", err, calls)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = Retry(ctx, RetryPolicy{Backoff: ConstantBackoff(time.Hour)}, func() error { return errors.New("temporary") })
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected the context error, got %v", err)
+	}
+}
+
+func TestTools_PushJSONToRemoteRetry(t *testing.T) {
+	var statuses []int
+	client := NewTestClient(func(req *http.Request) *http.Response {
+		body, _ := io.ReadAll(req.Body)
+		if string(body) != `{"a":"b"}` {
+			t.Errorf("unexpected body %q", body)
+		}
+		status := http.StatusServiceUnavailable
+		if len(statuses) == 2 {
+			status = http.StatusOK
+		}
+		statuses = append(statuses, status)
+		return &http.Response{StatusCode: status, Body: io.NopCloser(bytes.NewBufferString("")), Header: make(http.Header)}
+	})
+
+	testTools := Tools{RemoteRetry: &RetryPolicy{MaxAttempts: 3, Backoff: ConstantBackoff(time.Millisecond)}}
+	_, status, err := testTools.PushJSONToRemote("http://remote.example", map[string]string{"a": "b"}, client)
+	if err != nil || status != http.StatusOK || len(statuses) != 3 {
+		t.Errorf("expected success on the third attempt, got %d %v after %d calls", status, err, len(statuses))
+	}
+
+	statuses = nil
+	testTools.RemoteRetry.MaxAttempts = 2
+	_, status, err = testTools.PushJSONToRemote("http://remote.example", map[string]string{"a": "b"}, client)
+	if err != nil || status != http.StatusServiceUnavailable || len(statuses) != 2 {
+		t.Errorf("expected the last 503 to be returned, got %d %v after %d calls", status, err, len(statuses))
+	}
+}
+
+func TestTools_RetryAfter(t *testing.T) {
+	temporary := errors.New("temporary")
+
+	var delays []time.Duration
+	policy := RetryPolicy{MaxAttempts: 2, Backoff: ConstantBackoff(time.Hour)}
+	policy.OnRetry = func(attempt int, err error, delay time.Duration) { delays = append(delays, delay) }
+	err := Retry(context.Background(), policy, func() error { return RetryAfter(temporary, time.Millisecond) })
+	if err != temporary || len(delays) != 1 || delays[0] != time.Millisecond {
+		t.Errorf("expected the requested delay to replace the backoff, got %v %v", err, delays)
+	}
+
+	calls := 0
+	policy = RetryPolicy{MaxAttempts: 3, MaxRetryAfter: time.Second}
+	err = Retry(context.Background(), policy, func() error { calls++; return RetryAfter(temporary, time.Hour) })
+	if err != temporary || calls != 1 {
+		t.Errorf("expected a delay over MaxRetryAfter to end the retries, got %v after %d calls", err, calls)
+	}
+}
+
+var retryAfterTests = []struct {
+	header   string
+	expected time.Duration
+	ok       bool
+}{
+	{header: "", ok: false},
+	{header: "3", expected: 3 * time.Second, ok: true},
+	{header: "-1", expected: 0, ok: true},
+	{header: "Wed, 21 Oct 2015 07:28:10 GMT", expected: 10 * time.Second, ok: true},
+	{header: "Wed, 21 Oct 2015 07:27:00 GMT", expected: 0, ok: true},
+	{header: "soon", ok: false},
+}
+
+func TestTools_ParseRetryAfter(t *testing.T) {
+	now := time.Date(2015, 10, 21, 7, 28, 0, 0, time.UTC)
+	for _, test := range retryAfterTests {
+		d, ok := parseRetryAfter(test.header, now)
+		if d != test.expected || ok != test.ok {
+			t.Errorf("%q: expected %s %v, got %s %v", test.header, test.expected, test.ok, d, ok)
+		}
+	}
+}
+
+func TestTools_PushJSONToRemoteRetryOnStatus(t *testing.T) {
+	var statuses []int
+	client := NewTestClient(func(req *http.Request) *http.Response {
+		status := http.StatusInternalServerError
+		if len(statuses) == 1 {
+			status = http.StatusOK
+		}
+		statuses = append(statuses, status)
+		header := http.Header{"Retry-After": {"0"}}
+		return &http.Response{StatusCode: status, Body: io.NopCloser(bytes.NewBufferString("")), Header: header}
+	})
+
+	var delays []time.Duration
+	testTools := Tools{RemoteRetry: &RetryPolicy{
+		MaxAttempts:   3,
+		Backoff:       ConstantBackoff(time.Hour),
+		RetryOnStatus: []int{http.StatusInternalServerError},
+		OnRetry:       func(attempt int, err error, delay time.Duration) { delays = append(delays, delay) },
+	}}
+	_, status, err := testTools.PushJSONToRemote("http://remote.example", map[string]string{"a": "b"}, client)
+	if err != nil || status != http.StatusOK || len(statuses) != 2 {
+		t.Errorf("expected the 500 to be retried, got %d %v after %d calls", status, err, len(statuses))
+	}
+	if len(delays) != 1 || delays[0] != 0 {
+		t.Errorf("expected Retry-After to set the delay, got %v", delays)
+	}
+}
@@ -0,0 +1,234 @@
+package toolkit
+
+import (
+	"compress/gzip"
+	"errors"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const rotateTimeFormat = "20060102T150405.000"
+
+// RotateOptions configures a RotatingWriter. Zero values disable the corresponding limit.
+type RotateOptions struct {
+	// MaxSize is the size in bytes after which the current file is rotated.
+	MaxSize int64
+	// MaxAge is how long a file is written to before it is rotated, measured from when the
+	// writer opened or created it.
+	MaxAge time.Duration
+	// MaxBackups is the number of rotated files kept; older ones are removed.
+	MaxBackups int
+	// Compress gzips rotated files in the background.
+	Compress bool
+}
+
+// RotatingWriter is an io.Writer appending to a file that is rotated by size and age. Rotated
+// files are renamed to name-<timestamp>.ext next to it. It is safe for concurrent use, so it can
+// back a log.Logger or a slog handler directly.
+type RotatingWriter struct {
+	path     string
+	opts     RotateOptions
+	fileMode fs.FileMode
+	now      func() time.Time
+	tools    *Tools
+
+	mu     sync.Mutex
+	file   *os.File
+	size   int64
+	opened time.Time
+
+	postMu sync.Mutex
+	wg     sync.WaitGroup
+}
+
+// NewRotatingWriter opens, or creates, the file at path for appending and returns a writer
+// rotating it according to opts. Missing parent directories are created.
+func (t *Tools) NewRotatingWriter(path string, opts RotateOptions) (*RotatingWriter, error) {
+	if opts.MaxSize < 0 || opts.MaxAge < 0 || opts.MaxBackups < 0 {
+		return nil, errors.New("rotate options must not be negative")
+	}
+	if _, err := t.CreateDirIfNotExists(filepath.Dir(path)); err != nil {
+		return nil, err
+	}
+
+	w := &RotatingWriter{path: path, opts: opts, fileMode: t.fileMode(), now: time.Now, tools: t}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *RotatingWriter) open() error {
+	f, err := w.tools.openFile(w.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, w.fileMode)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	w.file, w.size, w.opened = f, info.Size(), w.now()
+	return nil
+}
+
+// Write appends p to the current file, rotating it first when p would push it over MaxSize or
+// when it is older than MaxAge. A single write larger than MaxSize goes to a fresh file whole.
+func (w *RotatingWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		return 0, os.ErrClosed
+	}
+
+	if w.size > 0 && (w.opts.MaxSize > 0 && w.size+int64(len(p)) > w.opts.MaxSize ||
+		w.opts.MaxAge > 0 && w.now().Sub(w.opened) >= w.opts.MaxAge) {
+		if err := w.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+// Rotate closes the current file, renames it to a backup and starts a new one.
+func (w *RotatingWriter) Rotate() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		return os.ErrClosed
+	}
+	return w.rotate()
+}
+
+func (w *RotatingWriter) rotate() error {
+	if err := w.file.Close(); err != nil {
+		return err
+	}
+	w.file = nil
+
+	ext := filepath.Ext(w.path)
+	backup := strings.TrimSuffix(w.path, ext) + "-" + w.now().Format(rotateTimeFormat) + ext
+	if err := os.Rename(w.path, backup); err != nil {
+		return err
+	}
+	if err := w.open(); err != nil {
+		return err
+	}
+
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		w.postMu.Lock()
+		defer w.postMu.Unlock()
+
+		if w.opts.Compress {
+			if err := w.tools.gzipFile(backup); err != nil {
+				w.tools.logger().Error("compressing rotated file failed", slog.String("file", backup), slog.String("error", err.Error()))
+			}
+		}
+		if err := w.pruneBackups(); err != nil {
+			w.tools.logger().Error("removing old rotated files failed", slog.String("error", err.Error()))
+		}
+	}()
+	return nil
+}
+
+// Close closes the current file and waits for background compression to finish.
+func (w *RotatingWriter) Close() error {
+	w.mu.Lock()
+	var err error
+	if w.file != nil {
+		err = w.file.Close()
+		w.file = nil
+	}
+	w.mu.Unlock()
+
+	w.wg.Wait()
+	return err
+}
+
+// backups returns the rotated files of w, oldest first.
+func (w *RotatingWriter) backups() ([]string, error) {
+	ext := filepath.Ext(w.path)
+	prefix := filepath.Base(strings.TrimSuffix(w.path, ext)) + "-"
+
+	entries, err := os.ReadDir(filepath.Dir(w.path))
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, entry := range entries {
+		name := entry.Name()
+		stamp, ok := strings.CutPrefix(strings.TrimSuffix(name, ".gz"), prefix)
+		if !ok || entry.IsDir() || !strings.HasSuffix(stamp, ext) {
+			continue
+		}
+		if _, err := time.Parse(rotateTimeFormat, strings.TrimSuffix(stamp, ext)); err != nil {
+			continue
+		}
+		names = append(names, name)
+	}
+	// The timestamp format sorts lexically in time order.
+	sort.Strings(names)
+
+	for i, name := range names {
+		names[i] = filepath.Join(filepath.Dir(w.path), name)
+	}
+	return names, nil
+}
+
+func (w *RotatingWriter) pruneBackups() error {
+	if w.opts.MaxBackups == 0 {
+		return nil
+	}
+	names, err := w.backups()
+	if err != nil {
+		return err
+	}
+	for len(names) > w.opts.MaxBackups {
+		if err = os.Remove(names[0]); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		names = names[1:]
+	}
+	return nil
+}
+
+// gzipFile compresses path into path.gz and removes the original.
+func (t *Tools) gzipFile(path string) error {
+	in, err := t.open(path)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	pr, pw := io.Pipe()
+	go func() {
+		zw := gzip.NewWriter(pw)
+		_, err := io.Copy(zw, in)
+		if closeErr := zw.Close(); err == nil {
+			err = closeErr
+		}
+		pw.CloseWithError(err)
+	}()
+
+	if _, err = t.WriteFileAtomic(path+".gz", pr, t.fileMode()); err != nil {
+		pr.CloseWithError(err)
+		return err
+	}
+	in.Close()
+	return os.Remove(path)
+}
@@ -0,0 +1,87 @@
+package toolkit
+
+import (
+	"compress/gzip"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTools_RotatingWriterSize(t *testing.T) {
+	var testTools Tools
+	dir := t.TempDir()
+	path := filepath.Join(dir, "logs", "app.log")
+
+	w, err := testTools.NewRotatingWriter(path, RotateOptions{MaxSize: 20, MaxBackups: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	clock := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	w.now = func() time.Time {
+		clock = clock.Add(time.Second)
+		return clock
+	}
+
+	for i := 0; i < 5; i++ {
+		if _, err = w.Write([]byte("0123456789abcdef\n")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	backups, _ := w.backups()
+	if len(backups) != 2 {
+		t.Fatalf("expected 2 backups to be kept, got %v", backups)
+	}
+	if base := filepath.Base(backups[1]); !strings.HasPrefix(base, "app-20260101T") || !strings.HasSuffix(base, ".log") {
+		t.Error("unexpected backup name:", base)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "0123456789abcdef\n" {
+		t.Errorf("unexpected current file content %q", data)
+	}
+	if _, err = w.Write([]byte("x")); err == nil {
+		t.Error("expected write after Close to fail")
+	}
+}
+
+func TestTools_RotatingWriterAgeAndCompress(t *testing.T) {
+	var testTools Tools
+	path := filepath.Join(t.TempDir(), "app.log")
+
+	w, err := testTools.NewRotatingWriter(path, RotateOptions{MaxAge: time.Hour, Compress: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	clock := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	w.now = func() time.Time { return clock }
+	w.opened = clock
+
+	logger := slog.New(slog.NewTextHandler(w, nil))
+	logger.Info("first")
+	clock = clock.Add(30 * time.Minute)
+	logger.Info("second")
+	clock = clock.Add(31 * time.Minute)
+	logger.Info("third")
+	_ = w.Close()
+
+	backups, _ := w.backups()
+	if len(backups) != 1 || !strings.HasSuffix(backups[0], ".log.gz") {
+		t.Fatalf("expected one compressed backup, got %v", backups)
+	}
+	f, _ := os.Open(backups[0])
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := io.ReadAll(zr)
+	if !strings.Contains(string(data), "first") || !strings.Contains(string(data), "second") || strings.Contains(string(data), "third") {
+		t.Errorf("unexpected backup content %q", data)
+	}
+}
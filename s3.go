@@ -0,0 +1,277 @@
+package toolkit
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// unsignedPayload is signed instead of the body's hash, so uploads are streamed without being read
+// twice. The body is still protected by TLS.
+const unsignedPayload = "UNSIGNED-PAYLOAD"
+
+// S3Options configures an S3Store.
+type S3Options struct {
+	// Endpoint is the URL of the service, https://s3.<Region>.amazonaws.com when empty. Set it
+	// for MinIO and other S3 compatible services.
+	Endpoint string
+	// Region is the region of the bucket, us-east-1 when empty.
+	Region string
+	Bucket string
+
+	AccessKeyID     string
+	SecretAccessKey string
+	// SessionToken is the token of temporary credentials, e.g. those of an IAM role.
+	SessionToken string
+
+	// PathStyle addresses the bucket as Endpoint/Bucket rather than as a subdomain of Endpoint,
+	// as MinIO and most self-hosted services expect.
+	PathStyle bool
+
+	// Client makes the requests, http.DefaultClient when nil.
+	Client *http.Client
+}
+
+// S3Store is a FileStore in an Amazon S3 bucket or a bucket of an S3 compatible service. Requests
+// are signed with AWS Signature Version 4.
+type S3Store struct {
+	opts     S3Options
+	endpoint *url.URL
+	tools    *Tools
+	now      func() time.Time
+}
+
+// NewS3Store returns a store keeping files in the bucket of opts. Files of unknown size are
+// spooled to a temporary file first, as S3 needs the length of an object before it is sent.
+// Objects larger than 5 GiB, the limit of a single upload, cannot be stored.
+func (t *Tools) NewS3Store(opts S3Options) (*S3Store, error) {
+	if opts.Bucket == "" {
+		return nil, errors.New("s3: bucket is required")
+	}
+	if opts.AccessKeyID == "" || opts.SecretAccessKey == "" {
+		return nil, errors.New("s3: credentials are required")
+	}
+	if opts.Region == "" {
+		opts.Region = "us-east-1"
+	}
+	if opts.Endpoint == "" {
+		opts.Endpoint = "https://s3." + opts.Region + ".amazonaws.com"
+	}
+	if opts.Client == nil {
+		opts.Client = http.DefaultClient
+	}
+
+	endpoint, err := url.Parse(opts.Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("s3: invalid endpoint: %w", err)
+	}
+	if endpoint.Scheme != "http" && endpoint.Scheme != "https" || endpoint.Host == "" {
+		return nil, fmt.Errorf("s3: invalid endpoint %q", opts.Endpoint)
+	}
+	return &S3Store{opts: opts, endpoint: endpoint, tools: t, now: time.Now}, nil
+}
+
+// NewMinIOStore returns an S3Store for a bucket on a MinIO server at opts.Endpoint, addressing the
+// bucket by path.
+func (t *Tools) NewMinIOStore(opts S3Options) (*S3Store, error) {
+	if opts.Endpoint == "" {
+		return nil, errors.New("s3: MinIO endpoint is required")
+	}
+	opts.PathStyle = true
+	return t.NewS3Store(opts)
+}
+
+func (s *S3Store) Save(ctx context.Context, name string, r io.Reader, size int64) (int64, error) {
+	if size < 0 {
+		tmp, err := os.CreateTemp(s.tools.tempRoot(), "s3-upload-*")
+		if err != nil {
+			return 0, err
+		}
+		defer os.Remove(tmp.Name())
+		defer tmp.Close()
+
+		if size, err = io.Copy(tmp, contextReader{ctx, r}); err != nil {
+			return 0, err
+		}
+		if _, err = tmp.Seek(0, io.SeekStart); err != nil {
+			return 0, err
+		}
+		r = tmp
+	}
+
+	resp, err := s.do(ctx, http.MethodPut, name, io.NopCloser(r), size)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, objectStoreError("s3", "put", name, resp)
+	}
+	return size, nil
+}
+
+func (s *S3Store) Open(ctx context.Context, name string) (io.ReadCloser, error) {
+	resp, err := s.do(ctx, http.MethodGet, name, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		return nil, objectStoreError("s3", "get", name, resp)
+	}
+	return resp.Body, nil
+}
+
+func (s *S3Store) Delete(ctx context.Context, name string) error {
+	resp, err := s.do(ctx, http.MethodDelete, name, nil, 0)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		return objectStoreError("s3", "delete", name, resp)
+	}
+	return nil
+}
+
+func (s *S3Store) Exists(ctx context.Context, name string) (bool, error) {
+	resp, err := s.do(ctx, http.MethodHead, name, nil, 0)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusNotFound:
+		return false, nil
+	}
+	return false, objectStoreError("s3", "head", name, resp)
+}
+
+func (s *S3Store) do(ctx context.Context, method, key string, body io.ReadCloser, size int64) (*http.Response, error) {
+	key, err := objectKey("", key)
+	if err != nil {
+		return nil, err
+	}
+
+	u := *s.endpoint
+	prefix := strings.TrimSuffix(u.Path, "/")
+	if s.opts.PathStyle {
+		prefix += "/" + s.opts.Bucket
+	} else {
+		u.Host = s.opts.Bucket + "." + u.Host
+	}
+	u.Path = prefix + "/" + key
+	u.RawPath = s3Escape(prefix+"/"+key, false)
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.ContentLength = size
+		if size == 0 {
+			req.Body = http.NoBody
+		}
+	}
+	s.sign(req, unsignedPayload, s.now())
+	return s.opts.Client.Do(req)
+}
+
+// sign adds the Signature Version 4 authorization of req, covering its Host and every header set
+// so far, to req.
+func (s *S3Store) sign(req *http.Request, payloadHash string, now time.Time) {
+	now = now.UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := amzDate[:8]
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if s.opts.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", s.opts.SessionToken)
+	}
+
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	headers := map[string]string{"host": host}
+	for k, v := range req.Header {
+		headers[strings.ToLower(k)] = strings.Join(strings.Fields(strings.Join(v, ",")), " ")
+	}
+	names := make([]string, 0, len(headers))
+	for k := range headers {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, k := range names {
+		canonicalHeaders.WriteString(k + ":" + headers[k] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	query := req.URL.Query()
+	params := make([]string, 0, len(query))
+	for k, vs := range query {
+		for _, v := range vs {
+			params = append(params, s3Escape(k, true)+"="+s3Escape(v, true))
+		}
+	}
+	sort.Strings(params)
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		strings.Join(params, "&"),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + s.opts.Region + "/s3/aws4_request"
+	hash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(hash[:])
+
+	key := []byte("AWS4" + s.opts.SecretAccessKey)
+	for _, part := range []string{date, s.opts.Region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.opts.AccessKeyID, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// s3Escape percent-encodes s the way Signature Version 4 expects: every byte but letters, digits
+// and -._~ is escaped, and so is / when slash is true.
+func s3Escape(s string, slash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' ||
+			c == '-' || c == '.' || c == '_' || c == '~' || c == '/' && !slash {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+	return b.String()
+}
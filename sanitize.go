@@ -0,0 +1,187 @@
+package toolkit
+
+import (
+	"io"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// SanitizePolicy describes which elements and attributes survive SanitizeHTML.
+type SanitizePolicy struct {
+	// AllowedTags maps a lower case tag name to the attributes allowed on it.
+	AllowedTags map[string][]string
+	// AllowedSchemes lists URL schemes permitted in href and src attributes; relative URLs are always allowed.
+	AllowedSchemes []string
+}
+
+// DefaultSanitizePolicy returns a policy suitable for user supplied rich text.
+func DefaultSanitizePolicy() SanitizePolicy {
+	return SanitizePolicy{
+		AllowedTags: map[string][]string{
+			"a":          {"href", "title"},
+			"b":          nil,
+			"blockquote": nil,
+			"br":         nil,
+			"code":       nil,
+			"em":         nil,
+			"h1":         nil,
+			"h2":         nil,
+			"h3":         nil,
+			"h4":         nil,
+			"hr":         nil,
+			"i":          nil,
+			"img":        {"src", "alt", "title", "width", "height"},
+			"li":         nil,
+			"ol":         nil,
+			"p":          nil,
+			"pre":        nil,
+			"s":          nil,
+			"span":       nil,
+			"strong":     nil,
+			"u":          nil,
+			"ul":         nil,
+		},
+		AllowedSchemes: []string{"http", "https", "mailto"},
+	}
+}
+
+// elements whose content is dropped together with the element itself
+var strippedContentTags = map[string]bool{
+	"script": true, "style": true, "iframe": true, "object": true, "embed": true,
+	"noscript": true, "template": true, "textarea": true, "title": true, "svg": true, "math": true,
+}
+
+var voidTags = map[string]bool{
+	"br": true, "hr": true, "img": true, "wbr": true, "col": true, "source": true,
+}
+
+var urlAttributes = map[string]bool{
+	"href": true, "src": true, "cite": true, "action": true, "poster": true,
+}
+
+// SanitizeHTML removes every element, attribute and URL not permitted by the policy
+// (DefaultSanitizePolicy when none is given) and returns well-formed markup.
+func (t *Tools) SanitizeHTML(input string, policy ...SanitizePolicy) string {
+	p := DefaultSanitizePolicy()
+	if len(policy) > 0 {
+		p = policy[0]
+	}
+
+	var out strings.Builder
+	var open []string
+	skipDepth := 0
+
+	z := html.NewTokenizer(strings.NewReader(input))
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			if z.Err() != io.EOF {
+				return ""
+			}
+			break
+		}
+
+		token := z.Token()
+		name := strings.ToLower(token.Data)
+
+		switch tt {
+		case html.StartTagToken, html.SelfClosingTagToken:
+			if strippedContentTags[name] {
+				if tt == html.StartTagToken && !voidTags[name] {
+					skipDepth++
+				}
+				continue
+			}
+			if skipDepth > 0 {
+				continue
+			}
+			allowedAttrs, ok := p.AllowedTags[name]
+			if !ok {
+				continue
+			}
+			out.WriteString("<" + name)
+			for _, attr := range token.Attr {
+				key := strings.ToLower(attr.Key)
+				if attr.Namespace != "" || !containsFold(allowedAttrs, key) {
+					continue
+				}
+				if urlAttributes[key] && !p.allowsURL(attr.Val) {
+					continue
+				}
+				out.WriteString(" " + key + `="` + html.EscapeString(attr.Val) + `"`)
+			}
+			if voidTags[name] {
+				out.WriteString(" />")
+				continue
+			}
+			out.WriteString(">")
+			if tt == html.SelfClosingTagToken {
+				out.WriteString("</" + name + ">")
+				continue
+			}
+			open = append(open, name)
+
+		case html.EndTagToken:
+			if strippedContentTags[name] {
+				if skipDepth > 0 {
+					skipDepth--
+				}
+				continue
+			}
+			if skipDepth > 0 {
+				continue
+			}
+			for i := len(open) - 1; i >= 0; i-- {
+				if open[i] != name {
+					continue
+				}
+				for j := len(open) - 1; j >= i; j-- {
+					out.WriteString("</" + open[j] + ">")
+				}
+				open = open[:i]
+				break
+			}
+
+		case html.TextToken:
+			if skipDepth == 0 {
+				out.WriteString(html.EscapeString(token.Data))
+			}
+		}
+	}
+
+	for i := len(open) - 1; i >= 0; i-- {
+		out.WriteString("</" + open[i] + ">")
+	}
+
+	return out.String()
+}
+
+func (p SanitizePolicy) allowsURL(raw string) bool {
+	cleaned := strings.Map(func(r rune) rune {
+		if r <= ' ' || r == 0x7f {
+			return -1
+		}
+		return r
+	}, raw)
+
+	u, err := url.Parse(cleaned)
+	if err != nil {
+		return false
+	}
+	if u.Scheme == "" {
+		// reject scheme-like prefixes the URL parser did not recognise, e.g. "java&#10;script:"
+		return !strings.Contains(strings.SplitN(cleaned, "/", 2)[0], ":")
+	}
+	return containsFold(p.AllowedSchemes, u.Scheme)
+}
+
+func containsFold(list []string, s string) bool {
+	for _, x := range list {
+		if strings.EqualFold(x, s) {
+			return true
+		}
+	}
+	return false
+}
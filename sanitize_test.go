@@ -0,0 +1,39 @@
+package toolkit
+
+import "testing"
+
+var sanitizeTests = []struct {
+	name     string
+	input    string
+	expected string
+}{
+	{name: "plain text", input: "hello & goodbye", expected: "hello &amp; goodbye"},
+	{name: "allowed markup", input: `<p>Hi <strong>there</strong></p>`, expected: `<p>Hi <strong>there</strong></p>`},
+	{name: "script removed", input: `<p>a<script>alert(1)</script>b</p>`, expected: `<p>ab</p>`},
+	{name: "event handler removed", input: `<img src="/a.png" onerror="alert(1)">`, expected: `<img src="/a.png" />`},
+	{name: "javascript url removed", input: `<a href="javascript:alert(1)">x</a>`, expected: `<a>x</a>`},
+	{name: "obfuscated javascript url removed", input: `<a href="java&#10;script:alert(1)">x</a>`, expected: `<a>x</a>`},
+	{name: "https url kept", input: `<a href="https://example.com/?a=1&b=2">x</a>`, expected: `<a href="https://example.com/?a=1&amp;b=2">x</a>`},
+	{name: "unknown tag unwrapped", input: `<div><em>x</em></div>`, expected: `<em>x</em>`},
+	{name: "unclosed tags closed", input: `<ul><li>one`, expected: `<ul><li>one</li></ul>`},
+	{name: "stray end tag dropped", input: `x</p>`, expected: `x`},
+	{name: "comment dropped", input: `a<!-- <script> -->b`, expected: `ab`},
+}
+
+func TestTools_SanitizeHTML(t *testing.T) {
+	var testTools Tools
+	for _, test := range sanitizeTests {
+		if s := testTools.SanitizeHTML(test.input); s != test.expected {
+			t.Errorf("%s: expected %q, got %q", test.name, test.expected, s)
+		}
+	}
+}
+
+func TestTools_SanitizeHTMLCustomPolicy(t *testing.T) {
+	var testTools Tools
+	policy := SanitizePolicy{AllowedTags: map[string][]string{"b": nil}}
+
+	if s := testTools.SanitizeHTML(`<p><b>bold</b></p>`, policy); s != `<b>bold</b>` {
+		t.Error("custom policy not applied:", s)
+	}
+}
@@ -0,0 +1,297 @@
+package toolkit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// CronSchedule is a parsed five-field cron expression: minute, hour, day of month, month and day
+// of week.
+type CronSchedule struct {
+	minute, hour, dom, month, dow uint64
+	// domStar and dowStar record unrestricted day fields. When both day fields are restricted a
+	// day matching either one matches, as in Vixie cron.
+	domStar, dowStar bool
+}
+
+var cronAliases = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+var cronNames = map[string]string{
+	"jan": "1", "feb": "2", "mar": "3", "apr": "4", "may": "5", "jun": "6",
+	"jul": "7", "aug": "8", "sep": "9", "oct": "10", "nov": "11", "dec": "12",
+	"sun": "0", "mon": "1", "tue": "2", "wed": "3", "thu": "4", "fri": "5", "sat": "6",
+}
+
+// ParseCron parses a cron expression such as "*/15 9-17 * * mon-fri" or an alias such as
+// "@daily". Fields accept *, numbers, ranges, lists, steps and month and weekday names; 7 is
+// Sunday as well as 0.
+func ParseCron(expr string) (*CronSchedule, error) {
+	if alias, ok := cronAliases[strings.ToLower(strings.TrimSpace(expr))]; ok {
+		expr = alias
+	}
+	fields := strings.Fields(strings.ToLower(expr))
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron expression %q should have 5 fields", expr)
+	}
+
+	s := &CronSchedule{domStar: fields[2] == "*", dowStar: fields[4] == "*"}
+	var err error
+	for i, f := range []struct {
+		dst      *uint64
+		min, max int
+	}{{&s.minute, 0, 59}, {&s.hour, 0, 23}, {&s.dom, 1, 31}, {&s.month, 1, 12}, {&s.dow, 0, 7}} {
+		if *f.dst, err = parseCronField(fields[i], f.min, f.max); err != nil {
+			return nil, fmt.Errorf("cron expression %q: %w", expr, err)
+		}
+	}
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1
+	}
+	return s, nil
+}
+
+func parseCronField(field string, min, max int) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		rng, stepStr, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			var err error
+			if step, err = strconv.Atoi(stepStr); err != nil || step <= 0 {
+				return 0, fmt.Errorf("invalid step in %q", part)
+			}
+		}
+
+		lo, hi := min, max
+		if rng != "*" {
+			loStr, hiStr, isRange := strings.Cut(rng, "-")
+			var err error
+			if lo, err = cronValue(loStr, min, max); err != nil {
+				return 0, err
+			}
+			hi = lo
+			if isRange {
+				if hi, err = cronValue(hiStr, min, max); err != nil {
+					return 0, err
+				}
+			} else if hasStep {
+				hi = max
+			}
+			if hi < lo {
+				return 0, fmt.Errorf("invalid range %q", rng)
+			}
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << v
+		}
+	}
+	return bits, nil
+}
+
+func cronValue(s string, min, max int) (int, error) {
+	if n, ok := cronNames[s]; ok {
+		s = n
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil || v < min || v > max {
+		return 0, fmt.Errorf("value %q out of range %d-%d", s, min, max)
+	}
+	return v, nil
+}
+
+// Next returns the first time after t that matches the schedule, in t's location, or the zero
+// time when none does within five years, as with "0 0 30 2 *".
+func (s *CronSchedule) Next(t time.Time) time.Time {
+	// Truncate and Round work on absolute time, which misses local hours and minutes in zones
+	// with an offset that is not a whole number of them, so steps are taken in local time.
+	t = t.Add(-time.Duration(t.Second())*time.Second - time.Duration(t.Nanosecond())).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		switch {
+		case s.month&(1<<uint(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+		case !s.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+		case s.hour&(1<<uint(t.Hour())) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+		case s.minute&(1<<uint(t.Minute())) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+func (s *CronSchedule) dayMatches(t time.Time) bool {
+	dom := s.dom&(1<<uint(t.Day())) != 0
+	dow := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domStar || s.dowStar {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+// Scheduler runs jobs on cron schedules or at intervals. A job still running when it is due
+// again is skipped for that run rather than started twice.
+type Scheduler struct {
+	tools *Tools
+
+	mu      sync.Mutex
+	entries []*scheduleEntry
+	ctx     context.Context
+	cancel  context.CancelFunc
+	stop    chan struct{}
+	started bool
+	stopped bool
+	loops   sync.WaitGroup
+	runs    sync.WaitGroup
+}
+
+type scheduleEntry struct {
+	name    string
+	next    func(time.Time) time.Time
+	job     Job
+	running atomic.Bool
+}
+
+// NewScheduler returns a Scheduler without jobs. Jobs run once Start is called.
+func (t *Tools) NewScheduler() *Scheduler {
+	s := &Scheduler{tools: t, stop: make(chan struct{})}
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+	return s
+}
+
+// Cron runs job on the schedule of a cron expression, in local time.
+func (s *Scheduler) Cron(name, expr string, job Job) error {
+	schedule, err := ParseCron(expr)
+	if err != nil {
+		return err
+	}
+	return s.add(&scheduleEntry{name: name, next: schedule.Next, job: job})
+}
+
+// Every runs job every interval plus a random delay of up to jitter, which keeps instances of a
+// service from running it at the same moment.
+func (s *Scheduler) Every(name string, interval, jitter time.Duration, job Job) error {
+	if interval <= 0 || jitter < 0 {
+		return errors.New("interval should be greater than 0 and jitter not negative")
+	}
+	next := func(t time.Time) time.Time {
+		d := interval
+		if jitter > 0 {
+			d += rand.N(jitter + 1)
+		}
+		return t.Add(d)
+	}
+	return s.add(&scheduleEntry{name: name, next: next, job: job})
+}
+
+func (s *Scheduler) add(e *scheduleEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stopped {
+		return errors.New("scheduler is stopped")
+	}
+	s.entries = append(s.entries, e)
+	if s.started {
+		s.loops.Add(1)
+		go s.loop(e)
+	}
+	return nil
+}
+
+// Start begins running the scheduled jobs. Calling it more than once has no effect.
+func (s *Scheduler) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.started || s.stopped {
+		return
+	}
+	s.started = true
+	for _, e := range s.entries {
+		s.loops.Add(1)
+		go s.loop(e)
+	}
+}
+
+// Stop stops scheduling jobs and waits for running ones to finish. When ctx ends first, the
+// context of running jobs is cancelled and ctx.Err() is returned.
+func (s *Scheduler) Stop(ctx context.Context) error {
+	s.mu.Lock()
+	if !s.stopped {
+		s.stopped = true
+		close(s.stop)
+	}
+	s.mu.Unlock()
+	s.loops.Wait()
+
+	done := make(chan struct{})
+	go func() {
+		s.runs.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		s.cancel()
+		return nil
+	case <-ctx.Done():
+		s.cancel()
+		<-done
+		return ctx.Err()
+	}
+}
+
+func (s *Scheduler) loop(e *scheduleEntry) {
+	defer s.loops.Done()
+	log := s.tools.logger().With(slog.String("job", e.name))
+	for {
+		next := e.next(time.Now())
+		if next.IsZero() {
+			log.Warn("scheduled job will never run again")
+			return
+		}
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-s.stop:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		if !e.running.CompareAndSwap(false, true) {
+			log.Warn("scheduled job skipped, previous run still in progress")
+			continue
+		}
+		s.runs.Add(1)
+		go func() {
+			defer s.runs.Done()
+			defer e.running.Store(false)
+			defer func() {
+				if p := recover(); p != nil {
+					log.Error("scheduled job panicked", slog.Any("panic", p), slog.String("stack", string(debug.Stack())))
+				}
+			}()
+			if err := e.job(s.ctx); err != nil {
+				log.Error("scheduled job failed", slog.String("error", err.Error()))
+			}
+		}()
+	}
+}
@@ -0,0 +1,108 @@
+package toolkit
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var cronTests = []struct {
+	expr     string
+	from     string
+	expected string
+	invalid  bool
+}{
+	{expr: "*/15 * * * *", from: "2026-03-10 10:07", expected: "2026-03-10 10:15"},
+	{expr: "0 9-17 * * mon-fri", from: "2026-03-13 17:30", expected: "2026-03-16 09:00"},
+	{expr: "30 2 1 * *", from: "2026-03-10 10:07", expected: "2026-04-01 02:30"},
+	{expr: "@daily", from: "2026-12-31 23:59", expected: "2027-01-01 00:00"},
+	{expr: "0 0 * * 7", from: "2026-03-10 10:07", expected: "2026-03-15 00:00"},
+	{expr: "0 12 13 * fri", from: "2026-03-10 10:07", expected: "2026-03-13 12:00"},
+	{expr: "0 0 29 feb *", from: "2026-03-01 00:00", expected: "2028-02-29 00:00"},
+	{expr: "5,10 1 * jan-mar/2 *", from: "2026-01-31 01:10", expected: "2026-03-01 01:05"},
+	{expr: "0 0 30 2 *", from: "2026-01-01 00:00", expected: ""},
+	{expr: "* * * *", invalid: true},
+	{expr: "60 * * * *", invalid: true},
+	{expr: "5-1 * * * *", invalid: true},
+	{expr: "*/0 * * * *", invalid: true},
+}
+
+func TestTools_ParseCron(t *testing.T) {
+	const layout = "2006-01-02 15:04"
+	// Schedules run in local time, including in zones with half and quarter hour offsets.
+	locations := []*time.Location{
+		time.UTC,
+		time.FixedZone("IST", 5*3600+30*60),
+		time.FixedZone("NPT", 5*3600+45*60),
+		time.FixedZone("NST", -(3*3600 + 30*60)),
+	}
+	for _, test := range cronTests {
+		s, err := ParseCron(test.expr)
+		if test.invalid {
+			if err == nil {
+				t.Errorf("%q: expected an error", test.expr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: %v", test.expr, err)
+			continue
+		}
+		for _, loc := range locations {
+			from, _ := time.ParseInLocation(layout, test.from, loc)
+			next := s.Next(from)
+			if got := next.Format(layout); test.expected == "" && !next.IsZero() || test.expected != "" && got != test.expected {
+				t.Errorf("%q from %s in %s: expected %q, got %s", test.expr, test.from, loc, test.expected, got)
+			}
+		}
+	}
+}
+
+func TestTools_Scheduler(t *testing.T) {
+	testTools := Tools{Logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+	s := testTools.NewScheduler()
+
+	var fast, slow, failing atomic.Int32
+	_ = s.Every("fast", 10*time.Millisecond, 5*time.Millisecond, func(ctx context.Context) error {
+		fast.Add(1)
+		return nil
+	})
+	_ = s.Every("slow", 5*time.Millisecond, 0, func(ctx context.Context) error {
+		slow.Add(1)
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	_ = s.Every("failing", 10*time.Millisecond, 0, func(ctx context.Context) error {
+		failing.Add(1)
+		panic("boom")
+	})
+	if err := s.Cron("bad", "not a cron", nil); err == nil {
+		t.Error("expected an invalid expression to fail")
+	}
+
+	s.Start()
+	time.Sleep(100 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := s.Stop(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the stop to time out waiting for the slow job, got %v", err)
+	}
+
+	if n := fast.Load(); n < 3 || n > 10 {
+		t.Errorf("unexpected number of fast runs %d", n)
+	}
+	if slow.Load() != 1 {
+		t.Errorf("expected overlapping runs of the slow job to be skipped, got %d", slow.Load())
+	}
+	if failing.Load() < 2 {
+		t.Errorf("expected the panicking job to keep running, got %d runs", failing.Load())
+	}
+	if err := s.Every("late", time.Second, 0, func(context.Context) error { return nil }); err == nil {
+		t.Error("expected adding to a stopped scheduler to fail")
+	}
+}
@@ -0,0 +1,81 @@
+package toolkit
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const maxSymlinkFollows = 255
+
+// SecureJoin joins unsafe onto root the way a process chrooted into root would resolve it:
+// ".." never climbs above root and symbolic links, including absolute ones, are resolved
+// relative to root. The returned path is therefore always inside root. Components that do
+// not exist yet are appended as they are. With Tools.NoFollowSymlinks set, encountering any
+// symbolic link, including root itself, is an error wrapping ErrSymlink instead.
+func (t *Tools) SecureJoin(root, unsafe string) (string, error) {
+	root = filepath.Clean(root)
+	if err := t.checkNotSymlink(root); err != nil {
+		return "", err
+	}
+	unsafe = filepath.FromSlash(unsafe)
+
+	var resolved string // path relative to root, already free of links
+	follows := 0
+
+	for unsafe != "" {
+		var part string
+		if i := strings.IndexRune(unsafe, filepath.Separator); i >= 0 {
+			part, unsafe = unsafe[:i], unsafe[i+1:]
+		} else {
+			part, unsafe = unsafe, ""
+		}
+
+		switch part {
+		case "", ".":
+			continue
+		case "..":
+			resolved = filepath.Dir(resolved)
+			if resolved == "." || resolved == string(filepath.Separator) {
+				resolved = ""
+			}
+			continue
+		}
+
+		candidate := filepath.Join(resolved, part)
+		info, err := os.Lstat(filepath.Join(root, candidate))
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				resolved = candidate
+				continue
+			}
+			return "", err
+		}
+
+		if info.Mode()&fs.ModeSymlink == 0 {
+			resolved = candidate
+			continue
+		}
+
+		if t.NoFollowSymlinks {
+			return "", &fs.PathError{Op: "join", Path: filepath.Join(root, candidate), Err: ErrSymlink}
+		}
+
+		follows++
+		if follows > maxSymlinkFollows {
+			return "", errors.New("too many symbolic links while resolving path")
+		}
+		link, err := os.Readlink(filepath.Join(root, candidate))
+		if err != nil {
+			return "", err
+		}
+		if filepath.IsAbs(link) {
+			resolved = ""
+		}
+		unsafe = link + string(filepath.Separator) + unsafe
+	}
+
+	return filepath.Join(root, resolved), nil
+}
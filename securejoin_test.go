@@ -0,0 +1,68 @@
+package toolkit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var secureJoinTests = []struct {
+	name     string
+	unsafe   string
+	expected string
+}{
+	{name: "plain", unsafe: "a/b.txt", expected: "a/b.txt"},
+	{name: "parent traversal", unsafe: "../../etc/passwd", expected: "etc/passwd"},
+	{name: "inner traversal", unsafe: "a/../../b.txt", expected: "b.txt"},
+	{name: "absolute", unsafe: "/etc/passwd", expected: "etc/passwd"},
+	{name: "relative link", unsafe: "rel/b.txt", expected: "a/b.txt"},
+	{name: "escaping link", unsafe: "escape/passwd", expected: "passwd"},
+	{name: "absolute link", unsafe: "abs/passwd", expected: "etc/passwd"},
+	{name: "missing", unsafe: "missing/file.txt", expected: "missing/file.txt"},
+}
+
+func TestTools_SecureJoin(t *testing.T) {
+	var testTools Tools
+	root := t.TempDir()
+	_ = os.MkdirAll(filepath.Join(root, "a"), 0755)
+	_ = os.WriteFile(filepath.Join(root, "a", "b.txt"), []byte("b"), 0644)
+	if err := os.Symlink("a", filepath.Join(root, "rel")); err != nil {
+		t.Skip("symlinks not supported:", err)
+	}
+	_ = os.Symlink("../..", filepath.Join(root, "escape"))
+	_ = os.Symlink("/etc", filepath.Join(root, "abs"))
+
+	for _, test := range secureJoinTests {
+		p, err := testTools.SecureJoin(root, test.unsafe)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", test.name, err)
+			continue
+		}
+		if expected := filepath.Join(root, test.expected); p != expected {
+			t.Errorf("%s: expected %s, got %s", test.name, expected, p)
+		}
+	}
+
+	_ = os.Symlink("loop", filepath.Join(root, "loop"))
+	if _, err := testTools.SecureJoin(root, "loop"); err == nil {
+		t.Error("expected error for symlink loop")
+	}
+}
+
+func TestTools_DownloadStaticFileTraversal(t *testing.T) {
+	var testTools Tools
+	dir := t.TempDir()
+	public := filepath.Join(dir, "public")
+	_ = os.MkdirAll(public, 0755)
+	_ = os.WriteFile(filepath.Join(dir, "secret.txt"), []byte("secret"), 0644)
+
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	testTools.DownloadStaticFile(rr, req, public, "../secret.txt", "secret.txt")
+
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", rr.Code)
+	}
+}
@@ -0,0 +1,548 @@
+package toolkit
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/gob"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// SessionStore persists encoded sessions by token until their expiry.
+type SessionStore interface {
+	// Find returns the data of an unexpired session and whether it was found.
+	Find(ctx context.Context, token string) ([]byte, bool, error)
+	// Commit saves a session, replacing any previous data under the same token.
+	Commit(ctx context.Context, token string, data []byte, expiry time.Time) error
+	// Delete removes a session. Deleting a missing session is not an error.
+	Delete(ctx context.Context, token string) error
+}
+
+// SessionCookie configures the cookie carrying the session token.
+type SessionCookie struct {
+	Name     string
+	Domain   string
+	Path     string
+	Secure   bool
+	SameSite http.SameSite
+	// Persist sets an expiry on the cookie so it survives browser restarts.
+	Persist bool
+}
+
+// SessionManager loads and saves sessions around each request. Values live in the request
+// context and are read and changed with the Get, Put and Pop family of methods. Values are gob
+// encoded, so custom types stored in a session must be registered with gob.Register.
+type SessionManager struct {
+	Store  SessionStore
+	Cookie SessionCookie
+	// Lifetime is the absolute time a session lives from its creation.
+	Lifetime time.Duration
+	// IdleTimeout, when greater than 0, expires sessions not used for that long.
+	IdleTimeout time.Duration
+
+	tools *Tools
+}
+
+// NewSessionManager returns a manager using store, a 24 hour lifetime and a secure, HTTP-only,
+// SameSite=Lax cookie named "session".
+func (t *Tools) NewSessionManager(store SessionStore) *SessionManager {
+	return &SessionManager{
+		Store:    store,
+		Cookie:   SessionCookie{Name: "session", Path: "/", Secure: true, SameSite: http.SameSiteLaxMode, Persist: true},
+		Lifetime: 24 * time.Hour,
+		tools:    t,
+	}
+}
+
+type sessionStatus int
+
+const (
+	sessionUnmodified sessionStatus = iota
+	sessionModified
+	sessionDestroyed
+)
+
+type sessionData struct {
+	mu       sync.Mutex
+	token    string
+	deadline time.Time
+	values   map[string]any
+	status   sessionStatus
+}
+
+type sessionContextKey struct{}
+
+// encodedSession is the gob encoded form of a session.
+type encodedSession struct {
+	Deadline time.Time
+	Values   map[string]any
+}
+
+// LoadAndSave loads the session named by the request cookie into the context, and saves it and
+// sets the cookie before the response is written.
+func (m *SessionManager) LoadAndSave(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Cookie")
+
+		sd, err := m.load(r)
+		if err != nil {
+			m.tools.logger().ErrorContext(r.Context(), "loading session failed", slog.String("error", err.Error()))
+			_ = m.tools.ErrorJSON(w, errors.New("could not load session"), http.StatusInternalServerError)
+			return
+		}
+
+		sw := &sessionWriter{ResponseWriter: w, commit: func() { m.commit(w, r, sd) }}
+		next.ServeHTTP(sw, r.WithContext(context.WithValue(r.Context(), sessionContextKey{}, sd)))
+		sw.commitOnce()
+	})
+}
+
+func (m *SessionManager) load(r *http.Request) (*sessionData, error) {
+	sd := &sessionData{deadline: time.Now().Add(m.Lifetime), values: make(map[string]any)}
+
+	cookie, err := r.Cookie(m.Cookie.Name)
+	if err != nil || cookie.Value == "" {
+		return sd, nil
+	}
+	data, found, err := m.Store.Find(r.Context(), cookie.Value)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return sd, nil
+	}
+
+	var enc encodedSession
+	if err = gob.NewDecoder(bytes.NewReader(data)).Decode(&enc); err != nil {
+		// Data that no longer decodes, as after a type was renamed or left unregistered with
+		// gob.Register, is dropped for a fresh session rather than failing every request.
+		m.tools.logger().ErrorContext(r.Context(), "discarding undecodable session", slog.String("error", err.Error()))
+		if err = m.Store.Delete(r.Context(), cookie.Value); err != nil {
+			m.tools.logger().ErrorContext(r.Context(), "deleting session failed", slog.String("error", err.Error()))
+		}
+		return sd, nil
+	}
+	if time.Now().After(enc.Deadline) {
+		return sd, nil
+	}
+	sd.token, sd.deadline = cookie.Value, enc.Deadline
+	if enc.Values != nil {
+		sd.values = enc.Values
+	}
+	return sd, nil
+}
+
+func (m *SessionManager) commit(w http.ResponseWriter, r *http.Request, sd *sessionData) {
+	sd.mu.Lock()
+	defer sd.mu.Unlock()
+
+	switch {
+	case sd.status == sessionDestroyed:
+		m.setCookie(w, "", time.Unix(1, 0))
+		return
+	case sd.status == sessionUnmodified && (sd.token == "" || m.IdleTimeout == 0):
+		return
+	}
+
+	if sd.token == "" {
+		sd.token = m.tools.RandomURLToken(32)
+	}
+	expiry := sd.deadline
+	if idle := time.Now().Add(m.IdleTimeout); m.IdleTimeout > 0 && idle.Before(expiry) {
+		expiry = idle
+	}
+
+	var buf bytes.Buffer
+	err := gob.NewEncoder(&buf).Encode(encodedSession{Deadline: sd.deadline, Values: sd.values})
+	if err == nil {
+		err = m.Store.Commit(r.Context(), sd.token, buf.Bytes(), expiry)
+	}
+	if err != nil {
+		m.tools.logger().ErrorContext(r.Context(), "saving session failed", slog.String("error", err.Error()))
+		return
+	}
+	m.setCookie(w, sd.token, expiry)
+}
+
+func (m *SessionManager) setCookie(w http.ResponseWriter, token string, expiry time.Time) {
+	cookie := &http.Cookie{
+		Name:     m.Cookie.Name,
+		Value:    token,
+		Domain:   m.Cookie.Domain,
+		Path:     m.Cookie.Path,
+		Secure:   m.Cookie.Secure,
+		SameSite: m.Cookie.SameSite,
+		HttpOnly: true,
+	}
+	if token == "" {
+		cookie.Expires, cookie.MaxAge = expiry, -1
+	} else if m.Cookie.Persist {
+		cookie.Expires = expiry.UTC()
+		cookie.MaxAge = max(int(time.Until(expiry).Seconds()+1), 1)
+	}
+	w.Header().Add("Set-Cookie", cookie.String())
+	w.Header().Add("Cache-Control", `no-cache="Set-Cookie"`)
+}
+
+func (m *SessionManager) data(ctx context.Context) *sessionData {
+	sd, ok := ctx.Value(sessionContextKey{}).(*sessionData)
+	if !ok {
+		panic("toolkit: no session in context, is LoadAndSave missing?")
+	}
+	return sd
+}
+
+// Get returns the value stored under key, or nil.
+func (m *SessionManager) Get(ctx context.Context, key string) any {
+	sd := m.data(ctx)
+	sd.mu.Lock()
+	defer sd.mu.Unlock()
+	return sd.values[key]
+}
+
+// GetString returns the string stored under key, or "".
+func (m *SessionManager) GetString(ctx context.Context, key string) string {
+	s, _ := m.Get(ctx, key).(string)
+	return s
+}
+
+// GetInt returns the int stored under key, or 0.
+func (m *SessionManager) GetInt(ctx context.Context, key string) int {
+	i, _ := m.Get(ctx, key).(int)
+	return i
+}
+
+// GetBool returns the bool stored under key, or false.
+func (m *SessionManager) GetBool(ctx context.Context, key string) bool {
+	b, _ := m.Get(ctx, key).(bool)
+	return b
+}
+
+// Exists reports whether a value is stored under key.
+func (m *SessionManager) Exists(ctx context.Context, key string) bool {
+	sd := m.data(ctx)
+	sd.mu.Lock()
+	defer sd.mu.Unlock()
+	_, ok := sd.values[key]
+	return ok
+}
+
+// Put stores value under key.
+func (m *SessionManager) Put(ctx context.Context, key string, value any) {
+	sd := m.data(ctx)
+	sd.mu.Lock()
+	defer sd.mu.Unlock()
+	sd.values[key] = value
+	sd.status = sessionModified
+}
+
+// Remove deletes the value stored under key.
+func (m *SessionManager) Remove(ctx context.Context, key string) {
+	sd := m.data(ctx)
+	sd.mu.Lock()
+	defer sd.mu.Unlock()
+	if _, ok := sd.values[key]; ok {
+		delete(sd.values, key)
+		sd.status = sessionModified
+	}
+}
+
+// Pop returns the value stored under key and removes it, which suits one-time flash messages.
+func (m *SessionManager) Pop(ctx context.Context, key string) any {
+	sd := m.data(ctx)
+	sd.mu.Lock()
+	defer sd.mu.Unlock()
+	value, ok := sd.values[key]
+	if ok {
+		delete(sd.values, key)
+		sd.status = sessionModified
+	}
+	return value
+}
+
+// PopString is Pop for string values.
+func (m *SessionManager) PopString(ctx context.Context, key string) string {
+	s, _ := m.Pop(ctx, key).(string)
+	return s
+}
+
+// flashKey is the session key of the message set by Flash.
+const flashKey = "flash"
+
+// Flash stores a message shown once on the next page rendered by RenderTemplate, such as
+// "Settings saved" after a redirect.
+func (m *SessionManager) Flash(ctx context.Context, message string) {
+	m.Put(ctx, flashKey, message)
+}
+
+// Renew gives the session a new token while keeping its values, and should be called when the
+// privilege level changes, such as on login, to prevent session fixation.
+func (m *SessionManager) Renew(ctx context.Context) error {
+	sd := m.data(ctx)
+	sd.mu.Lock()
+	defer sd.mu.Unlock()
+
+	if sd.token != "" {
+		if err := m.Store.Delete(ctx, sd.token); err != nil {
+			return err
+		}
+	}
+	sd.token = m.tools.RandomURLToken(32)
+	sd.deadline = time.Now().Add(m.Lifetime)
+	sd.status = sessionModified
+	return nil
+}
+
+// Destroy deletes the session from the store and expires the cookie.
+func (m *SessionManager) Destroy(ctx context.Context) error {
+	sd := m.data(ctx)
+	sd.mu.Lock()
+	defer sd.mu.Unlock()
+
+	if sd.token != "" {
+		if err := m.Store.Delete(ctx, sd.token); err != nil {
+			return err
+		}
+	}
+	sd.token = ""
+	sd.values = make(map[string]any)
+	sd.status = sessionDestroyed
+	return nil
+}
+
+// sessionWriter commits the session just before the response header is sent.
+type sessionWriter struct {
+	http.ResponseWriter
+	commit func()
+	once   sync.Once
+}
+
+func (w *sessionWriter) commitOnce() {
+	w.once.Do(w.commit)
+}
+
+func (w *sessionWriter) WriteHeader(status int) {
+	w.commitOnce()
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *sessionWriter) Write(b []byte) (int, error) {
+	w.commitOnce()
+	return w.ResponseWriter.Write(b)
+}
+
+// Hijack saves the session before handing the connection over, as no header follows.
+func (w *sessionWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	w.commitOnce()
+	return http.NewResponseController(w.ResponseWriter).Hijack()
+}
+
+func (w *sessionWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// MemorySessionStore keeps sessions in memory, for tests and single-instance deployments.
+type MemorySessionStore struct {
+	mu        sync.Mutex
+	items     map[string]memorySession
+	lastSweep time.Time
+}
+
+type memorySession struct {
+	data   []byte
+	expiry time.Time
+}
+
+// NewMemorySessionStore returns an empty in-memory store.
+func NewMemorySessionStore() *MemorySessionStore {
+	return &MemorySessionStore{items: make(map[string]memorySession)}
+}
+
+// Find returns the data of an unexpired session.
+func (s *MemorySessionStore) Find(_ context.Context, token string) ([]byte, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	item, ok := s.items[token]
+	if !ok || time.Now().After(item.expiry) {
+		return nil, false, nil
+	}
+	return item.data, true, nil
+}
+
+// Commit saves a session and drops expired ones every minute.
+func (s *MemorySessionStore) Commit(_ context.Context, token string, data []byte, expiry time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(s.lastSweep) > time.Minute {
+		for k, item := range s.items {
+			if now.After(item.expiry) {
+				delete(s.items, k)
+			}
+		}
+		s.lastSweep = now
+	}
+	s.items[token] = memorySession{data: bytes.Clone(data), expiry: expiry}
+	return nil
+}
+
+// Delete removes a session.
+func (s *MemorySessionStore) Delete(_ context.Context, token string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.items, token)
+	return nil
+}
+
+// FileSessionStore keeps one file per session in Dir, named after a hash of the token so tokens
+// never reach the filesystem. Expired files are removed on access and by Cleanup.
+type FileSessionStore struct {
+	Dir string
+
+	tools *Tools
+}
+
+// NewFileSessionStore returns a store in dir, creating the directory if needed.
+func (t *Tools) NewFileSessionStore(dir string) (*FileSessionStore, error) {
+	if _, err := t.CreateDirIfNotExists(dir, 0700); err != nil {
+		return nil, err
+	}
+	return &FileSessionStore{Dir: dir, tools: t}, nil
+}
+
+func (s *FileSessionStore) path(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return filepath.Join(s.Dir, hex.EncodeToString(sum[:])+".session")
+}
+
+// Find returns the data of an unexpired session.
+func (s *FileSessionStore) Find(_ context.Context, token string) ([]byte, bool, error) {
+	path := s.path(token)
+	b, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	if len(b) < 8 {
+		return nil, false, fmt.Errorf("corrupt session file %s", path)
+	}
+	if time.Now().UnixNano() > int64(binary.BigEndian.Uint64(b)) {
+		_ = os.Remove(path)
+		return nil, false, nil
+	}
+	return b[8:], true, nil
+}
+
+// Commit saves a session, prefixed with its expiry, atomically.
+func (s *FileSessionStore) Commit(_ context.Context, token string, data []byte, expiry time.Time) error {
+	b := binary.BigEndian.AppendUint64(nil, uint64(expiry.UnixNano()))
+	_, err := s.tools.WriteFileAtomic(s.path(token), bytes.NewReader(append(b, data...)), 0600)
+	return err
+}
+
+// Delete removes a session.
+func (s *FileSessionStore) Delete(_ context.Context, token string) error {
+	if err := os.Remove(s.path(token)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// Cleanup removes expired session files and returns how many were removed. It is meant to be
+// run periodically, for example from a scheduler.
+func (s *FileSessionStore) Cleanup() (int, error) {
+	entries, err := os.ReadDir(s.Dir)
+	if err != nil {
+		return 0, err
+	}
+	removed, now := 0, time.Now().UnixNano()
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".session" {
+			continue
+		}
+		path := filepath.Join(s.Dir, entry.Name())
+		f, err := os.Open(path)
+		if err != nil {
+			continue
+		}
+		var expiry [8]byte
+		_, err = f.Read(expiry[:])
+		f.Close()
+		if err == nil && now > int64(binary.BigEndian.Uint64(expiry[:])) {
+			if os.Remove(path) == nil {
+				removed++
+			}
+		}
+	}
+	return removed, nil
+}
+
+// RedisSessionStore keeps sessions in Redis with a matching expiry, through the same minimal
+// RedisEvaler client interface as RedisRateLimitStore.
+type RedisSessionStore struct {
+	Client RedisEvaler
+	// Prefix is prepended to session keys, "session:" when empty.
+	Prefix string
+}
+
+func (s *RedisSessionStore) key(token string) string {
+	if s.Prefix == "" {
+		return "session:" + token
+	}
+	return s.Prefix + token
+}
+
+// Find returns the data of an unexpired session.
+func (s *RedisSessionStore) Find(ctx context.Context, token string) ([]byte, bool, error) {
+	reply, err := s.Client.Eval(ctx, `local v = redis.call('GET', KEYS[1]) if v then return {1, v} end return {0}`, []string{s.key(token)})
+	if err != nil {
+		return nil, false, err
+	}
+	values, ok := reply.([]any)
+	if !ok || len(values) == 0 {
+		return nil, false, fmt.Errorf("unexpected session script reply %v", reply)
+	}
+	if found, _ := values[0].(int64); found != 1 || len(values) != 2 {
+		return nil, false, nil
+	}
+	switch v := values[1].(type) {
+	case string:
+		return []byte(v), true, nil
+	case []byte:
+		return v, true, nil
+	}
+	return nil, false, fmt.Errorf("unexpected session script reply %v", reply)
+}
+
+// Commit saves a session with a Redis expiry.
+func (s *RedisSessionStore) Commit(ctx context.Context, token string, data []byte, expiry time.Time) error {
+	ttl := time.Until(expiry).Milliseconds()
+	if ttl <= 0 {
+		return s.Delete(ctx, token)
+	}
+	_, err := s.Client.Eval(ctx, `redis.call('SET', KEYS[1], ARGV[1], 'PX', ARGV[2]) return 1`, []string{s.key(token)},
+		string(data), strconv.FormatInt(ttl, 10))
+	return err
+}
+
+// Delete removes a session.
+func (s *RedisSessionStore) Delete(ctx context.Context, token string) error {
+	_, err := s.Client.Eval(ctx, `redis.call('DEL', KEYS[1]) return 1`, []string{s.key(token)})
+	return err
+}
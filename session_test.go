@@ -0,0 +1,180 @@
+package toolkit
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newSessionTestServer(m *SessionManager) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/put", func(w http.ResponseWriter, r *http.Request) {
+		m.Put(r.Context(), "user", r.URL.Query().Get("user"))
+		m.Put(r.Context(), "visits", m.GetInt(r.Context(), "visits")+1)
+		m.Put(r.Context(), "flash", "saved")
+	})
+	mux.HandleFunc("/get", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(m.GetString(r.Context(), "user") + "|" + m.PopString(r.Context(), "flash")))
+	})
+	mux.HandleFunc("/login", func(w http.ResponseWriter, r *http.Request) {
+		if err := m.Renew(r.Context()); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+	mux.HandleFunc("/logout", func(w http.ResponseWriter, r *http.Request) {
+		_ = m.Destroy(r.Context())
+	})
+	return m.LoadAndSave(mux)
+}
+
+func sessionRequest(h http.Handler, path string, cookie *http.Cookie) (*httptest.ResponseRecorder, *http.Cookie) {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if cookie != nil {
+		req.AddCookie(cookie)
+	}
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+	for _, c := range rr.Result().Cookies() {
+		return rr, c
+	}
+	return rr, nil
+}
+
+func testSessionFlow(t *testing.T, store SessionStore) {
+	var testTools Tools
+	m := testTools.NewSessionManager(store)
+	h := newSessionTestServer(m)
+
+	if _, c := sessionRequest(h, "/get", nil); c != nil {
+		t.Error("unmodified session should not set a cookie")
+	}
+
+	_, cookie := sessionRequest(h, "/put?user=alice", nil)
+	if cookie == nil || !cookie.HttpOnly || !cookie.Secure || cookie.SameSite != http.SameSiteLaxMode {
+		t.Fatalf("unexpected cookie %+v", cookie)
+	}
+
+	rr, _ := sessionRequest(h, "/get", cookie)
+	if rr.Body.String() != "alice|saved" {
+		t.Errorf("unexpected session values %q", rr.Body.String())
+	}
+	if rr, _ = sessionRequest(h, "/get", cookie); rr.Body.String() != "alice|" {
+		t.Errorf("flash value was not popped: %q", rr.Body.String())
+	}
+
+	_, renewed := sessionRequest(h, "/login", cookie)
+	if renewed == nil || renewed.Value == cookie.Value {
+		t.Fatal("Renew did not issue a new token")
+	}
+	if rr, _ = sessionRequest(h, "/get", cookie); rr.Body.String() != "|" {
+		t.Error("old token still valid after Renew")
+	}
+	if rr, _ = sessionRequest(h, "/get", renewed); rr.Body.String() != "alice|" {
+		t.Errorf("values lost on Renew: %q", rr.Body.String())
+	}
+
+	_, expired := sessionRequest(h, "/logout", renewed)
+	if expired == nil || expired.MaxAge >= 0 {
+		t.Errorf("expected Destroy to expire the cookie, got %+v", expired)
+	}
+	if rr, _ = sessionRequest(h, "/get", renewed); rr.Body.String() != "|" {
+		t.Error("session still valid after Destroy")
+	}
+}
+
+func TestTools_SessionMemoryStore(t *testing.T) {
+	testSessionFlow(t, NewMemorySessionStore())
+}
+
+func TestTools_SessionUndecodable(t *testing.T) {
+	testTools := Tools{Logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+	store := NewMemorySessionStore()
+	m := testTools.NewSessionManager(store)
+	h := newSessionTestServer(m)
+
+	ctx := context.Background()
+	_ = store.Commit(ctx, "corrupt", []byte("not gob"), time.Now().Add(time.Hour))
+	cookie := &http.Cookie{Name: m.Cookie.Name, Value: "corrupt"}
+
+	rr, _ := sessionRequest(h, "/get", cookie)
+	if rr.Code != http.StatusOK || rr.Body.String() != "|" {
+		t.Errorf("expected a fresh session, got %d %q", rr.Code, rr.Body.String())
+	}
+	if _, found, _ := store.Find(ctx, "corrupt"); found {
+		t.Error("undecodable session was not deleted")
+	}
+	if _, renewed := sessionRequest(h, "/put?user=bob", cookie); renewed == nil || renewed.Value == "corrupt" {
+		t.Errorf("expected a new token, got %+v", renewed)
+	}
+}
+
+func TestTools_SessionFileStore(t *testing.T) {
+	var testTools Tools
+	store, err := testTools.NewFileSessionStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	testSessionFlow(t, store)
+
+	ctx := context.Background()
+	_ = store.Commit(ctx, "old", []byte("x"), time.Now().Add(-time.Second))
+	_ = store.Commit(ctx, "new", []byte("y"), time.Now().Add(time.Hour))
+	if removed, _ := store.Cleanup(); removed != 1 {
+		t.Errorf("expected 1 expired session to be removed, got %d", removed)
+	}
+	if data, found, _ := store.Find(ctx, "new"); !found || string(data) != "y" {
+		t.Error("live session removed by Cleanup")
+	}
+}
+
+func TestTools_SessionIdleTimeout(t *testing.T) {
+	var testTools Tools
+	store := NewMemorySessionStore()
+	m := testTools.NewSessionManager(store)
+	m.IdleTimeout = time.Minute
+	h := newSessionTestServer(m)
+
+	_, cookie := sessionRequest(h, "/put?user=bob", nil)
+	_, touched := sessionRequest(h, "/get", cookie)
+	if touched == nil || touched.Value != cookie.Value {
+		t.Error("expected reading the session to extend its idle timeout")
+	}
+
+	store.items[cookie.Value] = memorySession{data: store.items[cookie.Value].data, expiry: time.Now().Add(-time.Second)}
+	if rr, _ := sessionRequest(h, "/get", cookie); rr.Body.String() != "|" {
+		t.Error("idle session was not expired")
+	}
+}
+
+// fakeRedisKV interprets the session store scripts against a map.
+type fakeRedisKV map[string]string
+
+func (f fakeRedisKV) Eval(_ context.Context, script string, keys []string, args ...any) (any, error) {
+	switch {
+	case strings.Contains(script, "'GET'"):
+		if v, ok := f[keys[0]]; ok {
+			return []any{int64(1), v}, nil
+		}
+		return []any{int64(0)}, nil
+	case strings.Contains(script, "'SET'"):
+		f[keys[0]] = args[0].(string)
+	case strings.Contains(script, "'DEL'"):
+		delete(f, keys[0])
+	}
+	return int64(1), nil
+}
+
+func TestTools_SessionRedisStore(t *testing.T) {
+	kv := fakeRedisKV{}
+	testSessionFlow(t, &RedisSessionStore{Client: kv})
+	for key := range kv {
+		if !strings.HasPrefix(key, "session:") {
+			t.Error("unexpected key", key)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package toolkit
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+var (
+	// ErrInvalidSignature is returned by DownloadSigner.Verify for links that were not made by
+	// the signer or were altered.
+	ErrInvalidSignature = errors.New("invalid download link")
+	// ErrLinkExpired is returned by DownloadSigner.Verify for links past their expiry.
+	ErrLinkExpired = errors.New("download link has expired")
+)
+
+// DownloadSigner makes expiring links to files in a directory and serves them, so files can be
+// handed out without a session, e.g. in emails.
+type DownloadSigner struct {
+	secret []byte
+	dir    string
+	prefix string
+	tools  *Tools
+	now    func() time.Time
+}
+
+// NewDownloadSigner returns a signer for the files in dir, whose links point at prefix, the path
+// the signer is mounted at. The secret should be at least 32 random bytes and shared by all
+// instances serving the links.
+func (t *Tools) NewDownloadSigner(secret []byte, dir, prefix string) (*DownloadSigner, error) {
+	if len(secret) < 32 {
+		return nil, errors.New("download signing secret should be at least 32 bytes")
+	}
+	return &DownloadSigner{secret: secret, dir: dir, prefix: prefix, tools: t, now: time.Now}, nil
+}
+
+// URL returns a link to file, relative to the signer's directory, that downloads it as
+// displayName until ttl has passed.
+func (s *DownloadSigner) URL(file, displayName string, ttl time.Duration) string {
+	expires := strconv.FormatInt(s.now().Add(ttl).Unix(), 10)
+	q := url.Values{
+		"file":    {file},
+		"name":    {displayName},
+		"expires": {expires},
+		"sig":     {s.sign(file, displayName, expires)},
+	}
+	return s.prefix + "?" + q.Encode()
+}
+
+// Verify checks a link's query and returns the file and display name it grants.
+func (s *DownloadSigner) Verify(q url.Values) (file, displayName string, err error) {
+	file, displayName, expires := q.Get("file"), q.Get("name"), q.Get("expires")
+	sig, err := base64.RawURLEncoding.DecodeString(q.Get("sig"))
+	if err != nil || file == "" {
+		return "", "", ErrInvalidSignature
+	}
+	expected, _ := base64.RawURLEncoding.DecodeString(s.sign(file, displayName, expires))
+	if !hmac.Equal(sig, expected) {
+		return "", "", ErrInvalidSignature
+	}
+	exp, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil {
+		return "", "", ErrInvalidSignature
+	}
+	if !s.now().Before(time.Unix(exp, 0)) {
+		return "", "", ErrLinkExpired
+	}
+	return file, displayName, nil
+}
+
+// ServeHTTP serves the file of a valid link with DownloadStaticFile. Invalid and expired links
+// get a 403 JSON error.
+func (s *DownloadSigner) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	file, name, err := s.Verify(r.URL.Query())
+	if err != nil {
+		_ = s.tools.ErrorJSON(w, err, http.StatusForbidden)
+		return
+	}
+	s.tools.DownloadStaticFile(w, r, s.dir, file, name)
+}
+
+func (s *DownloadSigner) sign(file, displayName, expires string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	// The parts are length-prefixed so their boundaries cannot be shifted.
+	for _, part := range []string{file, displayName, expires} {
+		mac.Write([]byte(strconv.Itoa(len(part)) + ":" + part))
+	}
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
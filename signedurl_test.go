@@ -0,0 +1,52 @@
+package toolkit
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestTools_DownloadSigner(t *testing.T) {
+	var testTools Tools
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "report.csv"), []byte("a,b\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := testTools.NewDownloadSigner([]byte("short"), dir, "/downloads"); err == nil {
+		t.Error("expected a short secret to fail")
+	}
+	s, err := testTools.NewDownloadSigner(bytes.Repeat([]byte("k"), 32), dir, "/downloads")
+	if err != nil {
+		t.Fatal(err)
+	}
+	link := s.URL("report.csv", "March report.csv", time.Hour)
+
+	rr := httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, link, nil))
+	if rr.Code != http.StatusOK || rr.Body.String() != "a,b\n" {
+		t.Errorf("expected the file, got %d %q", rr.Code, rr.Body.String())
+	}
+
+	u, _ := url.Parse(link)
+	tampered := u.Query()
+	tampered.Set("file", "other.csv")
+	if _, _, err = s.Verify(tampered); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("expected a tampered link to fail, got %v", err)
+	}
+
+	s.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	if _, _, err = s.Verify(u.Query()); !errors.Is(err, ErrLinkExpired) {
+		t.Errorf("expected the link to expire, got %v", err)
+	}
+	rr = httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, link, nil))
+	if rr.Code != http.StatusForbidden {
+		t.Errorf("expected 403 for an expired link, got %d", rr.Code)
+	}
+}
@@ -0,0 +1,165 @@
+package toolkit
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// SlugOptions configures Slugify.
+type SlugOptions struct {
+	// MaxLength is the longest slug, in characters, no limit when 0. Longer slugs are cut at the
+	// last separator that keeps them within it, or mid-word when there is none.
+	MaxLength int
+	// Separator replaces runs of other characters between words, "-" when empty.
+	Separator string
+	// KeepCase keeps upper case letters instead of lowering them.
+	KeepCase bool
+	// Unicode keeps the letters and digits of every script, such as Cyrillic or Chinese, instead
+	// of dropping those that cannot be transliterated to ASCII.
+	Unicode bool
+	// Reserved lists slugs that may not be used, such as "new" or "admin" for slugs in URLs.
+	// Slugify returns an error for them.
+	Reserved []string
+}
+
+// transliterations spells letters with diacritics, and other Latin letters, in ASCII.
+var transliterations = map[rune]string{
+	'à': "a", 'á': "a", 'â': "a", 'ã': "a", 'ä': "a", 'å': "a", 'ā': "a", 'ă': "a", 'ą': "a",
+	'æ': "ae", 'ç': "c", 'ć': "c", 'ĉ': "c", 'ċ': "c", 'č': "c", 'ď': "d", 'đ': "d", 'ð': "d",
+	'è': "e", 'é': "e", 'ê': "e", 'ë': "e", 'ē': "e", 'ĕ': "e", 'ė': "e", 'ę': "e", 'ě': "e",
+	'ĝ': "g", 'ğ': "g", 'ġ': "g", 'ģ': "g", 'ĥ': "h", 'ħ': "h",
+	'ì': "i", 'í': "i", 'î': "i", 'ï': "i", 'ĩ': "i", 'ī': "i", 'ĭ': "i", 'į': "i", 'ı': "i",
+	'ĳ': "ij", 'ĵ': "j", 'ķ': "k", 'ĺ': "l", 'ļ': "l", 'ľ': "l", 'ŀ': "l", 'ł': "l",
+	'ñ': "n", 'ń': "n", 'ņ': "n", 'ň': "n", 'ŉ': "n",
+	'ò': "o", 'ó': "o", 'ô': "o", 'õ': "o", 'ö': "o", 'ø': "o", 'ō': "o", 'ŏ': "o", 'ő': "o",
+	'œ': "oe", 'ŕ': "r", 'ŗ': "r", 'ř': "r", 'ś': "s", 'ŝ': "s", 'ş': "s", 'š': "s", 'ș': "s",
+	'ß': "ss", 'ţ': "t", 'ť': "t", 'ŧ': "t", 'ț': "t", 'þ': "th",
+	'ù': "u", 'ú': "u", 'û': "u", 'ü': "u", 'ũ': "u", 'ū': "u", 'ŭ': "u", 'ů': "u", 'ű': "u", 'ų': "u",
+	'ŵ': "w", 'ý': "y", 'ÿ': "y", 'ŷ': "y", 'ź': "z", 'ż': "z", 'ž': "z",
+}
+
+// slugWords returns the words of s for a slug: runs of ASCII letters and digits, after
+// transliteration, or of letters and digits of any script with opts.Unicode.
+func slugWords(s string, opts SlugOptions) []string {
+	var words []string
+	var word strings.Builder
+	endWord := func() {
+		if word.Len() > 0 {
+			words = append(words, word.String())
+			word.Reset()
+		}
+	}
+
+	for _, r := range s {
+		lower := unicode.ToLower(r)
+		if !opts.KeepCase {
+			r = lower
+		}
+		if t, ok := transliterations[lower]; ok {
+			if r != lower {
+				t = strings.ToUpper(t[:1]) + t[1:]
+			}
+			word.WriteString(t)
+			continue
+		}
+		switch {
+		case r < utf8.RuneSelf && (r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' && opts.KeepCase || r >= '0' && r <= '9'):
+			word.WriteRune(r)
+		case r >= utf8.RuneSelf && opts.Unicode && (unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.Mn, r) && word.Len() > 0):
+			word.WriteRune(r)
+		default:
+			endWord()
+		}
+	}
+	endWord()
+	return words
+}
+
+// joinSlug joins words with sep, leaving out the words that do not fit within maxLength
+// characters, or cutting the first one when it alone is too long.
+func joinSlug(words []string, sep string, maxLength int) string {
+	slug := strings.Join(words, sep)
+	if maxLength <= 0 || utf8.RuneCountInString(slug) <= maxLength {
+		return slug
+	}
+
+	var b strings.Builder
+	n := 0
+	for i, w := range words {
+		add := utf8.RuneCountInString(w)
+		if i > 0 {
+			add += utf8.RuneCountInString(sep)
+		}
+		if n+add > maxLength {
+			break
+		}
+		if i > 0 {
+			b.WriteString(sep)
+		}
+		b.WriteString(w)
+		n += add
+	}
+	if b.Len() > 0 {
+		return b.String()
+	}
+	return string([]rune(words[0])[:maxLength])
+}
+
+// maxNumberedSlugs is how many numbered variants SlugifyUnique tries before random suffixes.
+const maxNumberedSlugs = 100
+
+// SlugifyUnique is Slugify for slugs that must be unique, such as those stored with a unique
+// constraint. While exists reports the slug as taken, or it is reserved, -2, -3 and so on up to
+// -100 are appended, and after that short random suffixes. Suffixes use the separator of opts and
+// fit within its MaxLength.
+func (t *Tools) SlugifyUnique(s string, exists func(slug string) bool, opts ...SlugOptions) (string, error) {
+	var o SlugOptions
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+	if o.Separator == "" {
+		o.Separator = "-"
+	}
+	reserved := o.Reserved
+	o.Reserved = nil
+
+	base, err := t.Slugify(s, o)
+	if err != nil {
+		return "", err
+	}
+	taken := func(slug string) bool {
+		return slices.Contains(reserved, slug) || exists(slug)
+	}
+	if !taken(base) {
+		return base, nil
+	}
+
+	withSuffix := func(suffix string) string {
+		stem := base
+		if o.MaxLength > 0 {
+			room := o.MaxLength - utf8.RuneCountInString(o.Separator+suffix)
+			if room < 1 {
+				return suffix
+			}
+			if runes := []rune(stem); len(runes) > room {
+				stem = strings.TrimSuffix(string(runes[:room]), o.Separator)
+			}
+		}
+		return stem + o.Separator + suffix
+	}
+	for n := 2; n <= maxNumberedSlugs; n++ {
+		if slug := withSuffix(strconv.Itoa(n)); !taken(slug) {
+			return slug, nil
+		}
+	}
+	for range 10 {
+		if slug := withSuffix(t.RandomStringFrom("abcdefghijklmnopqrstuvwxyz0123456789", 6)); !taken(slug) {
+			return slug, nil
+		}
+	}
+	return "", fmt.Errorf("could not find a unique slug for %q", base)
+}
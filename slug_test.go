@@ -0,0 +1,75 @@
+package toolkit
+
+import (
+	"regexp"
+	"testing"
+)
+
+var slugOptionsTests = []struct {
+	name          string
+	s             string
+	opts          SlugOptions
+	expected      string
+	errorExpected bool
+}{
+	{name: "diacritics", s: "Łódź żółć", expected: "lodz-zolc"},
+	{name: "upper case", s: "Now Is The Time", expected: "now-is-the-time"},
+	{name: "ligatures", s: "Straße Œuvre Æsir", expected: "strasse-oeuvre-aesir"},
+	{name: "keep case", s: "Łódź Straße", opts: SlugOptions{KeepCase: true}, expected: "Lodz-Strasse"},
+	{name: "separator", s: "a b  c", opts: SlugOptions{Separator: "_"}, expected: "a_b_c"},
+	{name: "max length at word", s: "the quick brown fox", opts: SlugOptions{MaxLength: 12}, expected: "the-quick"},
+	{name: "max length exact", s: "the quick", opts: SlugOptions{MaxLength: 9}, expected: "the-quick"},
+	{name: "max length mid word", s: "extraordinary", opts: SlugOptions{MaxLength: 5}, expected: "extra"},
+	{name: "other scripts dropped", s: "Привет world", expected: "world"},
+	{name: "other scripts kept", s: "Привет world 日本", opts: SlugOptions{Unicode: true}, expected: "привет-world-日本"},
+	{name: "only other scripts", s: "日本", errorExpected: true},
+	{name: "reserved", s: "New", opts: SlugOptions{Reserved: []string{"new", "edit"}}, errorExpected: true},
+	{name: "not reserved", s: "New York", opts: SlugOptions{Reserved: []string{"new"}}, expected: "new-york"},
+}
+
+func TestTools_SlugifyOptions(t *testing.T) {
+	var testTools Tools
+	for _, test := range slugOptionsTests {
+		slug, err := testTools.Slugify(test.s, test.opts)
+		if test.errorExpected {
+			if err == nil {
+				t.Errorf("%s: expected an error, got %q", test.name, slug)
+			}
+			continue
+		}
+		if err != nil || slug != test.expected {
+			t.Errorf("%s: expected %q, got %q %v", test.name, test.expected, slug, err)
+		}
+	}
+}
+
+func TestTools_SlugifyUnique(t *testing.T) {
+	var testTools Tools
+
+	taken := map[string]bool{"hello-world": true, "hello-world-2": true, "hello_world": true}
+	exists := func(slug string) bool { return taken[slug] }
+
+	if slug, err := testTools.SlugifyUnique("Hello World", exists); err != nil || slug != "hello-world-3" {
+		t.Errorf("expected the first free number, got %q %v", slug, err)
+	}
+	if slug, err := testTools.SlugifyUnique("Goodbye", exists); err != nil || slug != "goodbye" {
+		t.Errorf("expected the slug itself when free, got %q %v", slug, err)
+	}
+	if slug, err := testTools.SlugifyUnique("New", exists, SlugOptions{Reserved: []string{"new"}}); err != nil || slug != "new-2" {
+		t.Errorf("expected a reserved slug to get a suffix, got %q %v", slug, err)
+	}
+	if slug, err := testTools.SlugifyUnique("Hello World", exists, SlugOptions{MaxLength: 11, Separator: "_"}); err != nil || slug != "hello_wor_2" {
+		t.Errorf("expected the suffix to fit within MaxLength, got %q %v", slug, err)
+	}
+
+	numbered := func(slug string) bool { return !regexp.MustCompile(`^a-[a-z0-9]{6}$`).MatchString(slug) }
+	if slug, err := testTools.SlugifyUnique("a", numbered); err != nil || !regexp.MustCompile(`^a-[a-z0-9]{6}$`).MatchString(slug) {
+		t.Errorf("expected a random suffix once numbers run out, got %q %v", slug, err)
+	}
+	if _, err := testTools.SlugifyUnique("a", func(string) bool { return true }); err == nil {
+		t.Error("expected an error when every slug is taken")
+	}
+	if _, err := testTools.SlugifyUnique("", exists); err == nil {
+		t.Error("expected an error for an empty string")
+	}
+}
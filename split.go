@@ -0,0 +1,141 @@
+package toolkit
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// SplitManifest describes a file cut into parts by SplitFile.
+type SplitManifest struct {
+	File      string      `json:"file"`
+	Size      int64       `json:"size"`
+	ChunkSize int64       `json:"chunk_size"`
+	SHA256    string      `json:"sha256"`
+	Parts     []SplitPart `json:"parts"`
+}
+
+// SplitPart is one part listed in a SplitManifest.
+type SplitPart struct {
+	Name   string `json:"name"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// SplitFile cuts the file at path into parts of at most chunkSize bytes, written next to it as
+// name.part0001, name.part0002, ... together with a name.manifest.json file listing every part
+// with its SHA-256 checksum.
+func (t *Tools) SplitFile(path string, chunkSize int64) (*SplitManifest, error) {
+	if chunkSize <= 0 {
+		return nil, errors.New("chunk size should be greater than 0")
+	}
+
+	in, err := t.open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer in.Close()
+
+	dir, base := filepath.Split(path)
+	manifest := &SplitManifest{File: base, ChunkSize: chunkSize}
+	whole := sha256.New()
+
+	br := bufio.NewReader(in)
+	for i := 1; ; i++ {
+		if _, err := br.Peek(1); err == io.EOF && i > 1 {
+			break
+		} else if err != nil && err != io.EOF {
+			return nil, err
+		}
+
+		partHash := sha256.New()
+		name := fmt.Sprintf("%s.part%04d", base, i)
+		r := io.TeeReader(io.LimitReader(br, chunkSize), io.MultiWriter(partHash, whole))
+		n, err := t.WriteFileAtomic(filepath.Join(dir, name), r, t.fileMode())
+		if err != nil {
+			return nil, err
+		}
+		manifest.Parts = append(manifest.Parts, SplitPart{Name: name, Size: n, SHA256: hex.EncodeToString(partHash.Sum(nil))})
+		manifest.Size += n
+	}
+	manifest.SHA256 = hex.EncodeToString(whole.Sum(nil))
+
+	out, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if _, err = t.WriteFileAtomic(filepath.Join(dir, base+".manifest.json"), bytes.NewReader(out), t.fileMode()); err != nil {
+		return nil, err
+	}
+
+	return manifest, nil
+}
+
+// ReadSplitManifest loads a manifest written by SplitFile.
+func (t *Tools) ReadSplitManifest(path string) (*SplitManifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var manifest SplitManifest
+	if err = json.Unmarshal(data, &manifest); err != nil {
+		return nil, err
+	}
+	return &manifest, nil
+}
+
+// JoinFiles concatenates parts, in order, into dst. When a manifest is given every part and the
+// joined result are checked against its checksums and dst is only created if all of them match;
+// otherwise an error wrapping ErrChecksumMismatch is returned.
+func (t *Tools) JoinFiles(parts []string, dst string, manifest ...*SplitManifest) error {
+	var m *SplitManifest
+	if len(manifest) > 0 && manifest[0] != nil {
+		m = manifest[0]
+		if len(m.Parts) != len(parts) {
+			return fmt.Errorf("manifest lists %d parts, got %d", len(m.Parts), len(parts))
+		}
+	}
+
+	pr, pw := io.Pipe()
+	go func() {
+		whole := sha256.New()
+		for i, part := range parts {
+			f, err := t.open(part)
+			if err != nil {
+				pw.CloseWithError(err)
+				return
+			}
+
+			partHash := sha256.New()
+			n, err := io.Copy(io.MultiWriter(pw, partHash, whole), f)
+			f.Close()
+			if err != nil {
+				pw.CloseWithError(err)
+				return
+			}
+
+			if m != nil && (n != m.Parts[i].Size || hex.EncodeToString(partHash.Sum(nil)) != m.Parts[i].SHA256) {
+				pw.CloseWithError(fmt.Errorf("%w: part %s", ErrChecksumMismatch, part))
+				return
+			}
+		}
+
+		if m != nil && hex.EncodeToString(whole.Sum(nil)) != m.SHA256 {
+			pw.CloseWithError(fmt.Errorf("%w: joined file %s", ErrChecksumMismatch, dst))
+			return
+		}
+		pw.Close()
+	}()
+
+	_, err := t.WriteFileAtomic(dst, pr, t.fileMode())
+	pr.Close()
+	return err
+}
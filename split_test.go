@@ -0,0 +1,95 @@
+package toolkit
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var splitTests = []struct {
+	name          string
+	size          int
+	chunkSize     int64
+	expectedParts int
+}{
+	{name: "uneven", size: 2500, chunkSize: 1000, expectedParts: 3},
+	{name: "even", size: 3000, chunkSize: 1000, expectedParts: 3},
+	{name: "single", size: 10, chunkSize: 1000, expectedParts: 1},
+	{name: "empty", size: 0, chunkSize: 1000, expectedParts: 1},
+}
+
+func TestTools_SplitAndJoinFiles(t *testing.T) {
+	var testTools Tools
+
+	for _, test := range splitTests {
+		dir := t.TempDir()
+		data := make([]byte, test.size)
+		_, _ = rand.Read(data)
+		src := filepath.Join(dir, "export.bin")
+		_ = os.WriteFile(src, data, 0644)
+
+		manifest, err := testTools.SplitFile(src, test.chunkSize)
+		if err != nil {
+			t.Fatalf("%s: %s", test.name, err)
+		}
+		if len(manifest.Parts) != test.expectedParts {
+			t.Errorf("%s: expected %d parts, got %d", test.name, test.expectedParts, len(manifest.Parts))
+		}
+
+		loaded, err := testTools.ReadSplitManifest(src + ".manifest.json")
+		if err != nil {
+			t.Fatalf("%s: %s", test.name, err)
+		}
+
+		var parts []string
+		for _, p := range loaded.Parts {
+			parts = append(parts, filepath.Join(dir, p.Name))
+		}
+
+		dst := filepath.Join(dir, "joined.bin")
+		if err = testTools.JoinFiles(parts, dst, loaded); err != nil {
+			t.Fatalf("%s: %s", test.name, err)
+		}
+		joined, _ := os.ReadFile(dst)
+		if !bytes.Equal(joined, data) {
+			t.Errorf("%s: joined file differs from original", test.name)
+		}
+	}
+}
+
+func TestTools_JoinFilesCorrupted(t *testing.T) {
+	var testTools Tools
+	dir := t.TempDir()
+	src := filepath.Join(dir, "export.bin")
+	_ = os.WriteFile(src, bytes.Repeat([]byte("abc"), 100), 0644)
+
+	manifest, err := testTools.SplitFile(src, 64)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var parts []string
+	for _, p := range manifest.Parts {
+		parts = append(parts, filepath.Join(dir, p.Name))
+	}
+	_ = os.WriteFile(parts[1], bytes.Repeat([]byte("x"), 64), 0644)
+
+	dst := filepath.Join(dir, "joined.bin")
+	if err = testTools.JoinFiles(parts, dst, manifest); !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("expected ErrChecksumMismatch, got %v", err)
+	}
+	if _, err = os.Stat(dst); !os.IsNotExist(err) {
+		t.Error("destination created despite corrupted part")
+	}
+
+	if err = testTools.JoinFiles(parts[:2], dst, manifest); err == nil {
+		t.Error("expected error for missing parts")
+	}
+
+	if err = testTools.JoinFiles(parts, dst); err != nil {
+		t.Error("join without manifest should not verify:", err)
+	}
+}
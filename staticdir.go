@@ -0,0 +1,154 @@
+package toolkit
+
+import (
+	"html/template"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// StaticDirOptions configures ServeStaticDir.
+type StaticDirOptions struct {
+	// AutoIndex lists the content of directories that have no index.html.
+	AutoIndex bool
+	// SPA serves the index.html of the root directory for paths matching no file or directory,
+	// so a single page application can route them. Paths with an extension, such as missing
+	// scripts or images, are still answered with 404.
+	SPA bool
+	// CacheControl, when set, is sent as the Cache-Control header with files. index.html pages
+	// are always sent with no-cache, so clients pick up new deployments.
+	CacheControl string
+}
+
+// ServeStaticDir returns a handler serving the directory tree rooted at dir under the URL path
+// prefix, such as "/assets/". Paths are cleaned and resolved with SecureJoin, so they never
+// leave dir, and names starting with a dot, like .git or .env, are never served or listed.
+// Files are served with ServeStaticFile, inline, and directories with their index.html.
+func (t *Tools) ServeStaticDir(prefix, dir string, opts StaticDirOptions) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		rest, ok := strings.CutPrefix(r.URL.Path, prefix)
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		rel := path.Clean("/" + rest)
+		for _, part := range strings.Split(rel, "/") {
+			if strings.HasPrefix(part, ".") {
+				http.NotFound(w, r)
+				return
+			}
+		}
+
+		fp, err := t.SecureJoin(dir, rel)
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		info, err := os.Stat(fp)
+		switch {
+		case err != nil:
+			if opts.SPA && path.Ext(rel) == "" {
+				t.serveIndex(w, r, dir)
+				return
+			}
+			http.NotFound(w, r)
+		case info.IsDir():
+			if !strings.HasSuffix(r.URL.Path, "/") {
+				target := r.URL.Path + "/"
+				if r.URL.RawQuery != "" {
+					target += "?" + r.URL.RawQuery
+				}
+				http.Redirect(w, r, target, http.StatusMovedPermanently)
+				return
+			}
+			if index, err := os.Stat(filepath.Join(fp, "index.html")); err == nil && index.Mode().IsRegular() {
+				t.serveIndex(w, r, fp)
+				return
+			}
+			if !opts.AutoIndex {
+				http.NotFound(w, r)
+				return
+			}
+			t.serveDirListing(w, r, fp)
+		default:
+			t.ServeStaticFile(w, r, filepath.Dir(fp), filepath.Base(fp), StaticFileOptions{Inline: true, CacheControl: opts.CacheControl})
+		}
+	})
+}
+
+// serveIndex serves the index.html of dir, revalidated on every use.
+func (t *Tools) serveIndex(w http.ResponseWriter, r *http.Request, dir string) {
+	t.ServeStaticFile(w, r, dir, "index.html", StaticFileOptions{Inline: true, CacheControl: CacheRevalidate})
+}
+
+// dirListing is the page ServeStaticDir shows for directories with AutoIndex.
+var dirListing = template.Must(template.New("listing").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>Index of {{.Path}}</title></head>
+<body>
+<h1>Index of {{.Path}}</h1>
+<table>
+<tr><th>Name</th><th>Size</th><th>Modified</th></tr>
+{{if ne .Path "/"}}<tr><td><a href="../">../</a></td><td></td><td></td></tr>
+{{end}}{{range .Entries}}<tr><td><a href="{{.Href}}">{{.Name}}</a></td><td>{{.Size}}</td><td>{{.Modified}}</td></tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+type dirListingEntry struct {
+	Name     string
+	Href     string
+	Size     string
+	Modified string
+}
+
+// serveDirListing lists the directory fp, subdirectories first, leaving out hidden entries.
+func (t *Tools) serveDirListing(w http.ResponseWriter, r *http.Request, fp string) {
+	entries, err := os.ReadDir(fp)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	var dirs, files []dirListingEntry
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		item := dirListingEntry{
+			Name:     entry.Name(),
+			Href:     (&url.URL{Path: entry.Name()}).String(),
+			Modified: info.ModTime().UTC().Format(time.DateTime),
+		}
+		if entry.IsDir() {
+			item.Name += "/"
+			item.Href += "/"
+			dirs = append(dirs, item)
+			continue
+		}
+		item.Size = t.HumanBytes(info.Size())
+		files = append(files, item)
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", CacheRevalidate)
+	_ = dirListing.Execute(w, struct {
+		Path    string
+		Entries []dirListingEntry
+	}{r.URL.Path, append(dirs, files...)})
+}
@@ -0,0 +1,75 @@
+package toolkit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var staticDirTests = []struct {
+	name     string
+	path     string
+	opts     StaticDirOptions
+	status   int
+	contains string
+	cache    string
+}{
+	{name: "file", path: "/assets/app.js", status: http.StatusOK, contains: "console.log", cache: CacheImmutable},
+	{name: "nested file", path: "/assets/docs/readme.txt", status: http.StatusOK, contains: "read me"},
+	{name: "root index", path: "/assets/", status: http.StatusOK, contains: "<h1>app</h1>", cache: CacheRevalidate},
+	{name: "directory redirect", path: "/assets/docs", status: http.StatusMovedPermanently},
+	{name: "no autoindex", path: "/assets/docs/", status: http.StatusNotFound},
+	{name: "autoindex", path: "/assets/docs/", opts: StaticDirOptions{AutoIndex: true}, status: http.StatusOK, contains: `<a href="readme.txt">readme.txt</a>`},
+	{name: "dot file", path: "/assets/.env", status: http.StatusNotFound},
+	{name: "traversal", path: "/assets/../secret.txt", status: http.StatusNotFound},
+	{name: "outside prefix", path: "/other/app.js", status: http.StatusNotFound},
+	{name: "missing", path: "/assets/users/42", status: http.StatusNotFound},
+	{name: "spa fallback", path: "/assets/users/42", opts: StaticDirOptions{SPA: true}, status: http.StatusOK, contains: "<h1>app</h1>", cache: CacheRevalidate},
+	{name: "spa missing asset", path: "/assets/missing.js", opts: StaticDirOptions{SPA: true}, status: http.StatusNotFound},
+}
+
+func TestTools_ServeStaticDir(t *testing.T) {
+	var testTools Tools
+	parent := t.TempDir()
+	dir := filepath.Join(parent, "public")
+	_ = os.MkdirAll(filepath.Join(dir, "docs"), 0755)
+	_ = os.WriteFile(filepath.Join(dir, "index.html"), []byte("<h1>app</h1>"), 0644)
+	_ = os.WriteFile(filepath.Join(dir, "app.js"), []byte("console.log(1)"), 0644)
+	_ = os.WriteFile(filepath.Join(dir, ".env"), []byte("SECRET=1"), 0644)
+	_ = os.WriteFile(filepath.Join(dir, "docs", "readme.txt"), []byte("read me"), 0644)
+	_ = os.WriteFile(filepath.Join(dir, "docs", ".hidden"), []byte("hidden"), 0644)
+	_ = os.WriteFile(filepath.Join(parent, "secret.txt"), []byte("secret"), 0644)
+
+	for _, test := range staticDirTests {
+		test.opts.CacheControl = CacheImmutable
+		handler := testTools.ServeStaticDir("/assets/", dir, test.opts)
+
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.URL.Path = test.path
+		handler.ServeHTTP(rr, req)
+
+		if rr.Code != test.status {
+			t.Errorf("%s: expected status %d, got %d", test.name, test.status, rr.Code)
+			continue
+		}
+		if !strings.Contains(rr.Body.String(), test.contains) {
+			t.Errorf("%s: expected body to contain %q, got %q", test.name, test.contains, rr.Body.String())
+		}
+		if strings.Contains(rr.Body.String(), ".hidden") || strings.Contains(rr.Body.String(), "secret") {
+			t.Errorf("%s: hidden content served", test.name)
+		}
+		if test.cache != "" && rr.Header().Get("Cache-Control") != test.cache {
+			t.Errorf("%s: expected Cache-Control %q, got %q", test.name, test.cache, rr.Header().Get("Cache-Control"))
+		}
+	}
+
+	rr := httptest.NewRecorder()
+	testTools.ServeStaticDir("/", dir, StaticDirOptions{}).ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/app.js", nil))
+	if rr.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected 405 for POST, got %d", rr.Code)
+	}
+}
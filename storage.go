@@ -0,0 +1,122 @@
+package toolkit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// FileStore is where UploadFiles keeps files when Tools.Storage is set. Names are slash
+// separated paths relative to the store.
+type FileStore interface {
+	// Save stores the content of r as name, replacing any existing file, and returns the number
+	// of bytes written. size is the length of the content, or -1 when it is not known.
+	Save(ctx context.Context, name string, r io.Reader, size int64) (int64, error)
+	// Open returns the content of name, or an error matching fs.ErrNotExist when there is none.
+	Open(ctx context.Context, name string) (io.ReadCloser, error)
+	// Delete removes name. Removing a file that does not exist is not an error.
+	Delete(ctx context.Context, name string) error
+	// Exists reports whether name is stored.
+	Exists(ctx context.Context, name string) (bool, error)
+}
+
+// LocalStore is a FileStore in a directory on the local filesystem. It is what UploadFiles uses
+// when Tools.Storage is not set.
+type LocalStore struct {
+	Dir string
+
+	tools *Tools
+}
+
+// NewLocalStore returns a store keeping files in dir, which is created on the first Save. Files
+// are written atomically with the Tools' FileMode and DirMode.
+func (t *Tools) NewLocalStore(dir string) *LocalStore {
+	return &LocalStore{Dir: dir, tools: t}
+}
+
+// Save writes the content of r to name, creating its parent directories. It fails with
+// ErrInsufficientStorage when Tools.MinFreeSpace would not be left afterwards.
+func (s *LocalStore) Save(ctx context.Context, name string, r io.Reader, size int64) (int64, error) {
+	dst, err := s.tools.SecureJoin(s.Dir, name)
+	if err != nil {
+		return 0, err
+	}
+	if _, err = s.tools.CreateDirIfNotExists(filepath.Dir(dst)); err != nil {
+		return 0, err
+	}
+	if err = s.tools.ensureFreeSpace(s.Dir, max(size, 0)); err != nil {
+		return 0, err
+	}
+	return s.tools.WriteFileAtomic(dst, contextReader{ctx, r}, s.tools.fileMode())
+}
+
+func (s *LocalStore) Open(ctx context.Context, name string) (io.ReadCloser, error) {
+	p, err := s.tools.SecureJoin(s.Dir, name)
+	if err != nil {
+		return nil, err
+	}
+	return s.tools.open(p)
+}
+
+func (s *LocalStore) Delete(ctx context.Context, name string) error {
+	p, err := s.tools.SecureJoin(s.Dir, name)
+	if err != nil {
+		return err
+	}
+	if err = os.Remove(p); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (s *LocalStore) Exists(ctx context.Context, name string) (bool, error) {
+	p, err := s.tools.SecureJoin(s.Dir, name)
+	if err != nil {
+		return false, err
+	}
+	_, err = os.Stat(p)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// objectKey joins dir and name into a slash separated key that stays under dir whatever name
+// contains.
+func objectKey(dir, name string) (string, error) {
+	dir = strings.TrimPrefix(path.Clean("/"+filepath.ToSlash(dir)), "/")
+	clean := strings.TrimPrefix(path.Clean("/"+filepath.ToSlash(name)), "/")
+	if clean == "" {
+		return "", fmt.Errorf("invalid file name %q", name)
+	}
+	return path.Join(dir, clean), nil
+}
+
+// objectStoreError describes a failed request to an object storage service, including the start
+// of the error document it returned.
+func objectStoreError(service, op, key string, resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	if resp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("%s: %s %s: %w", service, op, key, fs.ErrNotExist)
+	}
+	return fmt.Errorf("%s: %s %s: %s: %s", service, op, key, resp.Status, strings.TrimSpace(string(body)))
+}
+
+// countingReader counts the bytes read through it.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
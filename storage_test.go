@@ -0,0 +1,97 @@
+package toolkit
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTools_LocalStore(t *testing.T) {
+	var testTools Tools
+	ctx := context.Background()
+	store := testTools.NewLocalStore(t.TempDir())
+
+	if n, err := store.Save(ctx, "a/b/c.txt", strings.NewReader("hello"), 5); err != nil || n != 5 {
+		t.Fatalf("unexpected save %d %v", n, err)
+	}
+	if ok, err := store.Exists(ctx, "a/b/c.txt"); !ok || err != nil {
+		t.Errorf("expected the file to exist, got %v %v", ok, err)
+	}
+	rc, err := store.Open(ctx, "a/b/c.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := io.ReadAll(rc)
+	rc.Close()
+	if string(data) != "hello" {
+		t.Errorf("unexpected content %q", data)
+	}
+
+	if _, err = store.Save(ctx, "../escape.txt", strings.NewReader("x"), 1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = os.Stat(filepath.Join(store.Dir, "escape.txt")); err != nil {
+		t.Errorf("expected the name to be confined to the store, got %v", err)
+	}
+
+	if err = store.Delete(ctx, "a/b/c.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if err = store.Delete(ctx, "a/b/c.txt"); err != nil {
+		t.Errorf("expected deleting a missing file to succeed, got %v", err)
+	}
+	if ok, _ := store.Exists(ctx, "a/b/c.txt"); ok {
+		t.Error("expected the file to be gone")
+	}
+	if _, err = store.Open(ctx, "a/b/c.txt"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected fs.ErrNotExist, got %v", err)
+	}
+}
+
+var objectKeyTests = []struct {
+	dir      string
+	name     string
+	expected string
+	errorExp bool
+}{
+	{dir: "uploads", name: "a.png", expected: "uploads/a.png"},
+	{dir: "/uploads/", name: "../../a.png", expected: "uploads/a.png"},
+	{dir: "", name: "x/./y.png", expected: "x/y.png"},
+	{dir: "uploads", name: "..", errorExp: true},
+}
+
+func TestTools_ObjectKey(t *testing.T) {
+	for _, test := range objectKeyTests {
+		key, err := objectKey(test.dir, test.name)
+		if (err != nil) != test.errorExp || key != test.expected {
+			t.Errorf("%s %s: expected %q, got %q %v", test.dir, test.name, test.expected, key, err)
+		}
+	}
+}
+
+func TestTools_UploadFilesStorage(t *testing.T) {
+	dir := t.TempDir()
+	testTools := Tools{}
+	testTools.Storage = testTools.NewLocalStore(dir)
+
+	files, err := testTools.UploadFiles(newUploadRequest(t, map[string][]byte{"img.png": testPNG(t)}), "avatars", false)
+	if err != nil || len(files) != 1 {
+		t.Fatalf("expected one file, got %v %v", files, err)
+	}
+	if ok, _ := testTools.Storage.Exists(context.Background(), "avatars/img.png"); !ok {
+		t.Error("expected the file in the store under the upload directory")
+	}
+	if files[0].NewFileName != "img.png" || files[0].FileSize != int64(len(testPNG(t))) {
+		t.Errorf("unexpected file %+v", files[0])
+	}
+
+	testTools.ContentAddressed = true
+	if _, err = testTools.UploadFiles(newUploadRequest(t, map[string][]byte{"img.png": testPNG(t)}), "avatars"); err == nil {
+		t.Error("expected content addressed uploads to need local storage")
+	}
+}
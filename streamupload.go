@@ -0,0 +1,100 @@
+package toolkit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+)
+
+// maxStreamedFormValues is the most bytes of non-file form fields streamUploads keeps.
+const maxStreamedFormValues = 10 << 20
+
+var errUploadTooBig = errors.New("upload exceeds the size limit")
+
+// streamUploads stores the files of the multipart request r as they are read from its body. The
+// values of the other form fields are made available through r.FormValue and r.PostFormValue.
+func (t *Tools) streamUploads(ctx context.Context, r *http.Request, store FileStore, uploadDir string, renameFile bool) ([]*UploadResult, error) {
+	mr, err := r.MultipartReader()
+	if err != nil {
+		return nil, err
+	}
+
+	var results []*UploadResult
+	form := &multipart.Form{Value: map[string][]string{}, File: map[string][]*multipart.FileHeader{}}
+	valueBytes := int64(maxStreamedFormValues)
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return results, ctx.Err()
+			}
+			return results, err
+		}
+
+		if part.FileName() == "" {
+			value, err := io.ReadAll(io.LimitReader(part, valueBytes+1))
+			part.Close()
+			if err != nil {
+				return results, err
+			}
+			if valueBytes -= int64(len(value)); valueBytes < 0 {
+				return results, errors.New("the form values are too big")
+			}
+			form.Value[part.FormName()] = append(form.Value[part.FormName()], string(value))
+			continue
+		}
+
+		limited := &uploadLimitReader{r: part, n: int64(t.MaxFileSize)}
+		header := &multipart.FileHeader{Filename: part.FileName(), Header: part.Header, Size: -1}
+		uploadedFile, err := t.storeUpload(ctx, store, uploadDir, header, limited, renameFile)
+		part.Close()
+		if limited.n < 0 {
+			err = &uploadError{"too_large", fmt.Errorf("the uploaded file is too big. Max size is %s", t.HumanBytes(int64(t.MaxFileSize)))}
+		}
+		results = append(results, t.uploadResult(ctx, part.FormName(), part.FileName(), uploadedFile, err))
+		if err != nil && (!t.ContinueOnUploadError || ctx.Err() != nil) {
+			return results, ctx.Err()
+		}
+	}
+
+	r.MultipartForm = form
+	if r.Form == nil {
+		_ = r.ParseForm()
+	}
+	if r.PostForm == nil {
+		r.PostForm = url.Values{}
+	}
+	for k, v := range form.Value {
+		r.Form[k] = append(r.Form[k], v...)
+		r.PostForm[k] = append(r.PostForm[k], v...)
+	}
+	return results, nil
+}
+
+// uploadLimitReader fails with errUploadTooBig once more than n bytes are read from r, leaving n
+// negative.
+type uploadLimitReader struct {
+	r io.Reader
+	n int64
+}
+
+func (l *uploadLimitReader) Read(p []byte) (int, error) {
+	if l.n < 0 {
+		return 0, errUploadTooBig
+	}
+	if int64(len(p)) > l.n+1 {
+		p = p[:l.n+1]
+	}
+	n, err := l.r.Read(p)
+	if l.n -= int64(n); l.n < 0 {
+		return n, errUploadTooBig
+	}
+	return n, err
+}
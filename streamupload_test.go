@@ -0,0 +1,83 @@
+package toolkit
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func newStreamedUploadRequest(t *testing.T, fields map[string]string, name string, content []byte) *http.Request {
+	t.Helper()
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	for k, v := range fields {
+		_ = writer.WriteField(k, v)
+	}
+	part, err := writer.CreateFormFile("file", name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = part.Write(content)
+	_ = writer.Close()
+
+	request := httptest.NewRequest(http.MethodPost, "/?album=holidays", &body)
+	request.Header.Add("Content-Type", writer.FormDataContentType())
+	return request
+}
+
+var streamUploadTests = []struct {
+	name         string
+	content      []byte
+	maxFileSize  int
+	allowedTypes []string
+	errorExp     string
+}{
+	{name: "image", content: nil, maxFileSize: 1024},
+	{name: "exact limit", content: bytes.Repeat([]byte("a"), 100), maxFileSize: 100},
+	{name: "too big", content: bytes.Repeat([]byte("a"), 101), maxFileSize: 100, errorExp: "too big"},
+	{name: "not allowed", content: []byte("plain text"), maxFileSize: 1024, allowedTypes: []string{"image/png"}, errorExp: "not permitted"},
+}
+
+func TestTools_StreamUploads(t *testing.T) {
+	for _, test := range streamUploadTests {
+		var testTools Tools
+		testTools.StreamUploads = true
+		testTools.MaxFileSize = test.maxFileSize
+		testTools.AllowedFileTypes = test.allowedTypes
+		dir := t.TempDir()
+
+		content := test.content
+		if content == nil {
+			content = testPNG(t)
+		}
+		req := newStreamedUploadRequest(t, map[string]string{"title": "Beach"}, "img.png", content)
+		files, err := testTools.UploadFiles(req, dir)
+
+		if test.errorExp != "" {
+			if err == nil || !strings.Contains(err.Error(), test.errorExp) {
+				t.Errorf("%s: expected an error containing %q, got %v", test.name, test.errorExp, err)
+			}
+			if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+				t.Errorf("%s: expected nothing to be stored, got %d files", test.name, len(entries))
+			}
+			continue
+		}
+		if err != nil || len(files) != 1 {
+			t.Errorf("%s: expected one file, got %v %v", test.name, files, err)
+			continue
+		}
+		if files[0].FileSize != int64(len(content)) {
+			t.Errorf("%s: expected %d bytes, got %d", test.name, len(content), files[0].FileSize)
+		}
+		if stored, _ := os.ReadFile(dir + "/" + files[0].NewFileName); !bytes.Equal(stored, content) {
+			t.Errorf("%s: stored content differs", test.name)
+		}
+		if req.FormValue("title") != "Beach" || req.FormValue("album") != "holidays" || req.PostFormValue("album") != "" {
+			t.Errorf("%s: unexpected form values %v", test.name, req.Form)
+		}
+	}
+}
@@ -0,0 +1,200 @@
+package toolkit
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Struct checks the fields of s, a struct or a pointer to one, against the comma separated rules
+// of their validate tags:
+//
+//	type signup struct {
+//		Email string   `json:"email" validate:"required,email"`
+//		Name  string   `json:"name" validate:"required,min=2,max=50"`
+//		Age   int      `json:"age" validate:"min=18"`
+//		Tags  []string `json:"tags" validate:"max=5"`
+//		Plan  string   `json:"plan" validate:"oneof=free pro"`
+//	}
+//
+// required fails blank strings and zero, nil or empty values. min and max bound the length of
+// strings, the number of items of slices and maps and the value of numbers. email, url and oneof,
+// with space separated values, are IsEmail, IsURL and In. Rules other than required pass nil
+// pointers. Fields are named as in JSON; the fields of nested structs are named parent.field, or
+// parent[i].field in slices. Other rules, such as those of other validation packages, are ignored,
+// as are rules that do not apply to the type of their field.
+func (v *Validator) Struct(s any) *Validator {
+	return v.structNamed(s, "json")
+}
+
+// structNamed is Struct naming fields by their nameTag tag.
+func (v *Validator) structNamed(s any, nameTag string) *Validator {
+	rv := indirectValue(reflect.ValueOf(s))
+	if rv.Kind() == reflect.Struct {
+		v.structFields(rv, "", nameTag)
+	}
+	return v
+}
+
+func (v *Validator) structFields(rv reflect.Value, prefix, nameTag string) {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		sf := rt.Field(i)
+		name, _, _ := strings.Cut(sf.Tag.Get(nameTag), ",")
+		if name == "-" {
+			continue
+		}
+		fv := rv.Field(i)
+		if sf.Anonymous && name == "" {
+			// Embedded structs have their fields promoted, as in JSON.
+			if ev := indirectValue(fv); ev.Kind() == reflect.Struct {
+				v.structFields(ev, prefix, nameTag)
+				continue
+			}
+		}
+		if !sf.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+
+		field := prefix + name
+		if tag := sf.Tag.Get("validate"); tag != "" && tag != "-" {
+			v.fieldRules(field, fv, tag)
+		}
+		v.nestedFields(field, fv, nameTag)
+	}
+}
+
+// nestedFields checks the structs fv holds, directly or in a slice.
+func (v *Validator) nestedFields(field string, fv reflect.Value, nameTag string) {
+	fv = indirectValue(fv)
+	switch fv.Kind() {
+	case reflect.Struct:
+		v.structFields(fv, field+".", nameTag)
+	case reflect.Slice, reflect.Array:
+		et := fv.Type().Elem()
+		for et.Kind() == reflect.Pointer {
+			et = et.Elem()
+		}
+		if et.Kind() != reflect.Struct {
+			return
+		}
+		for i := 0; i < fv.Len(); i++ {
+			v.nestedFields(fmt.Sprintf("%s[%d]", field, i), fv.Index(i), nameTag)
+		}
+	}
+}
+
+func (v *Validator) fieldRules(field string, fv reflect.Value, tag string) {
+	for _, rule := range strings.Split(tag, ",") {
+		name, arg, _ := strings.Cut(strings.TrimSpace(rule), "=")
+		if name == "required" {
+			v.checkKey(!isBlankValue(fv), field, "validation.required", "must be provided")
+			continue
+		}
+
+		value := indirectValue(fv)
+		if !value.IsValid() {
+			continue
+		}
+		switch name {
+		case "min", "max":
+			v.boundRule(field, value, name, arg)
+		case "email", "url":
+			if value.Kind() != reflect.String {
+				continue
+			}
+			if name == "email" {
+				v.IsEmail(field, value.String())
+			} else {
+				v.IsURL(field, value.String())
+			}
+		case "oneof":
+			// Numbers are compared as they are written in the tag, as in oneof=1 2 3.
+			if s, ok := oneOfText(value); ok {
+				v.In(field, s, strings.Fields(arg)...)
+			}
+		}
+	}
+}
+
+// boundRule applies the min or max rule with the bound arg to value.
+func (v *Validator) boundRule(field string, value reflect.Value, rule, arg string) {
+	bound, err := strconv.ParseFloat(arg, 64)
+	if err != nil {
+		return
+	}
+	isMin := rule == "min"
+
+	var n float64
+	switch value.Kind() {
+	case reflect.String:
+		if isMin {
+			v.MinLength(field, value.String(), int(bound))
+		} else {
+			v.MaxLength(field, value.String(), int(bound))
+		}
+		return
+	case reflect.Slice, reflect.Array, reflect.Map:
+		if isMin {
+			v.checkKey(float64(value.Len()) >= bound, field, "validation.min_items",
+				fmt.Sprintf("must have at least %s items", arg), "Min", arg)
+		} else {
+			v.checkKey(float64(value.Len()) <= bound, field, "validation.max_items",
+				fmt.Sprintf("must not have more than %s items", arg), "Max", arg)
+		}
+		return
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n = float64(value.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n = float64(value.Uint())
+	case reflect.Float32, reflect.Float64:
+		n = value.Float()
+	default:
+		return
+	}
+	if isMin {
+		v.checkKey(n >= bound, field, "validation.min", "must be at least "+arg, "Min", arg)
+	} else {
+		v.checkKey(n <= bound, field, "validation.max", "must not be more than "+arg, "Max", arg)
+	}
+}
+
+// oneOfText formats value, a string or a number, for the oneof rule.
+func oneOfText(value reflect.Value) (string, bool) {
+	switch value.Kind() {
+	case reflect.String:
+		return value.String(), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(value.Int(), 10), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(value.Uint(), 10), true
+	}
+	return "", false
+}
+
+// indirectValue follows pointers and interfaces to the value they hold, the zero Value when one is
+// nil.
+func indirectValue(v reflect.Value) reflect.Value {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+// isBlankValue reports whether v fails the required rule.
+func isBlankValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.String:
+		return strings.TrimSpace(v.String()) == ""
+	case reflect.Slice, reflect.Map:
+		return v.Len() == 0
+	}
+	return !v.IsValid() || v.IsZero()
+}
@@ -0,0 +1,120 @@
+package toolkit
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type taggedAddress struct {
+	City string `json:"city" validate:"required"`
+}
+
+type taggedBase struct {
+	ID string `json:"id" validate:"required"`
+}
+
+type taggedRequest struct {
+	taggedBase
+	Email     string          `json:"email" validate:"required,email"`
+	Name      string          `json:"name" validate:"min=2,max=5"`
+	Age       int             `json:"age" validate:"min=18,max=130"`
+	Score     *float64        `json:"score,omitempty" validate:"max=1"`
+	Tags      []string        `json:"tags" validate:"max=2"`
+	Plan      string          `json:"plan" validate:"oneof=free pro"`
+	Site      string          `json:"site" validate:"url"`
+	Address   taggedAddress   `json:"address"`
+	Addresses []taggedAddress `json:"addresses" validate:"min=1"`
+	Skipped   string          `json:"-" validate:"required"`
+	Untagged  string
+}
+
+var structValidationTests = []struct {
+	name     string
+	body     string
+	expected map[string]string
+}{
+	{
+		name: "valid",
+		body: `{"id":"1","email":"a@example.com","name":"Ann","age":30,"score":0.5,"tags":["a"],"plan":"pro",
+			"site":"https://example.com","address":{"city":"Oslo"},"addresses":[{"city":"Rome"}]}`,
+	},
+	{
+		name: "invalid",
+		body: `{"email":"nope","name":"A","age":12,"score":2,"tags":["a","b","c"],"plan":"gold",
+			"site":"/a","address":{},"addresses":[{"city":"Rome"},{"city":" "}]}`,
+		expected: map[string]string{
+			"id":                "must be provided",
+			"email":             "must be a valid email address",
+			"name":              "must be at least 2 characters long",
+			"age":               "must be at least 18",
+			"score":             "must not be more than 1",
+			"tags":              "must not have more than 2 items",
+			"plan":              "must be one of free, pro",
+			"site":              "must be a valid URL",
+			"address.city":      "must be provided",
+			"addresses[1].city": "must be provided",
+		},
+	},
+	{
+		name: "missing",
+		body: `{"id":"1","age":18,"address":{"city":"Oslo"}}`,
+		expected: map[string]string{
+			"email":     "must be provided",
+			"addresses": "must have at least 1 items",
+		},
+	},
+}
+
+func TestTools_ReadJSONStructValidation(t *testing.T) {
+	var testTools Tools
+	for _, test := range structValidationTests {
+		var in taggedRequest
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(test.body))
+		err := testTools.ReadJSON(httptest.NewRecorder(), req, &in)
+
+		var verr *ValidationError
+		if test.expected == nil {
+			if err != nil {
+				t.Errorf("%s: unexpected error %v", test.name, err)
+			}
+			continue
+		}
+		if !errors.As(err, &verr) {
+			t.Errorf("%s: expected a validation error, got %v", test.name, err)
+			continue
+		}
+		if len(verr.Fields) != len(test.expected) {
+			t.Errorf("%s: expected %d field errors, got %v", test.name, len(test.expected), verr.Fields)
+		}
+		for field, msg := range test.expected {
+			if verr.Fields[field] != msg {
+				t.Errorf("%s: expected %q for %s, got %q", test.name, msg, field, verr.Fields[field])
+			}
+		}
+	}
+}
+
+func TestTools_ValidatorStruct(t *testing.T) {
+	var testTools Tools
+
+	if v := testTools.NewValidator().Struct((*taggedRequest)(nil)); !v.Valid() {
+		t.Errorf("expected a nil pointer to pass, got %v", v.Errors)
+	}
+	if v := testTools.NewValidator().Struct(map[string]string{}); !v.Valid() {
+		t.Errorf("expected a value other than a struct to pass, got %v", v.Errors)
+	}
+
+	// Rules of other validation packages and rules that do not apply to a field are ignored.
+	v := testTools.NewValidator().Struct(struct {
+		ID    string    `validate:"required,uuid4"`
+		Count int       `validate:"email,min=x,oneof=1 2"`
+		At    time.Time `validate:"max=5"`
+	}{ID: "f47ac10b-58cc-4372-a567-0e02b2c3d479", Count: 3})
+	if len(v.Errors) != 1 || v.Errors["Count"] != "must be one of 1, 2" {
+		t.Errorf("unexpected errors %v", v.Errors)
+	}
+}
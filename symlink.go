@@ -0,0 +1,58 @@
+package toolkit
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+)
+
+// ErrSymlink is returned when Tools.NoFollowSymlinks is set and an operation would have to go
+// through a symbolic link.
+var ErrSymlink = errors.New("refusing to follow symbolic link")
+
+// checkNotSymlink fails with ErrSymlink when NoFollowSymlinks is set and path is a symbolic link.
+func (t *Tools) checkNotSymlink(path string) error {
+	if !t.NoFollowSymlinks {
+		return nil
+	}
+	info, err := os.Lstat(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	if info.Mode()&fs.ModeSymlink != 0 {
+		return &fs.PathError{Op: "open", Path: path, Err: ErrSymlink}
+	}
+	return nil
+}
+
+// openFile is os.OpenFile honouring NoFollowSymlinks: the final path component must not be a
+// symbolic link, which is enforced with O_NOFOLLOW where the platform supports it.
+func (t *Tools) openFile(path string, flag int, perm fs.FileMode) (*os.File, error) {
+	if !t.NoFollowSymlinks {
+		return os.OpenFile(path, flag, perm)
+	}
+	if err := t.checkNotSymlink(path); err != nil {
+		return nil, err
+	}
+	return os.OpenFile(path, flag|oNoFollow, perm)
+}
+
+func (t *Tools) open(path string) (*os.File, error) {
+	return t.openFile(path, os.O_RDONLY, 0)
+}
+
+// stat is os.Stat honouring NoFollowSymlinks: the file is then described with os.Lstat, and a
+// symbolic link fails with ErrSymlink.
+func (t *Tools) stat(path string) (fs.FileInfo, error) {
+	if !t.NoFollowSymlinks {
+		return os.Stat(path)
+	}
+	info, err := os.Lstat(path)
+	if err == nil && info.Mode()&fs.ModeSymlink != 0 {
+		return nil, &fs.PathError{Op: "stat", Path: path, Err: ErrSymlink}
+	}
+	return info, err
+}
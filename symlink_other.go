@@ -0,0 +1,6 @@
+//go:build !unix
+
+package toolkit
+
+// without O_NOFOLLOW the Lstat check in openFile is the only protection
+const oNoFollow = 0
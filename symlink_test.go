@@ -0,0 +1,81 @@
+package toolkit
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestTools_NoFollowSymlinks(t *testing.T) {
+	testTools := Tools{NoFollowSymlinks: true}
+	dir := t.TempDir()
+	secret := filepath.Join(dir, "secret.txt")
+	_ = os.WriteFile(secret, []byte("secret"), 0644)
+
+	public := filepath.Join(dir, "public")
+	_ = os.Mkdir(public, 0755)
+	if err := os.Symlink(secret, filepath.Join(public, "link.txt")); err != nil {
+		t.Skip("symlinks not supported:", err)
+	}
+	_ = os.Symlink(public, filepath.Join(dir, "uploads"))
+
+	if _, err := testTools.SecureJoin(public, "link.txt"); !errors.Is(err, ErrSymlink) {
+		t.Errorf("SecureJoin: expected ErrSymlink, got %v", err)
+	}
+	if _, err := testTools.CopyFile(filepath.Join(public, "link.txt"), filepath.Join(dir, "copy.txt")); !errors.Is(err, ErrSymlink) {
+		t.Errorf("CopyFile: expected ErrSymlink, got %v", err)
+	}
+	if _, err := testTools.FileChecksum(filepath.Join(public, "link.txt"), HashSHA256); !errors.Is(err, ErrSymlink) {
+		t.Errorf("FileChecksum: expected ErrSymlink, got %v", err)
+	}
+	if err := testTools.ReadLines(filepath.Join(public, "link.txt"), func(string) error { return nil }); !errors.Is(err, ErrSymlink) {
+		t.Errorf("ReadLines: expected ErrSymlink, got %v", err)
+	}
+	if _, err := testTools.NewLocalStore(public).Open(context.Background(), "link.txt"); !errors.Is(err, ErrSymlink) {
+		t.Errorf("LocalStore.Open: expected ErrSymlink, got %v", err)
+	}
+	if _, err := testTools.TryLockFile(filepath.Join(public, "link.txt")); !errors.Is(err, ErrSymlink) {
+		t.Errorf("TryLockFile: expected ErrSymlink, got %v", err)
+	}
+	if _, err := testTools.NewRotatingWriter(filepath.Join(public, "link.txt"), RotateOptions{}); !errors.Is(err, ErrSymlink) {
+		t.Errorf("NewRotatingWriter: expected ErrSymlink, got %v", err)
+	}
+
+	if err := testTools.CreateZip(io.Discard, []string{filepath.Join(public, "link.txt")}); !errors.Is(err, ErrSymlink) {
+		t.Errorf("CreateZip: expected ErrSymlink, got %v", err)
+	}
+	if err := testTools.ZipFiles(io.Discard, filepath.Join(public, "link.txt")); !errors.Is(err, ErrSymlink) {
+		t.Errorf("ZipFiles: expected ErrSymlink, got %v", err)
+	}
+
+	_, err := testTools.UploadFiles(newUploadRequest(t, map[string][]byte{"img.png": testPNG(t)}), filepath.Join(dir, "uploads"))
+	if !errors.Is(err, ErrSymlink) {
+		t.Errorf("UploadFiles: expected ErrSymlink for linked upload dir, got %v", err)
+	}
+
+	rr := httptest.NewRecorder()
+	testTools.DownloadStaticFile(rr, httptest.NewRequest(http.MethodGet, "/", nil), public, "link.txt", "file.txt")
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("DownloadStaticFile: expected 404, got %d", rr.Code)
+	}
+
+	_ = os.WriteFile(filepath.Join(public, "plain.txt"), []byte("hello"), 0644)
+	rr = httptest.NewRecorder()
+	testTools.DownloadStaticFile(rr, httptest.NewRequest(http.MethodGet, "/", nil), public, "plain.txt", "file.txt")
+	if rr.Code != http.StatusOK || rr.Body.String() != "hello" {
+		t.Errorf("DownloadStaticFile: expected regular file to be served, got %d", rr.Code)
+	}
+	if rr.Header().Get("Content-Disposition") != `attachement; filename="file.txt"` {
+		t.Error("wrong content disposition")
+	}
+
+	var following Tools
+	if _, err := following.CopyFile(filepath.Join(public, "link.txt"), filepath.Join(dir, "copy.txt")); err != nil {
+		t.Error("links should be followed by default:", err)
+	}
+}
@@ -0,0 +1,7 @@
+//go:build unix
+
+package toolkit
+
+import "syscall"
+
+const oNoFollow = syscall.O_NOFOLLOW
@@ -0,0 +1,127 @@
+package toolkit
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"time"
+	"unicode/utf8"
+)
+
+var tailPollInterval = 250 * time.Millisecond
+
+// TailOptions configures TailFile.
+type TailOptions struct {
+	// MaxLineSize is the length of the longest line sent in one piece, 64 KiB when 0. Longer
+	// lines are sent in pieces of at most MaxLineSize bytes, so a file that never ends its line
+	// cannot make TailFile buffer without limit.
+	MaxLineSize int
+}
+
+// TailFile follows path like tail -f and sends every complete line on the returned channel,
+// which is closed when ctx is done. When fromEnd is true only lines appended after the call
+// are sent. Truncation and rotation (the path being replaced by a new file) are detected and
+// reading continues from the start of the new content.
+func (t *Tools) TailFile(ctx context.Context, path string, fromEnd bool, opts ...TailOptions) (<-chan string, error) {
+	maxLine := bufio.MaxScanTokenSize
+	if len(opts) > 0 && opts[0].MaxLineSize > 0 {
+		maxLine = opts[0].MaxLineSize
+	}
+
+	f, err := t.open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var offset int64
+	if fromEnd {
+		if offset, err = f.Seek(0, io.SeekEnd); err != nil {
+			f.Close()
+			return nil, err
+		}
+	}
+
+	interval := tailPollInterval
+	lines := make(chan string)
+	go func() {
+		defer close(lines)
+		defer func() { f.Close() }()
+
+		var partial []byte
+		buf := make([]byte, 32*1024)
+
+		for {
+			n, err := f.Read(buf)
+			if n > 0 {
+				offset += int64(n)
+				partial = append(partial, buf[:n]...)
+				for {
+					var line string
+					if i := bytes.IndexByte(partial, '\n'); i >= 0 && i <= maxLine {
+						line = string(bytes.TrimSuffix(partial[:i], []byte("\r")))
+						partial = partial[i+1:]
+					} else if len(partial) > maxLine {
+						// Cut at the start of a character, unless it is longer than the piece.
+						cut := maxLine
+						for cut > 0 && !utf8.RuneStart(partial[cut]) {
+							cut--
+						}
+						if cut == 0 {
+							cut = maxLine
+						}
+						line = string(partial[:cut])
+						partial = partial[cut:]
+					} else {
+						break
+					}
+					select {
+					case lines <- line:
+					case <-ctx.Done():
+						return
+					}
+				}
+			}
+			if err == nil {
+				continue
+			}
+			if err != io.EOF {
+				return
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(interval):
+			}
+
+			info, err := os.Stat(path)
+			if err != nil {
+				// rotated away and not recreated yet
+				continue
+			}
+			current, err := f.Stat()
+			if err != nil {
+				return
+			}
+
+			switch {
+			case !os.SameFile(info, current):
+				next, err := t.open(path)
+				if err != nil {
+					continue
+				}
+				f.Close()
+				f, offset, partial = next, 0, nil
+			case info.Size() < offset:
+				if _, err = f.Seek(0, io.SeekStart); err != nil {
+					return
+				}
+				offset, partial = 0, nil
+			}
+		}
+	}()
+
+	return lines, nil
+}
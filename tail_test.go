@@ -0,0 +1,109 @@
+package toolkit
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func expectLine(t *testing.T, lines <-chan string, expected string) {
+	t.Helper()
+	select {
+	case line := <-lines:
+		if line != expected {
+			t.Errorf("expected line %q, got %q", expected, line)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatalf("timed out waiting for line %q", expected)
+	}
+}
+
+func TestTools_TailFile(t *testing.T) {
+	defer func(d time.Duration) { tailPollInterval = d }(tailPollInterval)
+	tailPollInterval = 10 * time.Millisecond
+	var testTools Tools
+	path := filepath.Join(t.TempDir(), "app.log")
+	_ = os.WriteFile(path, []byte("old line\n"), 0644)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	lines, err := testTools.TailFile(ctx, path, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	_, _ = f.WriteString("first\r\nsec")
+	expectLine(t, lines, "first")
+	_, _ = f.WriteString("ond\n")
+	expectLine(t, lines, "second")
+	f.Close()
+
+	// truncation
+	_ = os.WriteFile(path, []byte("after truncate\n"), 0644)
+	expectLine(t, lines, "after truncate")
+
+	// rotation
+	_ = os.Rename(path, path+".1")
+	_ = os.WriteFile(path, []byte("rotated\n"), 0644)
+	expectLine(t, lines, "rotated")
+
+	cancel()
+	select {
+	case _, ok := <-lines:
+		if ok {
+			t.Error("expected channel to be closed")
+		}
+	case <-time.After(3 * time.Second):
+		t.Error("channel not closed after cancel")
+	}
+}
+
+func TestTools_TailFileFromStart(t *testing.T) {
+	var testTools Tools
+	path := filepath.Join(t.TempDir(), "app.log")
+	_ = os.WriteFile(path, []byte("one\ntwo\n"), 0644)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	lines, err := testTools.TailFile(ctx, path, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectLine(t, lines, "one")
+	expectLine(t, lines, "two")
+
+	if _, err = testTools.TailFile(ctx, path+".missing", false); err == nil {
+		t.Error("expected error for missing file")
+	}
+}
+
+func TestTools_TailFileMaxLineSize(t *testing.T) {
+	defer func(d time.Duration) { tailPollInterval = d }(tailPollInterval)
+	tailPollInterval = 10 * time.Millisecond
+	var testTools Tools
+	path := filepath.Join(t.TempDir(), "app.log")
+	_ = os.WriteFile(path, []byte("abcdefghij"), 0644)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	lines, err := testTools.TailFile(ctx, path, false, TailOptions{MaxLineSize: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectLine(t, lines, "abcd")
+	expectLine(t, lines, "efgh")
+
+	f, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	defer f.Close()
+	_, _ = f.WriteString("\nwxyz\nhééllo\n")
+	expectLine(t, lines, "ij")
+	expectLine(t, lines, "wxyz")
+	// Pieces are not cut inside a character.
+	expectLine(t, lines, "hé")
+	expectLine(t, lines, "éll")
+	expectLine(t, lines, "o")
+}
@@ -0,0 +1,91 @@
+package toolkit
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// tempDirMarker is the file Tools.TempDir leaves in the directories it creates, so CleanTempDirs
+// never removes directories of other programs that happen to share the prefix.
+const tempDirMarker = ".toolkit-tempdir"
+
+// TempDir is a temporary directory removed, together with its content, by Close.
+type TempDir struct {
+	Path string
+
+	once sync.Once
+	err  error
+}
+
+// Join returns the path of name inside the temporary directory.
+func (d *TempDir) Join(name ...string) string {
+	return filepath.Join(append([]string{d.Path}, name...)...)
+}
+
+// Close removes the directory. It is safe to call more than once, so it can be deferred
+// right after creation and still be called explicitly at the end of a workflow.
+func (d *TempDir) Close() error {
+	d.once.Do(func() {
+		d.err = os.RemoveAll(d.Path)
+	})
+	return d.err
+}
+
+// TempDir creates a new temporary directory whose name starts with prefix inside
+// Tools.TempRoot (os.TempDir() when unset). The directory holds an empty marker file, named
+// .toolkit-tempdir, which CleanTempDirs looks for.
+func (t *Tools) TempDir(prefix string) (*TempDir, error) {
+	if _, err := t.CreateDirIfNotExists(t.tempRoot()); err != nil {
+		return nil, err
+	}
+
+	path, err := os.MkdirTemp(t.tempRoot(), prefix)
+	if err != nil {
+		return nil, err
+	}
+	if err = os.WriteFile(filepath.Join(path, tempDirMarker), nil, t.fileMode()); err != nil {
+		_ = os.RemoveAll(path)
+		return nil, err
+	}
+	return &TempDir{Path: path}, nil
+}
+
+// CleanTempDirs removes temporary directories made by Tools.TempDir with prefix that were last
+// modified more than ttl ago, typically left behind by a crashed process. It is meant to be
+// called on startup and returns the number of directories removed. prefix must not be empty,
+// and directories without the marker of Tools.TempDir are left alone.
+func (t *Tools) CleanTempDirs(prefix string, ttl time.Duration) (int, error) {
+	if prefix == "" {
+		return 0, errors.New("temp dir prefix must not be empty")
+	}
+
+	entries, err := os.ReadDir(t.tempRoot())
+	if err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	cutoff := time.Now().Add(-ttl)
+	for _, entry := range entries {
+		if !entry.IsDir() || !strings.HasPrefix(entry.Name(), prefix) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || info.ModTime().After(cutoff) {
+			continue
+		}
+		path := filepath.Join(t.tempRoot(), entry.Name())
+		if _, err = os.Lstat(filepath.Join(path, tempDirMarker)); err != nil {
+			continue
+		}
+		if err = os.RemoveAll(path); err != nil {
+			return removed, err
+		}
+		removed++
+	}
+	return removed, nil
+}
@@ -0,0 +1,76 @@
+package toolkit
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestTools_TempDir(t *testing.T) {
+	var testTools Tools
+
+	dir, err := testTools.TempDir("toolkit-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dir.Close()
+
+	if err = os.WriteFile(dir.Join("chunk.0"), []byte("data"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = dir.Close(); err != nil {
+		t.Error(err)
+	}
+	if _, err = os.Stat(dir.Path); !os.IsNotExist(err) {
+		t.Error("expected temp dir to be removed")
+	}
+	if err = dir.Close(); err != nil {
+		t.Error("second close failed:", err)
+	}
+}
+
+func TestTools_CleanTempDirs(t *testing.T) {
+	t.Setenv("TMPDIR", t.TempDir())
+	var testTools Tools
+
+	stale, _ := testTools.TempDir("toolkit-clean-")
+	fresh, _ := testTools.TempDir("toolkit-clean-")
+	other, _ := testTools.TempDir("other-")
+	foreign, _ := os.MkdirTemp("", "toolkit-clean-")
+	defer fresh.Close()
+	defer other.Close()
+	defer os.RemoveAll(foreign)
+
+	old := time.Now().Add(-2 * time.Hour)
+	_ = os.Chtimes(stale.Path, old, old)
+	_ = os.Chtimes(other.Path, old, old)
+	_ = os.Chtimes(foreign, old, old)
+
+	removed, err := testTools.CleanTempDirs("toolkit-clean-", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if removed != 1 {
+		t.Errorf("expected 1 directory removed, got %d", removed)
+	}
+	if _, err = os.Stat(stale.Path); !os.IsNotExist(err) {
+		t.Error("stale directory not removed")
+	}
+	if _, err = os.Stat(fresh.Path); err != nil {
+		t.Error("fresh directory removed")
+	}
+	if _, err = os.Stat(other.Path); err != nil {
+		t.Error("directory with other prefix removed")
+	}
+	if _, err = os.Stat(foreign); err != nil {
+		t.Error("directory not made by TempDir removed")
+	}
+
+	if _, err = testTools.CleanTempDirs("", time.Hour); err == nil {
+		t.Error("expected an error for an empty prefix")
+	}
+	if _, err = os.Stat(other.Path); err != nil {
+		t.Error("directory removed for an empty prefix")
+	}
+}
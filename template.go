@@ -0,0 +1,191 @@
+package toolkit
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"net/http"
+	"path"
+	"sync"
+)
+
+// TemplateOptions configures NewTemplates.
+type TemplateOptions struct {
+	// FS holds the templates, typically an embed.FS.
+	FS fs.FS
+	// Layouts and Partials are glob patterns of templates shared by every page, "layouts/*.tmpl"
+	// and "partials/*.tmpl" when empty. Patterns without matches are ignored.
+	Layouts  string
+	Partials string
+	// Pages is the directory of page templates, "pages" when empty. Pages are named by their path
+	// relative to it, e.g. "users/show.tmpl".
+	Pages string
+	Funcs template.FuncMap
+	// DevMode parses templates on every render, so edits show up without a restart.
+	DevMode bool
+	// Session, when set, provides the message stored with SessionManager.Flash to pages.
+	Session *SessionManager
+	// CSRFToken, when set, provides the request's CSRF token to pages.
+	CSRFToken func(r *http.Request) string
+	// DefaultData, when set, adds values every page gets, such as the signed in user.
+	DefaultData func(r *http.Request) map[string]any
+}
+
+// TemplateData is what pages are executed with; the handler's data is in Data. Under
+// I18n.Middleware, pages translate with the T and N methods, e.g. {{.T "greeting" "Name" .Data.Name}}.
+type TemplateData struct {
+	Data      any
+	Flash     string
+	CSRFToken string
+	Request   *http.Request
+	Values    map[string]any
+	// Lang is the language of the page, empty without I18n.Middleware.
+	Lang string
+
+	localizer *Localizer
+}
+
+// T translates key with Localizer.T.
+func (d TemplateData) T(key string, args ...any) string {
+	return d.localizer.T(key, args...)
+}
+
+// N translates the plural message key with Localizer.N.
+func (d TemplateData) N(key string, count int, args ...any) string {
+	return d.localizer.N(key, count, args...)
+}
+
+// Templates renders pages parsed together with shared layouts and partials. A page usually
+// defines blocks and invokes its layout, e.g. {{template "base" .}}.
+type Templates struct {
+	opts  TemplateOptions
+	tools *Tools
+
+	mu    sync.RWMutex
+	pages map[string]*template.Template
+}
+
+// NewTemplates parses the templates of opts.FS, reporting syntax errors right away.
+func (t *Tools) NewTemplates(opts TemplateOptions) (*Templates, error) {
+	if opts.FS == nil {
+		return nil, errors.New("templates need a file system")
+	}
+	if opts.Layouts == "" {
+		opts.Layouts = "layouts/*.tmpl"
+	}
+	if opts.Partials == "" {
+		opts.Partials = "partials/*.tmpl"
+	}
+	if opts.Pages == "" {
+		opts.Pages = "pages"
+	}
+
+	tp := &Templates{opts: opts, tools: t}
+	pages, err := tp.parse()
+	if err != nil {
+		return nil, err
+	}
+	tp.pages = pages
+	return tp, nil
+}
+
+func (tp *Templates) parse() (map[string]*template.Template, error) {
+	root := template.New("").Funcs(tp.opts.Funcs)
+	for _, pattern := range []string{tp.opts.Layouts, tp.opts.Partials} {
+		matches, err := fs.Glob(tp.opts.FS, pattern)
+		if err != nil {
+			return nil, err
+		}
+		if len(matches) == 0 {
+			continue
+		}
+		if root, err = root.ParseFS(tp.opts.FS, matches...); err != nil {
+			return nil, err
+		}
+	}
+
+	pages := make(map[string]*template.Template)
+	err := fs.WalkDir(tp.opts.FS, tp.opts.Pages, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		page, err := root.Clone()
+		if err != nil {
+			return err
+		}
+		if page, err = page.ParseFS(tp.opts.FS, p); err != nil {
+			return err
+		}
+		name := p[len(tp.opts.Pages)+1:]
+		pages[name] = page.Lookup(path.Base(p))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return pages, nil
+}
+
+func (tp *Templates) page(name string) (*template.Template, error) {
+	if tp.opts.DevMode {
+		pages, err := tp.parse()
+		if err != nil {
+			return nil, err
+		}
+		tp.mu.Lock()
+		tp.pages = pages
+		tp.mu.Unlock()
+	}
+
+	tp.mu.RLock()
+	defer tp.mu.RUnlock()
+	page, ok := tp.pages[name]
+	if !ok {
+		return nil, fmt.Errorf("template %s not found", name)
+	}
+	return page, nil
+}
+
+// Render executes the page name with data and writes it with status 200. The page is rendered
+// into a buffer first, so a failing template results in a clean 500 JSON error instead of a
+// half-written page.
+func (tp *Templates) Render(w http.ResponseWriter, r *http.Request, name string, data any) error {
+	page, err := tp.page(name)
+	if err != nil {
+		_ = tp.tools.ErrorJSON(w, errors.New("could not render page"), http.StatusInternalServerError)
+		return err
+	}
+
+	l := LocalizerFromContext(r.Context())
+	td := TemplateData{Data: data, Request: r, Lang: l.Language(), localizer: l}
+	if tp.opts.Session != nil && r.Context().Value(sessionContextKey{}) != nil {
+		td.Flash = tp.opts.Session.PopString(r.Context(), flashKey)
+	}
+	if tp.opts.CSRFToken != nil {
+		td.CSRFToken = tp.opts.CSRFToken(r)
+	}
+	if tp.opts.DefaultData != nil {
+		td.Values = tp.opts.DefaultData(r)
+	}
+
+	var buf bytes.Buffer
+	if err = page.Execute(&buf, td); err != nil {
+		_ = tp.tools.ErrorJSON(w, errors.New("could not render page"), http.StatusInternalServerError)
+		return err
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	_, err = buf.WriteTo(w)
+	return err
+}
+
+// RenderTemplate renders the page name with Tools.Templates.
+func (t *Tools) RenderTemplate(w http.ResponseWriter, r *http.Request, name string, data any) error {
+	if t.Templates == nil {
+		_ = t.ErrorJSON(w, errors.New("could not render page"), http.StatusInternalServerError)
+		return errors.New("no templates configured")
+	}
+	return t.Templates.Render(w, r, name, data)
+}
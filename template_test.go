@@ -0,0 +1,98 @@
+package toolkit
+
+import (
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func templateFS() fstest.MapFS {
+	return fstest.MapFS{
+		"layouts/base.tmpl":  {Data: []byte(`{{define "base"}}<title>{{template "title" .}}</title>{{if .Flash}}<p class="flash">{{.Flash}}</p>{{end}}{{template "main" .}}{{end}}`)},
+		"partials/nav.tmpl":  {Data: []byte(`{{define "nav"}}<nav>{{shout "menu"}}</nav>{{end}}`)},
+		"pages/home.tmpl":    {Data: []byte(`{{template "base" .}}{{define "title"}}Home{{end}}{{define "main"}}{{template "nav" .}}<p>{{.Data}}</p><input value="{{.CSRFToken}}">{{end}}`)},
+		"pages/users/x.tmpl": {Data: []byte(`{{template "base" .}}{{define "title"}}User{{end}}{{define "main"}}{{.Values.user}}{{end}}`)},
+		"pages/bad.tmpl":     {Data: []byte(`{{template "base" .}}{{define "title"}}{{end}}{{define "main"}}{{.Data.Missing}}{{end}}`)},
+	}
+}
+
+func TestTools_RenderTemplate(t *testing.T) {
+	var testTools Tools
+	tp, err := testTools.NewTemplates(TemplateOptions{
+		FS:          templateFS(),
+		Funcs:       template.FuncMap{"shout": strings.ToUpper},
+		CSRFToken:   func(r *http.Request) string { return "tok" },
+		DefaultData: func(r *http.Request) map[string]any { return map[string]any{"user": "ann"} },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	testTools.Templates = tp
+
+	rr := httptest.NewRecorder()
+	if err = testTools.RenderTemplate(rr, httptest.NewRequest(http.MethodGet, "/", nil), "home.tmpl", "<hi>"); err != nil {
+		t.Fatal(err)
+	}
+	expected := `<title>Home</title><nav>MENU</nav><p>&lt;hi&gt;</p><input value="tok">`
+	if rr.Body.String() != expected || rr.Header().Get("Content-Type") != "text/html; charset=utf-8" {
+		t.Errorf("unexpected page %q", rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	_ = testTools.RenderTemplate(rr, httptest.NewRequest(http.MethodGet, "/", nil), "users/x.tmpl", nil)
+	if rr.Body.String() != "<title>User</title>ann" {
+		t.Errorf("unexpected nested page %q", rr.Body.String())
+	}
+
+	for _, name := range []string{"missing.tmpl", "bad.tmpl"} {
+		rr = httptest.NewRecorder()
+		if err = testTools.RenderTemplate(rr, httptest.NewRequest(http.MethodGet, "/", nil), name, 1); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+		if rr.Code != http.StatusInternalServerError || rr.Header().Get("Content-Type") != "application/json" {
+			t.Errorf("%s: expected a 500 JSON error, got %d %q", name, rr.Code, rr.Body.String())
+		}
+	}
+}
+
+func TestTools_RenderTemplateDevModeAndFlash(t *testing.T) {
+	var testTools Tools
+	fsys := templateFS()
+	sessions := testTools.NewSessionManager(NewMemorySessionStore())
+	tp, err := testTools.NewTemplates(TemplateOptions{
+		FS:      fsys,
+		Funcs:   template.FuncMap{"shout": strings.ToUpper},
+		DevMode: true,
+		Session: sessions,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fsys["pages/users/x.tmpl"] = &fstest.MapFile{Data: []byte(`{{template "base" .}}{{define "title"}}Edited{{end}}{{define "main"}}{{end}}`)}
+	h := sessions.LoadAndSave(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/save" {
+			sessions.Flash(r.Context(), "Saved")
+			http.Redirect(w, r, "/", http.StatusSeeOther)
+			return
+		}
+		_ = tp.Render(w, r, "users/x.tmpl", nil)
+	}))
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/save", nil))
+	cookie := rr.Result().Cookies()[0]
+
+	for i, expected := range []string{`<title>Edited</title><p class="flash">Saved</p>`, `<title>Edited</title>`} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.AddCookie(cookie)
+		rr = httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		if rr.Body.String() != expected {
+			t.Errorf("request %d: expected %q, got %q", i, expected, rr.Body.String())
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package toolkit
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"path/filepath"
+	"strings"
+
+	"github.com/disintegration/imaging"
+)
+
+// thumbnailer decodes an uploaded image from what is written to it while the upload is stored, so
+// the content is read only once.
+type thumbnailer struct {
+	pw   *io.PipeWriter
+	done chan struct{}
+	img  *Image
+	err  error
+}
+
+func (t *Tools) newThumbnailer() *thumbnailer {
+	pr, pw := io.Pipe()
+	th := &thumbnailer{pw: pw, done: make(chan struct{})}
+	go func() {
+		defer close(th.done)
+		th.img, th.err = t.DecodeImage(pr)
+		// Keep consuming what follows the image, or what the decoder gave up on, so the upload
+		// is not blocked.
+		_, _ = io.Copy(io.Discard, pr)
+	}()
+	return th
+}
+
+func (th *thumbnailer) Write(p []byte) (int, error) {
+	return th.pw.Write(p)
+}
+
+// wait ends the input with err, nil when the whole upload was written, and returns the decoded
+// image.
+func (th *thumbnailer) wait(err error) (*Image, error) {
+	th.pw.CloseWithError(err)
+	<-th.done
+	return th.img, th.err
+}
+
+// saveThumbnails stores the variants of img for ThumbnailSizes next to the uploaded file name and
+// returns their names. Variants stored before a failure are removed.
+func (t *Tools) saveThumbnails(ctx context.Context, store FileStore, uploadDir, name string, img *Image) ([]string, error) {
+	format := t.ThumbnailFormat
+	if format == "" {
+		format = img.Format()
+		if _, err := imaging.FormatFromExtension(format); err != nil {
+			format = "jpeg"
+		}
+	}
+	ext := "." + format
+	if format == "jpeg" {
+		ext = ".jpg"
+	}
+	base := strings.TrimSuffix(name, filepath.Ext(name))
+
+	var names, stored []string
+	for _, size := range t.ThumbnailSizes {
+		thumbName := fmt.Sprintf("%s_%dx%d%s", base, size.X, size.Y, ext)
+		key, err := t.storeName(uploadDir, thumbName)
+		if err == nil {
+			r := thumbnailReader(img, size.X, size.Y, format)
+			_, err = t.saveUpload(ctx, store, key, r, -1)
+			r.CloseWithError(err)
+		}
+		if err != nil {
+			for _, key := range stored {
+				_ = store.Delete(ctx, key)
+			}
+			return nil, fmt.Errorf("thumbnail %dx%d: %w", size.X, size.Y, err)
+		}
+		names = append(names, thumbName)
+		stored = append(stored, key)
+	}
+	return names, nil
+}
+
+// thumbnailReader returns the encoding of img scaled down to fit width×height, where 0 means
+// unbounded. Closing it stops the encoding.
+func thumbnailReader(img *Image, width, height int, format string) *io.PipeReader {
+	if width <= 0 {
+		width = math.MaxInt32
+	}
+	if height <= 0 {
+		height = math.MaxInt32
+	}
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(img.Fit(width, height).Encode(pw, ImageEncodeOptions{Format: format}))
+	}()
+	return pr
+}
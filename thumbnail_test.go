@@ -0,0 +1,81 @@
+package toolkit
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"path/filepath"
+	"testing"
+)
+
+var thumbnailTests = []struct {
+	name     string
+	format   string
+	content  func(t *testing.T) []byte
+	expected map[string]image.Point
+}{
+	{
+		name:    "png",
+		content: func(t *testing.T) []byte { return encodeTestPNG(t, 40, 20) },
+		expected: map[string]image.Point{
+			"photo_10x10.png": {10, 5},
+			"photo_0x4.png":   {8, 4},
+			"photo_80x80.png": {40, 20},
+		},
+	},
+	{
+		name:    "jpeg",
+		format:  "jpeg",
+		content: func(t *testing.T) []byte { return encodeTestPNG(t, 40, 20) },
+		expected: map[string]image.Point{
+			"photo_10x10.jpg": {10, 5},
+			"photo_0x4.jpg":   {8, 4},
+			"photo_80x80.jpg": {40, 20},
+		},
+	},
+	{
+		name:    "not an image",
+		content: func(t *testing.T) []byte { return []byte("plain text") },
+	},
+}
+
+func encodeTestPNG(t *testing.T, w, h int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewNRGBA(image.Rect(0, 0, w, h))); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestTools_UploadThumbnails(t *testing.T) {
+	for _, test := range thumbnailTests {
+		testTools := Tools{
+			ThumbnailSizes:  []image.Point{{10, 10}, {0, 4}, {80, 80}},
+			ThumbnailFormat: test.format,
+		}
+		dir := t.TempDir()
+		files, err := testTools.UploadFiles(newUploadRequest(t, map[string][]byte{"photo.png": test.content(t)}), dir, false)
+		if err != nil || len(files) != 1 {
+			t.Fatalf("%s: expected one file, got %v %v", test.name, files, err)
+		}
+		if len(files[0].Thumbnails) != len(test.expected) {
+			t.Fatalf("%s: expected %d thumbnails, got %v", test.name, len(test.expected), files[0].Thumbnails)
+		}
+		for _, name := range files[0].Thumbnails {
+			size, ok := test.expected[name]
+			if !ok {
+				t.Errorf("%s: unexpected thumbnail %s", test.name, name)
+				continue
+			}
+			img, err := testTools.OpenImage(filepath.Join(dir, name))
+			if err != nil {
+				t.Errorf("%s: %v", test.name, err)
+				continue
+			}
+			if img.Width() != size.X || img.Height() != size.Y {
+				t.Errorf("%s: expected %s to be %v, got %dx%d", test.name, name, size, img.Width(), img.Height())
+			}
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package toolkit
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TimeoutOptions configures TimeoutMiddleware.
+type TimeoutOptions struct {
+	Timeout time.Duration
+	// Exclude lists path prefixes of streaming endpoints, such as downloads, that are not subject
+	// to the timeout. Requests accepting text/event-stream are always excluded.
+	Exclude []string
+	// ExcludeFunc, when set, excludes further requests.
+	ExcludeFunc func(r *http.Request) bool
+}
+
+// TimeoutMiddleware runs handlers with a context deadline and answers with a 504 JSON error when
+// they do not finish in time. The handler's response is buffered until it returns, which is why
+// streaming endpoints must be excluded; writes made after the deadline fail with
+// http.ErrHandlerTimeout. Handlers should watch r.Context() to stop working once it expires.
+func (t *Tools) TimeoutMiddleware(opts TimeoutOptions) (func(http.Handler) http.Handler, error) {
+	if opts.Timeout <= 0 {
+		return nil, errors.New("timeout should be greater than 0")
+	}
+
+	excluded := func(r *http.Request) bool {
+		for _, p := range opts.Exclude {
+			if strings.HasPrefix(r.URL.Path, p) {
+				return true
+			}
+		}
+		if strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+			return true
+		}
+		return opts.ExcludeFunc != nil && opts.ExcludeFunc(r)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if excluded(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ctx, cancel := context.WithTimeout(r.Context(), opts.Timeout)
+			defer cancel()
+
+			tw := &timeoutWriter{header: make(http.Header)}
+			done := make(chan struct{})
+			panicked := make(chan any, 1)
+			go func() {
+				defer func() {
+					if p := recover(); p != nil {
+						panicked <- p
+					}
+				}()
+				next.ServeHTTP(tw, r.WithContext(ctx))
+				close(done)
+			}()
+
+			select {
+			case p := <-panicked:
+				panic(p)
+			case <-done:
+				tw.mu.Lock()
+				defer tw.mu.Unlock()
+				for k, v := range tw.header {
+					w.Header()[k] = v
+				}
+				if tw.status == 0 {
+					tw.status = http.StatusOK
+				}
+				w.WriteHeader(tw.status)
+				_, _ = w.Write(tw.buf.Bytes())
+			case <-ctx.Done():
+				tw.mu.Lock()
+				defer tw.mu.Unlock()
+				tw.timedOut = true
+				if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+					_ = t.ErrorJSON(w, errors.New("the request took too long to process"), http.StatusGatewayTimeout)
+				}
+			}
+		})
+	}, nil
+}
+
+// timeoutWriter buffers a response until the handler returns or the deadline passes.
+type timeoutWriter struct {
+	mu       sync.Mutex
+	header   http.Header
+	buf      bytes.Buffer
+	status   int
+	timedOut bool
+}
+
+func (w *timeoutWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *timeoutWriter) WriteHeader(status int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.status == 0 && !w.timedOut {
+		w.status = status
+	}
+}
+
+func (w *timeoutWriter) Write(b []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.buf.Write(b)
+}
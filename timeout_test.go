@@ -0,0 +1,73 @@
+package toolkit
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTools_TimeoutMiddleware(t *testing.T) {
+	var testTools Tools
+	mw, err := testTools.TimeoutMiddleware(TimeoutOptions{Timeout: 50 * time.Millisecond, Exclude: []string{"/download"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lateWrite := make(chan error, 1)
+	served := make(chan struct{})
+	h := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/fast" {
+			w.Header().Set("X-Handler", "fast")
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte("done"))
+			return
+		}
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+		if r.URL.Path != "/download" {
+			// Write only once the 504 has been sent, as a handler running late would.
+			<-served
+			_, err := w.Write([]byte("late"))
+			lateWrite <- err
+		}
+	}))
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/fast", nil))
+	if rr.Code != http.StatusCreated || rr.Body.String() != "done" || rr.Header().Get("X-Handler") != "fast" {
+		t.Errorf("unexpected fast response %d %q", rr.Code, rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/slow", nil))
+	close(served)
+	if rr.Code != http.StatusGatewayTimeout {
+		t.Errorf("expected 504, got %d", rr.Code)
+	}
+	if err = <-lateWrite; !errors.Is(err, http.ErrHandlerTimeout) {
+		t.Error("expected writes after the deadline to fail, got", err)
+	}
+
+	start := time.Now()
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/download/big.zip", nil))
+	if rr.Code != http.StatusOK || time.Since(start) < time.Second {
+		t.Error("excluded path was subject to the timeout")
+	}
+}
+
+func TestTools_TimeoutMiddlewarePanic(t *testing.T) {
+	var testTools Tools
+	mw, _ := testTools.TimeoutMiddleware(TimeoutOptions{Timeout: time.Second})
+	defer func() {
+		if rec := recover(); rec != "boom" {
+			t.Error("expected the handler panic to propagate, got", rec)
+		}
+	}()
+	mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { panic("boom") })).
+		ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+}
@@ -2,17 +2,31 @@ package toolkit
 
 import (
 	"bytes"
-	"crypto/rand"
+	"cmp"
+	"context"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"hash"
+	"image"
 	"io"
+	"io/fs"
+	"log/slog"
+	"mime/multipart"
 	"net/http"
 	"os"
 	"path"
 	"path/filepath"
-	"regexp"
+	"slices"
+	"strconv"
 	"strings"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const randomStringSource = "abcdefghijklmnoprstuvxyzABCDEFGHIJKLMNOPRSTUVXYZ0123456789_+"
@@ -22,49 +36,258 @@ type Tools struct {
 	AllowedFileTypes   []string
 	MaxJSONSize        int
 	AllowUnknownFields bool
+	PluralOverrides    map[string]string
+	MIMETypes          map[string]string
+	MinFreeSpace       int64
+	NoFollowSymlinks   bool
+
+	// MaxBodySize is the largest body, in bytes, ReadJSON and ReadXML read, MaxJSONSize or 1 MiB
+	// when 0. It replaces MaxJSONSize, which is kept for existing callers.
+	MaxBodySize int
+
+	// ResponseEncoders are the media types WriteResponse can answer with besides JSON, XML and
+	// MessagePack, or replacements of those, keyed by media type such as "application/cbor".
+	ResponseEncoders map[string]ResponseEncoder
+
+	// AllowedFileExtensions, when not empty, lists the extensions, such as ".png", uploaded
+	// files may have. Matching ignores case.
+	AllowedFileExtensions []string
+	// RejectOnMismatch fails uploads whose extension disagrees with the type of their content,
+	// such as an HTML page named .png.
+	RejectOnMismatch bool
+
+	// RenameFunc returns the name under which an uploaded file, whose sanitized original name it
+	// is given, is stored when UploadFiles renames files. The name may include directories, such
+	// as those of DateShardedFileName, which are created as needed. RandomFileName when nil.
+	RenameFunc func(original string) string
+
+	// BaseUploadDir, when set, is the directory relative upload directories are resolved against.
+	BaseUploadDir string
+	// TempRoot is where temporary files and directories are created, os.TempDir() when empty.
+	TempRoot string
+	// DirMode and FileMode are the permissions of directories and files the toolkit creates,
+	// 0755 and 0644 when unset.
+	DirMode  fs.FileMode
+	FileMode fs.FileMode
+
+	// EncryptionKeys, when not empty, makes UploadFiles encrypt stored files with AES-GCM using the
+	// key at EncryptionKeyVersion. Older versions stay in the map so files sealed with them can
+	// still be read by the download helpers.
+	EncryptionKeys       map[uint32][]byte
+	EncryptionKeyVersion uint32
+
+	// ContentAddressed makes UploadFiles keep files in a ContentStore rooted at the upload
+	// directory, so identical uploads are stored once. NewFileName is then the object's path
+	// relative to the upload directory.
+	ContentAddressed bool
+
+	// Storage, when set, is where UploadFiles keeps files instead of the local filesystem. The
+	// upload directory is then a prefix of the stored names. It cannot be combined with
+	// ContentAddressed.
+	Storage FileStore
+
+	// StreamUploads makes UploadFiles copy files straight from the request body to their
+	// destination instead of having ParseMultipartForm buffer them in memory and temporary files
+	// first. MaxFileSize is then a hard limit on the size of every file, enforced while it is
+	// copied.
+	StreamUploads bool
+
+	// ChecksumAlgorithms are the digests UploadFiles computes of every file while storing it.
+	ChecksumAlgorithms []HashAlgorithm
+
+	// UploadValidators inspect every uploaded file before it is stored, after its type was
+	// checked against AllowedFileTypes. They get the file's multipart header, whose Size is -1
+	// for streamed uploads, and its first 512 bytes, or fewer for smaller files. The first error
+	// returned fails the upload.
+	UploadValidators []func(header *multipart.FileHeader, firstBytes []byte) error
+
+	// ContinueOnUploadError makes UploadFiles and UploadResults go on with the remaining files of
+	// a request when one fails, instead of stopping. UploadFiles then returns the files stored
+	// and the errors of the others joined.
+	ContinueOnUploadError bool
+
+	// ThumbnailSizes, when not empty, makes UploadFiles store a variant of every uploaded image
+	// scaled down to fit each size, named like the file with a _<width>x<height> suffix. A 0
+	// width or height leaves that dimension unbounded. Images that cannot be decoded and content
+	// addressed uploads get none.
+	ThumbnailSizes []image.Point
+	// ThumbnailFormat is the format of the variants, "jpeg" or "png". The format of the image is
+	// used when empty, or JPEG for formats that cannot be encoded, such as WebP.
+	ThumbnailFormat string
+
+	// ProblemJSON makes ErrorJSON write RFC 7807 application/problem+json responses, as
+	// WriteProblem does, instead of JSONResponse.
+	ProblemJSON bool
+
+	// MaxJSONStreamSize is the largest body, in bytes, ReadJSONStream reads, no limit when 0.
+	MaxJSONStreamSize int64
+
+	// Logger receives the toolkit's log output, such as panics caught by RecoverMiddleware,
+	// requests logged by RequestLogMiddleware and failed uploads and remote calls.
+	// slog.Default() is used when it is nil.
+	Logger *slog.Logger
+
+	// TrustedProxies lists the addresses and CIDR ranges of reverse proxies whose forwarding
+	// headers ClientIP believes.
+	TrustedProxies []string
+
+	// Metrics, when set, records uploads, downloads and remote calls made through this Tools.
+	Metrics *Metrics
+
+	// Tracer, when set, records spans of uploads, ReadJSON and remote calls. Remote calls carry
+	// the trace in a traceparent header whether it is set or not.
+	Tracer trace.Tracer
+
+	// RemoteRetry, when set, makes PushJSONToRemote retry failed requests and responses with a
+	// status of RetryOnStatus, waiting as long as their Retry-After header asks.
+	RemoteRetry *RetryPolicy
+
+	// RemoteBreaker, when set, makes PushJSONToRemote and CallRemote fail fast with
+	// ErrCircuitOpen for hosts that keep failing.
+	RemoteBreaker *CircuitBreaker
+
+	// JWT configures GenerateJWT, VerifyJWT and AuthJWT.
+	JWT *JWTOptions
+
+	// Password configures HashPassword, bcrypt with the default cost when it is nil.
+	Password *PasswordOptions
+
+	// Templates renders the pages of RenderTemplate.
+	Templates *Templates
+
+	// MaxImagePixels is the largest image, in pixels, DecodeImage accepts, so small files cannot
+	// expand into huge bitmaps. 50 megapixels when 0.
+	MaxImagePixels int
+
+	// I18n, when set, translates the messages of ErrorJSON to the language I18n.Middleware chose
+	// for the response.
+	I18n *I18n
+
+	// trusted caches TrustedProxies parsed, so requests do not parse it again.
+	trusted atomic.Pointer[trustedProxyCache]
 }
 
 type UploadedFile struct {
 	NewFileName      string
 	OriginalFileName string
 	FileSize         int64
+
+	// MD5 and SHA256 are the hex encoded digests of the uploaded content, set when the algorithm
+	// is in Tools.ChecksumAlgorithms. Checksums holds the digests of all of them.
+	MD5       string
+	SHA256    string
+	Checksums map[HashAlgorithm]string
+
+	// Thumbnails are the names of the resized variants made for Tools.ThumbnailSizes, in the same
+	// order, stored next to NewFileName.
+	Thumbnails []string
 }
 
+// RandomString returns a random string of n letters, digits, _ and +, as RandomStringFrom does.
 func (t *Tools) RandomString(n int) string {
-	s, r := make([]rune, n), []rune(randomStringSource)
-	for i := range s {
-		p, _ := rand.Prime(rand.Reader, len(r))
-		x, y := p.Uint64(), uint64(len(r)-1)
-		s[i] = r[x&y]
-	}
-	return string(s)
+	return t.RandomStringFrom(randomStringSource, n)
 }
 
 func (t *Tools) UploadFile(r *http.Request, uploadDir string, rename ...bool) (*UploadedFile, error) {
+	return t.UploadFileContext(r.Context(), r, uploadDir, rename...)
+}
+
+// UploadFileContext is UploadFile stopping when ctx is done.
+func (t *Tools) UploadFileContext(ctx context.Context, r *http.Request, uploadDir string, rename ...bool) (*UploadedFile, error) {
 	renameFile := true
 	if len(rename) > 0 {
 		renameFile = rename[0]
 	}
 
-	file, err := t.UploadFiles(r, uploadDir, renameFile)
+	file, err := t.UploadFilesContext(ctx, r, uploadDir, renameFile)
 	if err != nil {
 		return nil, err
 	}
 
 	return file[0], err
 }
+
+// UploadFiles stores the files of a multipart request in uploadDir. It stops when the request's
+// context is done, e.g. because the client went away.
 func (t *Tools) UploadFiles(r *http.Request, uploadDir string, rename ...bool) ([]*UploadedFile, error) {
+	return t.UploadFilesContext(r.Context(), r, uploadDir, rename...)
+}
+
+// UploadFilesContext is UploadFiles stopping with ctx.Err() when ctx is done while the request is
+// read or a file is stored. The file being stored is removed; files stored before are kept.
+func (t *Tools) UploadFilesContext(ctx context.Context, r *http.Request, uploadDir string, rename ...bool) ([]*UploadedFile, error) {
+	results, err := t.UploadResultsContext(ctx, r, uploadDir, rename...)
+
+	var uploadedFiles []*UploadedFile
+	var errs []error
+	for _, result := range results {
+		if result.Err != nil {
+			errs = append(errs, result.Err)
+			continue
+		}
+		uploadedFiles = append(uploadedFiles, result.File)
+	}
+	if err != nil {
+		return uploadedFiles, err
+	}
+	switch len(errs) {
+	case 0:
+		return uploadedFiles, nil
+	case 1:
+		return uploadedFiles, errs[0]
+	}
+	errs = errs[:0]
+	for _, result := range results {
+		if result.Err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", result.OriginalFileName, result.Err))
+		}
+	}
+	return uploadedFiles, errors.Join(errs...)
+}
+
+// UploadResult is the outcome of storing one file of a multipart request.
+type UploadResult struct {
+	// Field is the name of the form field the file was sent in.
+	Field            string
+	OriginalFileName string
+	// File is the stored file, nil when storing it failed with Err.
+	File *UploadedFile
+	Err  error
+}
+
+// UploadResults stores the files of a multipart request in uploadDir, like UploadFiles, and
+// reports the outcome for every file. It stops at the first file that fails, whose result is the
+// last one, unless Tools.ContinueOnUploadError is set. The error is for failures of the request
+// as a whole, such as a malformed body.
+func (t *Tools) UploadResults(r *http.Request, uploadDir string, rename ...bool) ([]*UploadResult, error) {
+	return t.UploadResultsContext(r.Context(), r, uploadDir, rename...)
+}
+
+// UploadResultsContext is UploadResults stopping with ctx.Err() when ctx is done.
+func (t *Tools) UploadResultsContext(ctx context.Context, r *http.Request, uploadDir string, rename ...bool) (results []*UploadResult, err error) {
+	ctx, span := t.startSpan(ctx, "toolkit.UploadFiles")
+	defer func() {
+		failed, spanErr := 0, err
+		for _, result := range results {
+			if result.Err != nil {
+				failed++
+				spanErr = cmp.Or(spanErr, result.Err)
+			}
+		}
+		span.SetAttributes(attribute.Int("upload.files", len(results)), attribute.Int("upload.failed", failed))
+		endSpan(span, spanErr)
+	}()
+
 	renameFile := true
 	if len(rename) > 0 {
 		renameFile = rename[0]
 	}
 
-	var uploadedFiles []*UploadedFile
 	if t.MaxFileSize < 0 {
 		return nil, errors.New("file size should be greater than 0")
 	}
 
-	err := t.CreateDirIfNotExistst(uploadDir)
+	store, uploadDir, err := t.uploadStore(uploadDir)
 	if err != nil {
 		return nil, err
 	}
@@ -73,158 +296,389 @@ func (t *Tools) UploadFiles(r *http.Request, uploadDir string, rename ...bool) (
 		t.MaxFileSize = 1024 * 1024 * 1024
 	}
 
+	r.Body = contextBody(ctx, r.Body)
+	if t.StreamUploads {
+		return t.streamUploads(ctx, r, store, uploadDir, renameFile)
+	}
+
 	err = r.ParseMultipartForm(int64(t.MaxFileSize))
 	if err != nil {
-		return nil, fmt.Errorf("the uploaded file is too big. Max size is %dB", t.MaxFileSize)
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, fmt.Errorf("the uploaded file is too big. Max size is %s", t.HumanBytes(int64(t.MaxFileSize)))
 	}
 
-	for _, headers := range r.MultipartForm.File {
+	for field, headers := range r.MultipartForm.File {
 		for _, header := range headers {
-			uploadedFiles, err = func(uploadedFiles []*UploadedFile) ([]*UploadedFile, error) {
-				var uploadedFile UploadedFile
-				infile, err := header.Open()
+			if err := ctx.Err(); err != nil {
+				return results, err
+			}
+			uploadedFile, err := func() (*UploadedFile, error) {
+				file, err := header.Open()
 				if err != nil {
 					return nil, err
 				}
-				defer infile.Close()
+				defer file.Close()
+				return t.storeUpload(ctx, store, uploadDir, header, contextReader{ctx, file}, renameFile)
+			}()
+			results = append(results, t.uploadResult(ctx, field, header.Filename, uploadedFile, err))
+			if err != nil && (!t.ContinueOnUploadError || ctx.Err() != nil) {
+				return results, ctx.Err()
+			}
+		}
+	}
+	return results, nil
+}
 
-				buff := make([]byte, 512)
-				_, err = infile.Read(buff)
-				if err != nil {
-					return nil, err
-				}
+// uploadResult records the outcome of storing a file in the metrics, and the log when it failed,
+// and returns it.
+func (t *Tools) uploadResult(ctx context.Context, field, filename string, uploadedFile *UploadedFile, err error) *UploadResult {
+	if err != nil {
+		t.Metrics.observeUpload(0, err)
+		t.logger().WarnContext(ctx, "upload failed",
+			slog.String("field", field),
+			slog.String("file", filename),
+			slog.String("error", err.Error()),
+		)
+		return &UploadResult{Field: field, OriginalFileName: filename, Err: err}
+	}
+	t.Metrics.observeUpload(uploadedFile.FileSize, nil)
+	return &UploadResult{Field: field, OriginalFileName: filename, File: uploadedFile}
+}
 
-				allowed := false
-				fileType := http.DetectContentType(buff)
-				if len(t.AllowedFileTypes) > 0 {
-					for _, x := range t.AllowedFileTypes {
-						if strings.EqualFold(x, fileType) {
-							allowed = true
-						}
-					}
-				} else {
-					allowed = true
-				}
+// uploadStore returns the store files uploaded to uploadDir are kept in and the directory they
+// are kept under: Storage, or a LocalStore in the resolved and created upload directory.
+func (t *Tools) uploadStore(uploadDir string) (FileStore, string, error) {
+	if t.Storage != nil {
+		if t.ContentAddressed {
+			return nil, "", errors.New("content addressed uploads need local storage")
+		}
+		return t.Storage, uploadDir, nil
+	}
+	uploadDir, err := t.uploadPath(uploadDir)
+	if err != nil {
+		return nil, "", err
+	}
+	if _, err = t.CreateDirIfNotExists(uploadDir); err != nil {
+		return nil, "", err
+	}
+	return t.NewLocalStore(uploadDir), uploadDir, nil
+}
 
-				if !allowed {
-					return nil, fmt.Errorf("the type %s of uploaded file is not permitted", fileType)
-				}
+// storeUpload checks the uploaded file of header, whose content is read from src, and keeps it
+// in store. The header's Size is -1 when the length of the content is not known.
+func (t *Tools) storeUpload(ctx context.Context, store FileStore, uploadDir string, header *multipart.FileHeader, src io.Reader, renameFile bool) (*UploadedFile, error) {
+	var uploadedFile UploadedFile
+	filename, size := header.Filename, header.Size
 
-				_, err = infile.Seek(0, 0)
-				if err != nil {
-					return nil, err
-				}
+	buff := make([]byte, 512)
+	n, err := io.ReadFull(src, buff)
+	if err != nil && (n == 0 || err != io.ErrUnexpectedEOF) {
+		return nil, err
+	}
+	buff = buff[:n]
+
+	allowed := false
+	fileType := http.DetectContentType(buff)
+	if len(t.AllowedFileTypes) > 0 {
+		for _, x := range t.AllowedFileTypes {
+			if strings.EqualFold(x, fileType) {
+				allowed = true
+			}
+		}
+	} else {
+		allowed = true
+	}
 
-				uploadedFile.OriginalFileName = header.Filename
-				if renameFile {
-					uploadedFile.NewFileName = fmt.Sprintf("%s%s", t.RandomString(25), filepath.Ext(header.Filename))
-				} else {
-					uploadedFile.NewFileName = header.Filename
-				}
+	if !allowed {
+		return nil, &uploadError{"type_not_allowed", fmt.Errorf("the type %s of uploaded file is not permitted", fileType)}
+	}
+	safeName := t.SanitizeFileName(filename)
+	if err := t.checkFileExtension(safeName, fileType); err != nil {
+		return nil, &uploadError{"type_not_allowed", err}
+	}
 
-				var outfile *os.File
-				defer outfile.Close()
+	for _, validate := range t.UploadValidators {
+		if err := validate(header, buff); err != nil {
+			return nil, &uploadError{"invalid", err}
+		}
+	}
 
-				if outfile, err = os.Create(filepath.Join(uploadDir, uploadedFile.NewFileName)); err != nil {
-					return nil, err
-				} else {
-					fileSize, err := io.Copy(outfile, infile)
-					if err != nil {
-						return nil, err
-					}
-					uploadedFile.FileSize = fileSize
-				}
+	var infile io.Reader = io.MultiReader(bytes.NewReader(buff), src)
+	hashes := make(map[HashAlgorithm]hash.Hash, len(t.ChecksumAlgorithms))
+	if len(t.ChecksumAlgorithms) > 0 {
+		writers := make([]io.Writer, 0, len(t.ChecksumAlgorithms))
+		for _, algo := range t.ChecksumAlgorithms {
+			h, err := newHash(algo)
+			if err != nil {
+				return nil, err
+			}
+			hashes[algo] = h
+			writers = append(writers, h)
+		}
+		infile = io.TeeReader(infile, io.MultiWriter(writers...))
+	}
+	setChecksums := func() {
+		if len(hashes) == 0 {
+			return
+		}
+		uploadedFile.Checksums = make(map[HashAlgorithm]string, len(hashes))
+		for algo, h := range hashes {
+			uploadedFile.Checksums[algo] = hex.EncodeToString(h.Sum(nil))
+		}
+		uploadedFile.MD5 = uploadedFile.Checksums[HashMD5]
+		uploadedFile.SHA256 = uploadedFile.Checksums[HashSHA256]
+	}
 
-				uploadedFiles = append(uploadedFiles, &uploadedFile)
-				return uploadedFiles, nil
-			}(uploadedFiles)
+	uploadedFile.OriginalFileName = filename
+	if t.ContentAddressed {
+		if err = t.ensureFreeSpace(uploadDir, max(size, 0)); err != nil {
+			return nil, err
+		}
+		store, err := t.NewContentStore(uploadDir)
+		if err != nil {
+			return nil, err
+		}
+		hash, fileSize, err := store.Put(infile)
+		if err != nil {
+			return nil, err
+		}
+		uploadedFile.NewFileName = store.RelPath(hash)
+		uploadedFile.FileSize = fileSize
+		setChecksums()
+		return &uploadedFile, nil
+	}
+
+	if renameFile {
+		rename := t.RenameFunc
+		if rename == nil {
+			rename = t.RandomFileName
+		}
+		// Keep the name relative to the upload directory, whatever the function returned.
+		uploadedFile.NewFileName = strings.TrimPrefix(path.Clean("/"+filepath.ToSlash(rename(safeName))), "/")
+	} else {
+		uploadedFile.NewFileName = safeName
+	}
+
+	name, err := t.storeName(uploadDir, uploadedFile.NewFileName)
+	if err != nil {
+		return nil, err
+	}
+
+	var thumbs *thumbnailer
+	if len(t.ThumbnailSizes) > 0 && strings.HasPrefix(fileType, "image/") {
+		thumbs = t.newThumbnailer()
+		infile = io.TeeReader(infile, thumbs)
+	}
+
+	uploadedFile.FileSize, err = t.saveUpload(ctx, store, name, infile, size)
+	if thumbs != nil {
+		img, decodeErr := thumbs.wait(err)
+		if err == nil && decodeErr == nil {
+			uploadedFile.Thumbnails, err = t.saveThumbnails(ctx, store, uploadDir, uploadedFile.NewFileName, img)
 			if err != nil {
-				return uploadedFiles, err
+				_ = store.Delete(ctx, name)
 			}
 		}
 	}
-	return uploadedFiles, nil
+	if err != nil {
+		return nil, err
+	}
+	setChecksums()
+	return &uploadedFile, nil
 }
 
-func (t *Tools) CreateDirIfNotExistst(path string) error {
-	const mode = 0755
-	if _, err := os.Stat(path); os.IsNotExist(err) {
-		err = os.MkdirAll(path, mode)
-		if err != nil {
-			return err
+// storeName is the name under which the file name uploaded to uploadDir is kept in the store.
+func (t *Tools) storeName(uploadDir, name string) (string, error) {
+	if t.Storage == nil {
+		return name, nil
+	}
+	return objectKey(uploadDir, name)
+}
+
+// saveUpload keeps the content of r as name in store, encrypted when EncryptionKeys is set, and
+// returns the size of the content.
+func (t *Tools) saveUpload(ctx context.Context, store FileStore, name string, r io.Reader, size int64) (int64, error) {
+	if !t.encryptionEnabled() {
+		return store.Save(ctx, name, r, size)
+	}
+	enc, err := t.encryptingReader(r)
+	if err != nil {
+		return 0, err
+	}
+	_, err = store.Save(ctx, name, enc, -1)
+	enc.Close()
+	return enc.n, err
+}
+
+// CreateDirIfNotExists creates path, including any missing parents, when it does not exist yet
+// and reports whether it did. The mode defaults to Tools.DirMode, or 0755 when that is unset.
+func (t *Tools) CreateDirIfNotExists(path string, mode ...fs.FileMode) (bool, error) {
+	dirMode := t.dirMode()
+	if len(mode) > 0 {
+		dirMode = mode[0]
+	}
+
+	info, err := os.Stat(path)
+	if err == nil {
+		if !info.IsDir() {
+			return false, fmt.Errorf("%s exists and is not a directory", path)
 		}
+		return false, nil
 	}
-	return nil
+	if !os.IsNotExist(err) {
+		return false, err
+	}
+
+	if err = os.MkdirAll(path, dirMode); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// CreateDirIfNotExistst is the misspelled original name of CreateDirIfNotExists.
+//
+// Deprecated: use CreateDirIfNotExists.
+func (t *Tools) CreateDirIfNotExistst(path string) error {
+	_, err := t.CreateDirIfNotExists(path)
+	return err
 }
 
-func (t *Tools) Slugify(s string) (string, error) {
+// Slugify turns s into a slug fit for URLs and file names, such as "lodz-zolc" for "Łódź żółć":
+// letters are lowered, those with diacritics spelled in ASCII, and everything but letters and
+// digits joins the words with a "-". opts can change each of these steps.
+func (t *Tools) Slugify(s string, opts ...SlugOptions) (string, error) {
 	if s == "" {
 		return "", errors.New("given string is empty")
 	}
+	var o SlugOptions
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+	if o.Separator == "" {
+		o.Separator = "-"
+	}
 
-	//any length of letters a-z and digits
-	var re = regexp.MustCompile(`[^a-z\d]+`)
-	slug := strings.Trim(re.ReplaceAllString(s, "-"), "-")
-
+	slug := joinSlug(slugWords(s, o), o.Separator, o.MaxLength)
 	if len(slug) == 0 {
 		return "", errors.New("empty string after sluging")
 	}
+	if slices.Contains(o.Reserved, slug) {
+		return "", fmt.Errorf("slug %q is reserved", slug)
+	}
 	return slug, nil
 }
 
+// DownloadStaticFile serves file from the directory p as an attachment named displayName, with
+// ServeStaticFile.
 func (t *Tools) DownloadStaticFile(w http.ResponseWriter, r *http.Request, p, file, displayName string) {
-	fp := path.Join(p, file)
-	w.Header().Set("Content-Disposition", fmt.Sprintf("attachement; filename=\"%s\"", displayName))
+	t.ServeStaticFile(w, r, p, file, StaticFileOptions{DisplayName: displayName})
+}
 
-	http.ServeFile(w, r, fp)
+// StaticFileOptions configures ServeStaticFile.
+type StaticFileOptions struct {
+	// DisplayName is the file name the client sees, the base name of the file when empty.
+	DisplayName string
+	// Inline lets the browser display the file, e.g. play a video, instead of downloading it.
+	Inline bool
+	// CacheControl, when set, is sent as the Cache-Control header.
+	CacheControl string
+}
+
+// ServeStaticFile serves file from the directory p. It answers range requests, so videos can be
+// seeked and downloads resumed, and conditional requests, with an ETag and Last-Modified date
+// taken from the file's modification time and size. It stops when the client goes away.
+func (t *Tools) ServeStaticFile(w http.ResponseWriter, r *http.Request, p, file string, opts StaticFileOptions) {
+	if t.Metrics != nil {
+		sw := &statusWriter{ResponseWriter: w}
+		defer func() { t.Metrics.observeDownload(sw.code()) }()
+		w = sw
+	}
+
+	fp, err := t.SecureJoin(p, file)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	f, err := t.open(fp)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil || info.IsDir() {
+		http.NotFound(w, r)
+		return
+	}
+
+	var content io.ReadSeeker = f
+	if t.encryptionEnabled() {
+		// Files stored before encryption was turned on are served as they are.
+		if content, err = t.DecryptReader(f); errors.Is(err, ErrNotEncrypted) {
+			content = f
+		} else if err != nil {
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	displayName := opts.DisplayName
+	if displayName == "" {
+		displayName = filepath.Base(fp)
+	}
+	disposition := "attachement"
+	if opts.Inline {
+		disposition = "inline"
+	}
+	w.Header().Set("Content-Disposition", fmt.Sprintf("%s; filename=\"%s\"", disposition, displayName))
+	ext := filepath.Ext(fp)
+	if ext == "" {
+		// Content-addressed files have no extension of their own.
+		ext = filepath.Ext(displayName)
+	}
+	w.Header().Set("Content-Type", t.MIMEByExtension(ext))
+	w.Header().Set("ETag", fmt.Sprintf(`"%x-%x"`, info.ModTime().UnixNano(), info.Size()))
+	if opts.CacheControl != "" {
+		w.Header().Set("Cache-Control", opts.CacheControl)
+	}
+
+	http.ServeContent(w, r, displayName, info.ModTime(), newContextReadSeeker(r.Context(), content))
 }
 
 type JSONResponse struct {
-	Error   bool        `json:"error"`
-	Message string      `json:"message"`
-	Data    interface{} `json:"data,omitempty"`
+	Error     bool              `json:"error"`
+	Message   string            `json:"message"`
+	Data      interface{}       `json:"data,omitempty"`
+	Errors    map[string]string `json:"errors,omitempty"`
+	RequestID string            `json:"request_id,omitempty"`
 }
 
 func (t *Tools) ReadJSON(w http.ResponseWriter, r *http.Request, data interface{}) error {
-	maxBytes := 1024 * 1024
-	if t.MaxJSONSize > 0 {
-		maxBytes = t.MaxJSONSize
-	}
+	return t.ReadJSONContext(r.Context(), w, r, data)
+}
 
-	r.Body = http.MaxBytesReader(w, r.Body, int64(maxBytes))
+// ReadJSONContext is ReadJSON stopping with ctx.Err() when ctx is done before the body is read.
+func (t *Tools) ReadJSONContext(ctx context.Context, w http.ResponseWriter, r *http.Request, data interface{}) (err error) {
+	_, span := t.startSpan(ctx, "toolkit.ReadJSON")
+	defer func() { endSpan(span, err) }()
+
+	r.Body = http.MaxBytesReader(w, contextBody(ctx, r.Body), t.maxBodySize())
 	dec := json.NewDecoder(r.Body)
 
 	if !t.AllowUnknownFields {
 		dec.DisallowUnknownFields()
 	}
 
-	err := dec.Decode(data)
+	err = dec.Decode(data)
 	if err != nil {
-		var syntaxError *json.SyntaxError
-		var unmarshalTypeError *json.UnmarshalTypeError
-		var invalidUnmarshalError *json.InvalidUnmarshalError
-		switch {
-		case errors.As(err, &syntaxError):
-			return fmt.Errorf("body contains badly-formed JSON (at characted %d)", syntaxError.Offset)
-		case errors.Is(err, io.ErrUnexpectedEOF):
-			return errors.New("body contains badly-formed JSON")
-		case errors.As(err, &unmarshalTypeError):
-			if unmarshalTypeError.Field != "" {
-				return fmt.Errorf("body contains incorrect JSON type for field %q", unmarshalTypeError.Field)
-			}
-			return fmt.Errorf("body contains incorrect JSON type (at character %d)", unmarshalTypeError.Offset)
-		case errors.Is(err, io.EOF):
-			return errors.New("body must not be empty")
-		case strings.HasPrefix(err.Error(), "json: unknown field"):
-			fieldName := strings.TrimPrefix(err.Error(), "json: unknown field")
-			return fmt.Errorf("body contains unknown key %s", fieldName)
-		case err.Error() == "http: request body too large":
-			return fmt.Errorf("body must not be larger than %d bytes", maxBytes)
-		case errors.As(err, &invalidUnmarshalError):
-			return fmt.Errorf("error unmarshalling JSON: %s", err.Error())
-		default:
-			return err
+		if ctx.Err() != nil {
+			return ctx.Err()
 		}
+		return jsonDecodeError(err)
 	}
 
 	err = dec.Decode(&struct{}{})
@@ -232,7 +686,48 @@ func (t *Tools) ReadJSON(w http.ResponseWriter, r *http.Request, data interface{
 		return errors.New("body must contain only one JSON value")
 	}
 
-	return nil
+	return t.NewValidator().validate(data, "json")
+}
+
+// maxBodySize returns the size limit of ReadJSON and ReadXML.
+func (t *Tools) maxBodySize() int64 {
+	switch {
+	case t.MaxBodySize > 0:
+		return int64(t.MaxBodySize)
+	case t.MaxJSONSize > 0:
+		return int64(t.MaxJSONSize)
+	}
+	return 1024 * 1024
+}
+
+// jsonDecodeError turns an error of json.Decoder into a message fit for the client.
+func jsonDecodeError(err error) error {
+	var syntaxError *json.SyntaxError
+	var unmarshalTypeError *json.UnmarshalTypeError
+	var invalidUnmarshalError *json.InvalidUnmarshalError
+	var maxBytesError *http.MaxBytesError
+	switch {
+	case errors.As(err, &syntaxError):
+		return fmt.Errorf("body contains badly-formed JSON (at characted %d)", syntaxError.Offset)
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return errors.New("body contains badly-formed JSON")
+	case errors.As(err, &unmarshalTypeError):
+		if unmarshalTypeError.Field != "" {
+			return fmt.Errorf("body contains incorrect JSON type for field %q", unmarshalTypeError.Field)
+		}
+		return fmt.Errorf("body contains incorrect JSON type (at character %d)", unmarshalTypeError.Offset)
+	case errors.Is(err, io.EOF):
+		return errors.New("body must not be empty")
+	case strings.HasPrefix(err.Error(), "json: unknown field"):
+		fieldName := strings.TrimPrefix(err.Error(), "json: unknown field")
+		return fmt.Errorf("body contains unknown key %s", fieldName)
+	case errors.As(err, &maxBytesError):
+		return &bodyTooLargeError{limit: maxBytesError.Limit}
+	case errors.As(err, &invalidUnmarshalError):
+		return fmt.Errorf("error unmarshalling JSON: %s", err.Error())
+	default:
+		return err
+	}
 }
 
 func (t *Tools) WriteJSON(w http.ResponseWriter, status int, data interface{}, headers ...http.Header) error {
@@ -256,20 +751,62 @@ func (t *Tools) WriteJSON(w http.ResponseWriter, status int, data interface{}, h
 	return nil
 }
 
+// ErrorJSON writes err as a JSON error response. Without a status, it is chosen from err: 422
+// for a *ValidationError, whose field errors are included, 404 for ErrNotFound, 409 for
+// ErrConflict, 413 and 507 for oversized bodies and full disks, 503 for ErrCircuitOpen and 400
+// otherwise. With ProblemJSON the response is an RFC 7807 problem instead.
 func (t *Tools) ErrorJSON(w http.ResponseWriter, err error, status ...int) error {
-	statusCode := http.StatusBadRequest
-	if len(status) > 0 {
-		statusCode = status[0]
+	statusCode, message, fields := t.errorPayload(w, err, status)
+	if t.ProblemJSON {
+		if fields != nil {
+			return t.WriteProblem(w, statusCode, message, "", fields)
+		}
+		return t.WriteProblem(w, statusCode, "", message, nil)
 	}
 
 	var payload JSONResponse
 	payload.Error = true
-	payload.Message = err.Error()
+	payload.Message = message
+	payload.Errors = fields
+	payload.RequestID = w.Header().Get(RequestIDHeader)
 
 	return t.WriteJSON(w, statusCode, payload)
 }
 
+// errorPayload returns the status, the translated message and, for a *ValidationError, the field
+// errors of the error responses of err.
+func (t *Tools) errorPayload(w http.ResponseWriter, err error, status []int) (int, string, map[string]string) {
+	localizer := t.responseLocalizer(w)
+	var statusCode int
+	var message string
+	var fields map[string]string
+
+	var validationError *ValidationError
+	var localizedError *LocalizedError
+	switch {
+	case errors.As(err, &validationError):
+		statusCode = http.StatusUnprocessableEntity
+		message = localizer.translate("validation.failed", "validation failed", -1, nil)
+		fields = localizer.localizedFields(validationError.Fields, validationError.keys)
+	case errors.As(err, &localizedError):
+		statusCode = errorStatus(err)
+		message = localizer.translate(localizedError.Key, localizedError.Message, -1, localizedError.Args)
+	default:
+		statusCode = errorStatus(err)
+		message = err.Error()
+	}
+	if len(status) > 0 {
+		statusCode = status[0]
+	}
+	return statusCode, message, fields
+}
+
 func (t *Tools) PushJSONToRemote(uri string, data interface{}, client ...*http.Client) (*http.Response, int, error) {
+	return t.PushJSONToRemoteContext(context.Background(), uri, data, client...)
+}
+
+// PushJSONToRemoteContext is PushJSONToRemote with the request and its retries bound to ctx.
+func (t *Tools) PushJSONToRemoteContext(ctx context.Context, uri string, data interface{}, client ...*http.Client) (*http.Response, int, error) {
 	jsonData, err := json.Marshal(data)
 	if err != nil {
 		return nil, 0, err
@@ -279,18 +816,135 @@ func (t *Tools) PushJSONToRemote(uri string, data interface{}, client ...*http.C
 		httpClient = client[0]
 	}
 
-	request, err := http.NewRequest("POST", uri, bytes.NewBuffer(jsonData))
+	response, err := t.doRemote(ctx, httpClient, t.RemoteRetry, func() (*http.Request, error) {
+		request, err := http.NewRequestWithContext(ctx, "POST", uri, bytes.NewReader(jsonData))
+		if err != nil {
+			return nil, err
+		}
+		request.Header.Set("Content-Type", "application/json")
+		return request, nil
+	})
 	if err != nil {
 		return nil, 0, err
 	}
+	defer response.Body.Close()
+
+	return response, response.StatusCode, nil
+}
 
-	request.Header.Set("Content-Type", "application/json")
+// doRemote sends the request newRequest makes with client, again with policy, when it is not nil,
+// if it fails or the response has a retryable status.
+func (t *Tools) doRemote(ctx context.Context, client *http.Client, policy *RetryPolicy, newRequest func() (*http.Request, error)) (*http.Response, error) {
+	var host string
+	send := func() (*http.Response, error) {
+		request, err := newRequest()
+		if err != nil {
+			return nil, Permanent(err)
+		}
+
+		host = request.URL.Host
+		if err := t.RemoteBreaker.allow(host); err != nil {
+			return nil, Permanent(err)
+		}
+
+		spanCtx, span := t.startSpan(ctx, request.Method, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(
+			attribute.String("http.request.method", request.Method),
+			attribute.String("server.address", request.URL.Hostname()),
+			attribute.String("url.full", request.URL.Redacted()),
+		))
+		injectTraceContext(spanCtx, request.Header)
 
-	response, err := httpClient.Do(request)
+		start := time.Now()
+		response, err := client.Do(request)
+		if err != nil {
+			endSpan(span, err)
+			t.RemoteBreaker.record(host, 0, ctx.Err() != nil)
+			t.Metrics.observeRemoteCall(host, 0, time.Since(start))
+			t.logger().WarnContext(ctx, "remote call failed",
+				slog.String("method", request.Method),
+				slog.String("host", host),
+				slog.Duration("duration", time.Since(start)),
+				slog.String("error", err.Error()),
+			)
+			return nil, err
+		}
+		span.SetAttributes(attribute.Int("http.response.status_code", response.StatusCode))
+		if response.StatusCode >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, response.Status)
+			t.logger().WarnContext(ctx, "remote call failed",
+				slog.String("method", request.Method),
+				slog.String("host", host),
+				slog.Int("status", response.StatusCode),
+				slog.Duration("duration", time.Since(start)),
+			)
+		}
+		span.End()
+		t.RemoteBreaker.record(host, response.StatusCode, false)
+		t.Metrics.observeRemoteCall(host, response.StatusCode, time.Since(start))
+		return response, nil
+	}
+
+	if policy == nil {
+		return send()
+	}
+	var last *http.Response
+	attempts := 0
+	response, err := RetryValue(ctx, *policy, func() (*http.Response, error) {
+		if last != nil {
+			_ = last.Body.Close()
+		}
+		if attempts++; attempts > 1 {
+			t.Metrics.observeRemoteRetry(host)
+		}
+		response, err := send()
+		last = response
+		if err == nil && policy.retryableStatus(response.StatusCode) {
+			if delay, ok := parseRetryAfter(response.Header.Get("Retry-After"), time.Now()); ok {
+				return response, RetryAfter(errRetryableStatus, delay)
+			}
+			return response, errRetryableStatus
+		}
+		return response, err
+	})
+	if errors.Is(err, errRetryableStatus) {
+		// The last attempt's response is returned as it would be without retries.
+		err = nil
+	}
 	if err != nil {
-		return nil, 0, err
+		if last != nil {
+			_ = last.Body.Close()
+		}
+		return nil, err
 	}
-	defer response.Body.Close()
+	return response, nil
+}
 
-	return response, response.StatusCode, nil
+var errRetryableStatus = errors.New("remote responded with a retryable status")
+
+// retryableStatus reports whether a remote's response status is retried: one of RetryOnStatus,
+// or likely to be temporary without them.
+func (p *RetryPolicy) retryableStatus(status int) bool {
+	if len(p.RetryOnStatus) > 0 {
+		return slices.Contains(p.RetryOnStatus, status)
+	}
+	switch status {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// parseRetryAfter parses a Retry-After header, in seconds or as an HTTP date, into the delay from
+// now it asks for.
+func parseRetryAfter(header string, now time.Time) (time.Duration, bool) {
+	if header == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(header); err == nil {
+		return time.Duration(max(seconds, 0)) * time.Second, true
+	}
+	if at, err := http.ParseTime(header); err == nil {
+		return max(at.Sub(now), 0), true
+	}
+	return 0, false
 }
@@ -2,6 +2,10 @@ package toolkit
 
 import (
 	"bytes"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -186,6 +190,41 @@ func TestTools_CreateDirIfNotExists(t *testing.T) {
 	}
 }
 
+func TestTools_CreateDirIfNotExistsCreated(t *testing.T) {
+	tt := Tools{DirMode: 0700}
+	dir := fmt.Sprintf("%s/a/b", t.TempDir())
+
+	created, err := tt.CreateDirIfNotExists(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !created {
+		t.Error("expected directory to be reported as created")
+	}
+	if info, _ := os.Stat(dir); info.Mode().Perm() != 0700 {
+		t.Errorf("expected Tools.DirMode to be applied, got %v", info.Mode().Perm())
+	}
+
+	created, err = tt.CreateDirIfNotExists(dir)
+	if err != nil || created {
+		t.Errorf("expected existing directory to be left alone: %v %v", created, err)
+	}
+
+	other := fmt.Sprintf("%s/c", t.TempDir())
+	if _, err = tt.CreateDirIfNotExists(other, 0750); err != nil {
+		t.Fatal(err)
+	}
+	if info, _ := os.Stat(other); info.Mode().Perm() != 0750 {
+		t.Errorf("expected explicit mode to be applied, got %v", info.Mode().Perm())
+	}
+
+	file := fmt.Sprintf("%s/file", t.TempDir())
+	_ = os.WriteFile(file, nil, 0644)
+	if _, err = tt.CreateDirIfNotExists(file); err == nil {
+		t.Error("expected error when path is a file")
+	}
+}
+
 var slugTest = []struct {
 	name          string
 	s             string
@@ -327,3 +366,211 @@ func TestTools_ErrorJSON(t *testing.T) {
 		t.Errorf("wrong status code returned; expected 503, but got %d", rr.Code)
 	}
 }
+
+func testPNG(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 4, 4))); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func newUploadRequest(t *testing.T, files map[string][]byte) *http.Request {
+	t.Helper()
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	for name, content := range files {
+		part, err := writer.CreateFormFile("file", name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _ = part.Write(content)
+	}
+	_ = writer.Close()
+
+	request := httptest.NewRequest("POST", "/", &body)
+	request.Header.Add("Content-Type", writer.FormDataContentType())
+	return request
+}
+
+func TestTools_UploadFilesInMemory(t *testing.T) {
+	var testTools Tools
+	dir := t.TempDir()
+
+	uploadedFiles, err := testTools.UploadFiles(newUploadRequest(t, map[string][]byte{"img.png": testPNG(t)}), dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(uploadedFiles) != 1 || uploadedFiles[0].NewFileName != "img.png" {
+		t.Fatal("unexpected uploaded files", uploadedFiles)
+	}
+
+	info, err := os.Stat(fmt.Sprintf("%s/img.png", dir))
+	if err != nil {
+		t.Fatal("expected file to exist:", err)
+	}
+	if info.Size() != uploadedFiles[0].FileSize {
+		t.Errorf("wrong file size %d, expected %d", info.Size(), uploadedFiles[0].FileSize)
+	}
+}
+
+func TestTools_UploadChecksums(t *testing.T) {
+	content := testPNG(t)
+	md5Sum, sha1Sum, sha256Sum := md5.Sum(content), sha1.Sum(content), sha256.Sum256(content)
+
+	for _, stream := range []bool{false, true} {
+		testTools := Tools{
+			StreamUploads:        stream,
+			ChecksumAlgorithms:   []HashAlgorithm{HashMD5, HashSHA1, HashSHA256},
+			EncryptionKeys:       map[uint32][]byte{1: make([]byte, 32)},
+			EncryptionKeyVersion: 1,
+		}
+		files, err := testTools.UploadFiles(newUploadRequest(t, map[string][]byte{"img.png": content}), t.TempDir())
+		if err != nil || len(files) != 1 {
+			t.Fatalf("expected one file, got %v %v", files, err)
+		}
+		f := files[0]
+		if f.MD5 != hex.EncodeToString(md5Sum[:]) || f.SHA256 != hex.EncodeToString(sha256Sum[:]) ||
+			f.Checksums[HashSHA1] != hex.EncodeToString(sha1Sum[:]) {
+			t.Errorf("stream %v: unexpected checksums of the plain content %+v", stream, f)
+		}
+	}
+
+	testTools := Tools{ChecksumAlgorithms: []HashAlgorithm{"crc32"}}
+	if _, err := testTools.UploadFiles(newUploadRequest(t, map[string][]byte{"img.png": content}), t.TempDir()); err == nil {
+		t.Error("expected an unsupported algorithm to fail")
+	}
+}
+
+var errInfected = errors.New("file is infected")
+
+func TestTools_UploadValidators(t *testing.T) {
+	var seen []string
+	validators := []func(header *multipart.FileHeader, firstBytes []byte) error{
+		func(header *multipart.FileHeader, firstBytes []byte) error {
+			seen = append(seen, fmt.Sprintf("%s %d", header.Filename, len(firstBytes)))
+			return nil
+		},
+		func(header *multipart.FileHeader, firstBytes []byte) error {
+			if bytes.Contains(firstBytes, []byte("EICAR")) {
+				return errInfected
+			}
+			return nil
+		},
+	}
+
+	for _, stream := range []bool{false, true} {
+		seen = nil
+		testTools := Tools{StreamUploads: stream, UploadValidators: validators}
+		dir := t.TempDir()
+
+		if _, err := testTools.UploadFiles(newUploadRequest(t, map[string][]byte{"virus.txt": []byte("X5O!P%@AP EICAR test")}), dir); !errors.Is(err, errInfected) {
+			t.Errorf("stream %v: expected the validator's error, got %v", stream, err)
+		}
+		if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+			t.Errorf("stream %v: expected nothing to be stored, got %d files", stream, len(entries))
+		}
+
+		if _, err := testTools.UploadFiles(newUploadRequest(t, map[string][]byte{"img.png": testPNG(t)}), dir); err != nil {
+			t.Errorf("stream %v: unexpected error %v", stream, err)
+		}
+		expected := fmt.Sprintf("img.png %d", len(testPNG(t)))
+		if len(seen) != 2 || seen[1] != expected {
+			t.Errorf("stream %v: expected the validators to see %q, got %v", stream, expected, seen)
+		}
+	}
+}
+
+func TestTools_UploadResults(t *testing.T) {
+	files := map[string][]byte{"a.png": testPNG(t), "b.txt": []byte("plain text"), "c.png": testPNG(t)}
+
+	for _, stream := range []bool{false, true} {
+		testTools := Tools{StreamUploads: stream, AllowedFileTypes: []string{"image/png"}, ContinueOnUploadError: true}
+		results, err := testTools.UploadResults(newUploadRequest(t, files), t.TempDir())
+		if err != nil || len(results) != 3 {
+			t.Fatalf("stream %v: expected 3 results, got %d %v", stream, len(results), err)
+		}
+		for _, result := range results {
+			failed := result.OriginalFileName == "b.txt"
+			if (result.Err != nil) != failed || (result.File == nil) != failed || result.Field != "file" {
+				t.Errorf("stream %v: unexpected result %+v", stream, result)
+			}
+		}
+
+		uploaded, err := testTools.UploadFiles(newUploadRequest(t, files), t.TempDir())
+		if len(uploaded) != 2 || err == nil {
+			t.Errorf("stream %v: expected 2 files and an error, got %d %v", stream, len(uploaded), err)
+		}
+
+		testTools.ContinueOnUploadError = false
+		results, err = testTools.UploadResults(newUploadRequest(t, files), t.TempDir())
+		if err != nil || len(results) == 0 {
+			t.Fatalf("stream %v: unexpected results %v %v", stream, results, err)
+		}
+		for i, result := range results {
+			if last := i == len(results)-1; (result.Err != nil) != last {
+				t.Errorf("stream %v: expected processing to stop at the failed file, got %+v", stream, results)
+			}
+		}
+	}
+}
+
+func TestTools_ServeStaticFile(t *testing.T) {
+	var testTools Tools
+	dir := t.TempDir()
+	if err := os.WriteFile(dir+"/clip.mp4", []byte("0123456789"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	_ = os.Mkdir(dir+"/sub", 0755)
+
+	serve := func(file string, opts StaticFileOptions, header http.Header) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		rr := httptest.NewRecorder()
+		testTools.ServeStaticFile(rr, req, dir, file, opts)
+		return rr
+	}
+
+	rr := serve("clip.mp4", StaticFileOptions{Inline: true, CacheControl: "private, max-age=60"}, nil)
+	etag, modified := rr.Header().Get("ETag"), rr.Header().Get("Last-Modified")
+	if rr.Code != http.StatusOK || rr.Body.String() != "0123456789" || etag == "" || modified == "" {
+		t.Fatalf("unexpected response %d %v", rr.Code, rr.Header())
+	}
+	if rr.Header().Get("Content-Disposition") != `inline; filename="clip.mp4"` ||
+		rr.Header().Get("Content-Type") != "video/mp4" ||
+		rr.Header().Get("Cache-Control") != "private, max-age=60" ||
+		rr.Header().Get("Accept-Ranges") != "bytes" {
+		t.Errorf("unexpected headers %v", rr.Header())
+	}
+
+	conditionalTests := []struct {
+		name   string
+		header http.Header
+		status int
+		body   string
+	}{
+		{name: "range", header: http.Header{"Range": {"bytes=2-5"}}, status: http.StatusPartialContent, body: "2345"},
+		{name: "suffix range", header: http.Header{"Range": {"bytes=-3"}}, status: http.StatusPartialContent, body: "789"},
+		{name: "unsatisfiable range", header: http.Header{"Range": {"bytes=20-"}}, status: http.StatusRequestedRangeNotSatisfiable},
+		{name: "etag", header: http.Header{"If-None-Match": {etag}}, status: http.StatusNotModified},
+		{name: "stale etag", header: http.Header{"If-None-Match": {`"other"`}}, status: http.StatusOK, body: "0123456789"},
+		{name: "modified since", header: http.Header{"If-Modified-Since": {modified}}, status: http.StatusNotModified},
+		{name: "if-range", header: http.Header{"Range": {"bytes=0-1"}, "If-Range": {`"other"`}}, status: http.StatusOK, body: "0123456789"},
+	}
+	for _, test := range conditionalTests {
+		rr = serve("clip.mp4", StaticFileOptions{}, test.header)
+		if rr.Code != test.status || test.body != "" && rr.Body.String() != test.body {
+			t.Errorf("%s: expected %d %q, got %d %q", test.name, test.status, test.body, rr.Code, rr.Body.String())
+		}
+	}
+
+	if rr = serve("sub", StaticFileOptions{}, nil); rr.Code != http.StatusNotFound {
+		t.Errorf("expected a directory to be not found, got %d", rr.Code)
+	}
+	if rr = serve("clip.mp4", StaticFileOptions{DisplayName: "holiday.mp4"}, nil); rr.Header().Get("Content-Disposition") != `attachement; filename="holiday.mp4"` {
+		t.Errorf("unexpected disposition %q", rr.Header().Get("Content-Disposition"))
+	}
+}
@@ -0,0 +1,39 @@
+package toolkit
+
+import (
+	"context"
+	"net/http"
+
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// traceContext propagates spans of remote calls in W3C traceparent and tracestate headers.
+var traceContext = propagation.TraceContext{}
+
+// startSpan starts a span of Tools.Tracer, or a span recording nothing when it is nil, so callers
+// can always end it.
+func (t *Tools) startSpan(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	if t.Tracer == nil {
+		return ctx, noop.Span{}
+	}
+	return t.Tracer.Start(ctx, name, opts...)
+}
+
+// endSpan marks span as failed with err, when it is not nil, and ends it.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// injectTraceContext adds the traceparent of the span in ctx to the headers of an outgoing request.
+func injectTraceContext(ctx context.Context, h http.Header) {
+	if trace.SpanContextFromContext(ctx).IsValid() {
+		traceContext.Inject(ctx, propagation.HeaderCarrier(h))
+	}
+}
@@ -0,0 +1,72 @@
+package toolkit
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func newTestTracer() (*tracetest.SpanRecorder, *sdktrace.TracerProvider) {
+	recorder := tracetest.NewSpanRecorder()
+	return recorder, sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+}
+
+func TestTools_TracerSpans(t *testing.T) {
+	recorder, provider := newTestTracer()
+	testTools := Tools{Tracer: provider.Tracer("test"), AllowedFileTypes: []string{"image/png"}}
+
+	var traceparent string
+	client := NewTestClient(func(req *http.Request) *http.Response {
+		traceparent = req.Header.Get("traceparent")
+		return &http.Response{StatusCode: http.StatusCreated, Body: io.NopCloser(bytes.NewBufferString("ok")), Header: make(http.Header)}
+	})
+	_, _, _ = testTools.PushJSONToRemote("http://remote.example/hook", map[string]string{"a": "b"}, client)
+
+	var data struct {
+		Name string `json:"name"`
+	}
+	rr := httptest.NewRecorder()
+	_ = testTools.ReadJSON(rr, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"name": 1}`)), &data)
+
+	_, _ = testTools.UploadFiles(newUploadRequest(t, map[string][]byte{"notes.txt": []byte("plain text")}), t.TempDir())
+
+	spans := recorder.Ended()
+	if len(spans) != 3 {
+		t.Fatalf("expected 3 spans, got %d", len(spans))
+	}
+
+	remote := spans[0]
+	if remote.Name() != http.MethodPost || remote.Status().Code == codes.Error {
+		t.Errorf("unexpected remote span %s %v", remote.Name(), remote.Status())
+	}
+	if want := "00-" + remote.SpanContext().TraceID().String() + "-" + remote.SpanContext().SpanID().String() + "-01"; traceparent != want {
+		t.Errorf("expected traceparent %s, got %s", want, traceparent)
+	}
+
+	if spans[1].Name() != "toolkit.ReadJSON" || spans[1].Status().Code != codes.Error {
+		t.Errorf("unexpected ReadJSON span %s %v", spans[1].Name(), spans[1].Status())
+	}
+	if spans[2].Name() != "toolkit.UploadFiles" || spans[2].Status().Code != codes.Error {
+		t.Errorf("unexpected upload span %s %v", spans[2].Name(), spans[2].Status())
+	}
+}
+
+func TestTools_NoTracer(t *testing.T) {
+	var testTools Tools
+	client := NewTestClient(func(req *http.Request) *http.Response {
+		if req.Header.Get("traceparent") != "" {
+			t.Error("traceparent sent without a trace")
+		}
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewBufferString("ok")), Header: make(http.Header)}
+	})
+	if _, _, err := testTools.PushJSONToRemote("http://remote.example/hook", nil, client); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,73 @@
+package toolkit
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"time"
+)
+
+// UUIDv4 returns a random RFC 9562 version 4 UUID in its canonical form, such as
+// 9b2e4c1a-7f3d-4e8b-a1c5-2d6f8e0b3a97.
+func (t *Tools) UUIDv4() string {
+	var u [16]byte
+	copy(u[:], t.RandomBytes(16))
+	u[6] = u[6]&0x0f | 0x40
+	u[8] = u[8]&0x3f | 0x80
+	return formatUUID(u)
+}
+
+// UUIDv7 returns an RFC 9562 version 7 UUID: its first 48 bits are the current Unix time in
+// milliseconds, so they sort by creation time and index well in databases, the rest is random.
+func (t *Tools) UUIDv7() string {
+	var u [16]byte
+	copy(u[:], t.RandomBytes(16))
+	putMillis(u[:], time.Now())
+	u[6] = u[6]&0x0f | 0x70
+	u[8] = u[8]&0x3f | 0x80
+	return formatUUID(u)
+}
+
+func formatUUID(u [16]byte) string {
+	var s [36]byte
+	hex.Encode(s[0:8], u[0:4])
+	hex.Encode(s[9:13], u[4:6])
+	hex.Encode(s[14:18], u[6:8])
+	hex.Encode(s[19:23], u[8:10])
+	hex.Encode(s[24:], u[10:])
+	s[8], s[13], s[18], s[23] = '-', '-', '-', '-'
+	return string(s[:])
+}
+
+// crockfordBase32 is the alphabet of ULIDs, which leaves out I, L, O and U.
+const crockfordBase32 = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// ULID returns a ULID, 26 characters of Crockford's base32 encoding a 48 bit millisecond
+// timestamp followed by 80 random bits, such as 01HZX3V8Q4M7K2R9T6W1B5N0CD. ULIDs sort by
+// creation time, also as strings.
+func (t *Tools) ULID() string {
+	var u [16]byte
+	copy(u[:], t.RandomBytes(16))
+	putMillis(u[:], time.Now())
+	return encodeULID(u)
+}
+
+func encodeULID(u [16]byte) string {
+	// The 128 bits are read 5 at a time from the end, the first character holding the top 3.
+	hi, lo := binary.BigEndian.Uint64(u[:8]), binary.BigEndian.Uint64(u[8:])
+	var s [26]byte
+	for i := len(s) - 1; i >= 0; i-- {
+		s[i] = crockfordBase32[lo&0x1f]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return string(s[:])
+}
+
+// putMillis writes the Unix time of now in milliseconds to the first 6 bytes of b.
+func putMillis(b []byte, now time.Time) {
+	ms := uint64(now.UnixMilli())
+	for i := 5; i >= 0; i-- {
+		b[i] = byte(ms)
+		ms >>= 8
+	}
+}
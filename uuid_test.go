@@ -0,0 +1,59 @@
+package toolkit
+
+import (
+	"encoding/hex"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTools_UUID(t *testing.T) {
+	var testTools Tools
+
+	v4 := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	v7 := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-7[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	for range 100 {
+		if u := testTools.UUIDv4(); !v4.MatchString(u) {
+			t.Fatalf("malformed version 4 UUID %s", u)
+		}
+		if u := testTools.UUIDv7(); !v7.MatchString(u) {
+			t.Fatalf("malformed version 7 UUID %s", u)
+		}
+	}
+	if testTools.UUIDv4() == testTools.UUIDv4() {
+		t.Error("expected UUIDs to differ")
+	}
+
+	before := time.Now().UnixMilli()
+	u := testTools.UUIDv7()
+	b, _ := hex.DecodeString(strings.ReplaceAll(u, "-", "")[:12])
+	var ms int64
+	for _, c := range b {
+		ms = ms<<8 | int64(c)
+	}
+	if ms < before || ms > time.Now().UnixMilli() {
+		t.Errorf("expected the timestamp of %s to be now, got %d", u, ms)
+	}
+}
+
+func TestTools_ULID(t *testing.T) {
+	var testTools Tools
+
+	format := regexp.MustCompile(`^[0-7][0-9A-HJKMNP-TV-Z]{25}$`)
+	first := testTools.ULID()
+	if !format.MatchString(first) {
+		t.Fatalf("malformed ULID %s", first)
+	}
+	time.Sleep(2 * time.Millisecond)
+	if second := testTools.ULID(); second <= first {
+		t.Errorf("expected %s to sort after %s", second, first)
+	}
+
+	// The timestamp of the spec's example, 01ARYZ6S41, is 1469918176385.
+	var u [16]byte
+	putMillis(u[:], time.UnixMilli(1469918176385))
+	if got := encodeULID(u); got != "01ARYZ6S410000000000000000" {
+		t.Errorf("unexpected encoding %s", got)
+	}
+}
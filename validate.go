@@ -0,0 +1,209 @@
+package toolkit
+
+import (
+	"fmt"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Validator accumulates field errors from a chain of checks, keeping the first error of each
+// field:
+//
+//	v := t.NewValidator().
+//		Required("email", in.Email).IsEmail("email", in.Email).
+//		MinLength("password", in.Password, 12)
+//	if !v.Valid() {
+//		_ = t.ErrorsJSON(w, v.Errors)
+//	}
+type Validator struct {
+	Errors map[string]string
+	// keys holds the i18n keys of the errors of the built-in checks and AddErrorKey.
+	keys map[string]i18nKey
+}
+
+// NewValidator returns a Validator without errors.
+func (t *Tools) NewValidator() *Validator {
+	return &Validator{Errors: make(map[string]string)}
+}
+
+// Validatable is implemented by request types that check themselves. ReadJSON calls Validate
+// after decoding into such a type and checking its validate tags with Validator.Struct, and
+// returns the resulting *ValidationError.
+type Validatable interface {
+	Validate(v *Validator)
+}
+
+// SelfValidator is implemented by request types that check themselves with a single error.
+// ReadJSON calls Validate once the validate tags and Validatable passed. The field errors of a
+// returned *ValidationError are rendered as a 422 like the others; other errors are returned as
+// they are.
+type SelfValidator interface {
+	Validate() error
+}
+
+// ValidationError carries the field errors of a failed validation. ErrorJSON renders it as a 422
+// with the field errors in the payload.
+type ValidationError struct {
+	Fields map[string]string
+	keys   map[string]i18nKey
+}
+
+func (e *ValidationError) Error() string {
+	fields := make([]string, 0, len(e.Fields))
+	for field := range e.Fields {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	for i, field := range fields {
+		fields[i] = field + ": " + e.Fields[field]
+	}
+	return "validation failed: " + strings.Join(fields, "; ")
+}
+
+// validate checks dst against its validate tags, with fields named by their nameTag tag, and
+// Validatable, and then SelfValidator when those passed.
+func (v *Validator) validate(dst any, nameTag string) error {
+	v.structNamed(dst, nameTag)
+	if c, ok := dst.(Validatable); ok {
+		c.Validate(v)
+	}
+	if err := v.Err(); err != nil {
+		return err
+	}
+	if c, ok := dst.(SelfValidator); ok {
+		return c.Validate()
+	}
+	return nil
+}
+
+// Valid reports whether no check failed.
+func (v *Validator) Valid() bool {
+	return len(v.Errors) == 0
+}
+
+// Err returns a *ValidationError with the field errors, or nil when all checks passed.
+func (v *Validator) Err() error {
+	if v.Valid() {
+		return nil
+	}
+	return &ValidationError{Fields: v.Errors, keys: v.keys}
+}
+
+// AddError records message for field unless the field already has an error.
+func (v *Validator) AddError(field, message string) *Validator {
+	if v.Errors == nil {
+		v.Errors = make(map[string]string)
+	}
+	if _, exists := v.Errors[field]; !exists {
+		v.Errors[field] = message
+	}
+	return v
+}
+
+// AddErrorKey records message for field like AddError, translated from the i18n key with args,
+// given as name and value pairs, when the response has a language. Messages also get the field
+// name as .Field.
+func (v *Validator) AddErrorKey(field, key, message string, args ...any) *Validator {
+	if _, exists := v.Errors[field]; exists {
+		return v
+	}
+	if v.keys == nil {
+		v.keys = make(map[string]i18nKey)
+	}
+	v.keys[field] = i18nKey{key: key, args: args}
+	return v.AddError(field, message)
+}
+
+func (v *Validator) checkKey(ok bool, field, key, message string, args ...any) *Validator {
+	if !ok {
+		v.AddErrorKey(field, key, message, args...)
+	}
+	return v
+}
+
+// Check records message for field when ok is false.
+func (v *Validator) Check(ok bool, field, message string) *Validator {
+	if !ok {
+		v.AddError(field, message)
+	}
+	return v
+}
+
+// Func records the error returned by fn for field, for checks the Validator has no method for.
+func (v *Validator) Func(field string, fn func() error) *Validator {
+	if err := fn(); err != nil {
+		v.AddError(field, err.Error())
+	}
+	return v
+}
+
+// Required checks that value is not blank.
+func (v *Validator) Required(field, value string) *Validator {
+	return v.checkKey(strings.TrimSpace(value) != "", field, "validation.required", "must be provided")
+}
+
+// MinLength checks that value has at least n characters. Empty values pass, so optional fields
+// are only checked when set.
+func (v *Validator) MinLength(field, value string, n int) *Validator {
+	return v.checkKey(value == "" || utf8.RuneCountInString(value) >= n, field, "validation.min_length",
+		fmt.Sprintf("must be at least %d characters long", n), "Min", n)
+}
+
+// MaxLength checks that value has at most n characters.
+func (v *Validator) MaxLength(field, value string, n int) *Validator {
+	return v.checkKey(utf8.RuneCountInString(value) <= n, field, "validation.max_length",
+		fmt.Sprintf("must not be more than %d characters long", n), "Max", n)
+}
+
+// IsEmail checks that value is a bare e-mail address, without a display name.
+func (v *Validator) IsEmail(field, value string) *Validator {
+	if value == "" {
+		return v
+	}
+	addr, err := mail.ParseAddress(value)
+	return v.checkKey(err == nil && addr.Address == value && addr.Name == "", field, "validation.email", "must be a valid email address")
+}
+
+// IsURL checks that value is an absolute http or https URL.
+func (v *Validator) IsURL(field, value string) *Validator {
+	if value == "" {
+		return v
+	}
+	u, err := url.Parse(value)
+	return v.checkKey(err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "", field, "validation.url", "must be a valid URL")
+}
+
+// In checks that value is one of allowed.
+func (v *Validator) In(field, value string, allowed ...string) *Validator {
+	return v.checkKey(value == "" || slices.Contains(allowed, value), field, "validation.in",
+		"must be one of "+strings.Join(allowed, ", "), "Allowed", strings.Join(allowed, ", "))
+}
+
+// Matches checks that value matches re.
+func (v *Validator) Matches(field, value string, re *regexp.Regexp) *Validator {
+	return v.checkKey(value == "" || re.MatchString(value), field, "validation.format", "has an invalid format")
+}
+
+// ErrorsJSON writes field errors, such as Validator.Errors, as a JSON error response, with status
+// 422 unless another is given. Field errors are written as they are; ErrorJSON with the
+// Validator's Err translates them.
+func (t *Tools) ErrorsJSON(w http.ResponseWriter, fields map[string]string, status ...int) error {
+	statusCode := http.StatusUnprocessableEntity
+	if len(status) > 0 {
+		statusCode = status[0]
+	}
+
+	var payload JSONResponse
+	payload.Error = true
+	payload.Message = t.responseLocalizer(w).translate("validation.failed", "validation failed", -1, nil)
+	payload.Errors = fields
+	payload.RequestID = w.Header().Get(RequestIDHeader)
+
+	return t.WriteJSON(w, statusCode, payload)
+}
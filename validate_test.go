@@ -0,0 +1,134 @@
+package toolkit
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+var validatorTests = []struct {
+	name  string
+	check func(v *Validator) *Validator
+	valid bool
+}{
+	{name: "required", check: func(v *Validator) *Validator { return v.Required("f", "x") }, valid: true},
+	{name: "required blank", check: func(v *Validator) *Validator { return v.Required("f", "  ") }},
+	{name: "min length", check: func(v *Validator) *Validator { return v.MinLength("f", "żółw", 4) }, valid: true},
+	{name: "min length short", check: func(v *Validator) *Validator { return v.MinLength("f", "abc", 4) }},
+	{name: "min length empty", check: func(v *Validator) *Validator { return v.MinLength("f", "", 4) }, valid: true},
+	{name: "max length long", check: func(v *Validator) *Validator { return v.MaxLength("f", "abcde", 4) }},
+	{name: "email", check: func(v *Validator) *Validator { return v.IsEmail("f", "a@example.com") }, valid: true},
+	{name: "email with name", check: func(v *Validator) *Validator { return v.IsEmail("f", "A <a@example.com>") }},
+	{name: "email invalid", check: func(v *Validator) *Validator { return v.IsEmail("f", "example.com") }},
+	{name: "url", check: func(v *Validator) *Validator { return v.IsURL("f", "https://example.com/a") }, valid: true},
+	{name: "url relative", check: func(v *Validator) *Validator { return v.IsURL("f", "/a") }},
+	{name: "url scheme", check: func(v *Validator) *Validator { return v.IsURL("f", "javascript:alert(1)") }},
+	{name: "in", check: func(v *Validator) *Validator { return v.In("f", "b", "a", "b") }, valid: true},
+	{name: "not in", check: func(v *Validator) *Validator { return v.In("f", "c", "a", "b") }},
+	{name: "matches", check: func(v *Validator) *Validator { return v.Matches("f", "ab12", regexp.MustCompile(`^[a-z]+\d+$`)) }, valid: true},
+	{name: "no match", check: func(v *Validator) *Validator { return v.Matches("f", "12ab", regexp.MustCompile(`^[a-z]+\d+$`)) }},
+	{name: "func", check: func(v *Validator) *Validator { return v.Func("f", func() error { return nil }) }, valid: true},
+	{name: "check", check: func(v *Validator) *Validator { return v.Check(false, "f", "bad") }},
+}
+
+func TestTools_Validator(t *testing.T) {
+	var testTools Tools
+	for _, test := range validatorTests {
+		v := test.check(testTools.NewValidator())
+		if v.Valid() != test.valid {
+			t.Errorf("%s: expected valid %v, got errors %v", test.name, test.valid, v.Errors)
+		}
+		if (v.Err() == nil) != test.valid {
+			t.Errorf("%s: Err does not agree with Valid", test.name)
+		}
+	}
+
+	v := testTools.NewValidator().Required("name", "").MinLength("name", "", 3).Required("email", "")
+	if len(v.Errors) != 2 || v.Errors["name"] != "must be provided" {
+		t.Errorf("expected the first error of each field, got %v", v.Errors)
+	}
+	if msg := v.Err().Error(); msg != "validation failed: email: must be provided; name: must be provided" {
+		t.Errorf("unexpected error message %q", msg)
+	}
+}
+
+type signupRequest struct {
+	Email string `json:"email"`
+	Plan  string `json:"plan"`
+}
+
+func (s *signupRequest) Validate(v *Validator) {
+	v.Required("email", s.Email).IsEmail("email", s.Email).In("plan", s.Plan, "free", "pro")
+}
+
+type transferRequest struct {
+	From   string `json:"from" validate:"required"`
+	To     string `json:"to" validate:"required"`
+	Amount int    `json:"amount"`
+}
+
+func (r *transferRequest) Validate() error {
+	if r.From == r.To {
+		return &ValidationError{Fields: map[string]string{"to": "must differ from from"}}
+	}
+	if r.Amount > 1000 {
+		return errors.New("amount exceeds the daily limit")
+	}
+	return nil
+}
+
+var selfValidatorTests = []struct {
+	body     string
+	expected string
+}{
+	{body: `{"from":"a","to":"b","amount":10}`},
+	{body: `{"from":"a","amount":10}`, expected: "validation failed: to: must be provided"},
+	{body: `{"from":"a","to":"a","amount":10}`, expected: "validation failed: to: must differ from from"},
+	{body: `{"from":"a","to":"b","amount":5000}`, expected: "amount exceeds the daily limit"},
+}
+
+func TestTools_ReadJSONSelfValidator(t *testing.T) {
+	var testTools Tools
+	for _, test := range selfValidatorTests {
+		var in transferRequest
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(test.body))
+		err := testTools.ReadJSON(httptest.NewRecorder(), req, &in)
+		if test.expected == "" && err != nil || test.expected != "" && (err == nil || err.Error() != test.expected) {
+			t.Errorf("%s: expected %q, got %v", test.body, test.expected, err)
+		}
+	}
+}
+
+func TestTools_ReadJSONValidation(t *testing.T) {
+	var testTools Tools
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"email":"nope","plan":"gold"}`))
+	rr := httptest.NewRecorder()
+	var in signupRequest
+	err := testTools.ReadJSON(rr, req, &in)
+	if err == nil {
+		t.Fatal("expected a validation error")
+	}
+
+	rr.Header().Set(RequestIDHeader, "abc")
+	_ = testTools.ErrorJSON(rr, err)
+	if rr.Code != http.StatusUnprocessableEntity {
+		t.Errorf("expected 422, got %d", rr.Code)
+	}
+	var payload JSONResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &payload); err != nil {
+		t.Fatal(err)
+	}
+	if !payload.Error || payload.RequestID != "abc" || len(payload.Errors) != 2 || payload.Errors["plan"] != "must be one of free, pro" {
+		t.Errorf("unexpected payload %+v", payload)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"email":"a@example.com","plan":"pro"}`))
+	if err := testTools.ReadJSON(httptest.NewRecorder(), req, &in); err != nil {
+		t.Errorf("expected valid request, got %v", err)
+	}
+}
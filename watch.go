@@ -0,0 +1,194 @@
+package toolkit
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// FileOp describes what happened to a watched file. Several operations coalesced by the
+// debounce window are combined into one value.
+type FileOp uint32
+
+const (
+	FileCreated FileOp = 1 << iota
+	FileWritten
+	FileRemoved
+	FileRenamed
+	FileChmod
+)
+
+// Has reports whether op includes other.
+func (op FileOp) Has(other FileOp) bool {
+	return op&other != 0
+}
+
+// FileEvent is a debounced change to a file below a directory watched by WatchDir.
+type FileEvent struct {
+	Path string
+	Op   FileOp
+}
+
+// WatchOptions configures WatchDir.
+type WatchOptions struct {
+	// Recursive watches every subdirectory, including ones created later.
+	Recursive bool
+	// Debounce is how long a path must stay quiet before its event is delivered. Defaults to 100ms.
+	Debounce time.Duration
+	// OnError receives errors reported by the underlying watcher; they are dropped when nil.
+	OnError func(error)
+}
+
+type pendingFileEvent struct {
+	op  FileOp
+	due time.Time
+}
+
+// WatchDir watches path for changes and delivers debounced events on the returned channel
+// until ctx is done, at which point the channel is closed.
+func (t *Tools) WatchDir(ctx context.Context, path string, opts ...WatchOptions) (<-chan FileEvent, error) {
+	var o WatchOptions
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+	if o.Debounce <= 0 {
+		o.Debounce = 100 * time.Millisecond
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	if err = watchTree(watcher, path, o.Recursive); err != nil {
+		watcher.Close()
+		return nil, err
+	}
+
+	events := make(chan FileEvent)
+	go func() {
+		defer close(events)
+		defer watcher.Close()
+
+		pending := make(map[string]*pendingFileEvent)
+		timer := time.NewTimer(time.Hour)
+		timer.Stop()
+
+		add := func(name string, op FileOp) {
+			p, ok := pending[name]
+			if !ok {
+				p = &pendingFileEvent{}
+				pending[name] = p
+			}
+			p.op |= op
+			p.due = time.Now().Add(o.Debounce)
+			timer.Reset(o.Debounce)
+		}
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+
+			case ev, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				op := fileOpFromNotify(ev.Op)
+				if op == 0 {
+					continue
+				}
+				add(ev.Name, op)
+
+				if o.Recursive && op.Has(FileCreated) {
+					if info, err := os.Lstat(ev.Name); err == nil && info.IsDir() {
+						if err = watchTree(watcher, ev.Name, true); err != nil && o.OnError != nil {
+							o.OnError(err)
+						}
+						// files created before the new directory was watched produce no events
+						_ = filepath.WalkDir(ev.Name, func(p string, d fs.DirEntry, err error) error {
+							if err == nil && p != ev.Name {
+								add(p, FileCreated)
+							}
+							return nil
+						})
+					}
+				}
+
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				if o.OnError != nil {
+					o.OnError(err)
+				}
+
+			case now := <-timer.C:
+				var ready []FileEvent
+				var next time.Time
+				for name, p := range pending {
+					if !p.due.After(now) {
+						ready = append(ready, FileEvent{Path: name, Op: p.op})
+						delete(pending, name)
+					} else if next.IsZero() || p.due.Before(next) {
+						next = p.due
+					}
+				}
+				if !next.IsZero() {
+					timer.Reset(time.Until(next))
+				}
+
+				sort.Slice(ready, func(i, j int) bool { return ready[i].Path < ready[j].Path })
+				for _, ev := range ready {
+					select {
+					case events <- ev:
+					case <-ctx.Done():
+						return
+					}
+				}
+			}
+		}
+	}()
+
+	return events, nil
+}
+
+func watchTree(watcher *fsnotify.Watcher, root string, recursive bool) error {
+	if !recursive {
+		return watcher.Add(root)
+	}
+	return filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return watcher.Add(p)
+		}
+		return nil
+	})
+}
+
+func fileOpFromNotify(op fsnotify.Op) FileOp {
+	var out FileOp
+	if op.Has(fsnotify.Create) {
+		out |= FileCreated
+	}
+	if op.Has(fsnotify.Write) {
+		out |= FileWritten
+	}
+	if op.Has(fsnotify.Remove) {
+		out |= FileRemoved
+	}
+	if op.Has(fsnotify.Rename) {
+		out |= FileRenamed
+	}
+	if op.Has(fsnotify.Chmod) {
+		out |= FileChmod
+	}
+	return out
+}
@@ -0,0 +1,77 @@
+package toolkit
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func nextFileEvent(t *testing.T, events <-chan FileEvent) FileEvent {
+	t.Helper()
+	select {
+	case ev := <-events:
+		return ev
+	case <-time.After(3 * time.Second):
+		t.Fatal("timed out waiting for file event")
+	}
+	return FileEvent{}
+}
+
+func TestTools_WatchDir(t *testing.T) {
+	var testTools Tools
+	dir := t.TempDir()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events, err := testTools.WatchDir(ctx, dir, WatchOptions{Recursive: true, Debounce: 50 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// several writes in quick succession collapse into one event
+	p := filepath.Join(dir, "upload.csv")
+	f, _ := os.Create(p)
+	for i := 0; i < 5; i++ {
+		_, _ = f.WriteString("row\n")
+	}
+	f.Close()
+
+	ev := nextFileEvent(t, events)
+	if ev.Path != p || !ev.Op.Has(FileCreated) || !ev.Op.Has(FileWritten) {
+		t.Errorf("unexpected event %+v", ev)
+	}
+
+	select {
+	case ev := <-events:
+		t.Errorf("expected writes to be debounced, got extra event %+v", ev)
+	case <-time.After(150 * time.Millisecond):
+	}
+
+	// files in new subdirectories are reported as well
+	sub := filepath.Join(dir, "landing")
+	_ = os.Mkdir(sub, 0755)
+	ev = nextFileEvent(t, events)
+	if ev.Path != sub || !ev.Op.Has(FileCreated) {
+		t.Errorf("unexpected event %+v", ev)
+	}
+
+	_ = os.WriteFile(filepath.Join(sub, "drop.txt"), []byte("x"), 0644)
+	ev = nextFileEvent(t, events)
+	if ev.Path != filepath.Join(sub, "drop.txt") || !ev.Op.Has(FileCreated) {
+		t.Errorf("unexpected event %+v", ev)
+	}
+
+	cancel()
+	for range events {
+	}
+}
+
+func TestTools_WatchDirMissing(t *testing.T) {
+	var testTools Tools
+	if _, err := testTools.WatchDir(context.Background(), filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("expected error for missing directory")
+	}
+}
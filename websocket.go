@@ -0,0 +1,275 @@
+package toolkit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+var (
+	// ErrWebSocketClosed is returned when writing to a closed connection.
+	ErrWebSocketClosed = errors.New("websocket connection is closed")
+	// ErrWebSocketSlow is returned when a connection's send queue is full. The connection is
+	// closed, as a client that far behind is unlikely to catch up.
+	ErrWebSocketSlow = errors.New("websocket send queue is full")
+)
+
+// WebSocketOptions configures UpgradeWebSocket.
+type WebSocketOptions struct {
+	// AllowedOrigins lists the origins, such as "https://app.example.com", allowed to connect.
+	// Only same-origin requests are accepted when empty; "*" allows any origin.
+	AllowedOrigins []string
+	// Subprotocols lists the supported subprotocols in order of preference.
+	Subprotocols []string
+	// PingInterval is how often the connection is pinged, 30 seconds when 0. A connection that
+	// does not answer within two intervals is closed.
+	PingInterval time.Duration
+	// WriteTimeout bounds each write, 10 seconds when 0.
+	WriteTimeout time.Duration
+	// SendQueue is the number of messages waiting to be written, 64 when 0.
+	SendQueue int
+	// MaxMessageSize bounds incoming messages, 1 MiB when 0.
+	MaxMessageSize int64
+}
+
+// WebSocketConn is a WebSocket connection exchanging JSON messages. Writes are queued and sent by
+// a background goroutine, which also keeps the connection alive with pings, so they never block
+// on a slow client.
+type WebSocketConn struct {
+	ctx   context.Context
+	conn  *websocket.Conn
+	opts  WebSocketOptions
+	send  chan []byte
+	done  chan struct{}
+	close sync.Once
+}
+
+// UpgradeWebSocket upgrades the request to a WebSocket connection. A failed handshake has
+// already been answered with a JSON error.
+//
+// The connection must be read continuously, e.g. with ReadJSON in a loop, even when clients send
+// nothing, as the answers to pings are processed while reading.
+func (t *Tools) UpgradeWebSocket(w http.ResponseWriter, r *http.Request, opts ...WebSocketOptions) (*WebSocketConn, error) {
+	var o WebSocketOptions
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+	if o.PingInterval <= 0 {
+		o.PingInterval = 30 * time.Second
+	}
+	if o.WriteTimeout <= 0 {
+		o.WriteTimeout = 10 * time.Second
+	}
+	if o.SendQueue <= 0 {
+		o.SendQueue = 64
+	}
+	if o.MaxMessageSize <= 0 {
+		o.MaxMessageSize = 1 << 20
+	}
+
+	u := websocket.Upgrader{
+		Subprotocols: o.Subprotocols,
+		Error: func(w http.ResponseWriter, r *http.Request, status int, reason error) {
+			_ = t.ErrorJSON(w, reason, status)
+		},
+	}
+	if len(o.AllowedOrigins) > 0 {
+		u.CheckOrigin = func(r *http.Request) bool {
+			return originAllowed(r.Header.Get("Origin"), o.AllowedOrigins)
+		}
+	}
+	conn, err := u.Upgrade(w, r, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &WebSocketConn{
+		// The request's context ends when the handler returns, while the connection may live on.
+		ctx:  context.WithoutCancel(r.Context()),
+		conn: conn,
+		opts: o,
+		send: make(chan []byte, o.SendQueue),
+		done: make(chan struct{}),
+	}
+	conn.SetReadLimit(o.MaxMessageSize)
+	_ = conn.SetReadDeadline(time.Now().Add(2 * o.PingInterval))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(2 * o.PingInterval))
+	})
+	go c.writeLoop()
+	return c, nil
+}
+
+func originAllowed(origin string, allowed []string) bool {
+	if slices.Contains(allowed, "*") {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	return slices.ContainsFunc(allowed, func(a string) bool {
+		return strings.EqualFold(strings.TrimSuffix(a, "/"), u.Scheme+"://"+u.Host)
+	})
+}
+
+// Context returns the context of the upgraded request, with its values, such as the session or
+// API key identity, but not its cancellation.
+func (c *WebSocketConn) Context() context.Context { return c.ctx }
+
+// Subprotocol returns the negotiated subprotocol.
+func (c *WebSocketConn) Subprotocol() string { return c.conn.Subprotocol() }
+
+// ReadJSON reads the next message into v. The connection is closed on errors, including when
+// the client went away, which can be told apart with IsWebSocketClosed.
+func (c *WebSocketConn) ReadJSON(v any) error {
+	err := c.conn.ReadJSON(v)
+	var syntax *json.SyntaxError
+	var typ *json.UnmarshalTypeError
+	if err != nil && !errors.As(err, &syntax) && !errors.As(err, &typ) {
+		c.Close()
+	}
+	return err
+}
+
+// WriteJSON queues v to be sent as a JSON message.
+func (c *WebSocketConn) WriteJSON(v any) error {
+	msg, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return c.enqueue(msg)
+}
+
+func (c *WebSocketConn) enqueue(msg []byte) error {
+	select {
+	case <-c.done:
+		return ErrWebSocketClosed
+	default:
+	}
+	select {
+	case c.send <- msg:
+		return nil
+	default:
+		c.Close()
+		return ErrWebSocketSlow
+	}
+}
+
+// Close sends a close message and closes the connection. Queued messages are dropped.
+func (c *WebSocketConn) Close() error {
+	c.close.Do(func() { close(c.done) })
+	return nil
+}
+
+// Done is closed when the connection is closed.
+func (c *WebSocketConn) Done() <-chan struct{} { return c.done }
+
+func (c *WebSocketConn) writeLoop() {
+	ping := time.NewTicker(c.opts.PingInterval)
+	defer func() {
+		ping.Stop()
+		c.Close()
+		_ = c.conn.Close()
+	}()
+
+	for {
+		select {
+		case msg := <-c.send:
+			_ = c.conn.SetWriteDeadline(time.Now().Add(c.opts.WriteTimeout))
+			if err := c.conn.WriteMessage(websocket.TextMessage, msg); err != nil {
+				return
+			}
+		case <-ping.C:
+			if err := c.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(c.opts.WriteTimeout)); err != nil {
+				return
+			}
+		case <-c.done:
+			msg := websocket.FormatCloseMessage(websocket.CloseNormalClosure, "")
+			_ = c.conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(c.opts.WriteTimeout))
+			return
+		}
+	}
+}
+
+// IsWebSocketClosed reports whether err means the connection was closed, by either side and
+// cleanly or not, rather than a failure worth logging.
+func IsWebSocketClosed(err error) bool {
+	return errors.Is(err, ErrWebSocketClosed) || errors.Is(err, websocket.ErrCloseSent) ||
+		errors.Is(err, net.ErrClosed) ||
+		websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway,
+			websocket.CloseNoStatusReceived, websocket.CloseAbnormalClosure)
+}
+
+// WebSocketHub tracks connections to broadcast messages to them, e.g. progress updates or
+// notifications. Connections leave the hub when they close.
+type WebSocketHub struct {
+	mu    sync.RWMutex
+	conns map[*WebSocketConn]struct{}
+}
+
+// NewWebSocketHub returns an empty hub.
+func (t *Tools) NewWebSocketHub() *WebSocketHub {
+	return &WebSocketHub{conns: make(map[*WebSocketConn]struct{})}
+}
+
+// Add adds c to the hub until it closes.
+func (h *WebSocketHub) Add(c *WebSocketConn) {
+	h.mu.Lock()
+	h.conns[c] = struct{}{}
+	h.mu.Unlock()
+	go func() {
+		<-c.Done()
+		h.Remove(c)
+	}()
+}
+
+// Remove removes c from the hub.
+func (h *WebSocketHub) Remove(c *WebSocketConn) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.conns, c)
+}
+
+// Len returns the number of connections in the hub.
+func (h *WebSocketHub) Len() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.conns)
+}
+
+// Broadcast queues v for every connection in the hub, and returns the number of connections it
+// was queued for. Connections too far behind are closed.
+func (h *WebSocketHub) Broadcast(v any) (int, error) {
+	return h.BroadcastFunc(v, nil)
+}
+
+// BroadcastFunc is Broadcast limited to the connections match reports true for, or all of them
+// when match is nil.
+func (h *WebSocketHub) BroadcastFunc(v any, match func(c *WebSocketConn) bool) (int, error) {
+	msg, err := json.Marshal(v)
+	if err != nil {
+		return 0, err
+	}
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	n := 0
+	for c := range h.conns {
+		if match != nil && !match(c) {
+			continue
+		}
+		if c.enqueue(msg) == nil {
+			n++
+		}
+	}
+	return n, nil
+}
@@ -0,0 +1,182 @@
+package toolkit
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+type wsMessage struct {
+	Text string `json:"text"`
+}
+
+func dialWebSocket(t *testing.T, srv *httptest.Server, origin string) (*websocket.Conn, *http.Response, error) {
+	t.Helper()
+	header := http.Header{}
+	if origin != "" {
+		header.Set("Origin", origin)
+	}
+	return websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), header)
+}
+
+func TestTools_UpgradeWebSocket(t *testing.T) {
+	var testTools Tools
+	hub := testTools.NewWebSocketHub()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c, err := testTools.UpgradeWebSocket(w, r, WebSocketOptions{
+			AllowedOrigins: []string{"https://app.example.com"},
+			PingInterval:   20 * time.Millisecond,
+		})
+		if err != nil {
+			return
+		}
+		hub.Add(c)
+		for {
+			var msg wsMessage
+			if err := c.ReadJSON(&msg); err != nil {
+				if !IsWebSocketClosed(err) {
+					t.Errorf("unexpected read error %v", err)
+				}
+				return
+			}
+			_ = c.WriteJSON(wsMessage{Text: "echo: " + msg.Text})
+		}
+	}))
+	defer srv.Close()
+
+	if _, resp, err := dialWebSocket(t, srv, "https://evil.example.com"); err == nil || resp.StatusCode != http.StatusForbidden {
+		t.Errorf("expected a foreign origin to be rejected, got %v", err)
+	}
+
+	ws, _, err := dialWebSocket(t, srv, "https://app.example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pings := make(chan struct{}, 10)
+	ws.SetPingHandler(func(data string) error {
+		pings <- struct{}{}
+		return ws.WriteControl(websocket.PongMessage, []byte(data), time.Now().Add(time.Second))
+	})
+
+	if err = ws.WriteJSON(wsMessage{Text: "hi"}); err != nil {
+		t.Fatal(err)
+	}
+	var got wsMessage
+	if err = ws.ReadJSON(&got); err != nil || got.Text != "echo: hi" {
+		t.Errorf("unexpected echo %+v %v", got, err)
+	}
+
+	if n, _ := hub.Broadcast(wsMessage{Text: "news"}); n != 1 {
+		t.Errorf("expected a broadcast to 1 connection, got %d", n)
+	}
+	if err = ws.ReadJSON(&got); err != nil || got.Text != "news" {
+		t.Errorf("unexpected broadcast %+v %v", got, err)
+	}
+
+	// Keep reading so pings are answered, for longer than the two intervals the server waits.
+	_ = ws.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+	_, _, err = ws.ReadMessage()
+	if len(pings) == 0 {
+		t.Error("expected the server to ping")
+	}
+	if !strings.Contains(err.Error(), "timeout") {
+		t.Errorf("expected the connection to stay open, got %v", err)
+	}
+	_ = ws.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseGoingAway, ""), time.Now().Add(time.Second))
+	ws.Close()
+
+	for deadline := time.Now().Add(time.Second); hub.Len() > 0 && time.Now().Before(deadline); {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if hub.Len() != 0 {
+		t.Error("expected the closed connection to leave the hub")
+	}
+}
+
+func TestTools_WebSocketSlowClient(t *testing.T) {
+	var testTools Tools
+	conns := make(chan *WebSocketConn, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c, err := testTools.UpgradeWebSocket(w, r, WebSocketOptions{SendQueue: 1})
+		if err != nil {
+			return
+		}
+		conns <- c
+		var msg wsMessage
+		_ = c.ReadJSON(&msg)
+	}))
+	defer srv.Close()
+
+	ws, _, err := dialWebSocket(t, srv, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ws.Close()
+	c := <-conns
+
+	// The client never reads, so the queue eventually fills up once the socket buffers do.
+	payload := wsMessage{Text: strings.Repeat("x", 64<<10)}
+	for range 1000 {
+		if err = c.WriteJSON(payload); err != nil {
+			break
+		}
+	}
+	if err != ErrWebSocketSlow && err != ErrWebSocketClosed {
+		t.Fatalf("expected the slow client to be dropped, got %v", err)
+	}
+	select {
+	case <-c.Done():
+	case <-time.After(time.Second):
+		t.Error("expected the connection to close")
+	}
+	if err = c.WriteJSON(payload); err != ErrWebSocketClosed {
+		t.Errorf("expected writes to a closed connection to fail, got %v", err)
+	}
+}
+
+func TestTools_UpgradeWebSocketBehindMiddleware(t *testing.T) {
+	testTools := Tools{Logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+	echo := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c, err := testTools.UpgradeWebSocket(w, r)
+		if err != nil {
+			t.Errorf("upgrade failed: %s", err)
+			return
+		}
+		var msg wsMessage
+		if err := c.ReadJSON(&msg); err == nil {
+			_ = c.WriteJSON(msg)
+		}
+		_ = c.Close()
+	})
+
+	tests := map[string]http.Handler{
+		"recover":  testTools.RecoverMiddleware(echo),
+		"compress": testTools.CompressMiddleware(CompressOptions{})(echo),
+		"log":      testTools.RequestLogMiddleware(echo),
+	}
+	for name, h := range tests {
+		srv := httptest.NewServer(h)
+		header := http.Header{"Accept-Encoding": {"gzip"}}
+		ws, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), header)
+		if err != nil {
+			t.Errorf("%s: %s", name, err)
+			srv.Close()
+			continue
+		}
+		var msg wsMessage
+		if err = ws.WriteJSON(wsMessage{Text: "hi"}); err == nil {
+			err = ws.ReadJSON(&msg)
+		}
+		if err != nil || msg.Text != "hi" {
+			t.Errorf("%s: expected an echo, got %v, %v", name, msg, err)
+		}
+		_ = ws.Close()
+		srv.Close()
+	}
+}
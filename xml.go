@@ -0,0 +1,237 @@
+package toolkit
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// XMLResponse is the XML counterpart of JSONResponse, written by ErrorXML.
+type XMLResponse struct {
+	XMLName   xml.Name        `xml:"response"`
+	Error     bool            `xml:"error"`
+	Message   string          `xml:"message"`
+	Data      any             `xml:"data,omitempty"`
+	Errors    []XMLFieldError `xml:"errors>field,omitempty"`
+	RequestID string          `xml:"request_id,omitempty"`
+}
+
+// XMLFieldError is the error of one field in an XMLResponse.
+type XMLFieldError struct {
+	Name    string `xml:"name,attr"`
+	Message string `xml:",chardata"`
+}
+
+// ReadXML decodes an XML request body into data, the way ReadJSON decodes JSON: the body is
+// limited to MaxBodySize, must hold a single document, elements data has no field for are
+// rejected unless AllowUnknownFields is set, and validate tags, Validatable and SelfValidator are checked.
+func (t *Tools) ReadXML(w http.ResponseWriter, r *http.Request, data any) error {
+	ctx := r.Context()
+	r.Body = http.MaxBytesReader(w, contextBody(ctx, r.Body), t.maxBodySize())
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return xmlDecodeError(err)
+	}
+
+	dec := xml.NewDecoder(bytes.NewReader(body))
+	if err := dec.Decode(data); err != nil {
+		return xmlDecodeError(err)
+	}
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return xmlDecodeError(err)
+		}
+		switch tok := tok.(type) {
+		case xml.CharData:
+			if len(bytes.TrimSpace(tok)) == 0 {
+				continue
+			}
+		case xml.Comment, xml.ProcInst:
+			continue
+		}
+		return errors.New("body must contain only one XML document")
+	}
+
+	if !t.AllowUnknownFields {
+		if err := checkXMLFields(body, reflect.TypeOf(data)); err != nil {
+			return err
+		}
+	}
+
+	return t.NewValidator().validate(data, "json")
+}
+
+// xmlDecodeError turns an error of xml.Decoder into a message fit for the client.
+func xmlDecodeError(err error) error {
+	var syntaxError *xml.SyntaxError
+	var maxBytesError *http.MaxBytesError
+	switch {
+	case errors.As(err, &syntaxError):
+		return fmt.Errorf("body contains badly-formed XML (at line %d)", syntaxError.Line)
+	case errors.Is(err, io.EOF):
+		return errors.New("body must not be empty")
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return errors.New("body contains badly-formed XML")
+	case errors.As(err, &maxBytesError):
+		return &bodyTooLargeError{limit: maxBytesError.Limit}
+	}
+	return fmt.Errorf("body contains incorrect XML: %w", err)
+}
+
+var xmlUnmarshalerType = reflect.TypeFor[xml.Unmarshaler]()
+var textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+
+// checkXMLFields reports the first element of the document body that typ, the type decoded into,
+// has no field for. Elements of types that decode themselves, of fields with an any or innerxml
+// tag and below fields with a parent>child path are not checked.
+func checkXMLFields(body []byte, typ reflect.Type) error {
+	dec := xml.NewDecoder(bytes.NewReader(body))
+	// stack holds the struct type of every open element, nil for those not checked.
+	var stack []reflect.Type
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return xmlDecodeError(err)
+		}
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			if len(stack) == 0 {
+				stack = append(stack, xmlStructType(typ))
+				continue
+			}
+			parent := stack[len(stack)-1]
+			if parent == nil {
+				stack = append(stack, nil)
+				continue
+			}
+			field, known := xmlField(parent, tok.Name.Local)
+			if !known {
+				return fmt.Errorf("body contains unknown element <%s>", tok.Name.Local)
+			}
+			stack = append(stack, field)
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		}
+	}
+}
+
+// xmlField looks up the field of the struct typ element name is decoded into. It returns the
+// struct type to check the element's children against, nil when they are not checked, and false
+// when typ has no field for the element.
+func xmlField(typ reflect.Type, name string) (reflect.Type, bool) {
+	var anyField reflect.Type
+	hasAny := false
+	for i := 0; i < typ.NumField(); i++ {
+		sf := typ.Field(i)
+		tag := sf.Tag.Get("xml")
+		if tag == "-" || !sf.IsExported() && !sf.Anonymous {
+			continue
+		}
+		tagName, opts, _ := strings.Cut(tag, ",")
+		opts = "," + opts + ","
+		switch {
+		case strings.Contains(opts, ",attr,"), strings.Contains(opts, ",chardata,"),
+			strings.Contains(opts, ",cdata,"), strings.Contains(opts, ",comment,"):
+			continue
+		case strings.Contains(opts, ",innerxml,"):
+			return nil, true
+		case strings.Contains(opts, ",any,"):
+			anyField, hasAny = xmlStructType(sf.Type), true
+			continue
+		}
+		if sf.Anonymous && tagName == "" {
+			if et := xmlStructType(sf.Type); et != nil {
+				if field, ok := xmlField(et, name); ok {
+					return field, true
+				}
+			}
+			continue
+		}
+		if sf.Name == "XMLName" {
+			continue
+		}
+
+		if first, _, isPath := strings.Cut(tagName, ">"); isPath {
+			if first == name || first == "" {
+				return nil, true
+			}
+			continue
+		}
+		if tagName == "" {
+			tagName = sf.Name
+		}
+		if _, local, ok := strings.Cut(tagName, " "); ok {
+			tagName = local
+		}
+		if tagName == name {
+			return xmlStructType(sf.Type), true
+		}
+	}
+	return anyField, hasAny
+}
+
+// xmlStructType returns the struct type elements decoded into typ have their children checked
+// against, or nil.
+func xmlStructType(typ reflect.Type) reflect.Type {
+	for typ.Kind() == reflect.Pointer || typ.Kind() == reflect.Slice && typ.Elem().Kind() != reflect.Uint8 {
+		typ = typ.Elem()
+	}
+	if typ.Kind() != reflect.Struct || reflect.PointerTo(typ).Implements(xmlUnmarshalerType) ||
+		reflect.PointerTo(typ).Implements(textUnmarshalerType) {
+		return nil
+	}
+	return typ
+}
+
+// WriteXML writes data as an XML response with the given status and optional headers.
+func (t *Tools) WriteXML(w http.ResponseWriter, status int, data any, headers ...http.Header) error {
+	out, err := xml.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	if len(headers) > 0 {
+		for key, value := range headers[0] {
+			w.Header()[key] = value
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	_, err = w.Write(append([]byte(xml.Header), out...))
+	return err
+}
+
+// ErrorXML is ErrorJSON writing an XMLResponse, with the status chosen the same way.
+func (t *Tools) ErrorXML(w http.ResponseWriter, err error, status ...int) error {
+	statusCode, message, fields := t.errorPayload(w, err, status)
+
+	payload := XMLResponse{Error: true, Message: message, RequestID: w.Header().Get(RequestIDHeader)}
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		payload.Errors = append(payload.Errors, XMLFieldError{Name: name, Message: fields[name]})
+	}
+
+	return t.WriteXML(w, statusCode, payload)
+}
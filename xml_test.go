@@ -0,0 +1,115 @@
+package toolkit
+
+import (
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type xmlItem struct {
+	SKU string `xml:"sku,attr"`
+	Qty int    `xml:"qty"`
+}
+
+type xmlOrder struct {
+	XMLName xml.Name  `xml:"order"`
+	ID      string    `xml:"id,attr"`
+	Email   string    `xml:"customer>email" validate:"email"`
+	Items   []xmlItem `xml:"item"`
+	Note    string    `xml:"note,omitempty"`
+}
+
+var xmlTests = []struct {
+	name          string
+	xml           string
+	maxSize       int
+	allowUnknown  bool
+	errorContains string
+}{
+	{name: "good xml", xml: `<?xml version="1.0"?><order id="1"><customer><email>a@example.com</email></customer><item sku="a"><qty>2</qty></item><item sku="b"><qty>1</qty></item></order>`},
+	{name: "badly formatted", xml: `<order id="1"><note>x</order>`, errorContains: "badly-formed XML"},
+	{name: "incorrect type", xml: `<order><item><qty>many</qty></item></order>`, errorContains: "incorrect XML"},
+	{name: "two documents", xml: `<order></order><order></order>`, errorContains: "only one XML document"},
+	{name: "comment after", xml: "<order></order>\n<!-- end -->\n"},
+	{name: "empty body", xml: ``, errorContains: "must not be empty"},
+	{name: "wrong root", xml: `<invoice></invoice>`, errorContains: "incorrect XML"},
+	{name: "unknown element", xml: `<order><coupon>x</coupon></order>`, errorContains: "unknown element <coupon>"},
+	{name: "unknown nested element", xml: `<order><item sku="a"><price>1</price></item></order>`, errorContains: "unknown element <price>"},
+	{name: "allow unknown", xml: `<order><coupon>x</coupon></order>`, allowUnknown: true},
+	{name: "too large", xml: `<order><note>` + strings.Repeat("x", 20) + `</note></order>`, maxSize: 16, errorContains: "must not be larger than 16 bytes"},
+	{name: "validation", xml: `<order><customer><email>nope</email></customer></order>`, errorContains: "validation failed"},
+}
+
+func TestTools_ReadXML(t *testing.T) {
+	for _, test := range xmlTests {
+		var testTools Tools
+		testTools.MaxBodySize = test.maxSize
+		testTools.AllowUnknownFields = test.allowUnknown
+
+		var order xmlOrder
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(test.xml))
+		err := testTools.ReadXML(httptest.NewRecorder(), req, &order)
+		if test.errorContains == "" && err != nil {
+			t.Errorf("%s: unexpected error %v", test.name, err)
+		}
+		if test.errorContains != "" && (err == nil || !strings.Contains(err.Error(), test.errorContains)) {
+			t.Errorf("%s: expected error containing %q, got %v", test.name, test.errorContains, err)
+		}
+	}
+
+	var testTools Tools
+	var order xmlOrder
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(xmlTests[0].xml))
+	if err := testTools.ReadXML(httptest.NewRecorder(), req, &order); err != nil {
+		t.Fatal(err)
+	}
+	if order.ID != "1" || order.Email != "a@example.com" || len(order.Items) != 2 || order.Items[0].Qty != 2 {
+		t.Errorf("unexpected order %+v", order)
+	}
+}
+
+func TestTools_MaxBodySize(t *testing.T) {
+	testTools := Tools{MaxJSONSize: 5}
+	if n := testTools.maxBodySize(); n != 5 {
+		t.Errorf("expected MaxJSONSize to be used, got %d", n)
+	}
+	testTools.MaxBodySize = 10
+	if n := testTools.maxBodySize(); n != 10 {
+		t.Errorf("expected MaxBodySize to take precedence, got %d", n)
+	}
+}
+
+func TestTools_WriteXML(t *testing.T) {
+	var testTools Tools
+
+	rr := httptest.NewRecorder()
+	headers := http.Header{"Foo": {"bar"}}
+	if err := testTools.WriteXML(rr, http.StatusCreated, xmlOrder{ID: "7"}, headers); err != nil {
+		t.Fatal(err)
+	}
+	if rr.Code != http.StatusCreated || rr.Header().Get("Content-Type") != "application/xml" || rr.Header().Get("Foo") != "bar" {
+		t.Errorf("unexpected response %d %v", rr.Code, rr.Header())
+	}
+	if expected := xml.Header + `<order id="7"><customer><email></email></customer></order>`; rr.Body.String() != expected {
+		t.Errorf("expected %q, got %q", expected, rr.Body.String())
+	}
+}
+
+func TestTools_ErrorXML(t *testing.T) {
+	var testTools Tools
+
+	rr := httptest.NewRecorder()
+	_ = testTools.ErrorXML(rr, ErrConflict)
+	if rr.Code != http.StatusConflict || !strings.Contains(rr.Body.String(), "<message>conflict</message>") {
+		t.Errorf("unexpected response %d %s", rr.Code, rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	_ = testTools.ErrorXML(rr, testTools.NewValidator().Required("b", "").Required("a", "").Err())
+	expected := `<errors><field name="a">must be provided</field><field name="b">must be provided</field></errors>`
+	if rr.Code != http.StatusUnprocessableEntity || !strings.Contains(rr.Body.String(), expected) {
+		t.Errorf("unexpected response %d %s", rr.Code, rr.Body.String())
+	}
+}